@@ -217,13 +217,18 @@ func Cli(command string, strict bool, instance string, destination string, owner
 	// begin commands
 	switch command {
 	// smart mode
-	case registerCliCommand("relocate", "Smart relocation", `Relocate a replica beneath another instance`), registerCliCommand("relocate-below", "Smart relocation", `Synonym to 'relocate', will be deprecated`):
+	case registerCliCommand("relocate", "Smart relocation", `Relocate a replica beneath another instance, or a single channel of a multi-source replica given --channel`), registerCliCommand("relocate-below", "Smart relocation", `Synonym to 'relocate', will be deprecated`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
 			if destinationKey == nil {
 				log.Fatal("Cannot deduce destination:", destination)
 			}
-			_, err := inst.RelocateBelow(instanceKey, destinationKey)
+			var err error
+			if channel := *config.RuntimeCLIFlags.Channel; channel != "" {
+				_, err = inst.RelocateChannelBelow(instanceKey, channel, destinationKey)
+			} else {
+				_, err = inst.RelocateBelow(instanceKey, destinationKey)
+			}
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -606,19 +611,29 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(instanceKey.DisplayString())
 		}
-	case registerCliCommand("stop-slave", "Replication, general", `Issue a STOP SLAVE on an instance`):
+	case registerCliCommand("stop-slave", "Replication, general", `Issue a STOP SLAVE on an instance, or on a single channel given --channel`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
-			_, err := inst.StopReplication(instanceKey)
+			var err error
+			if channel := *config.RuntimeCLIFlags.Channel; channel != "" {
+				_, err = inst.StopReplicationChannel(instanceKey, channel)
+			} else {
+				_, err = inst.StopReplication(instanceKey)
+			}
 			if err != nil {
 				log.Fatale(err)
 			}
 			fmt.Println(instanceKey.DisplayString())
 		}
-	case registerCliCommand("start-slave", "Replication, general", `Issue a START SLAVE on an instance`):
+	case registerCliCommand("start-slave", "Replication, general", `Issue a START SLAVE on an instance, or on a single channel given --channel`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
-			_, err := inst.StartReplication(instanceKey)
+			var err error
+			if channel := *config.RuntimeCLIFlags.Channel; channel != "" {
+				_, err = inst.StartReplicationChannel(instanceKey, channel)
+			} else {
+				_, err = inst.StartReplication(instanceKey)
+			}
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -1176,6 +1191,17 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				fmt.Println(clusterInstance.Key.DisplayString())
 			}
 		}
+	case registerCliCommand("which-candidates", "Information", `Output the replicas of a cluster's master ranked as candidates for promotion, with the reasoning behind each score`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			scores, err := inst.ScoreClusterCandidates(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, score := range scores {
+				fmt.Println(score.Summary())
+			}
+		}
 	case registerCliCommand("which-cluster-osc-replicas", "Information", `Output a list of replicas in a cluster, that could serve as a pt-online-schema-change operation control replicas`):
 		{
 			clusterName := getClusterName(clusterAlias, instanceKey)
@@ -1275,6 +1301,36 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				fmt.Println(fmt.Sprintf("%s:%s", kvPair.Key, kvPair.Value))
 			}
 		}
+	case registerCliCommand("kv-reconcile", "Key-value", `Rewrite all clusters' entries to key-value stores, and list stale keys, which belong to no known cluster`):
+		{
+			_, staleKeys, err := logic.ReconcileKVStores()
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, key := range staleKeys {
+				fmt.Println(key)
+			}
+		}
+	case registerCliCommand("kv-outbox", "Key-value", `List KV outbox entries of a recovery given its UID via --pattern, or all pending entries`):
+		{
+			entries, err := logic.ReadKVOutboxEntries(pattern)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, entry := range entries {
+				fmt.Println(fmt.Sprintf("%s\t%s\t%t\t%s\t%s\t%d\t%s", entry.RecoveryUID, entry.StoreName, entry.IsDistribution, entry.Key, entry.Status, entry.CountAttempts, entry.LastError))
+			}
+		}
+	case registerCliCommand("kv-gc", "Key-value", `Delete stale keys, which belong to no known cluster, from key-value stores`):
+		{
+			staleKeys, err := logic.GarbageCollectKVStores()
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, key := range staleKeys {
+				fmt.Println(key)
+			}
+		}
 
 	case registerCliCommand("tags", "tags", `List tags for a given instance`):
 		{
@@ -1474,6 +1530,14 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				log.Fatal("Cannot deduce instance:", instance)
 			}
 
+			if *config.RuntimeCLIFlags.DryRun {
+				plan, err := logic.PlanCheckAndRecover(instanceKey, destinationKey, (command == "recover-lite"))
+				if err != nil {
+					log.Fatale(err)
+				}
+				fmt.Println(plan.ToJSONString())
+				break
+			}
 			recoveryAttempted, promotedInstanceKey, err := logic.CheckAndRecover(instanceKey, destinationKey, (command == "recover-lite"))
 			if err != nil {
 				log.Fatale(err)
@@ -1488,6 +1552,14 @@ func Cli(command string, strict bool, instance string, destination string, owner
 	case registerCliCommand("force-master-failover", "Recovery", `Forcibly discard master and initiate a failover, even if orchestrator doesn't see a problem. This command lets orchestrator choose the replacement master`):
 		{
 			clusterName := getClusterName(clusterAlias, instanceKey)
+			if *config.RuntimeCLIFlags.DryRun {
+				plan, err := logic.PlanForceMasterFailover(clusterName)
+				if err != nil {
+					log.Fatale(err)
+				}
+				fmt.Println(plan.ToJSONString())
+				break
+			}
 			topologyRecovery, err := logic.ForceMasterFailover(clusterName)
 			if err != nil {
 				log.Fatale(err)
@@ -1513,6 +1585,14 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if destinationKey != nil {
 				validateInstanceIsFound(destinationKey)
 			}
+			if *config.RuntimeCLIFlags.DryRun {
+				plan, err := logic.PlanGracefulMasterTakeover(clusterName, destinationKey, false)
+				if err != nil {
+					log.Fatale(err)
+				}
+				fmt.Println(plan.ToJSONString())
+				break
+			}
 			topologyRecovery, promotedMasterCoordinates, err := logic.GracefulMasterTakeover(clusterName, destinationKey, false)
 			if err != nil {
 				log.Fatale(err)
@@ -1529,6 +1609,14 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if destinationKey != nil {
 				validateInstanceIsFound(destinationKey)
 			}
+			if *config.RuntimeCLIFlags.DryRun {
+				plan, err := logic.PlanGracefulMasterTakeover(clusterName, destinationKey, true)
+				if err != nil {
+					log.Fatale(err)
+				}
+				fmt.Println(plan.ToJSONString())
+				break
+			}
 			topologyRecovery, promotedMasterCoordinates, err := logic.GracefulMasterTakeover(clusterName, destinationKey, true)
 			if err != nil {
 				log.Fatale(err)
@@ -106,6 +106,12 @@ func standardHttp(continuousDiscovery bool) {
 		}
 	}
 
+	m.Use(func(req *nethttp.Request) {
+		if http.IsStreamingAPIRequest(req) {
+			// gzip only applies to clients accepting it; it would hold streamed events back
+			req.Header.Del("Accept-Encoding")
+		}
+	})
 	m.Use(gzip.All())
 	// Render html templates from templates directory
 	m.Use(render.Renderer(render.Options{
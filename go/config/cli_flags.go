@@ -19,6 +19,7 @@ package config
 // CLIFlags stores some command line flags that are globally available in the process' lifetime
 type CLIFlags struct {
 	Noop                       *bool
+	DryRun                     *bool
 	SkipUnresolve              *bool
 	SkipUnresolveCheck         *bool
 	BinlogFile                 *string
@@ -32,6 +33,7 @@ type CLIFlags struct {
 	EnableDatabaseUpdate       *bool
 	IgnoreRaftSetup            *bool
 	Tag                        *string
+	Channel                    *string
 }
 
 var RuntimeCLIFlags CLIFlags
@@ -21,8 +21,11 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/gcfg.v1"
 
@@ -84,6 +87,82 @@ var deprecatedConfigurationVariables = []string{
 	"MaxOutdatedKeysToShow",
 }
 
+// RecoveryHooks lists the recovery hook stages, named after their processes configuration
+var RecoveryHooks = []string{
+	"OnFailureDetectionProcesses",
+	"PreGracefulTakeoverProcesses",
+	"PreFailoverProcesses",
+	"PostFailoverProcesses",
+	"PostUnsuccessfulFailoverProcesses",
+	"PostMasterFailoverProcesses",
+	"PostIntermediateMasterFailoverProcesses",
+	"PostGracefulTakeoverProcesses",
+}
+
+// IsRecoveryHook returns true when given name is one of RecoveryHooks
+func IsRecoveryHook(hook string) bool {
+	for _, recoveryHook := range RecoveryHooks {
+		if hook == recoveryHook {
+			return true
+		}
+	}
+	return false
+}
+
+// RecoveryWebhook is an HTTP endpoint to which recovery hook stages POST a JSON document describing the recovery
+type RecoveryWebhook struct {
+	URL            string   // http:// or https:// endpoint
+	Hooks          []string // Hook stages on which to POST, named as in RecoveryHooks (e.g. "PreFailoverProcesses"). Empty means all stages
+	Secret         string   // When non empty, the body is signed with HMAC-SHA256 keyed by this secret. The hex signature is sent in the X-Orchestrator-Signature header as "sha256=<signature>"
+	TimeoutSeconds uint     // Timeout of a single delivery attempt. 0 means RecoveryWebhookTimeoutSeconds
+	MaxAttempts    uint     // Number of delivery attempts before giving up. 0 means RecoveryWebhookMaxAttempts
+	FailOnError    bool     // When true, the webhook is delivered synchronously, and a failed delivery counts as a failed hook (thus aborting the operation on stages such as PreFailoverProcesses). Otherwise it is delivered asynchronously and failures are only audited
+}
+
+// AppliesToHook returns true when this webhook is to be delivered on given hook stage
+func (this *RecoveryWebhook) AppliesToHook(hook string) bool {
+	if len(this.Hooks) == 0 {
+		return true
+	}
+	for _, webhookHook := range this.Hooks {
+		if webhookHook == hook {
+			return true
+		}
+	}
+	return false
+}
+
+// Fencing methods, by which a failed master is made to refuse writes before a replacement is promoted
+const (
+	FencingMethodMySQL   = "mysql"
+	FencingMethodHTTP    = "http"
+	FencingMethodCommand = "command"
+)
+
+// Fencing policies, deciding whether a master failover may proceed when fencing could not be confirmed
+const (
+	FencingPolicyBestEffort = "best-effort"
+	FencingPolicyRequired   = "required"
+)
+
+// Ways of confirming an unreachable replication group member is dead, before forcing the group's members
+const (
+	ReplicationGroupDeadMemberConfirmationReplicas = "replicas"
+	ReplicationGroupDeadMemberConfirmationMembers  = "members"
+)
+
+// RollingOperationAgentPrefix marks a rolling operation command as an orchestrator-agent call rather than a shell command
+const RollingOperationAgentPrefix = "agent:"
+
+// GetRecoveryHookTimeout returns the timeout of processes of given hook stage; zero means no timeout
+func (this *Configuration) GetRecoveryHookTimeout(hook string) time.Duration {
+	timeoutSeconds, found := this.RecoveryHookTimeoutSeconds[hook]
+	if !found {
+		timeoutSeconds = this.RecoveryProcessesTimeoutSeconds
+	}
+	return time.Duration(timeoutSeconds) * time.Second
+}
+
 // Configuration makes for orchestrator configuration input, which can be provided by user via JSON formatted file.
 // Some of the parameteres have reasonable default values, and some (like database credentials) are
 // strictly expected from user.
@@ -138,12 +217,16 @@ type Configuration struct {
 	DefaultInstancePort                        int      // In case port was not specified on command line
 	SlaveLagQuery                              string   // Synonym to ReplicationLagQuery
 	ReplicationLagQuery                        string   // custom query to check on replica lg (e.g. heartbeat table). Must return a single row with a single numeric column, which is the lag.
+	ReplicationLagFromPerformanceSchema        bool     // On MySQL 8.0 replicas, read per-worker lag and apply rate from performance_schema. Takes precedence over ReplicationLagQuery in lag based decisions
 	ReplicationCredentialsQuery                string   // custom query to get replication credentials. Must return a single row, with five text columns: 1st is username, 2nd is password, 3rd is SSLCaCert, 4th is SSLCert, 5th is SSLKey. This is optional, and can be used by orchestrator to configure replication after master takeover or setup of co-masters. You need to ensure the orchestrator user has the privileges to run this query
 	DiscoverByShowSlaveHosts                   bool     // Attempt SHOW SLAVE HOSTS before PROCESSLIST
 	UseSuperReadOnly                           bool     // Should orchestrator super_read_only any time it sets read_only
 	InstancePollSeconds                        uint     // Number of seconds between instance reads
 	DeadInstancePollSecondsMultiplyFactor      float32  // InstancePoolSeconds increase factor for dead instances read time calculation
 	DeadInstancePollSecondsMax                 uint     // Maximum delay between dead instance read attempts
+	AdaptiveInstancePoll                       bool     // When true, poll intervals adapt per instance: stable, lag free leaf replicas are polled less often, masters and instances with recent state changes, lag or errors more often
+	AdaptiveInstancePollSecondsMin             uint     // With AdaptiveInstancePoll, the poll interval of masters and of instances with recent state changes, lag or errors
+	AdaptiveInstancePollSecondsMax             uint     // With AdaptiveInstancePoll, the maximum poll interval of stable, lag free leaf replicas
 	DeadInstanceDiscoveryMaxConcurrency        uint     // Number of goroutines doing dead hosts discovery
 	DeadInstanceDiscoveryLogsEnabled           bool     // Enable logs related to dead instances discoveries
 	ReasonableInstanceCheckSeconds             uint     // Number of seconds an instance read is allowed to take before it is considered invalid, i.e. before LastCheckValid will be false
@@ -158,6 +241,12 @@ type Configuration struct {
 	DiscoveryQueueMaxStatisticsSize            int      // The maximum number of individual secondly statistics taken of the discovery queue
 	DiscoveryCollectionRetentionSeconds        uint     // Number of seconds to retain the discovery collection information
 	DiscoverySeeds                             []string // Hard coded array of hostname:port, ensuring orchestrator discovers these hosts upon startup, assuming not already known to orchestrator
+	DiscoverySeedsDNSNames                     []string // DNS names periodically resolved into seeds: SRV records (e.g. "_mysql._tcp.db.example.com"), or A/AAAA records with optional port (e.g. "db.example.com:3306")
+	DiscoverySeedsFile                         string   // Path to a file listing hostname:port seeds, as JSON array or one per line. Re-read when modified
+	DiscoverySeedsURL                          string   // HTTP(S) endpoint returning a JSON array of hostname:port seeds
+	DiscoverySeedsHTTPTimeoutSeconds           uint     // Timeout for querying DiscoverySeedsURL
+	DiscoverySeedsPollSeconds                  uint     // Interval at which seed providers are re-evaluated
+	ForgetDisappearedDiscoverySeeds            bool     // When true, instances which were listed by a seed provider and no longer listed by any, are forgotten
 	InstanceBulkOperationsWaitTimeoutSeconds   uint     // Time to wait on a single instance when doing bulk (many instances) operation
 	HostnameResolveMethod                      string   // Method by which to "normalize" hostname ("none"/"default"/"cname")
 	MySQLHostnameResolveMethod                 string   // Method by which to "normalize" hostname via MySQL server. ("none"/"@@hostname"/"@@report_host"; default "@@hostname")
@@ -237,6 +326,8 @@ type Configuration struct {
 	RecoveryIgnoreHostnameFilters              []string          // Recovery analysis will completely ignore hosts matching given patterns
 	RecoverMasterClusterFilters                []string          // Only do master recovery on clusters matching these regexp patterns (of course the ".*" pattern matches everything)
 	RecoverIntermediateMasterClusterFilters    []string          // Only do IM recovery on clusters matching these regexp patterns (of course the ".*" pattern matches everything)
+	RecoverReplicationGroupClusterFilters      []string          // Only do group replication quorum recovery (forcing the members of the largest reachable partition) on clusters matching these regexp patterns
+	ReplicationGroupDeadMemberConfirmation     string            // How an unreachable group member is confirmed dead before forcing members: "replicas" (default; by its async replicas) or "members" (also by the UNREACHABLE view of a majority of reached members)
 	ProcessesShellCommand                      string            // Shell that executes command scripts
 	OnFailureDetectionProcesses                []string          // Processes to execute when detecting a failover scenario (before making a decision whether to failover or not). May and should use some of these placeholders: {failureType}, {instanceType}, {isMaster}, {isCoMaster}, {failureDescription}, {command}, {failedHost}, {failureCluster}, {failureClusterAlias}, {failureClusterDomain}, {failedPort}, {successorHost}, {successorPort}, {successorAlias}, {countReplicas}, {replicaHosts}, {isDowntimed}, {autoMasterRecovery}, {autoIntermediateMasterRecovery}
 	PreGracefulTakeoverProcesses               []string          // Processes to execute before doing a failover (aborting operation should any once of them exits with non-zero code; order of execution undefined). May and should use some of these placeholders: {failureType}, {instanceType}, {isMaster}, {isCoMaster}, {failureDescription}, {command}, {failedHost}, {failureCluster}, {failureClusterAlias}, {failureClusterDomain}, {failedPort}, {successorHost}, {successorPort}, {countReplicas}, {replicaHosts}, {isDowntimed}
@@ -247,6 +338,29 @@ type Configuration struct {
 	PostIntermediateMasterFailoverProcesses    []string          // Processes to execute after doing a master failover (order of execution undefined). Uses same placeholders as PostFailoverProcesses
 	PostGracefulTakeoverProcesses              []string          // Processes to execute after running a graceful master takeover. Uses same placeholders as PostFailoverProcesses
 	PostTakeMasterProcesses                    []string          // Processes to execute after a successful Take-Master event has taken place
+	AllowRecoveryPolicyHookOverrides           bool              // When true, cluster recovery policies may override the hook process lists above. Policies are set via API: enabling this lets API users have orchestrator run arbitrary commands
+	RecoveryProcessesTimeoutSeconds            uint              // Timeout for each recovery hook process, after which its process group is killed. 0 means no timeout
+	RecoveryHookTimeoutSeconds                 map[string]uint   // Per hook stage timeouts, overriding RecoveryProcessesTimeoutSeconds, keyed as in RecoveryHooks, e.g. {"PreFailoverProcesses": 30}
+	RecoveryProcessesAuditOutputBytes          uint              // Maximum bytes of stdout and of stderr of a recovery hook process to audit in recovery steps
+	RecoveryWebhooks                           []RecoveryWebhook // HTTP endpoints to POST a JSON document of the recovery to, on recovery hook stages, alongside the stages' processes
+	RecoveryWebhookTimeoutSeconds              uint              // Default timeout of a single webhook delivery attempt
+	RecoveryWebhookMaxAttempts                 uint              // Default number of webhook delivery attempts
+	RecoveryWebhookRetryBackoffMilliseconds    uint              // Wait before the first webhook delivery retry; doubled on each further retry
+	FencingMethods                             []string          // Methods by which to fence a dead master before promoting a replacement, attempted in order: "mysql" (set super_read_only and kill client connections), "http" (POST to FencingHTTPURL), "command" (run FencingCommand). Empty means no fencing
+	FencingHTTPURL                             string            // http:// or https:// endpoint to POST a fencing request to; a 2xx response confirms the master is fenced
+	FencingCommand                             string            // Command to run to fence a master; exit code 0 confirms the master is fenced. Uses same placeholders as PreFailoverProcesses
+	FencingTimeoutSeconds                      uint              // Timeout of each fencing method
+	FencingPolicy                              string            // "best-effort": failover proceeds even if no fencing method succeeds. "required": failover is aborted unless at least one fencing method succeeds
+	ScheduledTakeoverMaxDelayMinutes           uint              // A graceful master takeover scheduled at a given time (rather than within a maintenance window) is attempted for up to this many minutes past its time, while its pre-checks fail, before it expires
+	ScheduledTakeoverRunningTimeoutMinutes     uint              // A scheduled takeover still running this many minutes after it started, e.g. as the node running it lost leadership or died, is marked as failed
+	RollingOperationCommands                   map[string]string // Named per-instance commands a rolling operation may run, e.g. {"restart": "ssh {host} sudo systemctl restart mysql"}. Placeholders: {host}, {port}, {clusterName}, {clusterAlias}, {operationUID}. "agent:mysql-restart" restarts MySQL via orchestrator-agent; "agent:<name>" runs the agent's custom command <name>
+	RollingOperationCommandTimeoutSeconds      uint              // Timeout of a rolling operation command on a single instance, after which its process group is killed
+	RollingOperationCatchUpTimeoutSeconds      uint              // Time a rolling operation waits for an instance to be reachable, replicating and caught up (lag within ReasonableReplicationLagSeconds) after its command completes
+	ErrantGTIDAutoRemediationClusterFilters    []string          // Clusters (name, alias or regexp, as in RecoverMasterClusterFilters) whose replicas' errant GTIDs are remediated automatically, by injecting empty transactions on the master once verified harmless. Empty disables
+	ErrantGTIDAutoRemediationInstanceFilters   []string          // When non-empty, only replicas whose hostname:port matches one of these filters are remediated automatically
+	ErrantGTIDAutoRemediationSchemas           []string          // Errant transactions verified, via binlog inspection, to only touch these schemas are considered harmless
+	ErrantGTIDAutoRemediationMinAgeMinutes     uint              // Errant transactions observed, unchanged, for at least this many minutes are considered harmless. 0 disables this criterion
+	ErrantGTIDAutoRemediationMaxTransactions   uint              // Errant GTID sets of more transactions than this are never remediated automatically
 	RecoverNonWriteableMaster                  bool              // When 'true', orchestrator treats a read-only master as a failure scenario and attempts to make the master writeable
 	CoMasterRecoveryMustPromoteOtherCoMaster   bool              // When 'false', anything can get promoted (and candidates are preferred over others). When 'true', orchestrator will promote the other co-master or else fail
 	DetachLostSlavesAfterMasterFailover        bool              // synonym to DetachLostReplicasAfterMasterFailover
@@ -279,13 +393,38 @@ type Configuration struct {
 	ConsulCrossDataCenterDistribution          bool              // should orchestrator automatically auto-deduce all consul DCs and write KVs in all DCs
 	ConsulKVStoreProvider                      string            // Consul KV store provider (consul or consul-txn), default: "consul"
 	ConsulMaxKVsPerTransaction                 int               // Maximum number of KV operations to perform in a single Consul Transaction. Requires the "consul-txn" ConsulKVStoreProvider
+	EtcdEndpoints                              []string          // etcd v3 client endpoints (URLs) where KVs are written. Example: ["https://etcd-1:2379", "https://etcd-2:2379"]. Empty disables etcd
+	EtcdSSLCertFile                            string            // Client certificate file for etcd TLS
+	EtcdSSLPrivateKeyFile                      string            // Client private key file for etcd TLS
+	EtcdSSLCAFile                              string            // CA file to verify etcd servers with
+	EtcdSSLSkipVerify                          bool              // When true, do not verify etcd servers' certificates
+	EtcdMasterKeysLeaseSeconds                 uint              // When nonzero, clusters' master entries in etcd are attached to a lease of this TTL, kept alive by orchestrator. 0 (default) means no lease
+	EtcdCrossClusterEndpoints                  []string          // Additional etcd clusters, each as a comma separated list of endpoints, to which KVs are distributed
+	KVFileSinkDirectory                        string            // When set, KVs are written as a tree of files under this (absolute) directory, one file per key
+	KVFileSinkFormat                           string            // Format of KVFileSinkDirectory files: "json" (default) or "env"
+	KVFileSinkClusterDocumentsDirectory        string            // When set, each cluster's KVFileSinkDirectory pairs are also written together, as a single file per cluster alias under this (absolute) directory
+	KVFileSinkSocket                           string            // When set, path of a Unix socket on which KVFileSinkDirectory's pairs and their changes are streamed to local subscribers
+	KVFileSinkSocketMode                       string            // Octal permissions of KVFileSinkSocket, e.g. "0660" (default). Connecting requires write permission
 	ZkAddress                                  string            // UNSUPPERTED YET. Address where (single or multiple) ZooKeeper servers are found, in `srv1[:port1][,srv2[:port2]...]` format. Default port is 2181. Example: srv-a,srv-b:12181,srv-c
 	KVClusterMasterPrefix                      string            // Prefix to use for clusters' masters entries in KV stores (internal, consul, ZK), default: "mysql/master"
+	KVPublishReplicas                          bool              // When true, clusters' healthy replicas are published to KV stores under KVClusterReplicasPrefix
+	KVClusterReplicasPrefix                    string            // Prefix to use for clusters' replicas entries in KV stores, default: "mysql/replicas"
+	KVReplicasMaxLagSeconds                    int               // Replicas lagging more than this are not published. 0 (default) falls back to ReasonableReplicationLagSeconds
+	KVReplicaPools                             []string          // Pools (as submitted via submit-pool-instances) for which replicas sets are additionally published
+	KVReplicaTags                              []string          // Tags (e.g. "role=reader", or "role=reader,dc=east" for intersection) for which replicas sets are additionally published
+	KVPublishIntermediateMasters               bool              // When true, clusters' intermediate masters are published to KV stores under KVClusterIntermediateMastersPrefix
+	KVClusterIntermediateMastersPrefix         string            // Prefix to use for clusters' intermediate masters entries in KV stores, default: "mysql/intermediate-masters"
+	KVPublishClusterStatus                     bool              // When true, a JSON status document per cluster is published to KV stores under KVClusterStatusPrefix
+	KVClusterStatusPrefix                      string            // Prefix to use for clusters' status entries in KV stores, default: "mysql/status"
+	KVOutboxEnabled                            bool              // When true, KV writes of recoveries go through a durable outbox in the backend database, and are retried per store until delivered
+	KVOutboxRetryBackoffSeconds                uint              // Wait before the first retry of a failed outbox delivery; doubled on each further retry
+	KVOutboxMaxRetryBackoffSeconds             uint              // Maximum wait between retries of a failed outbox delivery
 	WebMessage                                 string            // If provided, will be shown on all web pages below the title bar
 	MaxConcurrentReplicaOperations             int               // Maximum number of concurrent operations on replicas
 	EnforceExactSemiSyncReplicas               bool              // If true, semi-sync replicas will be enabled/disabled to match the wait count in the desired priority order; this applies to LockedSemiSyncMaster and MasterWithTooManySemiSyncReplicas
 	RecoverLockedSemiSyncMaster                bool              // If true, orchestrator will recover from a LockedSemiSync state by enabling semi-sync on replicas to match the wait count; this behavior can be overridden by EnforceExactSemiSyncReplicas
 	ReasonableLockedSemiSyncMasterSeconds      uint              // Time to evaluate the LockedSemiSyncHypothesis before triggering the LockedSemiSync analysis; falls back to ReasonableReplicationLagSeconds if not set
+	ReasonableGroupMemberRecoveringSeconds     uint              // Time a group replication member may spend in RECOVERING state before being analyzed as stuck
 	PrependMessagesWithOrcIdentity             string            // use FQDN/hostname/custom to prefix error message returned to the client. Empty string (default)/none skips prefixing.
 	CustomOrcIdentity                          string            // use if PrependMessagesWithOrcIdentity is 'custom'
 }
@@ -338,6 +477,9 @@ func newConfiguration() *Configuration {
 		InstancePollSeconds:                        5,
 		DeadInstancePollSecondsMultiplyFactor:      1,
 		DeadInstancePollSecondsMax:                 5 * 60,
+		AdaptiveInstancePoll:                       false,
+		AdaptiveInstancePollSecondsMin:             2,
+		AdaptiveInstancePollSecondsMax:             60,
 		DeadInstanceDiscoveryMaxConcurrency:        0,
 		DeadInstanceDiscoveryLogsEnabled:           false,
 		ReasonableInstanceCheckSeconds:             1,
@@ -354,6 +496,12 @@ func newConfiguration() *Configuration {
 		DiscoveryQueueMaxStatisticsSize:            120,
 		DiscoveryCollectionRetentionSeconds:        120,
 		DiscoverySeeds:                             []string{},
+		DiscoverySeedsDNSNames:                     []string{},
+		DiscoverySeedsFile:                         "",
+		DiscoverySeedsURL:                          "",
+		DiscoverySeedsHTTPTimeoutSeconds:           10,
+		DiscoverySeedsPollSeconds:                  60,
+		ForgetDisappearedDiscoverySeeds:            false,
 		InstanceBulkOperationsWaitTimeoutSeconds:   10,
 		HostnameResolveMethod:                      "default",
 		MySQLHostnameResolveMethod:                 "@@hostname",
@@ -426,6 +574,8 @@ func newConfiguration() *Configuration {
 		RecoveryIgnoreHostnameFilters:              []string{},
 		RecoverMasterClusterFilters:                []string{},
 		RecoverIntermediateMasterClusterFilters:    []string{},
+		RecoverReplicationGroupClusterFilters:      []string{},
+		ReplicationGroupDeadMemberConfirmation:     ReplicationGroupDeadMemberConfirmationReplicas,
 		ProcessesShellCommand:                      "bash",
 		OnFailureDetectionProcesses:                []string{},
 		PreGracefulTakeoverProcesses:               []string{},
@@ -436,6 +586,29 @@ func newConfiguration() *Configuration {
 		PostUnsuccessfulFailoverProcesses:          []string{},
 		PostGracefulTakeoverProcesses:              []string{},
 		PostTakeMasterProcesses:                    []string{},
+		AllowRecoveryPolicyHookOverrides:           false,
+		RecoveryProcessesTimeoutSeconds:            0,
+		RecoveryHookTimeoutSeconds:                 make(map[string]uint),
+		RecoveryProcessesAuditOutputBytes:          1024,
+		RecoveryWebhooks:                           []RecoveryWebhook{},
+		RecoveryWebhookTimeoutSeconds:              10,
+		RecoveryWebhookMaxAttempts:                 3,
+		RecoveryWebhookRetryBackoffMilliseconds:    500,
+		FencingMethods:                             []string{},
+		FencingHTTPURL:                             "",
+		FencingCommand:                             "",
+		FencingTimeoutSeconds:                      10,
+		FencingPolicy:                              FencingPolicyBestEffort,
+		ScheduledTakeoverMaxDelayMinutes:           60,
+		ScheduledTakeoverRunningTimeoutMinutes:     30,
+		RollingOperationCommands:                   make(map[string]string),
+		RollingOperationCommandTimeoutSeconds:      600,
+		RollingOperationCatchUpTimeoutSeconds:      1800,
+		ErrantGTIDAutoRemediationClusterFilters:    []string{},
+		ErrantGTIDAutoRemediationInstanceFilters:   []string{},
+		ErrantGTIDAutoRemediationSchemas:           []string{},
+		ErrantGTIDAutoRemediationMinAgeMinutes:     0,
+		ErrantGTIDAutoRemediationMaxTransactions:   100,
 		RecoverNonWriteableMaster:                  false,
 		CoMasterRecoveryMustPromoteOtherCoMaster:   true,
 		DetachLostSlavesAfterMasterFailover:        true,
@@ -463,13 +636,38 @@ func newConfiguration() *Configuration {
 		ConsulCrossDataCenterDistribution:          false,
 		ConsulKVStoreProvider:                      "consul",
 		ConsulMaxKVsPerTransaction:                 ConsulKVsPerCluster,
+		EtcdEndpoints:                              []string{},
+		EtcdSSLCertFile:                            "",
+		EtcdSSLPrivateKeyFile:                      "",
+		EtcdSSLCAFile:                              "",
+		EtcdSSLSkipVerify:                          false,
+		EtcdMasterKeysLeaseSeconds:                 0,
+		EtcdCrossClusterEndpoints:                  []string{},
+		KVFileSinkDirectory:                        "",
+		KVFileSinkFormat:                           "json",
+		KVFileSinkClusterDocumentsDirectory:        "",
+		KVFileSinkSocket:                           "",
+		KVFileSinkSocketMode:                       "0660",
 		ZkAddress:                                  "",
 		KVClusterMasterPrefix:                      "mysql/master",
+		KVPublishReplicas:                          false,
+		KVClusterReplicasPrefix:                    "mysql/replicas",
+		KVReplicasMaxLagSeconds:                    0,
+		KVReplicaPools:                             []string{},
+		KVReplicaTags:                              []string{},
+		KVPublishIntermediateMasters:               false,
+		KVClusterIntermediateMastersPrefix:         "mysql/intermediate-masters",
+		KVPublishClusterStatus:                     false,
+		KVClusterStatusPrefix:                      "mysql/status",
+		KVOutboxEnabled:                            false,
+		KVOutboxRetryBackoffSeconds:                1,
+		KVOutboxMaxRetryBackoffSeconds:             60,
 		WebMessage:                                 "",
 		MaxConcurrentReplicaOperations:             5,
 		EnforceExactSemiSyncReplicas:               false,
 		RecoverLockedSemiSyncMaster:                false,
 		ReasonableLockedSemiSyncMasterSeconds:      0,
+		ReasonableGroupMemberRecoveringSeconds:     300,
 		PrependMessagesWithOrcIdentity:             "",
 		CustomOrcIdentity:                          "",
 	}
@@ -595,12 +793,23 @@ func (this *Configuration) postReadAdjustments() error {
 	if this.RaftAdvertise == "" {
 		this.RaftAdvertise = this.RaftBind
 	}
-	if this.KVClusterMasterPrefix != "/" {
-		// "/" remains "/"
-		// "prefix" turns to "prefix/"
-		// "some/prefix///" turns to "some/prefix/"
-		this.KVClusterMasterPrefix = strings.TrimRight(this.KVClusterMasterPrefix, "/")
-		this.KVClusterMasterPrefix = fmt.Sprintf("%s/", this.KVClusterMasterPrefix)
+	for _, kvPrefix := range []*string{&this.KVClusterMasterPrefix, &this.KVClusterReplicasPrefix, &this.KVClusterIntermediateMastersPrefix, &this.KVClusterStatusPrefix} {
+		if *kvPrefix != "/" {
+			// "/" remains "/"
+			// "prefix" turns to "prefix/"
+			// "some/prefix///" turns to "some/prefix/"
+			*kvPrefix = strings.TrimRight(*kvPrefix, "/")
+			*kvPrefix = fmt.Sprintf("%s/", *kvPrefix)
+		}
+	}
+	if this.KVReplicasMaxLagSeconds == 0 {
+		this.KVReplicasMaxLagSeconds = this.ReasonableReplicationLagSeconds
+	}
+	if this.KVOutboxRetryBackoffSeconds == 0 {
+		return fmt.Errorf("KVOutboxRetryBackoffSeconds must be greater than 0")
+	}
+	if this.KVOutboxMaxRetryBackoffSeconds < this.KVOutboxRetryBackoffSeconds {
+		return fmt.Errorf("KVOutboxMaxRetryBackoffSeconds must be at least KVOutboxRetryBackoffSeconds")
 	}
 	if this.AutoPseudoGTID {
 		this.PseudoGTIDPattern = "drop view if exists `_pseudo_gtid_`"
@@ -634,10 +843,60 @@ func (this *Configuration) postReadAdjustments() error {
 	} else if this.ConsulMaxKVsPerTransaction > ConsulMaxTransactionOps {
 		this.ConsulMaxKVsPerTransaction = ConsulMaxTransactionOps
 	}
+	etcdEndpoints := []string{}
+	etcdEndpoints = append(etcdEndpoints, this.EtcdEndpoints...)
+	for _, endpoints := range this.EtcdCrossClusterEndpoints {
+		etcdEndpoints = append(etcdEndpoints, strings.Split(endpoints, ",")...)
+	}
+	for _, endpoint := range etcdEndpoints {
+		endpoint = strings.TrimSpace(endpoint)
+		if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+			return fmt.Errorf("etcd endpoint must be an http:// or https:// URL: %s", endpoint)
+		}
+	}
+	if len(this.EtcdCrossClusterEndpoints) > 0 && len(this.EtcdEndpoints) == 0 {
+		return fmt.Errorf("EtcdCrossClusterEndpoints requires EtcdEndpoints")
+	}
+	if this.EtcdMasterKeysLeaseSeconds > 0 && this.EtcdMasterKeysLeaseSeconds < 5 {
+		return fmt.Errorf("EtcdMasterKeysLeaseSeconds must be 0 (no lease) or at least 5")
+	}
+	if this.KVFileSinkFormat == "" {
+		this.KVFileSinkFormat = "json"
+	}
+	if this.KVFileSinkFormat != "json" && this.KVFileSinkFormat != "env" {
+		return fmt.Errorf("KVFileSinkFormat must be either \"json\" or \"env\"")
+	}
+	if this.KVFileSinkDirectory != "" && !filepath.IsAbs(this.KVFileSinkDirectory) {
+		return fmt.Errorf("KVFileSinkDirectory must be an absolute path")
+	}
+	if this.KVFileSinkClusterDocumentsDirectory != "" {
+		if this.KVFileSinkDirectory == "" {
+			return fmt.Errorf("KVFileSinkClusterDocumentsDirectory requires KVFileSinkDirectory")
+		}
+		if !filepath.IsAbs(this.KVFileSinkClusterDocumentsDirectory) {
+			return fmt.Errorf("KVFileSinkClusterDocumentsDirectory must be an absolute path")
+		}
+		if relPath, err := filepath.Rel(this.KVFileSinkDirectory, this.KVFileSinkClusterDocumentsDirectory); err == nil && !strings.HasPrefix(relPath, "..") {
+			return fmt.Errorf("KVFileSinkClusterDocumentsDirectory must not be within KVFileSinkDirectory")
+		}
+	}
+	if this.KVFileSinkSocket != "" && this.KVFileSinkDirectory == "" {
+		return fmt.Errorf("KVFileSinkSocket requires KVFileSinkDirectory")
+	}
+	if this.KVFileSinkSocketMode == "" {
+		this.KVFileSinkSocketMode = "0660"
+	}
+	if mode, err := strconv.ParseUint(this.KVFileSinkSocketMode, 8, 32); err != nil || mode > 0777 {
+		return fmt.Errorf("KVFileSinkSocketMode must be octal permissions, e.g. \"0660\"")
+	}
 	if this.ReasonableLockedSemiSyncMasterSeconds == 0 {
 		this.ReasonableLockedSemiSyncMasterSeconds = uint(this.ReasonableReplicationLagSeconds)
 	}
 
+	if this.DiscoverySeedsPollSeconds == 0 && (len(this.DiscoverySeedsDNSNames) > 0 || this.DiscoverySeedsFile != "" || this.DiscoverySeedsURL != "") {
+		return fmt.Errorf("DiscoverySeedsPollSeconds must be greater than 0 when DiscoverySeedsDNSNames, DiscoverySeedsFile or DiscoverySeedsURL are configured")
+	}
+
 	if this.DeadInstancePollSecondsMultiplyFactor < 1 {
 		return fmt.Errorf("DeadInstancePollSecondsMultiplyFactor can not be smaller than 1")
 	}
@@ -645,6 +904,77 @@ func (this *Configuration) postReadAdjustments() error {
 	if this.DeadInstancePollSecondsMax < this.InstancePollSeconds {
 		return fmt.Errorf(("DeadInstancePollSecondsMax can not be smaller than InstancePollSeconds"))
 	}
+	for hook := range this.RecoveryHookTimeoutSeconds {
+		if !IsRecoveryHook(hook) {
+			return fmt.Errorf("Unknown hook %s in RecoveryHookTimeoutSeconds. Known hooks: %s", hook, strings.Join(RecoveryHooks, ", "))
+		}
+	}
+	if this.RecoveryWebhookTimeoutSeconds == 0 {
+		return fmt.Errorf("RecoveryWebhookTimeoutSeconds must be greater than 0")
+	}
+	if this.RecoveryWebhookMaxAttempts == 0 {
+		return fmt.Errorf("RecoveryWebhookMaxAttempts must be greater than 0")
+	}
+	for _, webhook := range this.RecoveryWebhooks {
+		u, err := url.Parse(webhook.URL)
+		if err != nil {
+			return fmt.Errorf("Failed parsing RecoveryWebhooks URL %s: %s", webhook.URL, err.Error())
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("RecoveryWebhooks URL must use http:// or https:// scheme; got %s", webhook.URL)
+		}
+		for _, hook := range webhook.Hooks {
+			if !IsRecoveryHook(hook) {
+				return fmt.Errorf("Unknown hook %s in RecoveryWebhooks for %s. Known hooks: %s", hook, webhook.URL, strings.Join(RecoveryHooks, ", "))
+			}
+		}
+	}
+	for _, method := range this.FencingMethods {
+		switch method {
+		case FencingMethodMySQL:
+		case FencingMethodHTTP:
+			if u, err := url.Parse(this.FencingHTTPURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				return fmt.Errorf("FencingMethods includes %s, but FencingHTTPURL is not an http:// or https:// URL: %s", method, this.FencingHTTPURL)
+			}
+		case FencingMethodCommand:
+			if this.FencingCommand == "" {
+				return fmt.Errorf("FencingMethods includes %s, but FencingCommand is empty", method)
+			}
+		default:
+			return fmt.Errorf("Unknown fencing method %s. Known methods: %s, %s, %s", method, FencingMethodMySQL, FencingMethodHTTP, FencingMethodCommand)
+		}
+	}
+	if this.FencingPolicy != FencingPolicyBestEffort && this.FencingPolicy != FencingPolicyRequired {
+		return fmt.Errorf("FencingPolicy must be either %s or %s; got %s", FencingPolicyBestEffort, FencingPolicyRequired, this.FencingPolicy)
+	}
+	if this.ReplicationGroupDeadMemberConfirmation == "" {
+		this.ReplicationGroupDeadMemberConfirmation = ReplicationGroupDeadMemberConfirmationReplicas
+	}
+	if this.ReplicationGroupDeadMemberConfirmation != ReplicationGroupDeadMemberConfirmationReplicas && this.ReplicationGroupDeadMemberConfirmation != ReplicationGroupDeadMemberConfirmationMembers {
+		return fmt.Errorf("ReplicationGroupDeadMemberConfirmation must be either %s or %s; got %s", ReplicationGroupDeadMemberConfirmationReplicas, ReplicationGroupDeadMemberConfirmationMembers, this.ReplicationGroupDeadMemberConfirmation)
+	}
+	if len(this.FencingMethods) > 0 && this.FencingTimeoutSeconds == 0 {
+		return fmt.Errorf("FencingTimeoutSeconds must be greater than 0")
+	}
+	for name, command := range this.RollingOperationCommands {
+		if strings.TrimSpace(command) == "" || strings.TrimSpace(command) == RollingOperationAgentPrefix {
+			return fmt.Errorf("RollingOperationCommands: empty command for %s", name)
+		}
+	}
+	if this.RollingOperationCommandTimeoutSeconds == 0 || this.RollingOperationCatchUpTimeoutSeconds == 0 {
+		return fmt.Errorf("RollingOperationCommandTimeoutSeconds and RollingOperationCatchUpTimeoutSeconds must be greater than 0")
+	}
+	if len(this.ErrantGTIDAutoRemediationClusterFilters) > 0 && len(this.ErrantGTIDAutoRemediationSchemas) == 0 && this.ErrantGTIDAutoRemediationMinAgeMinutes == 0 {
+		return fmt.Errorf("ErrantGTIDAutoRemediationClusterFilters is set, but neither ErrantGTIDAutoRemediationSchemas nor ErrantGTIDAutoRemediationMinAgeMinutes is; errant GTIDs could never be verified harmless")
+	}
+	if this.AdaptiveInstancePoll {
+		if this.AdaptiveInstancePollSecondsMin == 0 || this.AdaptiveInstancePollSecondsMin > this.InstancePollSeconds {
+			return fmt.Errorf("AdaptiveInstancePollSecondsMin must be positive and no greater than InstancePollSeconds")
+		}
+		if this.AdaptiveInstancePollSecondsMax < this.InstancePollSeconds {
+			return fmt.Errorf("AdaptiveInstancePollSecondsMax can not be smaller than InstancePollSeconds")
+		}
+	}
 	return nil
 }
 
@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/openark/golib/log"
 	test "github.com/openark/golib/tests"
@@ -215,3 +216,336 @@ func TestHttpAdvertise(t *testing.T) {
 		test.S(t).ExpectNotNil(err)
 	}
 }
+
+func TestDiscoverySeedsPollSeconds(t *testing.T) {
+	{
+		c := newConfiguration()
+		c.DiscoverySeedsPollSeconds = 0
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.DiscoverySeedsPollSeconds = 0
+		c.DiscoverySeedsFile = "/etc/orchestrator/seeds.json"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestRecoveryWebhooks(t *testing.T) {
+	{
+		c := newConfiguration()
+		c.RecoveryWebhooks = []RecoveryWebhook{{URL: "https://hooks.example.com/orchestrator", Hooks: []string{"PreFailoverProcesses", "PostFailoverProcesses"}}}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(c.RecoveryWebhooks[0].AppliesToHook("PreFailoverProcesses"))
+		test.S(t).ExpectFalse(c.RecoveryWebhooks[0].AppliesToHook("OnFailureDetectionProcesses"))
+	}
+	{
+		c := newConfiguration()
+		c.RecoveryWebhooks = []RecoveryWebhook{{URL: "http://127.0.0.1:8080/hook"}}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(c.RecoveryWebhooks[0].AppliesToHook("OnFailureDetectionProcesses"))
+	}
+	{
+		c := newConfiguration()
+		c.RecoveryWebhooks = []RecoveryWebhook{{URL: "hooks.example.com/orchestrator"}}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.RecoveryWebhooks = []RecoveryWebhook{{URL: "https://hooks.example.com/orchestrator", Hooks: []string{"PreFailover"}}}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.RecoveryWebhookMaxAttempts = 0
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestRecoveryHookTimeout(t *testing.T) {
+	{
+		c := newConfiguration()
+		c.RecoveryProcessesTimeoutSeconds = 60
+		c.RecoveryHookTimeoutSeconds = map[string]uint{"PreFailoverProcesses": 10, "PostFailoverProcesses": 0}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(c.GetRecoveryHookTimeout("PreFailoverProcesses"), 10*time.Second)
+		test.S(t).ExpectEquals(c.GetRecoveryHookTimeout("PostFailoverProcesses"), time.Duration(0))
+		test.S(t).ExpectEquals(c.GetRecoveryHookTimeout("PostMasterFailoverProcesses"), 60*time.Second)
+	}
+	{
+		c := newConfiguration()
+		c.RecoveryHookTimeoutSeconds = map[string]uint{"PreFailover": 10}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestFencing(t *testing.T) {
+	{
+		c := newConfiguration()
+		c.FencingMethods = []string{"mysql", "http", "command"}
+		c.FencingHTTPURL = "https://fencing.example.com/fence"
+		c.FencingCommand = "/usr/local/bin/fence {failedHost}"
+		c.FencingPolicy = "required"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.FencingMethods = []string{"http"}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.FencingMethods = []string{"command"}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.FencingMethods = []string{"stonith"}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.FencingPolicy = "always"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestRollingOperationCommands(t *testing.T) {
+	{
+		c := newConfiguration()
+		c.RollingOperationCommands = map[string]string{
+			"restart":       "ssh {host} sudo systemctl restart mysql",
+			"agent-restart": "agent:mysql-restart",
+		}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.RollingOperationCommands = map[string]string{"restart": " "}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.RollingOperationCommands = map[string]string{"agent": "agent:"}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.RollingOperationCatchUpTimeoutSeconds = 0
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestErrantGTIDAutoRemediation(t *testing.T) {
+	{
+		c := newConfiguration()
+		c.ErrantGTIDAutoRemediationClusterFilters = []string{"*"}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.ErrantGTIDAutoRemediationClusterFilters = []string{"*"}
+		c.ErrantGTIDAutoRemediationSchemas = []string{"meta"}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.ErrantGTIDAutoRemediationClusterFilters = []string{"*"}
+		c.ErrantGTIDAutoRemediationMinAgeMinutes = 60
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+	}
+}
+
+func TestEtcdEndpoints(t *testing.T) {
+	{
+		c := newConfiguration()
+		c.EtcdEndpoints = []string{"https://etcd-1:2379", "http://etcd-2:2379"}
+		c.EtcdCrossClusterEndpoints = []string{"https://etcd-dc2-1:2379,https://etcd-dc2-2:2379"}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.EtcdEndpoints = []string{"etcd-1:2379"}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.EtcdCrossClusterEndpoints = []string{"https://etcd-dc2-1:2379"}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.EtcdEndpoints = []string{"https://etcd-1:2379"}
+		c.EtcdMasterKeysLeaseSeconds = 2
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestKVPublication(t *testing.T) {
+	{
+		c := newConfiguration()
+		c.KVClusterReplicasPrefix = "some/replicas///"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(c.KVClusterReplicasPrefix, "some/replicas/")
+		test.S(t).ExpectEquals(c.KVClusterIntermediateMastersPrefix, "mysql/intermediate-masters/")
+		test.S(t).ExpectEquals(c.KVClusterStatusPrefix, "mysql/status/")
+		test.S(t).ExpectEquals(c.KVReplicasMaxLagSeconds, c.ReasonableReplicationLagSeconds)
+	}
+	{
+		c := newConfiguration()
+		c.KVReplicasMaxLagSeconds = 3
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(c.KVReplicasMaxLagSeconds, 3)
+	}
+}
+
+func TestKVOutboxRetryBackoff(t *testing.T) {
+	{
+		c := newConfiguration()
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.KVOutboxRetryBackoffSeconds = 0
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.KVOutboxRetryBackoffSeconds = 10
+		c.KVOutboxMaxRetryBackoffSeconds = 5
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestKVFileSink(t *testing.T) {
+	{
+		c := newConfiguration()
+		c.KVFileSinkFormat = ""
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(c.KVFileSinkFormat, "json")
+	}
+	{
+		c := newConfiguration()
+		c.KVFileSinkFormat = "yaml"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.KVFileSinkDirectory = "var/lib/orchestrator/kv"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.KVFileSinkSocket = "/var/run/orchestrator-kv.sock"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.KVFileSinkDirectory = "/var/lib/orchestrator/kv"
+		c.KVFileSinkFormat = "env"
+		c.KVFileSinkSocket = "/var/run/orchestrator-kv.sock"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.KVFileSinkClusterDocumentsDirectory = "/var/lib/orchestrator/kv-clusters"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.KVFileSinkDirectory = "/var/lib/orchestrator/kv"
+		c.KVFileSinkClusterDocumentsDirectory = "/var/lib/orchestrator/kv/clusters"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.KVFileSinkDirectory = "/var/lib/orchestrator/kv"
+		c.KVFileSinkClusterDocumentsDirectory = "/var/lib/orchestrator/kv-clusters"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.KVFileSinkSocketMode = ""
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(c.KVFileSinkSocketMode, "0660")
+	}
+	{
+		c := newConfiguration()
+		c.KVFileSinkSocketMode = "0600"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.KVFileSinkSocketMode = "0680"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.KVFileSinkSocketMode = "01777"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestReplicationGroupDeadMemberConfirmation(t *testing.T) {
+	{
+		c := newConfiguration()
+		c.ReplicationGroupDeadMemberConfirmation = ""
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(c.ReplicationGroupDeadMemberConfirmation, ReplicationGroupDeadMemberConfirmationReplicas)
+	}
+	{
+		c := newConfiguration()
+		c.ReplicationGroupDeadMemberConfirmation = ReplicationGroupDeadMemberConfirmationMembers
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.ReplicationGroupDeadMemberConfirmation = "raft"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+}
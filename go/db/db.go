@@ -89,6 +89,12 @@ func OpenTopology(host string, port int) (*sql.DB, error) {
 	return openTopology(host, port, config.Config.MySQLTopologyReadTimeoutSeconds)
 }
 
+// OpenTopologyWithReadTimeout returns a DB instance to access a topology instance, with given read timeout.
+// It is intended for operations which must complete within a deadline, such as fencing.
+func OpenTopologyWithReadTimeout(host string, port int, readTimeoutSeconds int) (*sql.DB, error) {
+	return openTopology(host, port, readTimeoutSeconds)
+}
+
 func openTopology(host string, port int, readTimeout int) (db *sql.DB, err error) {
 	mysql_uri := fmt.Sprintf("%s:%s@tcp(%s:%d)/?timeout=%ds&readTimeout=%ds&interpolateParams=true",
 		config.Config.MySQLTopologyUser,
@@ -851,4 +851,131 @@ var generateSQLBase = []string{
 	`
 		CREATE INDEX first_seen_idx_database_instance_stale_binlog_coordinates ON database_instance_stale_binlog_coordinates (first_seen)
 	`,
+	`
+		CREATE TABLE IF NOT EXISTS cluster_recovery_policy (
+			policy_name varchar(128) CHARACTER SET utf8 NOT NULL,
+			cluster_pattern varchar(1024) CHARACTER SET utf8 NOT NULL DEFAULT '',
+			tags varchar(1024) CHARACTER SET utf8 NOT NULL DEFAULT '',
+			priority int NOT NULL DEFAULT 0,
+			overrides text CHARACTER SET utf8 NOT NULL,
+			last_updated timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (policy_name)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS scheduled_takeover (
+			uid varchar(128) CHARACTER SET ascii NOT NULL,
+			cluster_name varchar(128) CHARACTER SET utf8 NOT NULL,
+			designated_hostname varchar(128) CHARACTER SET ascii NOT NULL DEFAULT '',
+			designated_port smallint(5) unsigned NOT NULL DEFAULT 0,
+			is_auto tinyint unsigned NOT NULL DEFAULT 0,
+			not_before_unixtime int unsigned NOT NULL DEFAULT 0,
+			expires_unixtime int unsigned NOT NULL DEFAULT 0,
+			maintenance_window varchar(128) CHARACTER SET ascii NOT NULL DEFAULT '',
+			status varchar(32) CHARACTER SET ascii NOT NULL DEFAULT '',
+			status_message text CHARACTER SET utf8 NOT NULL,
+			owner varchar(128) CHARACTER SET utf8 NOT NULL DEFAULT '',
+			reason text CHARACTER SET utf8 NOT NULL,
+			created_unixtime int unsigned NOT NULL DEFAULT 0,
+			last_attempt_unixtime int unsigned NOT NULL DEFAULT 0,
+			successor_hostname varchar(128) CHARACTER SET ascii NOT NULL DEFAULT '',
+			successor_port smallint(5) unsigned NOT NULL DEFAULT 0,
+			recovery_uid varchar(128) CHARACTER SET ascii NOT NULL DEFAULT '',
+			last_updated timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (uid)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX cluster_name_idx_scheduled_takeover ON scheduled_takeover (cluster_name)
+	`,
+	`
+		CREATE INDEX status_idx_scheduled_takeover ON scheduled_takeover (status)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS rolling_operation (
+			uid varchar(128) CHARACTER SET ascii NOT NULL,
+			cluster_name varchar(128) CHARACTER SET utf8 NOT NULL,
+			command_name varchar(128) CHARACTER SET utf8 NOT NULL DEFAULT '',
+			concurrency int unsigned NOT NULL DEFAULT 1,
+			status varchar(32) CHARACTER SET ascii NOT NULL DEFAULT '',
+			status_message text CHARACTER SET utf8 NOT NULL,
+			owner varchar(128) CHARACTER SET utf8 NOT NULL DEFAULT '',
+			reason text CHARACTER SET utf8 NOT NULL,
+			created_unixtime int unsigned NOT NULL DEFAULT 0,
+			ended_unixtime int unsigned NOT NULL DEFAULT 0,
+			last_updated timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (uid)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX cluster_name_idx_rolling_operation ON rolling_operation (cluster_name)
+	`,
+	`
+		CREATE INDEX status_idx_rolling_operation ON rolling_operation (status)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS rolling_operation_instance (
+			operation_uid varchar(128) CHARACTER SET ascii NOT NULL,
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			phase int unsigned NOT NULL DEFAULT 0,
+			role varchar(32) CHARACTER SET ascii NOT NULL DEFAULT '',
+			status varchar(32) CHARACTER SET ascii NOT NULL DEFAULT '',
+			status_message text CHARACTER SET utf8 NOT NULL,
+			start_unixtime int unsigned NOT NULL DEFAULT 0,
+			end_unixtime int unsigned NOT NULL DEFAULT 0,
+			last_updated timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (operation_uid, hostname, port)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS errant_gtid_remediation (
+			remediation_id bigint unsigned NOT NULL AUTO_INCREMENT,
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			cluster_name varchar(128) CHARACTER SET utf8 NOT NULL DEFAULT '',
+			gtid_errant text CHARACTER SET ascii NOT NULL,
+			count_transactions bigint unsigned NOT NULL DEFAULT 0,
+			first_seen_unixtime int unsigned NOT NULL DEFAULT 0,
+			last_evaluated_unixtime int unsigned NOT NULL DEFAULT 0,
+			status varchar(32) CHARACTER SET ascii NOT NULL DEFAULT '',
+			status_message text CHARACTER SET utf8 NOT NULL,
+			master_hostname varchar(128) CHARACTER SET ascii NOT NULL DEFAULT '',
+			master_port smallint(5) unsigned NOT NULL DEFAULT 0,
+			count_injected bigint unsigned NOT NULL DEFAULT 0,
+			last_updated timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (remediation_id)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX hostname_port_idx_errant_gtid_remediation ON errant_gtid_remediation (hostname, port)
+	`,
+	`
+		CREATE INDEX cluster_name_idx_errant_gtid_remediation ON errant_gtid_remediation (cluster_name)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS kv_outbox (
+			outbox_id bigint unsigned NOT NULL AUTO_INCREMENT,
+			recovery_uid varchar(128) CHARACTER SET ascii NOT NULL DEFAULT '',
+			store_name varchar(32) CHARACTER SET ascii NOT NULL,
+			is_distribution tinyint unsigned NOT NULL DEFAULT 0,
+			store_key varchar(255) CHARACTER SET ascii NOT NULL,
+			store_value text CHARACTER SET utf8 NOT NULL,
+			status varchar(32) CHARACTER SET ascii NOT NULL DEFAULT '',
+			count_attempts int unsigned NOT NULL DEFAULT 0,
+			last_error text CHARACTER SET utf8 NOT NULL,
+			created_unixtime int unsigned NOT NULL DEFAULT 0,
+			last_attempt_unixtime int unsigned NOT NULL DEFAULT 0,
+			next_attempt_unixtime int unsigned NOT NULL DEFAULT 0,
+			delivered_unixtime int unsigned NOT NULL DEFAULT 0,
+			last_updated timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (outbox_id)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE UNIQUE INDEX recovery_store_key_uidx_kv_outbox ON kv_outbox (recovery_uid, store_name, is_distribution, store_key)
+	`,
+	`
+		CREATE INDEX status_idx_kv_outbox ON kv_outbox (status)
+	`,
 }
@@ -617,4 +617,112 @@ var generateSQLPatches = []string{
 		database_instance
 			ADD COLUMN replication_group_primary_port smallint(5) unsigned NOT NULL DEFAULT 0 AFTER replication_group_primary_host
 	`,
+	// Fields related to MariaDB GTID
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN mariadb_gtid_domain_id INT UNSIGNED NOT NULL DEFAULT 0 AFTER mariadb_gtid
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN mariadb_gtid_binlog_pos text CHARACTER SET ascii NOT NULL AFTER mariadb_gtid_domain_id
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN mariadb_gtid_slave_pos text CHARACTER SET ascii NOT NULL AFTER mariadb_gtid_binlog_pos
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_channels text CHARACTER SET utf8 NOT NULL AFTER mariadb_gtid_slave_pos
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_group_local_address varchar(128) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER replication_group_primary_port
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_group_unreachable_members text CHARACTER SET ascii NOT NULL AFTER replication_group_local_address
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN wsrep_cluster_state_uuid varchar(64) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER replication_channels
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN wsrep_cluster_status varchar(32) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER wsrep_cluster_state_uuid
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN wsrep_local_state TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER wsrep_cluster_status
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN wsrep_local_state_comment varchar(64) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER wsrep_local_state
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN wsrep_cluster_size INT UNSIGNED NOT NULL DEFAULT 0 AFTER wsrep_local_state_comment
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN wsrep_desync TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER wsrep_cluster_size
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_true_lag_seconds bigint(20) unsigned DEFAULT NULL AFTER slave_lag_seconds
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_queue_lag_seconds bigint(20) unsigned DEFAULT NULL AFTER replication_true_lag_seconds
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_apply_rate double NOT NULL DEFAULT 0 AFTER replication_queue_lag_seconds
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN poll_interval_seconds int unsigned NOT NULL DEFAULT 0 AFTER replication_apply_rate
+	`,
+	`
+		ALTER TABLE
+			topology_recovery_steps
+			ADD COLUMN process_result text CHARACTER SET utf8 NOT NULL
+	`,
+	`
+		ALTER TABLE
+			topology_failure_detection
+			ADD COLUMN channel_name varchar(64) NOT NULL DEFAULT '' AFTER port
+	`,
+	`
+		DROP INDEX host_port_active_recoverable_uidx_topology_failure_detection ON topology_failure_detection
+	`,
+	`
+		CREATE UNIQUE INDEX host_port_channel_active_recoverable_uidx_topology_failure_detection ON topology_failure_detection (hostname, port, channel_name, in_active_period, end_active_period_unixtime, is_actionable)
+	`,
+	`
+		ALTER TABLE
+			topology_recovery
+			ADD COLUMN channel_name varchar(64) NOT NULL DEFAULT '' AFTER port
+	`,
+	`
+		DROP INDEX hostname_port_active_period_uidx_topology_recovery ON topology_recovery
+	`,
+	`
+		CREATE UNIQUE INDEX hostname_port_channel_active_period_uidx_topology_recovery ON topology_recovery (hostname, port, channel_name, in_active_period, end_active_period_unixtime)
+	`,
 }
@@ -19,6 +19,11 @@
 package discovery manages a queue of discovery requests: an ordered
 queue with no duplicates.
 
+The queue is split into priority lanes. A key pushed to a higher
+priority lane is consumed before any key waiting in a lower priority
+lane, so that masters and instances involved in an active analysis are
+not held back by routine polls of many replicas.
+
 push() operation never blocks while pop() blocks on an empty queue.
 
 */
@@ -34,10 +39,50 @@ import (
 	"github.com/openark/orchestrator/go/inst"
 )
 
-// QueueMetric contains the queue's active and queued sizes
+// QueueLane is a priority lane in the discovery queue. Lower values are consumed first.
+type QueueLane int
+
+const (
+	// UrgentLane is for explicitly requested discoveries and for instances involved in an active analysis
+	UrgentLane QueueLane = iota
+	// MasterLane is for masters and intermediate masters
+	MasterLane
+	// RoutineLane is for routine polls
+	RoutineLane
+)
+
+// QueueLanes lists all lanes, by order of priority
+var QueueLanes = []QueueLane{UrgentLane, MasterLane, RoutineLane}
+
+func (lane QueueLane) String() string {
+	switch lane {
+	case UrgentLane:
+		return "urgent"
+	case MasterLane:
+		return "master"
+	case RoutineLane:
+		return "routine"
+	}
+	return "unknown"
+}
+
+// QueueLaneMetric contains a single lane's active and queued sizes
+type QueueLaneMetric struct {
+	Active int
+	Queued int
+}
+
+// QueueMetric contains the queue's active and queued sizes, overall and per lane
 type QueueMetric struct {
 	Active int
 	Queued int
+	Lanes  map[string]QueueLaneMetric
+}
+
+// queuedKey is a key waiting in the queue, and the lane it is waiting in
+type queuedKey struct {
+	lane     QueueLane
+	queuedAt time.Time
 }
 
 // Queue contains information for managing discovery requests
@@ -46,9 +91,10 @@ type Queue struct {
 
 	name         string
 	done         chan struct{}
-	queue        chan inst.InstanceKey
-	queuedKeys   map[inst.InstanceKey]time.Time
-	consumedKeys map[inst.InstanceKey]time.Time
+	available    chan struct{}
+	lanes        map[QueueLane][]inst.InstanceKey
+	queuedKeys   map[inst.InstanceKey]queuedKey
+	consumedKeys map[inst.InstanceKey]queuedKey
 	metrics      []QueueMetric
 }
 
@@ -89,9 +135,10 @@ func CreateOrReturnQueue(name string) *Queue {
 
 	q := &Queue{
 		name:         name,
-		queuedKeys:   make(map[inst.InstanceKey]time.Time),
-		consumedKeys: make(map[inst.InstanceKey]time.Time),
-		queue:        make(chan inst.InstanceKey, config.Config.DiscoveryQueueCapacity),
+		available:    make(chan struct{}, config.Config.DiscoveryQueueCapacity),
+		lanes:        make(map[QueueLane][]inst.InstanceKey),
+		queuedKeys:   make(map[inst.InstanceKey]queuedKey),
+		consumedKeys: make(map[inst.InstanceKey]queuedKey),
 	}
 	go q.startMonitoring()
 
@@ -125,7 +172,21 @@ func (q *Queue) collectStatistics() {
 	q.Lock()
 	defer q.Unlock()
 
-	q.metrics = append(q.metrics, QueueMetric{Queued: len(q.queuedKeys), Active: len(q.consumedKeys)})
+	metric := QueueMetric{Queued: len(q.queuedKeys), Active: len(q.consumedKeys), Lanes: make(map[string]QueueLaneMetric)}
+	for _, lane := range QueueLanes {
+		metric.Lanes[lane.String()] = QueueLaneMetric{}
+	}
+	for _, queued := range q.queuedKeys {
+		laneMetric := metric.Lanes[queued.lane.String()]
+		laneMetric.Queued++
+		metric.Lanes[queued.lane.String()] = laneMetric
+	}
+	for _, consumed := range q.consumedKeys {
+		laneMetric := metric.Lanes[consumed.lane.String()]
+		laneMetric.Active++
+		metric.Lanes[consumed.lane.String()] = laneMetric
+	}
+	q.metrics = append(q.metrics, metric)
 
 	// remove old entries if we get too big
 	if len(q.metrics) > config.Config.DiscoveryQueueMaxStatisticsSize {
@@ -138,17 +199,29 @@ func (q *Queue) QueueLen() int {
 	q.Lock()
 	defer q.Unlock()
 
-	return len(q.queue) + len(q.queuedKeys)
+	return len(q.available) + len(q.queuedKeys)
 }
 
-// Push enqueues a key if it is not on a queue and is not being
+// Push enqueues a key for routine polling if it is not on a queue and is not being
 // processed; silently returns otherwise.
 func (q *Queue) Push(key inst.InstanceKey) {
+	q.PushToLane(key, RoutineLane)
+}
+
+// PushToLane enqueues a key in given lane if it is not on a queue and is not being
+// processed. A key already waiting in a lower priority lane is promoted to given lane;
+// silently returns otherwise.
+func (q *Queue) PushToLane(key inst.InstanceKey, lane QueueLane) {
 	q.Lock()
 	defer q.Unlock()
 
 	// is it enqueued already?
-	if _, found := q.queuedKeys[key]; found {
+	if queued, found := q.queuedKeys[key]; found {
+		if lane < queued.lane {
+			// Promote. The entry left behind in the lower priority lane is skipped on consumption.
+			q.queuedKeys[key] = queuedKey{lane: lane, queuedAt: queued.queuedAt}
+			q.lanes[lane] = append(q.lanes[lane], key)
+		}
 		return
 	}
 
@@ -157,29 +230,49 @@ func (q *Queue) Push(key inst.InstanceKey) {
 		return
 	}
 
-	q.queuedKeys[key] = time.Now()
-	q.queue <- key
+	q.queuedKeys[key] = queuedKey{lane: lane, queuedAt: time.Now()}
+	q.lanes[lane] = append(q.lanes[lane], key)
+	q.available <- struct{}{}
+}
+
+// popKey returns the next key by order of lane priority, skipping entries of keys which
+// were since promoted to a higher priority lane. Must be called under lock, and only when
+// the queue is known to have a queued key.
+func (q *Queue) popKey() (key inst.InstanceKey, queued queuedKey) {
+	for _, lane := range QueueLanes {
+		for len(q.lanes[lane]) > 0 {
+			key = q.lanes[lane][0]
+			q.lanes[lane] = q.lanes[lane][1:]
+			if queued, found := q.queuedKeys[key]; found && queued.lane == lane {
+				return key, queued
+			}
+		}
+	}
+	// Cannot happen: each queued key is accounted for by exactly one available token
+	return key, queued
 }
 
-// Consume fetches a key to process; blocks if queue is empty.
+// Consume fetches a key to process, by order of lane priority; blocks if queue is empty.
 // Release must be called once after Consume.
 func (q *Queue) Consume() inst.InstanceKey {
 	q.Lock()
-	queue := q.queue
+	available := q.available
 	q.Unlock()
 
-	key := <-queue
+	<-available
 
 	q.Lock()
 	defer q.Unlock()
 
+	key, queued := q.popKey()
+
 	// alarm if have been waiting for too long
-	timeOnQueue := time.Since(q.queuedKeys[key])
+	timeOnQueue := time.Since(queued.queuedAt)
 	if timeOnQueue > time.Duration(config.Config.InstancePollSeconds)*time.Second {
-		log.Warningf("key %v spent %.4fs waiting on a discoveryQueue", key, timeOnQueue.Seconds())
+		log.Warningf("key %v spent %.4fs waiting on a discoveryQueue (%s lane)", key, timeOnQueue.Seconds(), queued.lane.String())
 	}
 
-	q.consumedKeys[key] = q.queuedKeys[key]
+	q.consumedKeys[key] = queued
 
 	delete(q.queuedKeys, key)
 
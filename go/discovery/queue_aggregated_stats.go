@@ -34,6 +34,7 @@ type AggregatedQueueMetrics struct {
 	QueuedMedianEntries float64
 	QueuedP95Entries    float64
 	QueuedMaxEntries    float64
+	Lanes               map[string]*AggregatedQueueMetrics `json:",omitempty"`
 }
 
 // we pull out values in ints so convert to float64 for metric calculations
@@ -64,31 +65,48 @@ func (q *Queue) DiscoveryQueueMetrics(period int) []QueueMetric {
 	return a
 }
 
+// aggregateQueueMetrics computes aggregate statistics of given active and queued entries
+func aggregateQueueMetrics(activeEntries, queuedEntries []int) *AggregatedQueueMetrics {
+	return &AggregatedQueueMetrics{
+		ActiveMinEntries:    min(intSliceToFloat64Slice(activeEntries)),
+		ActiveMeanEntries:   mean(intSliceToFloat64Slice(activeEntries)),
+		ActiveMedianEntries: median(intSliceToFloat64Slice(activeEntries)),
+		ActiveP95Entries:    percentile(intSliceToFloat64Slice(activeEntries), 95),
+		ActiveMaxEntries:    max(intSliceToFloat64Slice(activeEntries)),
+		QueuedMinEntries:    min(intSliceToFloat64Slice(queuedEntries)),
+		QueuedMeanEntries:   mean(intSliceToFloat64Slice(queuedEntries)),
+		QueuedMedianEntries: median(intSliceToFloat64Slice(queuedEntries)),
+		QueuedP95Entries:    percentile(intSliceToFloat64Slice(queuedEntries), 95),
+		QueuedMaxEntries:    max(intSliceToFloat64Slice(queuedEntries)),
+	}
+}
+
 // AggregatedDiscoveryQueueMetrics Returns some aggregate statistics
 // based on the period (last N entries) requested.  We store up to
 // config.Config.DiscoveryQueueMaxStatisticsSize values and collect once
 // a second so we expect period to be a smaller value.
+// Statistics are reported for the queue as a whole, and per lane.
 func (q *Queue) AggregatedDiscoveryQueueMetrics(period int) *AggregatedQueueMetrics {
 	wanted := q.DiscoveryQueueMetrics(period)
 
 	var activeEntries, queuedEntries []int
+	laneActiveEntries := make(map[string][]int)
+	laneQueuedEntries := make(map[string][]int)
 	// fill vars
 	for i := range wanted {
 		activeEntries = append(activeEntries, wanted[i].Active)
 		queuedEntries = append(queuedEntries, wanted[i].Queued)
+		for _, lane := range QueueLanes {
+			laneMetric := wanted[i].Lanes[lane.String()]
+			laneActiveEntries[lane.String()] = append(laneActiveEntries[lane.String()], laneMetric.Active)
+			laneQueuedEntries[lane.String()] = append(laneQueuedEntries[lane.String()], laneMetric.Queued)
+		}
 	}
 
-	a := &AggregatedQueueMetrics{
-		ActiveMinEntries:    min(intSliceToFloat64Slice(activeEntries)),
-		ActiveMeanEntries:   mean(intSliceToFloat64Slice(activeEntries)),
-		ActiveMedianEntries: median(intSliceToFloat64Slice(activeEntries)),
-		ActiveP95Entries:    percentile(intSliceToFloat64Slice(activeEntries), 95),
-		ActiveMaxEntries:    max(intSliceToFloat64Slice(activeEntries)),
-		QueuedMinEntries:    min(intSliceToFloat64Slice(queuedEntries)),
-		QueuedMeanEntries:   mean(intSliceToFloat64Slice(queuedEntries)),
-		QueuedMedianEntries: median(intSliceToFloat64Slice(queuedEntries)),
-		QueuedP95Entries:    percentile(intSliceToFloat64Slice(queuedEntries), 95),
-		QueuedMaxEntries:    max(intSliceToFloat64Slice(queuedEntries)),
+	a := aggregateQueueMetrics(activeEntries, queuedEntries)
+	a.Lanes = make(map[string]*AggregatedQueueMetrics)
+	for _, lane := range QueueLanes {
+		a.Lanes[lane.String()] = aggregateQueueMetrics(laneActiveEntries[lane.String()], laneQueuedEntries[lane.String()])
 	}
 	log.Debugf("AggregatedDiscoveryQueueMetrics: returning values: %+v", a)
 	return a
@@ -0,0 +1,56 @@
+package discovery
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/inst"
+)
+
+func TestQueueLanes(t *testing.T) {
+	q := CreateOrReturnQueue("TestQueueLanes")
+	replica1 := inst.InstanceKey{Hostname: "replica1", Port: 3306}
+	replica2 := inst.InstanceKey{Hostname: "replica2", Port: 3306}
+	master := inst.InstanceKey{Hostname: "master", Port: 3306}
+	analyzed := inst.InstanceKey{Hostname: "analyzed", Port: 3306}
+
+	q.Push(replica1)
+	q.Push(replica2)
+	q.PushToLane(master, MasterLane)
+	q.PushToLane(analyzed, UrgentLane)
+	// Duplicates are ignored
+	q.PushToLane(master, RoutineLane)
+	test.S(t).ExpectEquals(len(q.queuedKeys), 4)
+
+	// Promotion from routine to urgent lane
+	q.PushToLane(replica2, UrgentLane)
+	test.S(t).ExpectEquals(len(q.queuedKeys), 4)
+
+	q.collectStatistics()
+	metrics := q.DiscoveryQueueMetrics(1)
+	test.S(t).ExpectEquals(metrics[0].Queued, 4)
+	test.S(t).ExpectEquals(metrics[0].Lanes[UrgentLane.String()].Queued, 2)
+	test.S(t).ExpectEquals(metrics[0].Lanes[MasterLane.String()].Queued, 1)
+	test.S(t).ExpectEquals(metrics[0].Lanes[RoutineLane.String()].Queued, 1)
+
+	expected := []inst.InstanceKey{analyzed, replica2, master, replica1}
+	for _, expectedKey := range expected {
+		key := q.Consume()
+		test.S(t).ExpectEquals(key, expectedKey)
+	}
+	test.S(t).ExpectEquals(len(q.available), 0)
+
+	q.collectStatistics()
+	aggregated := q.AggregatedDiscoveryQueueMetrics(1)
+	test.S(t).ExpectEquals(aggregated.ActiveMaxEntries, float64(4))
+	test.S(t).ExpectEquals(aggregated.QueuedMaxEntries, float64(0))
+	test.S(t).ExpectEquals(aggregated.Lanes[UrgentLane.String()].ActiveMaxEntries, float64(2))
+	test.S(t).ExpectEquals(aggregated.Lanes[RoutineLane.String()].ActiveMaxEntries, float64(1))
+
+	// Being processed: cannot be pushed again until released
+	q.PushToLane(master, UrgentLane)
+	test.S(t).ExpectEquals(len(q.queuedKeys), 0)
+	q.Release(master)
+	q.PushToLane(master, UrgentLane)
+	test.S(t).ExpectEquals(len(q.queuedKeys), 1)
+}
@@ -0,0 +1,174 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package discovery
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/inst"
+)
+
+// SeedProvider lists instances orchestrator should discover. Providers are evaluated periodically; the
+// listing may change between evaluations.
+type SeedProvider interface {
+	// Name identifies the provider in logs and audit
+	Name() string
+	// GetSeeds returns the instances currently listed by the provider
+	GetSeeds() ([]inst.InstanceKey, error)
+}
+
+// StaticSeedProvider lists a fixed set of seeds, as given by DiscoverySeeds
+type StaticSeedProvider struct {
+	seeds []string
+}
+
+func NewStaticSeedProvider(seeds []string) *StaticSeedProvider {
+	return &StaticSeedProvider{seeds: seeds}
+}
+
+func (this *StaticSeedProvider) Name() string {
+	return "static"
+}
+
+func (this *StaticSeedProvider) GetSeeds() ([]inst.InstanceKey, error) {
+	return parseSeeds(this.seeds)
+}
+
+// parseSeeds parses hostname:port strings into instance keys
+func parseSeeds(seeds []string) (instanceKeys []inst.InstanceKey, err error) {
+	for _, seed := range seeds {
+		instanceKey, err := inst.ParseRawInstanceKey(seed)
+		if err != nil {
+			return instanceKeys, fmt.Errorf("Error parsing seed %s: %+v", seed, err)
+		}
+		instanceKeys = append(instanceKeys, *instanceKey)
+	}
+	return instanceKeys, nil
+}
+
+var seedProvidersMutex sync.Mutex
+var seedProviders []SeedProvider
+
+// seeds as listed by each provider on its latest successful evaluation
+var providersSeeds = map[string]*inst.InstanceKeyMap{}
+
+// GetSeedProviders returns the seed providers configured by DiscoverySeeds, DiscoverySeedsDNSNames,
+// DiscoverySeedsFile and DiscoverySeedsURL. Providers are created once in the lifetime of this app.
+func GetSeedProviders() []SeedProvider {
+	seedProvidersMutex.Lock()
+	defer seedProvidersMutex.Unlock()
+
+	if seedProviders == nil {
+		seedProviders = []SeedProvider{NewStaticSeedProvider(config.Config.DiscoverySeeds)}
+		if len(config.Config.DiscoverySeedsDNSNames) > 0 {
+			seedProviders = append(seedProviders, NewDNSSeedProvider(config.Config.DiscoverySeedsDNSNames))
+		}
+		if config.Config.DiscoverySeedsFile != "" {
+			seedProviders = append(seedProviders, NewFileSeedProvider(config.Config.DiscoverySeedsFile))
+		}
+		if config.Config.DiscoverySeedsURL != "" {
+			seedProviders = append(seedProviders, NewHTTPSeedProvider(config.Config.DiscoverySeedsURL))
+		}
+	}
+	return seedProviders
+}
+
+// DiffSeeds compares a previous and current listing of seeds, and returns the seeds which were added and those
+// which disappeared
+func DiffSeeds(previous *inst.InstanceKeyMap, current *inst.InstanceKeyMap) (added []inst.InstanceKey, removed []inst.InstanceKey) {
+	for _, key := range current.GetInstanceKeys() {
+		if !previous.HasKey(key) {
+			added = append(added, key)
+		}
+	}
+	for _, key := range previous.GetInstanceKeys() {
+		if !current.HasKey(key) {
+			removed = append(removed, key)
+		}
+	}
+	return added, removed
+}
+
+// resolveSeeds resolves the hostnames of given seeds, such that they match instances as known to orchestrator
+func resolveSeeds(seeds []inst.InstanceKey) (resolvedSeeds []inst.InstanceKey) {
+	for _, seed := range seeds {
+		if resolvedHostname, err := inst.ResolveHostname(seed.Hostname); err == nil {
+			seed.Hostname = resolvedHostname
+		}
+		resolvedSeeds = append(resolvedSeeds, seed)
+	}
+	return resolvedSeeds
+}
+
+// RefreshSeeds evaluates all seed providers. Seeds newly listed by a provider are injected. When
+// ForgetDisappearedDiscoverySeeds is set and forgetSeed is non nil, seeds no longer listed by any provider
+// are forgotten via forgetSeed. A provider which fails evaluation is assumed to list the same seeds as before.
+func RefreshSeeds(forgetSeed func(instanceKey *inst.InstanceKey) error) (injected []inst.InstanceKey, forgotten []inst.InstanceKey, err error) {
+	providers := GetSeedProviders()
+
+	seedProvidersMutex.Lock()
+	defer seedProvidersMutex.Unlock()
+
+	allCurrentSeeds := inst.NewInstanceKeyMap()
+	disappearedSeeds := inst.NewInstanceKeyMap()
+	for _, provider := range providers {
+		previousSeeds, found := providersSeeds[provider.Name()]
+		if !found {
+			previousSeeds = inst.NewInstanceKeyMap()
+		}
+		seeds, providerErr := provider.GetSeeds()
+		if providerErr != nil {
+			err = log.Errorf("RefreshSeeds: %s seed provider: %+v", provider.Name(), providerErr)
+			allCurrentSeeds.AddKeys(previousSeeds.GetInstanceKeys())
+			continue
+		}
+		seeds = resolveSeeds(seeds)
+		currentSeeds := inst.NewInstanceKeyMap()
+		currentSeeds.AddKeys(seeds)
+		added, removed := DiffSeeds(previousSeeds, currentSeeds)
+		for _, seed := range added {
+			seed := seed
+			if injectErr := inst.InjectSeed(&seed); injectErr != nil {
+				err = log.Errore(injectErr)
+				continue
+			}
+			injected = append(injected, seed)
+		}
+		disappearedSeeds.AddKeys(removed)
+		allCurrentSeeds.AddKeys(seeds)
+		providersSeeds[provider.Name()] = currentSeeds
+	}
+	if config.Config.ForgetDisappearedDiscoverySeeds && forgetSeed != nil {
+		for _, seed := range disappearedSeeds.GetInstanceKeys() {
+			seed := seed
+			if allCurrentSeeds.HasKey(seed) {
+				// Still listed by another provider
+				continue
+			}
+			if forgetErr := forgetSeed(&seed); forgetErr != nil {
+				err = log.Errore(forgetErr)
+				continue
+			}
+			inst.AuditOperation("forget-disappeared-seed", &seed, "no longer listed by any seed provider")
+			forgotten = append(forgotten, seed)
+		}
+	}
+	return injected, forgotten, err
+}
@@ -0,0 +1,98 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package discovery
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/inst"
+)
+
+// DNSSeedProvider lists seeds by resolving DNS names. A name beginning with an underscore is looked up as
+// an SRV record (e.g. "_mysql._tcp.db.example.com"), providing both hosts and ports. Any other name is looked
+// up as A/AAAA records, with an optional port (e.g. "db.example.com:3306"), defaulting to DefaultInstancePort.
+// Addresses are named by their PTR records, so that seeds match instances as known by hostname; an address
+// without a PTR record is listed as is.
+type DNSSeedProvider struct {
+	names      []string
+	lookupSRV  func(name string) ([]*net.SRV, error)
+	lookupHost func(host string) ([]string, error)
+	lookupAddr func(addr string) ([]string, error)
+}
+
+func NewDNSSeedProvider(names []string) *DNSSeedProvider {
+	return &DNSSeedProvider{
+		names: names,
+		lookupSRV: func(name string) ([]*net.SRV, error) {
+			_, addrs, err := net.LookupSRV("", "", name)
+			return addrs, err
+		},
+		lookupHost: net.LookupHost,
+		lookupAddr: net.LookupAddr,
+	}
+}
+
+func (this *DNSSeedProvider) Name() string {
+	return "dns"
+}
+
+func (this *DNSSeedProvider) GetSeeds() (seeds []inst.InstanceKey, err error) {
+	for _, name := range this.names {
+		if strings.HasPrefix(name, "_") {
+			addrs, err := this.lookupSRV(name)
+			if err != nil {
+				return seeds, err
+			}
+			for _, addr := range addrs {
+				seeds = append(seeds, inst.InstanceKey{Hostname: strings.TrimSuffix(addr.Target, "."), Port: int(addr.Port)})
+			}
+			continue
+		}
+		host, port := name, config.Config.DefaultInstancePort
+		if strings.Contains(name, ":") {
+			hostToken, portToken, err := net.SplitHostPort(name)
+			if err != nil {
+				return seeds, err
+			}
+			if port, err = strconv.Atoi(portToken); err != nil {
+				return seeds, fmt.Errorf("Invalid port in DNS seed name %s", name)
+			}
+			host = hostToken
+		}
+		addrs, err := this.lookupHost(host)
+		if err != nil {
+			return seeds, err
+		}
+		for _, addr := range addrs {
+			seeds = append(seeds, inst.InstanceKey{Hostname: this.addrHostname(addr), Port: port})
+		}
+	}
+	return seeds, nil
+}
+
+// addrHostname returns the name of given address by its PTR record, or the address itself if it has none
+func (this *DNSSeedProvider) addrHostname(addr string) string {
+	names, err := this.lookupAddr(addr)
+	if err != nil || len(names) == 0 {
+		return addr
+	}
+	return strings.TrimSuffix(names[0], ".")
+}
@@ -0,0 +1,104 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/openark/orchestrator/go/inst"
+	"gopkg.in/yaml.v3"
+)
+
+// FileSeedProvider lists seeds from a file, which is re-read whenever modified. A ".yaml" or ".yml" file is
+// a YAML sequence of "hostname:port" strings. Otherwise, the file is either a JSON array of such strings, or
+// plain text listing one "hostname:port" per line.
+type FileSeedProvider struct {
+	path        string
+	lastModTime time.Time
+	lastSeeds   []inst.InstanceKey
+}
+
+func NewFileSeedProvider(path string) *FileSeedProvider {
+	return &FileSeedProvider{path: path}
+}
+
+func (this *FileSeedProvider) Name() string {
+	return "file"
+}
+
+func (this *FileSeedProvider) GetSeeds() ([]inst.InstanceKey, error) {
+	fileInfo, err := os.Stat(this.path)
+	if err != nil {
+		return nil, err
+	}
+	if !this.lastModTime.IsZero() && fileInfo.ModTime().Equal(this.lastModTime) {
+		return this.lastSeeds, nil
+	}
+	content, err := os.ReadFile(this.path)
+	if err != nil {
+		return nil, err
+	}
+	var seeds []inst.InstanceKey
+	switch strings.ToLower(filepath.Ext(this.path)) {
+	case ".yaml", ".yml":
+		seeds, err = ParseSeedsYAMLContent(content)
+	default:
+		seeds, err = ParseSeedsFileContent(content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %+v", this.path, err)
+	}
+	this.lastModTime = fileInfo.ModTime()
+	this.lastSeeds = seeds
+	return seeds, nil
+}
+
+// ParseSeedsFileContent parses a JSON array of seeds, or a plain text listing of seeds, one per line. Empty
+// lines and lines beginning with "#" are ignored.
+func ParseSeedsFileContent(content []byte) ([]inst.InstanceKey, error) {
+	trimmed := strings.TrimSpace(string(content))
+	if strings.HasPrefix(trimmed, "[") {
+		seeds := []string{}
+		if err := json.Unmarshal([]byte(trimmed), &seeds); err != nil {
+			return nil, err
+		}
+		return parseSeeds(seeds)
+	}
+	seeds := []string{}
+	for _, line := range strings.Split(trimmed, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		seeds = append(seeds, line)
+	}
+	return parseSeeds(seeds)
+}
+
+// ParseSeedsYAMLContent parses a YAML sequence of seeds. An empty document lists no seeds.
+func ParseSeedsYAMLContent(content []byte) ([]inst.InstanceKey, error) {
+	seeds := []string{}
+	if err := yaml.Unmarshal(content, &seeds); err != nil {
+		return nil, err
+	}
+	return parseSeeds(seeds)
+}
@@ -0,0 +1,66 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/inst"
+)
+
+// HTTPSeedProvider lists seeds by querying an HTTP endpoint, which returns a JSON array of "hostname:port" strings
+type HTTPSeedProvider struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewHTTPSeedProvider(url string) *HTTPSeedProvider {
+	return &HTTPSeedProvider{
+		url:        url,
+		httpClient: &http.Client{Timeout: time.Duration(config.Config.DiscoverySeedsHTTPTimeoutSeconds) * time.Second},
+	}
+}
+
+func (this *HTTPSeedProvider) Name() string {
+	return "http"
+}
+
+func (this *HTTPSeedProvider) GetSeeds() ([]inst.InstanceKey, error) {
+	response, err := this.httpClient.Get(this.url)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status code %d", this.url, response.StatusCode)
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	seeds := []string{}
+	if err := json.Unmarshal(body, &seeds); err != nil {
+		return nil, fmt.Errorf("%s: %+v", this.url, err)
+	}
+	return parseSeeds(seeds)
+}
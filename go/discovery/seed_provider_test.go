@@ -0,0 +1,145 @@
+package discovery
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openark/golib/log"
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/inst"
+)
+
+func init() {
+	config.Config.HostnameResolveMethod = "none"
+	config.MarkConfigurationLoaded()
+	log.SetLevel(log.ERROR)
+}
+
+func TestParseSeedsFileContent(t *testing.T) {
+	{
+		seeds, err := ParseSeedsFileContent([]byte(`["db1:3306", "db2:3307"]`))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(seeds), 2)
+		test.S(t).ExpectEquals(seeds[1], inst.InstanceKey{Hostname: "db2", Port: 3307})
+	}
+	{
+		seeds, err := ParseSeedsFileContent([]byte("# seeds\ndb1:3306\n  db2:3307\n\n"))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(seeds), 2)
+		test.S(t).ExpectEquals(seeds[0], inst.InstanceKey{Hostname: "db1", Port: 3306})
+		test.S(t).ExpectEquals(seeds[1], inst.InstanceKey{Hostname: "db2", Port: 3307})
+	}
+	{
+		_, err := ParseSeedsFileContent([]byte("db1:3306\ndb2:port\n"))
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := ParseSeedsFileContent([]byte(`["db1:3306", "db2:port"]`))
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestDiffSeeds(t *testing.T) {
+	previous := inst.NewInstanceKeyMap()
+	previous.AddKeys([]inst.InstanceKey{{Hostname: "db1", Port: 3306}, {Hostname: "db2", Port: 3306}})
+	current := inst.NewInstanceKeyMap()
+	current.AddKeys([]inst.InstanceKey{{Hostname: "db2", Port: 3306}, {Hostname: "db3", Port: 3306}})
+
+	added, removed := DiffSeeds(previous, current)
+	test.S(t).ExpectEquals(len(added), 1)
+	test.S(t).ExpectEquals(added[0].Hostname, "db3")
+	test.S(t).ExpectEquals(len(removed), 1)
+	test.S(t).ExpectEquals(removed[0].Hostname, "db1")
+}
+
+func TestParseSeedsYAMLContent(t *testing.T) {
+	{
+		seeds, err := ParseSeedsYAMLContent([]byte(`["db1:3306", "db2:3307"]`))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(seeds), 2)
+		test.S(t).ExpectEquals(seeds[1], inst.InstanceKey{Hostname: "db2", Port: 3307})
+	}
+	{
+		seeds, err := ParseSeedsYAMLContent([]byte("# no seeds\n"))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(seeds), 0)
+	}
+	{
+		// Not a sequence
+		_, err := ParseSeedsYAMLContent([]byte("seeds:\n  - db1:3306\n"))
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := ParseSeedsYAMLContent([]byte("- db1:3306\n- db2:port\n"))
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestFileSeedProviderYAML(t *testing.T) {
+	seeds, err := NewFileSeedProvider("testdata/seeds.yaml").GetSeeds()
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(seeds), 3)
+	test.S(t).ExpectEquals(seeds[0], inst.InstanceKey{Hostname: "db1.example.com", Port: 3306})
+	test.S(t).ExpectEquals(seeds[1], inst.InstanceKey{Hostname: "db2.example.com", Port: 3307})
+	test.S(t).ExpectEquals(seeds[2], inst.InstanceKey{Hostname: "10.0.0.3", Port: 3306})
+}
+
+func TestDNSSeedProvider(t *testing.T) {
+	provider := &DNSSeedProvider{
+		names: []string{"_mysql._tcp.db.example.com", "replicas.example.com", "other.example.com:3307"},
+		lookupSRV: func(name string) ([]*net.SRV, error) {
+			return []*net.SRV{{Target: "db1.example.com.", Port: 3306}, {Target: "db2.example.com.", Port: 3316}}, nil
+		},
+		lookupHost: func(host string) ([]string, error) {
+			if host == "replicas.example.com" {
+				return []string{"10.0.0.1", "10.0.0.2"}, nil
+			}
+			return []string{"10.0.1.1"}, nil
+		},
+		lookupAddr: func(addr string) ([]string, error) {
+			if addr == "10.0.0.1" {
+				return []string{"replica1.example.com."}, nil
+			}
+			return nil, fmt.Errorf("no PTR record")
+		},
+	}
+	seeds, err := provider.GetSeeds()
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(seeds), 5)
+	test.S(t).ExpectEquals(seeds[0], inst.InstanceKey{Hostname: "db1.example.com", Port: 3306})
+	test.S(t).ExpectEquals(seeds[1], inst.InstanceKey{Hostname: "db2.example.com", Port: 3316})
+	test.S(t).ExpectEquals(seeds[2], inst.InstanceKey{Hostname: "replica1.example.com", Port: config.Config.DefaultInstancePort})
+	test.S(t).ExpectEquals(seeds[3], inst.InstanceKey{Hostname: "10.0.0.2", Port: config.Config.DefaultInstancePort})
+	test.S(t).ExpectEquals(seeds[4], inst.InstanceKey{Hostname: "10.0.1.1", Port: 3307})
+
+	provider.lookupSRV = func(name string) ([]*net.SRV, error) {
+		return nil, fmt.Errorf("no such host")
+	}
+	_, err = provider.GetSeeds()
+	test.S(t).ExpectNotNil(err)
+}
+
+func TestHTTPSeedProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/seeds" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `["db1:3306", "db2:3306"]`)
+	}))
+	defer server.Close()
+
+	{
+		seeds, err := NewHTTPSeedProvider(server.URL + "/seeds").GetSeeds()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(seeds), 2)
+	}
+	{
+		_, err := NewHTTPSeedProvider(server.URL + "/nothing").GetSeeds()
+		test.S(t).ExpectNotNil(err)
+	}
+}
@@ -39,6 +39,7 @@ import (
 	"github.com/openark/orchestrator/go/config"
 	"github.com/openark/orchestrator/go/discovery"
 	"github.com/openark/orchestrator/go/inst"
+	"github.com/openark/orchestrator/go/kv"
 	"github.com/openark/orchestrator/go/logic"
 	"github.com/openark/orchestrator/go/metrics/query"
 	"github.com/openark/orchestrator/go/process"
@@ -280,6 +281,7 @@ func (this *HttpAPI) Discover(params martini.Params, r render.Render, req *http.
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
+	logic.PrioritizeDiscovery(&instanceKey)
 	instance, err := inst.ReadTopologyInstance(&instanceKey)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
@@ -312,6 +314,7 @@ func (this *HttpAPI) Refresh(params martini.Params, r render.Render, req *http.R
 		return
 	}
 
+	logic.PrioritizeDiscovery(&instanceKey)
 	_, err = inst.RefreshTopologyInstance(&instanceKey)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
@@ -357,10 +360,9 @@ func (this *HttpAPI) ForgetCluster(params martini.Params, r render.Render, req *
 		return
 	}
 
-	if orcraft.IsRaftEnabled() {
-		orcraft.PublishCommand("forget-cluster", clusterName)
-	} else {
-		inst.ForgetCluster(clusterName)
+	if err := logic.ForgetCluster(clusterName); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
 	}
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cluster forgotten: %+v", clusterName)})
 }
@@ -818,6 +820,25 @@ func (this *HttpAPI) ErrantGTIDInjectEmpty(params martini.Params, r render.Rende
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Have injected %+v transactions on cluster master %+v", countInjectedTransactions, clusterMaster.Key), Details: instance})
 }
 
+// ErrantGTIDRemediations lists errant GTID sets tracked for automated remediation, of all clusters or of a given cluster
+func (this *HttpAPI) ErrantGTIDRemediations(params martini.Params, r render.Render, req *http.Request) {
+	clusterName := ""
+	if getClusterHint(params) != "" {
+		var err error
+		if clusterName, err = figureClusterName(getClusterHint(params)); err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+	}
+	remediations, err := inst.ReadErrantGTIDRemediations(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	r.JSON(http.StatusOK, remediations)
+}
+
 // MoveBelow attempts to move an instance below its supposed sibling
 func (this *HttpAPI) MoveBelow(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -956,7 +977,12 @@ func (this *HttpAPI) RelocateBelow(params martini.Params, r render.Render, req *
 		return
 	}
 
-	instance, err := inst.RelocateBelow(&instanceKey, &belowKey)
+	var instance *inst.Instance
+	if channel := req.URL.Query().Get("channel"); channel != "" {
+		instance, err = inst.RelocateChannelBelow(&instanceKey, channel, &belowKey)
+	} else {
+		instance, err = inst.RelocateBelow(&instanceKey, &belowKey)
+	}
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -1318,7 +1344,12 @@ func (this *HttpAPI) StartReplication(params martini.Params, r render.Render, re
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	instance, err := inst.StartReplication(&instanceKey)
+	var instance *inst.Instance
+	if channel := req.URL.Query().Get("channel"); channel != "" {
+		instance, err = inst.StartReplicationChannel(&instanceKey, channel)
+	} else {
+		instance, err = inst.StartReplication(&instanceKey)
+	}
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -1360,7 +1391,12 @@ func (this *HttpAPI) StopReplication(params martini.Params, r render.Render, req
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	instance, err := inst.StopReplication(&instanceKey)
+	var instance *inst.Instance
+	if channel := req.URL.Query().Get("channel"); channel != "" {
+		instance, err = inst.StopReplicationChannel(&instanceKey, channel)
+	} else {
+		instance, err = inst.StopReplication(&instanceKey)
+	}
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -1856,26 +1892,142 @@ func (this *HttpAPI) ClusterOSCReplicas(params martini.Params, r render.Render,
 	r.JSON(http.StatusOK, instances)
 }
 
-// SetClusterAlias will change an alias for a given clustername
-func (this *HttpAPI) SetClusterAliasManualOverride(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+// Candidates returns the replicas of a cluster's master ranked as candidates for promotion, with the reasoning behind each score
+func (this *HttpAPI) Candidates(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	scores, err := inst.ScoreClusterCandidates(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, scores)
+}
+
+// ClusterRecoveryPolicies lists all cluster recovery policies
+func (this *HttpAPI) ClusterRecoveryPolicies(params martini.Params, r render.Render, req *http.Request) {
+	policies, err := inst.ReadClusterRecoveryPolicies()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, policies)
+}
+
+// ClusterRecoveryPolicy returns a single cluster recovery policy
+func (this *HttpAPI) ClusterRecoveryPolicy(params martini.Params, r render.Render, req *http.Request) {
+	policy, err := inst.ReadClusterRecoveryPolicy(params["policyName"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, policy)
+}
+
+// SetClusterRecoveryPolicy creates or replaces a cluster recovery policy. Clusters are matched by the `cluster`
+// regex and/or the `tags` query params, and overrides are given as query params named after configuration settings.
+func (this *HttpAPI) SetClusterRecoveryPolicy(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
 		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
 		return
 	}
-	clusterName := params["clusterName"]
-	alias := req.URL.Query().Get("alias")
+	query := req.URL.Query()
+	policy := inst.ClusterRecoveryPolicy{
+		PolicyName:     params["policyName"],
+		ClusterPattern: query.Get("cluster"),
+		Tags:           query.Get("tags"),
+	}
+	var err error
+	if priority := query.Get("priority"); priority != "" {
+		if policy.Priority, err = strconv.Atoi(priority); err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Invalid priority: %s", priority)})
+			return
+		}
+	}
+	if policy.Overrides, err = inst.ParseRecoveryPolicyOverrides(query); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if policy.Overrides.OverridesProcesses() && !config.Config.AllowRecoveryPolicyHookOverrides {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Cluster recovery policies may not override hook processes unless AllowRecoveryPolicyHookOverrides is set"})
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
 
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("put-cluster-recovery-policy", policy)
+	} else {
+		err = inst.WriteClusterRecoveryPolicy(&policy)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cluster recovery policy set: %s", policy.String()), Details: policy})
+}
+
+// DeleteClusterRecoveryPolicy removes a cluster recovery policy
+func (this *HttpAPI) DeleteClusterRecoveryPolicy(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	policyName := params["policyName"]
 	var err error
 	if orcraft.IsRaftEnabled() {
-		_, err = orcraft.PublishCommand("set-cluster-alias-manual-override", []string{clusterName, alias})
+		_, err = orcraft.PublishCommand("delete-cluster-recovery-policy", policyName)
 	} else {
-		err = inst.SetClusterAliasManualOverride(clusterName, alias)
+		err = inst.DeleteClusterRecoveryPolicy(policyName)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
 	}
 
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cluster recovery policy deleted: %s", policyName), Details: policyName})
+}
+
+// RecoveryPolicy returns the effective recovery policy of a cluster: the global configuration with
+// matching cluster recovery policies applied
+func (this *HttpAPI) RecoveryPolicy(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	clusterInfo, err := inst.ReadClusterInfo(clusterName)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
+
+	r.JSON(http.StatusOK, clusterInfo.GetRecoveryPolicy())
+}
+
+// SetClusterAlias will change an alias for a given clustername
+func (this *HttpAPI) SetClusterAliasManualOverride(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName := params["clusterName"]
+	alias := req.URL.Query().Get("alias")
+
+	if err := logic.SetClusterAliasManualOverride(clusterName, alias); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cluster %s now has alias '%s'", clusterName, alias)})
 }
 
@@ -2040,6 +2192,76 @@ func (this *HttpAPI) SubmitMastersToKvStores(params martini.Params, r render.Ren
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Submitted %d masters", submittedCount), Details: kvPairs})
 }
 
+// KVReconcile rewrites all clusters' entries to KV stores, and reports stale entries
+func (this *HttpAPI) KVReconcile(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	kvPairs, staleKeys, err := logic.ReconcileKVStores()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Submitted %d KV pairs; found %d stale keys", len(kvPairs), len(staleKeys)), Details: staleKeys})
+}
+
+// KVGarbageCollect deletes KV entries of clusters orchestrator no longer knows
+func (this *HttpAPI) KVGarbageCollect(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	staleKeys, err := logic.GarbageCollectKVStores()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Deleted %d stale keys", len(staleKeys)), Details: staleKeys})
+}
+
+// KVOutbox lists the KV outbox entries of a given recovery, or all pending entries
+func (this *HttpAPI) KVOutbox(params martini.Params, r render.Render, req *http.Request) {
+	entries, err := logic.ReadKVOutboxEntries(params["uid"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, entries)
+}
+
+// KVSubscribe streams the pairs of one of this node's KV stores (by default the file store), and then their
+// changes, as newline delimited JSON events. The response does not end until the client disconnects.
+func (this *HttpAPI) KVSubscribe(params martini.Params, r render.Render, w http.ResponseWriter, req *http.Request) {
+	storeName := req.URL.Query().Get("store")
+	if storeName == "" {
+		storeName = kv.FileKVStoreName
+	}
+	subscription, err := kv.WatchKeyPrefix(storeName, req.URL.Query().Get("prefix"))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	defer subscription.Close()
+	go func() {
+		<-req.Context().Done()
+		subscription.Close()
+	}()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flush := func() {
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+	flush()
+	if err := kv.WriteKVEvents(w, subscription, flush); err != nil {
+		log.Debugf("KVSubscribe: %+v", err)
+	}
+}
+
 // Clusters provides list of known masters
 func (this *HttpAPI) Masters(params martini.Params, r render.Render, req *http.Request) {
 	instances, err := inst.ReadWriteableClustersMasters()
@@ -3220,6 +3442,24 @@ func (this *HttpAPI) RecoverLite(params martini.Params, r render.Render, req *ht
 	this.Recover(params, r, req, user)
 }
 
+// isDryRunRequest returns true when a recovery request only asks for the recovery plan
+func isDryRunRequest(req *http.Request) bool {
+	return req.URL.Query().Get("dryRun") == "true"
+}
+
+// respondWithRecoveryPlan responds to a dry run recovery request
+func respondWithRecoveryPlan(r render.Render, plan *logic.RecoveryPlan, err error) {
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if plan.IsBlocked() {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%s dry run: blocked: %s", plan.Operation, strings.Join(plan.Blockers, "; ")), Details: plan})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%s dry run: nothing executed", plan.Operation), Details: plan})
+}
+
 // Recover attempts recovery on a given instance
 func (this *HttpAPI) Recover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -3237,6 +3477,11 @@ func (this *HttpAPI) Recover(params martini.Params, r render.Render, req *http.R
 	}
 
 	skipProcesses := (req.URL.Query().Get("skipProcesses") == "true") || (params["skipProcesses"] == "true")
+	if isDryRunRequest(req) {
+		plan, err := logic.PlanCheckAndRecover(&instanceKey, candidateKey, skipProcesses)
+		respondWithRecoveryPlan(r, plan, err)
+		return
+	}
 	recoveryAttempted, promotedInstanceKey, err := logic.CheckAndRecover(&instanceKey, candidateKey, skipProcesses)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: instanceKey})
@@ -3266,6 +3511,11 @@ func (this *HttpAPI) gracefulMasterTakeover(params martini.Params, r render.Rend
 	}
 	designatedKey, _ := this.getInstanceKey(params["designatedHost"], params["designatedPort"])
 	// designatedKey may be empty/invalid
+	if isDryRunRequest(req) {
+		plan, err := logic.PlanGracefulMasterTakeover(clusterName, &designatedKey, auto)
+		respondWithRecoveryPlan(r, plan, err)
+		return
+	}
 	topologyRecovery, _, err := logic.GracefulMasterTakeover(clusterName, &designatedKey, auto)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: topologyRecovery})
@@ -3290,6 +3540,202 @@ func (this *HttpAPI) GracefulMasterTakeoverAuto(params martini.Params, r render.
 	this.gracefulMasterTakeover(params, r, req, user, true)
 }
 
+// getScheduleTime parses given query param as a time: RFC3339, "2006-01-02 15:04[:05]" in UTC,
+// or relative to now, e.g. "2h" or "+2h". An empty param returns the zero time.
+func getScheduleTime(req *http.Request, param string) (time.Time, error) {
+	value := strings.TrimSpace(req.URL.Query().Get(param))
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if seconds, err := util.SimpleTimeToSeconds(strings.TrimPrefix(value, "+")); err == nil {
+		return time.Now().Add(time.Duration(seconds) * time.Second), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid %s: %s. Expected RFC3339, \"YYYY-MM-DD HH:MM\" (UTC) or e.g. \"2h\"", param, value)
+}
+
+func (this *HttpAPI) scheduleGracefulMasterTakeover(params martini.Params, r render.Render, req *http.Request, user auth.User, auto bool) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	designatedKey, _ := this.getInstanceKey(params["designatedHost"], params["designatedPort"])
+	// designatedKey may be empty/invalid
+	notBefore, err := getScheduleTime(req, "at")
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	expires, err := getScheduleTime(req, "expires")
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	userId := getUserId(req, user)
+	if userId == "" {
+		userId = inst.GetMaintenanceOwner()
+	}
+	takeover, err := logic.NewScheduledTakeover(clusterName, &designatedKey, auto, notBefore, req.URL.Query().Get("window"), expires, userId, req.URL.Query().Get("reason"))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if err := logic.ScheduleGracefulMasterTakeover(takeover); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Scheduled %s", takeover.String()), Details: takeover})
+}
+
+// ScheduleGracefulMasterTakeover schedules a graceful master takeover, at a given time and/or within a maintenance window
+func (this *HttpAPI) ScheduleGracefulMasterTakeover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	this.scheduleGracefulMasterTakeover(params, r, req, user, false)
+}
+
+// ScheduleGracefulMasterTakeoverAuto schedules a graceful master takeover onto a replica of orchestrator's choosing
+func (this *HttpAPI) ScheduleGracefulMasterTakeoverAuto(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	this.scheduleGracefulMasterTakeover(params, r, req, user, true)
+}
+
+// ScheduledTakeovers lists scheduled takeovers, of all clusters or of a given cluster
+func (this *HttpAPI) ScheduledTakeovers(params martini.Params, r render.Render, req *http.Request) {
+	clusterName := ""
+	if getClusterHint(params) != "" {
+		var err error
+		if clusterName, err = figureClusterName(getClusterHint(params)); err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+	}
+	takeovers, err := logic.ReadScheduledTakeovers(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	r.JSON(http.StatusOK, takeovers)
+}
+
+// CancelScheduledTakeover cancels a pending scheduled takeover
+func (this *HttpAPI) CancelScheduledTakeover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	userId := getUserId(req, user)
+	if userId == "" {
+		userId = inst.GetMaintenanceOwner()
+	}
+	takeover, err := logic.CancelScheduledTakeover(params["uid"], userId)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: takeover})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cancelled scheduled takeover %s", takeover.UID), Details: takeover})
+}
+
+// RollingOperation starts a rolling operation on a cluster: runs a configured command on each instance,
+// leaves first, then intermediate masters, and at last the master, following a graceful takeover
+func (this *HttpAPI) RollingOperation(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	concurrency := uint64(1)
+	if value := req.URL.Query().Get("concurrency"); value != "" {
+		if concurrency, err = strconv.ParseUint(value, 10, 0); err != nil || concurrency == 0 {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Invalid concurrency: %s", value)})
+			return
+		}
+	}
+	userId := getUserId(req, user)
+	if userId == "" {
+		userId = inst.GetMaintenanceOwner()
+	}
+	operation, err := logic.StartRollingOperation(clusterName, params["commandName"], uint(concurrency), userId, req.URL.Query().Get("reason"))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Started rolling operation %s on %s: %d instances", operation.UID, clusterName, len(operation.Instances)), Details: operation})
+}
+
+// RollingOperations lists rolling operations, of all clusters or of a given cluster
+func (this *HttpAPI) RollingOperations(params martini.Params, r render.Render, req *http.Request) {
+	clusterName := ""
+	if getClusterHint(params) != "" {
+		var err error
+		if clusterName, err = figureClusterName(getClusterHint(params)); err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+	}
+	operations, err := logic.ReadRollingOperations(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	r.JSON(http.StatusOK, operations)
+}
+
+// RollingOperationStatus returns a rolling operation along with the progress of each of its instances
+func (this *HttpAPI) RollingOperationStatus(params martini.Params, r render.Render, req *http.Request) {
+	operation, err := logic.ReadRollingOperation(params["uid"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	r.JSON(http.StatusOK, operation)
+}
+
+func (this *HttpAPI) changeRollingOperation(params martini.Params, r render.Render, req *http.Request, user auth.User, change func(uid string, owner string) (*logic.RollingOperation, error)) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	userId := getUserId(req, user)
+	if userId == "" {
+		userId = inst.GetMaintenanceOwner()
+	}
+	operation, err := change(params["uid"], userId)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: operation})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Rolling operation %s: %s", operation.UID, operation.Status), Details: operation})
+}
+
+// PauseRollingOperation pauses a running rolling operation
+func (this *HttpAPI) PauseRollingOperation(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	this.changeRollingOperation(params, r, req, user, logic.PauseRollingOperation)
+}
+
+// ResumeRollingOperation resumes a paused rolling operation, or retries a failed one
+func (this *HttpAPI) ResumeRollingOperation(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	this.changeRollingOperation(params, r, req, user, logic.ResumeRollingOperation)
+}
+
+// AbortRollingOperation aborts a running or paused rolling operation
+func (this *HttpAPI) AbortRollingOperation(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	this.changeRollingOperation(params, r, req, user, logic.AbortRollingOperation)
+}
+
 // ForceMasterFailover fails over a master (even if there's no particular problem with the master)
 func (this *HttpAPI) ForceMasterFailover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -3301,6 +3747,11 @@ func (this *HttpAPI) ForceMasterFailover(params martini.Params, r render.Render,
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
+	if isDryRunRequest(req) {
+		plan, err := logic.PlanForceMasterFailover(clusterName)
+		respondWithRecoveryPlan(r, plan, err)
+		return
+	}
 	topologyRecovery, err := logic.ForceMasterFailover(clusterName)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
@@ -3770,6 +4221,12 @@ func (this *HttpAPI) registerAPIRequestInternal(m *martini.ClassicMartini, path
 	}
 }
 
+// IsStreamingAPIRequest returns true for API requests whose response is streamed, and should be neither
+// buffered nor compressed
+func IsStreamingAPIRequest(req *http.Request) bool {
+	return strings.HasSuffix(req.URL.Path, "/api/kv-subscribe")
+}
+
 func (this *HttpAPI) registerAPIRequest(m *martini.ClassicMartini, path string, handler martini.Handler) {
 	this.registerAPIRequestInternal(m, path, handler, true)
 }
@@ -3823,6 +4280,8 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "locate-gtid-errant/:host/:port", this.LocateErrantGTID)
 	this.registerAPIRequest(m, "gtid-errant-reset-master/:host/:port", this.ErrantGTIDResetMaster)
 	this.registerAPIRequest(m, "gtid-errant-inject-empty/:host/:port", this.ErrantGTIDInjectEmpty)
+	this.registerAPIRequest(m, "errant-gtid-remediations", this.ErrantGTIDRemediations)
+	this.registerAPIRequest(m, "errant-gtid-remediations/:clusterHint", this.ErrantGTIDRemediations)
 	this.registerAPIRequest(m, "skip-query/:host/:port", this.SkipQuery)
 	this.registerAPIRequest(m, "start-slave/:host/:port", this.StartReplication)
 	this.registerAPIRequest(m, "restart-slave/:host/:port", this.RestartReplication)
@@ -3874,6 +4333,12 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "cluster-info/:clusterHint", this.ClusterInfo)
 	this.registerAPIRequest(m, "cluster-info/alias/:clusterAlias", this.ClusterInfoByAlias)
 	this.registerAPIRequest(m, "cluster-osc-slaves/:clusterHint", this.ClusterOSCReplicas)
+	this.registerAPIRequest(m, "candidates/:clusterHint", this.Candidates)
+	this.registerAPIRequest(m, "recovery-policy/:clusterHint", this.RecoveryPolicy)
+	this.registerAPIRequest(m, "cluster-recovery-policies", this.ClusterRecoveryPolicies)
+	this.registerAPIRequest(m, "cluster-recovery-policy/:policyName", this.ClusterRecoveryPolicy)
+	this.registerAPIRequest(m, "set-cluster-recovery-policy/:policyName", this.SetClusterRecoveryPolicy)
+	this.registerAPIRequest(m, "delete-cluster-recovery-policy/:policyName", this.DeleteClusterRecoveryPolicy)
 	this.registerAPIRequest(m, "set-cluster-alias/:clusterName", this.SetClusterAliasManualOverride)
 	this.registerAPIRequest(m, "clusters", this.Clusters)
 	this.registerAPIRequest(m, "clusters-info", this.ClustersInfo)
@@ -3895,6 +4360,11 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	// Key-value:
 	this.registerAPIRequest(m, "submit-masters-to-kv-stores", this.SubmitMastersToKvStores)
 	this.registerAPIRequest(m, "submit-masters-to-kv-stores/:clusterHint", this.SubmitMastersToKvStores)
+	this.registerAPIRequest(m, "kv-reconcile", this.KVReconcile)
+	this.registerAPIRequest(m, "kv-gc", this.KVGarbageCollect)
+	this.registerAPIRequestNoProxy(m, "kv-outbox", this.KVOutbox)
+	this.registerAPIRequestNoProxy(m, "kv-outbox/:uid", this.KVOutbox)
+	this.registerAPIRequestNoProxy(m, "kv-subscribe", this.KVSubscribe)
 
 	// Tags:
 	this.registerAPIRequest(m, "tagged", this.Tagged)
@@ -3940,6 +4410,20 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "graceful-master-takeover-auto/:host/:port/:designatedHost/:designatedPort", this.GracefulMasterTakeoverAuto)
 	this.registerAPIRequest(m, "graceful-master-takeover-auto/:clusterHint", this.GracefulMasterTakeoverAuto)
 	this.registerAPIRequest(m, "graceful-master-takeover-auto/:clusterHint/:designatedHost/:designatedPort", this.GracefulMasterTakeoverAuto)
+	this.registerAPIRequest(m, "schedule-graceful-master-takeover/:clusterHint", this.ScheduleGracefulMasterTakeover)
+	this.registerAPIRequest(m, "schedule-graceful-master-takeover/:clusterHint/:designatedHost/:designatedPort", this.ScheduleGracefulMasterTakeover)
+	this.registerAPIRequest(m, "schedule-graceful-master-takeover-auto/:clusterHint", this.ScheduleGracefulMasterTakeoverAuto)
+	this.registerAPIRequest(m, "schedule-graceful-master-takeover-auto/:clusterHint/:designatedHost/:designatedPort", this.ScheduleGracefulMasterTakeoverAuto)
+	this.registerAPIRequest(m, "scheduled-takeovers", this.ScheduledTakeovers)
+	this.registerAPIRequest(m, "scheduled-takeovers/:clusterHint", this.ScheduledTakeovers)
+	this.registerAPIRequest(m, "cancel-scheduled-takeover/:uid", this.CancelScheduledTakeover)
+	this.registerAPIRequest(m, "rolling-operation/:clusterHint/:commandName", this.RollingOperation)
+	this.registerAPIRequest(m, "rolling-operations", this.RollingOperations)
+	this.registerAPIRequest(m, "rolling-operations/:clusterHint", this.RollingOperations)
+	this.registerAPIRequest(m, "rolling-operation-status/:uid", this.RollingOperationStatus)
+	this.registerAPIRequest(m, "pause-rolling-operation/:uid", this.PauseRollingOperation)
+	this.registerAPIRequest(m, "resume-rolling-operation/:uid", this.ResumeRollingOperation)
+	this.registerAPIRequest(m, "abort-rolling-operation/:uid", this.AbortRollingOperation)
 	this.registerAPIRequest(m, "force-master-failover/:host/:port", this.ForceMasterFailover)
 	this.registerAPIRequest(m, "force-master-failover/:clusterHint", this.ForceMasterFailover)
 	this.registerAPIRequest(m, "force-master-takeover/:clusterHint/:designatedHost/:designatedPort", this.ForceMasterTakeover)
@@ -59,6 +59,21 @@ const (
 	BinlogServerFailingToConnectToMaster                                 = "BinlogServerFailingToConnectToMaster"
 	// Group replication problems
 	DeadReplicationGroupMemberWithReplicas = "DeadReplicationGroupMemberWithReplicas"
+	ReplicationGroupLostQuorum             = "ReplicationGroupLostQuorum"
+	ReplicationGroupPartitioned            = "ReplicationGroupPartitioned"
+	ReplicationGroupWithoutPrimary         = "ReplicationGroupWithoutPrimary"
+	ReplicationGroupMemberStuckRecovering  = "ReplicationGroupMemberStuckRecovering"
+	// Multi-source replication channel problems
+	DeadChannelMaster               = "DeadChannelMaster"
+	UnreachableChannelMaster        = "UnreachableChannelMaster"
+	ChannelFailingToConnectToMaster = "ChannelFailingToConnectToMaster"
+	ChannelReplicationBroken        = "ChannelReplicationBroken"
+	ChannelReplicationStopped       = "ChannelReplicationStopped"
+	// Galera cluster problems
+	DeadGaleraNodeWithReplicas = "DeadGaleraNodeWithReplicas"
+	GaleraNodeNonPrimary       = "GaleraNodeNonPrimary"
+	GaleraNodeDesynced         = "GaleraNodeDesynced"
+	GaleraClusterSizeShrunk    = "GaleraClusterSizeShrunk"
 )
 
 const (
@@ -72,6 +87,7 @@ const (
 	NoFailoverSupportStructureWarning                                 = "NoFailoverSupportStructureWarning"
 	NoWriteableMasterStructureWarning                                 = "NoWriteableMasterStructureWarning"
 	NotEnoughValidSemiSyncReplicasStructureWarning                    = "NotEnoughValidSemiSyncReplicasStructureWarning"
+	MultipleMustPromoteRuleStructureWarning                           = "MultipleMustPromoteRuleStructureWarning"
 )
 
 type InstanceAnalysis struct {
@@ -113,12 +129,15 @@ const (
 	AnalysisInstanceTypeCoMaster           AnalysisInstanceType = "co-master"
 	AnalysisInstanceTypeIntermediateMaster AnalysisInstanceType = "intermediate-master"
 	AnalysisInstanceTypeGroupMember        AnalysisInstanceType = "group-member"
+	AnalysisInstanceTypeGaleraNode         AnalysisInstanceType = "galera-node"
 )
 
 // ReplicationAnalysis notes analysis on replication chain status, per instance
 type ReplicationAnalysis struct {
 	AnalyzedInstanceKey                       InstanceKey
 	AnalyzedInstanceMasterKey                 InstanceKey
+	AnalyzedChannelName                       string
+	ReplicationGroupName                      string
 	ClusterDetails                            ClusterInfo
 	AnalyzedInstanceDataCenter                string
 	AnalyzedInstanceRegion                    string
@@ -126,6 +145,7 @@ type ReplicationAnalysis struct {
 	AnalyzedInstanceBinlogCoordinates         BinlogCoordinates
 	IsMaster                                  bool
 	IsReplicationGroupMember                  bool
+	IsGaleraNode                              bool
 	IsCoMaster                                bool
 	LastCheckValid                            bool
 	LastCheckPartialSuccess                   bool
@@ -162,6 +182,7 @@ type ReplicationAnalysis struct {
 	CountDistinctMajorVersionsLoggingReplicas uint
 	CountDelayedReplicas                      uint
 	CountLaggingReplicas                      uint
+	CountMustPromoteRuleInstances             uint
 	IsActionableRecovery                      bool
 	ProcessingNodeHostname                    string
 	ProcessingNodeToken                       string
@@ -220,6 +241,9 @@ func (this *ReplicationAnalysis) GetAnalysisInstanceType() AnalysisInstanceType
 	if this.IsReplicationGroupMember {
 		return AnalysisInstanceTypeGroupMember
 	}
+	if this.IsGaleraNode && this.IsMaster {
+		return AnalysisInstanceTypeGaleraNode
+	}
 	if this.IsMaster {
 		return AnalysisInstanceTypeMaster
 	}
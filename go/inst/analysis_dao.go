@@ -38,6 +38,9 @@ var analysisChangeWriteCounter = metrics.NewCounter()
 
 var recentInstantAnalysis *cache.Cache
 
+// first time each group member was analyzed as RECOVERING
+var recoveringGroupMembers = cache.New(24*time.Hour, time.Minute)
+
 func init() {
 	metrics.Register("analysis.change.write.attempt", analysisChangeWriteAttemptCounter)
 	metrics.Register("analysis.change.write", analysisChangeWriteCounter)
@@ -188,6 +191,7 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 			master_instance.replication_group_name != ''
 			AND master_instance.replication_group_member_state != 'OFFLINE'
 		) AS is_replication_group_member,
+		MIN(master_instance.wsrep_cluster_state_uuid != '') AS is_galera_node,
 		MIN(master_instance.is_co_master) AS is_co_master,
 		MIN(
 			CONCAT(
@@ -422,6 +426,7 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 	`,
 		analysisQueryReductionClause)
 
+	mustPromoteRuleCounts, _ := ReadClusterMustPromoteRuleCounts()
 	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
 		a := ReplicationAnalysis{
 			Analysis:               NoProblem,
@@ -431,6 +436,7 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 
 		a.IsMaster = m.GetBool("is_master")
 		a.IsReplicationGroupMember = m.GetBool("is_replication_group_member")
+		a.IsGaleraNode = m.GetBool("is_galera_node")
 		countCoMasterReplicas := m.GetUint("count_co_master_replicas")
 		a.IsCoMaster = m.GetBool("is_co_master") || (countCoMasterReplicas > 0)
 		a.AnalyzedInstanceKey = InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")}
@@ -489,6 +495,7 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 		a.CountMixedBasedLoggingReplicas = m.GetUint("count_mixed_based_logging_replicas")
 		a.CountRowBasedLoggingReplicas = m.GetUint("count_row_based_logging_replicas")
 		a.CountDistinctMajorVersionsLoggingReplicas = m.GetUint("count_distinct_logging_major_versions")
+		a.CountMustPromoteRuleInstances = mustPromoteRuleCounts[a.ClusterDetails.ClusterName]
 
 		a.CountDelayedReplicas = m.GetUint("count_delayed_replicas")
 		a.CountLaggingReplicas = m.GetUint("count_lagging_replicas")
@@ -503,7 +510,14 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 				log.Debugf(analysisMessage)
 			}
 		}
-		if !a.IsReplicationGroupMember /* Traditional Async/Semi-sync replication issue detection */ {
+		if a.IsGaleraNode && a.IsMaster /* Galera node issue detection */ {
+			// A Galera node is one of several writable peers. Its death is no reason for master failover, as the
+			// other nodes keep serving. Only its async replicas, if any, need a new source.
+			if !a.LastCheckValid && a.CountReplicas > 0 && a.CountValidReplicatingReplicas == 0 {
+				a.Analysis = DeadGaleraNodeWithReplicas
+				a.Description = "Galera node cannot be reached by orchestrator and none of its replicas is replicating"
+			}
+		} else if !a.IsReplicationGroupMember /* Traditional Async/Semi-sync replication issue detection */ {
 			if a.IsMaster && !a.LastCheckValid && a.CountReplicas == 0 {
 				a.Analysis = DeadMasterWithoutReplicas
 				a.Description = "Master cannot be reached by orchestrator and has no replica"
@@ -687,6 +701,7 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 				a.StructureAnalysis = append(a.StructureAnalysis, NoLoggingReplicasStructureWarning)
 			}
 			if a.IsMaster && a.CountReplicas > 1 &&
+				!a.IsGaleraNode &&
 				!a.OracleGTIDImmediateTopology &&
 				!a.MariaDBGTIDImmediateTopology &&
 				!a.BinlogServerImmediateTopology &&
@@ -713,13 +728,16 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 				a.StructureAnalysis = append(a.StructureAnalysis, ErrantGTIDStructureWarning)
 			}
 
-			if a.IsMaster && a.IsReadOnly {
+			if a.IsMaster && a.IsReadOnly && !a.IsGaleraNode {
 				a.StructureAnalysis = append(a.StructureAnalysis, NoWriteableMasterStructureWarning)
 			}
 
 			if a.IsMaster && a.SemiSyncMasterEnabled && !a.SemiSyncMasterStatus && a.SemiSyncMasterWaitForReplicaCount > 0 && a.SemiSyncMasterClients < a.SemiSyncMasterWaitForReplicaCount {
 				a.StructureAnalysis = append(a.StructureAnalysis, NotEnoughValidSemiSyncReplicasStructureWarning)
 			}
+			if a.IsMaster && a.CountMustPromoteRuleInstances > 1 {
+				a.StructureAnalysis = append(a.StructureAnalysis, MultipleMustPromoteRuleStructureWarning)
+			}
 		}
 		appendAnalysis(&a)
 
@@ -733,10 +751,338 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 	if err != nil {
 		return result, log.Errore(err)
 	}
+	channelAnalysis, err := getReplicationChannelAnalysis(clusterName, hints)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	result = append(result, channelAnalysis...)
+	groupAnalysis, err := getReplicationGroupAnalysis(clusterName, hints)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	result = append(result, groupAnalysis...)
+	galeraAnalysis, err := getGaleraAnalysis(clusterName, hints)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	result = append(result, galeraAnalysis...)
 	// TODO: result, err = getConcensusReplicationAnalysis(result)
 	return result, log.Errore(err)
 }
 
+// getReplicationGroupAnalysis analyzes replication groups as a whole, based on the group membership as
+// seen by each member: quorum, partitioning, and existence of a primary. The analysis is reported on a
+// member of the largest partition, which is where a quorum recovery would take place. Members stuck in
+// RECOVERING state are further reported each on its own.
+func getReplicationGroupAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
+	result := []ReplicationAnalysis{}
+
+	condition := `
+			replication_group_name != ''
+			and (cluster_name = ? or ? = '')
+		`
+	members, err := readInstancesByCondition(condition, sqlutils.Args(clusterName, clusterName), "")
+	if err != nil {
+		return result, err
+	}
+	groupsMembers := map[string][](*Instance){}
+	groupNames := []string{}
+	for _, member := range members {
+		if !member.IsLastCheckValid {
+			continue
+		}
+		if _, found := groupsMembers[member.ReplicationGroupName]; !found {
+			groupNames = append(groupNames, member.ReplicationGroupName)
+		}
+		groupsMembers[member.ReplicationGroupName] = append(groupsMembers[member.ReplicationGroupName], member)
+	}
+
+	appendAnalysis := func(a ReplicationAnalysis, member *Instance) {
+		if a.Analysis == NoProblem {
+			// The instance itself is already listed by the general analysis
+			return
+		}
+		if FiltersMatchInstanceKey(&member.Key, config.Config.RecoveryIgnoreHostnameFilters) {
+			return
+		}
+		if a.IsDowntimed && !hints.IncludeDowntimed {
+			return
+		}
+		result = append(result, a)
+	}
+	newGroupAnalysis := func(member *Instance) (ReplicationAnalysis, error) {
+		clusterInfo, err := ReadClusterInfo(member.ClusterName)
+		if err != nil {
+			return ReplicationAnalysis{}, err
+		}
+		return ReplicationAnalysis{
+			AnalyzedInstanceKey:                 member.Key,
+			AnalyzedInstanceMasterKey:           member.MasterKey,
+			ReplicationGroupName:                member.ReplicationGroupName,
+			ClusterDetails:                      *clusterInfo,
+			AnalyzedInstanceDataCenter:          member.DataCenter,
+			AnalyzedInstanceRegion:              member.Region,
+			AnalyzedInstancePhysicalEnvironment: member.PhysicalEnvironment,
+			AnalyzedInstanceBinlogCoordinates:   member.SelfBinlogCoordinates,
+			IsReplicationGroupMember:            true,
+			LastCheckValid:                      member.IsLastCheckValid,
+			Replicas:                            member.Replicas,
+			CountReplicas:                       uint(len(member.Replicas)),
+			Analysis:                            NoProblem,
+			IsDowntimed:                         member.IsDowntimed,
+			DowntimeEndTimestamp:                member.DowntimeEndTimestamp,
+			SkippableDueToDowntime:              member.IsDowntimed,
+			GTIDMode:                            member.GTIDMode,
+			IsReadOnly:                          member.ReadOnly,
+		}, nil
+	}
+
+	for _, groupName := range groupNames {
+		partitions := GetReplicationGroupPartitions(groupsMembers[groupName])
+		if len(partitions) == 0 {
+			continue
+		}
+		analyzedPartition, err := GetLargestReplicationGroupPartition(partitions)
+		if err != nil {
+			// No single largest partition; we still want to report on the group
+			analyzedPartition = partitions[0]
+		}
+		analyzedMember := analyzedPartition.Members[0]
+		a, err := newGroupAnalysis(analyzedMember)
+		if err != nil {
+			return result, err
+		}
+
+		var quorumPartition *ReplicationGroupPartition
+		for _, partition := range partitions {
+			if partition.HasQuorum {
+				quorumPartition = partition
+			}
+		}
+		if quorumPartition == nil {
+			a.Analysis = ReplicationGroupLostQuorum
+			a.Description = fmt.Sprintf("Replication group has lost quorum; found %d reachable partition(s), largest has %d member(s)", len(partitions), len(analyzedPartition.Members))
+		} else if len(partitions) > 1 {
+			a.Analysis = ReplicationGroupPartitioned
+			a.Description = fmt.Sprintf("Replication group is partitioned into %d reachable partitions; majority partition has %d member(s)", len(partitions), len(quorumPartition.Members))
+		} else if analyzedMember.ReplicationGroupIsSinglePrimary && !quorumPartition.HasPrimary() {
+			a.Analysis = ReplicationGroupWithoutPrimary
+			a.Description = "Replication group has quorum but no ONLINE primary"
+		}
+		appendAnalysis(a, analyzedMember)
+
+		for _, member := range groupsMembers[groupName] {
+			if member.ReplicationGroupMemberState != GroupReplicationMemberStateRecovering {
+				recoveringGroupMembers.Delete(member.Key.StringCode())
+				continue
+			}
+			recoveringGroupMembers.Add(member.Key.StringCode(), time.Now(), cache.DefaultExpiration)
+			recoveringSince, found := recoveringGroupMembers.Get(member.Key.StringCode())
+			if !found || time.Since(recoveringSince.(time.Time)) < time.Duration(config.Config.ReasonableGroupMemberRecoveringSeconds)*time.Second {
+				continue
+			}
+			a, err := newGroupAnalysis(member)
+			if err != nil {
+				return result, err
+			}
+			a.Analysis = ReplicationGroupMemberStuckRecovering
+			a.Description = fmt.Sprintf("Group member has been RECOVERING for over %d seconds", config.Config.ReasonableGroupMemberRecoveringSeconds)
+			appendAnalysis(a, member)
+		}
+	}
+	return result, nil
+}
+
+// getGaleraAnalysis analyzes Galera clusters, based on the wsrep status of their nodes: nodes which are not
+// part of the primary component, nodes which are desynced (typically when donating a state snapshot), and
+// clusters which have lost nodes. Death of a Galera node with replicas is detected by the general analysis.
+func getGaleraAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
+	result := []ReplicationAnalysis{}
+
+	condition := `
+			wsrep_cluster_state_uuid != ''
+			and (cluster_name = ? or ? = '')
+		`
+	nodes, err := readInstancesByCondition(condition, sqlutils.Args(clusterName, clusterName), "")
+	if err != nil {
+		return result, err
+	}
+	galeraClustersNodes := map[string][](*Instance){}
+	galeraClusterUUIDs := []string{}
+	for _, node := range nodes {
+		if _, found := galeraClustersNodes[node.WsrepClusterStateUUID]; !found {
+			galeraClusterUUIDs = append(galeraClusterUUIDs, node.WsrepClusterStateUUID)
+		}
+		galeraClustersNodes[node.WsrepClusterStateUUID] = append(galeraClustersNodes[node.WsrepClusterStateUUID], node)
+	}
+
+	appendAnalysis := func(a ReplicationAnalysis, node *Instance) {
+		if FiltersMatchInstanceKey(&node.Key, config.Config.RecoveryIgnoreHostnameFilters) {
+			return
+		}
+		if a.IsDowntimed && !hints.IncludeDowntimed {
+			return
+		}
+		result = append(result, a)
+	}
+	newGaleraAnalysis := func(node *Instance) (ReplicationAnalysis, error) {
+		clusterInfo, err := ReadClusterInfo(node.ClusterName)
+		if err != nil {
+			return ReplicationAnalysis{}, err
+		}
+		return ReplicationAnalysis{
+			AnalyzedInstanceKey:                 node.Key,
+			AnalyzedInstanceMasterKey:           node.MasterKey,
+			ClusterDetails:                      *clusterInfo,
+			AnalyzedInstanceDataCenter:          node.DataCenter,
+			AnalyzedInstanceRegion:              node.Region,
+			AnalyzedInstancePhysicalEnvironment: node.PhysicalEnvironment,
+			AnalyzedInstanceBinlogCoordinates:   node.SelfBinlogCoordinates,
+			IsMaster:                            !node.IsReplica(),
+			IsGaleraNode:                        true,
+			LastCheckValid:                      node.IsLastCheckValid,
+			Replicas:                            node.Replicas,
+			CountReplicas:                       uint(len(node.Replicas)),
+			Analysis:                            NoProblem,
+			IsDowntimed:                         node.IsDowntimed,
+			DowntimeEndTimestamp:                node.DowntimeEndTimestamp,
+			SkippableDueToDowntime:              node.IsDowntimed,
+			GTIDMode:                            node.GTIDMode,
+			IsReadOnly:                          node.ReadOnly,
+		}, nil
+	}
+
+	for _, galeraClusterUUID := range galeraClusterUUIDs {
+		galeraNodes := galeraClustersNodes[galeraClusterUUID]
+		var primaryComponentNode *Instance
+		for _, node := range galeraNodes {
+			if !node.IsLastCheckValid {
+				continue
+			}
+			if node.IsGaleraPrimaryComponent() && primaryComponentNode == nil {
+				primaryComponentNode = node
+			}
+			a, err := newGaleraAnalysis(node)
+			if err != nil {
+				return result, err
+			}
+			if !node.IsGaleraPrimaryComponent() {
+				a.Analysis = GaleraNodeNonPrimary
+				a.Description = fmt.Sprintf("Galera node is not part of the primary component; wsrep_cluster_status=%s", node.WsrepClusterStatus)
+			} else if node.IsGaleraDesynced() {
+				a.Analysis = GaleraNodeDesynced
+				a.Description = fmt.Sprintf("Galera node is desynced from the cluster; wsrep_local_state_comment=%s", node.WsrepLocalStateComment)
+			} else {
+				continue
+			}
+			appendAnalysis(a, node)
+		}
+		knownSize, reportedSize := GetGaleraClusterSize(galeraNodes)
+		if primaryComponentNode != nil && reportedSize < knownSize {
+			a, err := newGaleraAnalysis(primaryComponentNode)
+			if err != nil {
+				return result, err
+			}
+			a.Analysis = GaleraClusterSizeShrunk
+			a.Description = fmt.Sprintf("Galera cluster primary component has %d node(s), out of %d known nodes", reportedSize, knownSize)
+			appendAnalysis(a, primaryComponentNode)
+		}
+	}
+	return result, nil
+}
+
+// getReplicationChannelAnalysis analyzes the named channels of multi-source replicas. Such channels
+// do not take part in the topology as depicted by master_host/master_port, and so each is analyzed
+// on its own: an entry per problematic channel, analyzed on the replica, with the channel's master
+// as AnalyzedInstanceMasterKey. A named channel which does depict master_host/master_port (there being
+// no default channel) is left to the general analysis.
+func getReplicationChannelAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
+	result := []ReplicationAnalysis{}
+
+	condition := `
+			replication_channels not in ('', '[]')
+			and (cluster_name = ? or ? = '')
+		`
+	replicas, err := readInstancesByCondition(condition, sqlutils.Args(clusterName, clusterName), "")
+	if err != nil {
+		return result, err
+	}
+	clustersInfo := map[string]*ClusterInfo{}
+	for _, replica := range replicas {
+		if !replica.IsLastCheckValid {
+			// We can't tell the state of the channels
+			continue
+		}
+		if FiltersMatchInstanceKey(&replica.Key, config.Config.RecoveryIgnoreHostnameFilters) {
+			continue
+		}
+		if replica.IsDowntimed && !hints.IncludeDowntimed {
+			continue
+		}
+		clusterInfo, found := clustersInfo[replica.ClusterName]
+		if !found {
+			if clusterInfo, err = ReadClusterInfo(replica.ClusterName); err != nil {
+				return result, err
+			}
+			clustersInfo[replica.ClusterName] = clusterInfo
+		}
+		for _, channel := range replica.ReplicationChannels {
+			if channel.IsTopologyChannel {
+				// Analyzed by the general analysis, as the replica's master
+				continue
+			}
+			a := ReplicationAnalysis{
+				AnalyzedInstanceKey:                 replica.Key,
+				AnalyzedInstanceMasterKey:           channel.MasterKey,
+				AnalyzedChannelName:                 channel.ChannelName,
+				ClusterDetails:                      *clusterInfo,
+				AnalyzedInstanceDataCenter:          replica.DataCenter,
+				AnalyzedInstanceRegion:              replica.Region,
+				AnalyzedInstancePhysicalEnvironment: replica.PhysicalEnvironment,
+				AnalyzedInstanceBinlogCoordinates:   channel.ExecBinlogCoordinates,
+				LastCheckValid:                      replica.IsLastCheckValid,
+				ReplicationDepth:                    replica.ReplicationDepth,
+				Replicas:                            *NewInstanceKeyMap(),
+				IsFailingToConnectToMaster:          !channel.ReplicationIOThreadState.IsRunning() && channel.LastIOError != "",
+				Analysis:                            NoProblem,
+				IsDowntimed:                         replica.IsDowntimed,
+				DowntimeEndTimestamp:                replica.DowntimeEndTimestamp,
+				SkippableDueToDowntime:              replica.IsDowntimed,
+				OracleGTIDImmediateTopology:         channel.UsingOracleGTID,
+				MariaDBGTIDImmediateTopology:        channel.UsingMariaDBGTID,
+				IsReadOnly:                          replica.ReadOnly,
+			}
+			master, masterFound, err := ReadInstance(&channel.MasterKey)
+			if err != nil {
+				return result, err
+			}
+			masterReachable := masterFound && master.IsLastCheckValid
+			if !masterReachable && !channel.ReplicationIOThreadState.IsRunning() {
+				a.Analysis = DeadChannelMaster
+				a.Description = fmt.Sprintf("Master of channel %s is unreachable and the channel is not replicating", channel.ChannelName)
+			} else if !masterReachable {
+				a.Analysis = UnreachableChannelMaster
+				a.Description = fmt.Sprintf("Master of channel %s cannot be reached by orchestrator but the channel is replicating", channel.ChannelName)
+			} else if channel.LastSQLError != "" && !channel.ReplicationSQLThreadState.IsRunning() {
+				a.Analysis = ChannelReplicationBroken
+				a.Description = fmt.Sprintf("Channel %s SQL thread is stopped on error", channel.ChannelName)
+			} else if a.IsFailingToConnectToMaster {
+				a.Analysis = ChannelFailingToConnectToMaster
+				a.Description = fmt.Sprintf("Channel %s is failing to connect to its master", channel.ChannelName)
+			} else if channel.ReplicationStopped() {
+				a.Analysis = ChannelReplicationStopped
+				a.Description = fmt.Sprintf("Channel %s is not replicating", channel.ChannelName)
+			}
+			if a.Analysis == NoProblem {
+				// The replica itself is already listed by the general analysis
+				continue
+			}
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
 func getConcensusReplicationAnalysis(analysisEntries []ReplicationAnalysis) ([]ReplicationAnalysis, error) {
 	if !orcraft.IsRaftEnabled() {
 		return analysisEntries, nil
@@ -66,6 +66,28 @@ func ExpireCandidateInstances() error {
 	return ExecDBWriteFunc(writeFunc)
 }
 
+// ReadClusterMustPromoteRuleCounts returns the number of instances with "must" promotion rule, per cluster name
+func ReadClusterMustPromoteRuleCounts() (counts map[string]uint, err error) {
+	counts = make(map[string]uint)
+	query := `
+		select
+			database_instance.cluster_name,
+			count(*) as count_must_promote
+		from
+			candidate_database_instance
+			join database_instance using (hostname, port)
+		where
+			candidate_database_instance.promotion_rule = 'must'
+		group by
+			database_instance.cluster_name
+	`
+	err = db.QueryOrchestratorRowsMap(query, func(m sqlutils.RowMap) error {
+		counts[m.GetString("cluster_name")] = m.GetUint("count_must_promote")
+		return nil
+	})
+	return counts, log.Errore(err)
+}
+
 // BulkReadCandidateDatabaseInstance returns a slice of
 // CandidateDatabaseInstance converted to JSON.
 /*
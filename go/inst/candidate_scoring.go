@@ -0,0 +1,259 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openark/orchestrator/go/config"
+)
+
+// Scores given per promotion rule; promotion rule dominates all other criteria
+var promotionRuleScores = map[CandidatePromotionRule]int{
+	MustPromoteRule:      400,
+	PreferPromoteRule:    300,
+	NeutralPromoteRule:   200,
+	PreferNotPromoteRule: 100,
+	MustNotPromoteRule:   0,
+}
+
+const (
+	sameDataCenterScore          = 50
+	sameRegionScore              = 25
+	maxReplicationLagPenalty     = 100
+	unknownReplicationLagPenalty = 100
+	behindCoordinatesPenalty     = 20
+	errantGTIDPenalty            = 200
+	downtimedPenalty             = 50
+)
+
+// CandidateCriterion is the evaluation of a replica against a single promotion criterion
+type CandidateCriterion struct {
+	Name         string
+	Score        int
+	Disqualifies bool
+	Reason       string
+}
+
+// CandidateScore is the evaluation of a replica as candidate for promotion in place of its master
+type CandidateScore struct {
+	Key      InstanceKey
+	Rank     int
+	Eligible bool
+	Score    int
+	Criteria []CandidateCriterion
+}
+
+func (this *CandidateScore) addCriterion(name string, score int, disqualifies bool, reason string) {
+	this.Criteria = append(this.Criteria, CandidateCriterion{Name: name, Score: score, Disqualifies: disqualifies, Reason: reason})
+	this.Score += score
+	if disqualifies {
+		this.Eligible = false
+	}
+}
+
+// Summary returns a one line description of the score and the reasons behind it
+func (this *CandidateScore) Summary() string {
+	reasons := []string{}
+	for _, criterion := range this.Criteria {
+		reason := fmt.Sprintf("%s: %s (%+d)", criterion.Name, criterion.Reason, criterion.Score)
+		if criterion.Disqualifies {
+			reason = fmt.Sprintf("%s: %s (disqualified)", criterion.Name, criterion.Reason)
+		}
+		reasons = append(reasons, reason)
+	}
+	eligibility := "eligible"
+	if !this.Eligible {
+		eligibility = "not eligible"
+	}
+	return fmt.Sprintf("#%d %s score=%d %s; %s", this.Rank, this.Key.DisplayString(), this.Score, eligibility, strings.Join(reasons, "; "))
+}
+
+// scoreCandidate evaluates a single replica. master may be nil, in which case location criteria are skipped.
+func scoreCandidate(replica *Instance, master *Instance, recoveryPolicy *RecoveryPolicy, mostAdvanced *BinlogCoordinates, priorityMajorVersion string, priorityBinlogFormat string) *CandidateScore {
+	score := &CandidateScore{Key: replica.Key, Eligible: true, Criteria: []CandidateCriterion{}}
+
+	score.addCriterion("promotion rule", promotionRuleScores[replica.PromotionRule], replica.PromotionRule == MustNotPromoteRule, string(replica.PromotionRule))
+	if FiltersMatchInstanceKey(&replica.Key, config.Config.PromotionIgnoreHostnameFilters) {
+		score.addCriterion("promotion filters", 0, true, "matches PromotionIgnoreHostnameFilters")
+	}
+	if !replica.IsLastCheckValid {
+		score.addCriterion("health", 0, true, "last check invalid")
+	}
+	if replica.IsBinlogServer() {
+		score.addCriterion("binlog server", 0, true, "binlog servers cannot be promoted")
+	}
+
+	switch {
+	case !replica.LogBinEnabled:
+		score.addCriterion("binary logs", 0, true, "log_bin disabled")
+	case !replica.LogReplicationUpdatesEnabled:
+		score.addCriterion("binary logs", 0, true, "log_slave_updates disabled")
+	default:
+		score.addCriterion("binary logs", 0, false, "log_bin and log_slave_updates enabled")
+	}
+
+	if IsSmallerMajorVersion(priorityMajorVersion, replica.MajorVersionString()) {
+		score.addCriterion("version", 0, true, fmt.Sprintf("%s is newer than most replicas' %s", replica.MajorVersionString(), priorityMajorVersion))
+	} else {
+		score.addCriterion("version", 0, false, replica.MajorVersionString())
+	}
+	if IsSmallerBinlogFormat(priorityBinlogFormat, replica.Binlog_format) {
+		score.addCriterion("binlog format", 0, true, fmt.Sprintf("%s cannot be replicated by most replicas' %s", replica.Binlog_format, priorityBinlogFormat))
+	} else {
+		score.addCriterion("binlog format", 0, false, replica.Binlog_format)
+	}
+
+	if mostAdvanced != nil {
+		if replica.ExecBinlogCoordinates.SmallerThan(mostAdvanced) {
+			score.addCriterion("position", -behindCoordinatesPenalty, false, fmt.Sprintf("executed %s, behind most advanced %s", replica.ExecBinlogCoordinates.DisplayString(), mostAdvanced.DisplayString()))
+		} else {
+			score.addCriterion("position", 0, false, fmt.Sprintf("executed %s, most advanced", replica.ExecBinlogCoordinates.DisplayString()))
+		}
+	}
+
+	if lag := replica.EffectiveReplicationLagSeconds(); !lag.Valid {
+		score.addCriterion("lag", -unknownReplicationLagPenalty, false, "unknown")
+	} else {
+		failOnLag := recoveryPolicy.FailMasterPromotionOnLagMinutes > 0 &&
+			time.Duration(lag.Int64)*time.Second >= time.Duration(recoveryPolicy.FailMasterPromotionOnLagMinutes)*time.Minute
+		penalty := int(lag.Int64)
+		if penalty > maxReplicationLagPenalty {
+			penalty = maxReplicationLagPenalty
+		}
+		reason := fmt.Sprintf("%ds", lag.Int64)
+		if failOnLag {
+			reason = fmt.Sprintf("%ds exceeds FailMasterPromotionOnLagMinutes", lag.Int64)
+		}
+		score.addCriterion("lag", -penalty, failOnLag, reason)
+	}
+
+	if master != nil {
+		if replica.DataCenter == master.DataCenter {
+			score.addCriterion("data center", sameDataCenterScore, false, fmt.Sprintf("same as master: %s", replica.DataCenter))
+		} else {
+			score.addCriterion("data center", 0, recoveryPolicy.PreventCrossDataCenterMasterFailover, fmt.Sprintf("%s, master in %s", replica.DataCenter, master.DataCenter))
+		}
+		if replica.Region == master.Region {
+			score.addCriterion("region", sameRegionScore, false, fmt.Sprintf("same as master: %s", replica.Region))
+		} else {
+			score.addCriterion("region", 0, config.Config.PreventCrossRegionMasterFailover, fmt.Sprintf("%s, master in %s", replica.Region, master.Region))
+		}
+	}
+
+	if replica.GtidErrant != "" {
+		score.addCriterion("errant GTID", -errantGTIDPenalty, false, replica.GtidErrant)
+	}
+	if replica.IsDowntimed {
+		score.addCriterion("downtime", -downtimedPenalty, false, replica.DowntimeReason)
+	}
+	return score
+}
+
+// candidateSelectionOrder returns given replicas in the order failover goes over them when choosing a replica to
+// promote: sorted by executed coordinates with a preference to given data center (see sortInstancesDataCenterHint),
+// then replicas chooseCandidateReplica deems valid candidates first, then replicas not banned from promotion,
+// which it falls back to, then banned replicas.
+func candidateSelectionOrder(replicas [](*Instance), dataCenterHint string) (ordered [](*Instance)) {
+	sorted := RemoveNilInstances(append([](*Instance){}, replicas...))
+	sortInstancesDataCenterHint(sorted, dataCenterHint)
+	priorityMajorVersion, _ := getPriorityMajorVersionForCandidate(sorted)
+	priorityBinlogFormat, _ := getPriorityBinlogFormatForCandidate(sorted)
+
+	valid := [](*Instance){}
+	notBanned := [](*Instance){}
+	banned := [](*Instance){}
+	for _, replica := range sorted {
+		switch {
+		case isValidCandidateReplicaOf(replica, priorityMajorVersion, priorityBinlogFormat):
+			valid = append(valid, replica)
+		case !IsBannedFromBeingCandidateReplica(replica):
+			notBanned = append(notBanned, replica)
+		default:
+			banned = append(banned, replica)
+		}
+	}
+	ordered = append(ordered, valid...)
+	ordered = append(ordered, notBanned...)
+	ordered = append(ordered, banned...)
+	return ordered
+}
+
+// ScoreCandidates evaluates each of the given replicas as candidate for promotion in place of given master
+// (which may be nil when unknown), under given recovery policy, or the global configuration when nil.
+// Replicas are ranked in the order failover selects them (see candidateSelectionOrder): the first ranked is the
+// one chooseCandidateReplica promotes. Scores and criteria explain each replica's merits; a replica which is not
+// Eligible may still be selected, in which case its promotion is expected to fail.
+func ScoreCandidates(master *Instance, replicas [](*Instance), recoveryPolicy *RecoveryPolicy) (scores [](*CandidateScore)) {
+	scores = [](*CandidateScore){}
+	replicas = RemoveNilInstances(replicas)
+	if len(replicas) == 0 {
+		return scores
+	}
+	if recoveryPolicy == nil {
+		recoveryPolicy = NewGlobalRecoveryPolicy()
+	}
+	priorityMajorVersion, _ := getPriorityMajorVersionForCandidate(replicas)
+	priorityBinlogFormat, _ := getPriorityBinlogFormatForCandidate(replicas)
+	var mostAdvanced *BinlogCoordinates
+	for _, replica := range replicas {
+		if mostAdvanced == nil || mostAdvanced.SmallerThan(&replica.ExecBinlogCoordinates) {
+			mostAdvanced = &replica.ExecBinlogCoordinates
+		}
+	}
+	dataCenterHint := ""
+	if master != nil {
+		dataCenterHint = master.DataCenter
+	}
+	for i, replica := range candidateSelectionOrder(replicas, dataCenterHint) {
+		score := scoreCandidate(replica, master, recoveryPolicy, mostAdvanced, priorityMajorVersion, priorityBinlogFormat)
+		score.Rank = i + 1
+		scores = append(scores, score)
+	}
+	return scores
+}
+
+// ScoreReplicasAsCandidates reads the replicas of given instance, and evaluates them as candidates for its promotion
+func ScoreReplicasAsCandidates(masterKey *InstanceKey, recoveryPolicy *RecoveryPolicy) (scores [](*CandidateScore), err error) {
+	master, _, err := ReadInstance(masterKey)
+	if err != nil {
+		return scores, err
+	}
+	replicas, err := ReadReplicaInstances(masterKey)
+	if err != nil {
+		return scores, err
+	}
+	return ScoreCandidates(master, replicas, recoveryPolicy), nil
+}
+
+// ScoreClusterCandidates evaluates the replicas of the master of given cluster as candidates for its promotion
+func ScoreClusterCandidates(clusterName string) (scores [](*CandidateScore), err error) {
+	masters, err := ReadClusterMaster(clusterName)
+	if err != nil {
+		return scores, err
+	}
+	if len(masters) == 0 {
+		return scores, fmt.Errorf("No master found for cluster %+v", clusterName)
+	}
+	clusterInfo, err := ReadClusterInfo(clusterName)
+	if err != nil {
+		return scores, err
+	}
+	return ScoreReplicasAsCandidates(&masters[0].Key, clusterInfo.GetRecoveryPolicy())
+}
@@ -0,0 +1,111 @@
+package inst
+
+import (
+	"database/sql"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestScoreCandidates(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	for _, instance := range instances {
+		instance.PromotionRule = NeutralPromoteRule
+		instance.ReplicationLagSeconds = sql.NullInt64{Int64: 0, Valid: true}
+		instance.DataCenter = "dc1"
+	}
+	master := &Instance{Key: InstanceKey{Hostname: "master", Port: 3306}, DataCenter: "dc1"}
+
+	{
+		scores := ScoreCandidates(master, instances, nil)
+		test.S(t).ExpectEquals(len(scores), len(instances))
+		// All else being equal, the most advanced replica ranks first
+		test.S(t).ExpectEquals(scores[0].Key, i830Key)
+		test.S(t).ExpectEquals(scores[0].Rank, 1)
+		test.S(t).ExpectTrue(scores[0].Eligible)
+		test.S(t).ExpectTrue(scores[0].Score > scores[1].Score)
+	}
+	{
+		instancesMap[i710Key.StringCode()].PromotionRule = MustPromoteRule
+		instancesMap[i830Key.StringCode()].PromotionRule = MustNotPromoteRule
+		instancesMap[i820Key.StringCode()].LogReplicationUpdatesEnabled = false
+		instancesMap[i810Key.StringCode()].DataCenter = "dc2"
+		scores := ScoreCandidates(master, instances, nil)
+		// Ranked in selection order: valid candidates by executed coordinates, then the invalid i820, then the banned i830
+		for i, key := range []InstanceKey{i810Key, i730Key, i720Key, i710Key, i820Key, i830Key} {
+			test.S(t).ExpectEquals(scores[i].Key, key)
+			test.S(t).ExpectEquals(scores[i].Rank, i+1)
+		}
+		for _, score := range scores {
+			switch score.Key {
+			case i830Key, i820Key:
+				test.S(t).ExpectFalse(score.Eligible)
+			default:
+				test.S(t).ExpectTrue(score.Eligible)
+			}
+		}
+		// The must instance scores best, even though regroup would not choose it
+		test.S(t).ExpectTrue(scores[3].Score > scores[0].Score)
+	}
+}
+
+func TestScoreCandidatesTopIsChosenCandidate(t *testing.T) {
+	master := &Instance{Key: InstanceKey{Hostname: "master", Port: 3306}, DataCenter: "dc1"}
+	newInstances := func() ([](*Instance), map[string](*Instance)) {
+		instances, instancesMap := generateTestInstances()
+		applyGeneralGoodToGoReplicationParams(instances)
+		for _, instance := range instances {
+			instance.PromotionRule = NeutralPromoteRule
+			instance.DataCenter = "dc1"
+		}
+		return instances, instancesMap
+	}
+	expectTopIsChosen := func(instances [](*Instance)) {
+		sorted := append([](*Instance){}, instances...)
+		sortInstancesDataCenterHint(sorted, master.DataCenter)
+		candidate, _, _, _, _, _ := chooseCandidateReplica(sorted)
+		test.S(t).ExpectNotNil(candidate)
+		scores := ScoreCandidates(master, instances, nil)
+		test.S(t).ExpectEquals(scores[0].Key, candidate.Key)
+	}
+	{
+		instances, _ := newInstances()
+		expectTopIsChosen(instances)
+	}
+	{
+		// Promotion rules do not override executed coordinates
+		instances, instancesMap := newInstances()
+		instancesMap[i710Key.StringCode()].PromotionRule = MustPromoteRule
+		instancesMap[i830Key.StringCode()].PromotionRule = PreferNotPromoteRule
+		expectTopIsChosen(instances)
+	}
+	{
+		instances, instancesMap := newInstances()
+		instancesMap[i830Key.StringCode()].PromotionRule = MustNotPromoteRule
+		instancesMap[i820Key.StringCode()].LogBinEnabled = false
+		instancesMap[i810Key.StringCode()].Version = "5.7.8"
+		expectTopIsChosen(instances)
+	}
+	{
+		// Equal coordinates: the master's data center is preferred
+		instances, instancesMap := newInstances()
+		for _, instance := range instances {
+			instance.ExecBinlogCoordinates = instancesMap[i830Key.StringCode()].ExecBinlogCoordinates
+			instance.DataCenter = "dc2"
+		}
+		instancesMap[i720Key.StringCode()].DataCenter = "dc1"
+		expectTopIsChosen(instances)
+		test.S(t).ExpectEquals(ScoreCandidates(master, instances, nil)[0].Key, i720Key)
+	}
+	{
+		// No valid candidate: the fallback is the first replica not banned
+		instances, instancesMap := newInstances()
+		for _, instance := range instances {
+			instance.LogReplicationUpdatesEnabled = false
+		}
+		instancesMap[i830Key.StringCode()].PromotionRule = MustNotPromoteRule
+		expectTopIsChosen(instances)
+		test.S(t).ExpectEquals(ScoreCandidates(master, instances, nil)[0].Key, i820Key)
+	}
+}
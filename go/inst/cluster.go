@@ -86,12 +86,24 @@ type ClusterInfo struct {
 	HeuristicLag                           int64
 	HasAutomatedMasterRecovery             bool
 	HasAutomatedIntermediateMasterRecovery bool
+	HasAutomatedReplicationGroupRecovery   bool
+	RecoveryPolicy                         *RecoveryPolicy // Effective recovery settings, with cluster recovery policies applied; see GetRecoveryPolicy
 }
 
 // ReadRecoveryInfo
 func (this *ClusterInfo) ReadRecoveryInfo() {
 	this.HasAutomatedMasterRecovery = this.filtersMatchCluster(config.Config.RecoverMasterClusterFilters)
 	this.HasAutomatedIntermediateMasterRecovery = this.filtersMatchCluster(config.Config.RecoverIntermediateMasterClusterFilters)
+	this.HasAutomatedReplicationGroupRecovery = this.filtersMatchCluster(config.Config.RecoverReplicationGroupClusterFilters)
+}
+
+// GetRecoveryPolicy returns the effective recovery policy of this cluster, resolving it if not yet resolved.
+// Resolving may query the backend, hence is not done by ReadRecoveryInfo, which runs while reading rows.
+func (this *ClusterInfo) GetRecoveryPolicy() *RecoveryPolicy {
+	if this.RecoveryPolicy == nil {
+		this.RecoveryPolicy = ResolveClusterRecoveryPolicy(this.ClusterName, this.ClusterAlias)
+	}
+	return this.RecoveryPolicy
 }
 
 // filtersMatchCluster will see whether the given filters match the given cluster details
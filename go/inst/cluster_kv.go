@@ -0,0 +1,280 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openark/golib/sqlutils"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/kv"
+)
+
+const (
+	ClusterKVStatusOK       = "ok"
+	ClusterKVStatusDegraded = "degraded"
+	ClusterKVStatusNoMaster = "no-master"
+)
+
+// ClusterKVStatus is the status document of a cluster, as published to KV stores
+type ClusterKVStatus struct {
+	ClusterName                string
+	ClusterAlias               string
+	Status                     string
+	Master                     string
+	Replicas                   []string
+	IntermediateMasters        []string
+	CountInstances             int
+	CountDowntimedInstances    int
+	CountUnhealthyInstances    int
+	HasAutomatedMasterRecovery bool
+}
+
+// clusterRolesKVSources is what clusters' roles KV pairs are computed from
+type clusterRolesKVSources struct {
+	mastersKeys   map[string]InstanceKey
+	instances     map[string]([]*Instance)
+	poolInstances map[string](map[string]*InstanceKeyMap)
+	taggedKeys    map[string]*InstanceKeyMap
+}
+
+func GetClusterReplicasKVKey(clusterAlias string) string {
+	return fmt.Sprintf("%s%s", config.Config.KVClusterReplicasPrefix, clusterAlias)
+}
+
+func GetClusterIntermediateMastersKVKey(clusterAlias string) string {
+	return fmt.Sprintf("%s%s", config.Config.KVClusterIntermediateMastersPrefix, clusterAlias)
+}
+
+func GetClusterStatusKVKey(clusterAlias string) string {
+	return fmt.Sprintf("%s%s", config.Config.KVClusterStatusPrefix, clusterAlias)
+}
+
+// GetOrchestratorKVPrefixes returns the KV prefixes under which orchestrator writes per cluster entries:
+// the masters prefix, and the prefixes of whatever else is configured to be published
+func GetOrchestratorKVPrefixes() (prefixes []string) {
+	prefixes = append(prefixes, config.Config.KVClusterMasterPrefix)
+	if config.Config.KVPublishReplicas {
+		prefixes = append(prefixes, config.Config.KVClusterReplicasPrefix)
+	}
+	if config.Config.KVPublishIntermediateMasters {
+		prefixes = append(prefixes, config.Config.KVClusterIntermediateMastersPrefix)
+	}
+	if config.Config.KVPublishClusterStatus {
+		prefixes = append(prefixes, config.Config.KVClusterStatusPrefix)
+	}
+	return prefixes
+}
+
+// isKVPublishableReplica returns true for a replica which is fit to serve reads: it is healthy,
+// replicating, not downtimed and not lagging
+func isKVPublishableReplica(instance *Instance) bool {
+	if !instance.IsLastCheckValid || !instance.IsRecentlyChecked {
+		return false
+	}
+	if instance.IsDowntimed || instance.IsCoMaster {
+		return false
+	}
+	if !instance.ReplicaRunning() {
+		return false
+	}
+	lag := instance.EffectiveReplicationLagSeconds()
+	return lag.Valid && lag.Int64 <= int64(config.Config.KVReplicasMaxLagSeconds)
+}
+
+// isKVPublishableIntermediateMaster returns true for a healthy replica which has replicas of its own
+func isKVPublishableIntermediateMaster(instance *Instance) bool {
+	if !instance.IsLastCheckValid || instance.IsDowntimed || instance.IsCoMaster {
+		return false
+	}
+	return instance.IsReplica() && len(instance.Replicas) > 0
+}
+
+// instancesKVValue formats instances as a sorted, comma delimited list of hostname:port
+func instancesKVValue(instances [](*Instance)) string {
+	return strings.Join(instancesKVList(instances), ",")
+}
+
+func instancesKVList(instances [](*Instance)) []string {
+	list := []string{}
+	for _, instance := range instances {
+		list = append(list, instance.Key.StringCode())
+	}
+	sort.Strings(list)
+	return list
+}
+
+// getClusterRolesKVPairs returns the replicas, intermediate masters and status KV pairs of given cluster,
+// as configured
+func getClusterRolesKVPairs(clusterInfo *ClusterInfo, sources *clusterRolesKVSources) (kvPairs [](*kv.KVPair)) {
+	if clusterInfo.ClusterAlias == "" {
+		return kvPairs
+	}
+	instances := sources.instances[clusterInfo.ClusterName]
+	masterKey, hasMaster := sources.mastersKeys[clusterInfo.ClusterName]
+
+	replicas := [](*Instance){}
+	intermediateMasters := [](*Instance){}
+	status := &ClusterKVStatus{
+		ClusterName:                clusterInfo.ClusterName,
+		ClusterAlias:               clusterInfo.ClusterAlias,
+		Status:                     ClusterKVStatusOK,
+		CountInstances:             len(instances),
+		HasAutomatedMasterRecovery: clusterInfo.HasAutomatedMasterRecovery,
+	}
+	for _, instance := range instances {
+		if instance.IsDowntimed {
+			status.CountDowntimedInstances++
+		}
+		if hasMaster && instance.Key.Equals(&masterKey) {
+			if !instance.IsLastCheckValid {
+				status.CountUnhealthyInstances++
+			}
+			continue
+		}
+		if isKVPublishableReplica(instance) {
+			replicas = append(replicas, instance)
+		} else if !instance.IsDowntimed {
+			status.CountUnhealthyInstances++
+		}
+		if isKVPublishableIntermediateMaster(instance) {
+			intermediateMasters = append(intermediateMasters, instance)
+		}
+	}
+	if hasMaster {
+		status.Master = masterKey.StringCode()
+	} else {
+		status.Status = ClusterKVStatusNoMaster
+	}
+	if status.Status == ClusterKVStatusOK && status.CountUnhealthyInstances > 0 {
+		status.Status = ClusterKVStatusDegraded
+	}
+	status.Replicas = instancesKVList(replicas)
+	status.IntermediateMasters = instancesKVList(intermediateMasters)
+
+	if config.Config.KVPublishReplicas {
+		replicasKey := GetClusterReplicasKVKey(clusterInfo.ClusterAlias)
+		kvPairs = append(kvPairs, kv.NewKVPair(replicasKey, instancesKVValue(replicas)))
+
+		filterReplicas := func(keyMap *InstanceKeyMap) (filtered [](*Instance)) {
+			for _, replica := range replicas {
+				if keyMap != nil && keyMap.HasKey(replica.Key) {
+					filtered = append(filtered, replica)
+				}
+			}
+			return filtered
+		}
+		for _, pool := range config.Config.KVReplicaPools {
+			poolReplicas := filterReplicas(sources.poolInstances[clusterInfo.ClusterName][pool])
+			kvPairs = append(kvPairs, kv.NewKVPair(fmt.Sprintf("%s/pool/%s", replicasKey, pool), instancesKVValue(poolReplicas)))
+		}
+		for _, tagsString := range config.Config.KVReplicaTags {
+			taggedReplicas := filterReplicas(sources.taggedKeys[tagsString])
+			kvPairs = append(kvPairs, kv.NewKVPair(fmt.Sprintf("%s/tag/%s", replicasKey, tagsString), instancesKVValue(taggedReplicas)))
+		}
+	}
+	if config.Config.KVPublishIntermediateMasters {
+		kvPairs = append(kvPairs, kv.NewKVPair(GetClusterIntermediateMastersKVKey(clusterInfo.ClusterAlias), instancesKVValue(intermediateMasters)))
+	}
+	if config.Config.KVPublishClusterStatus {
+		if statusJSON, err := json.Marshal(status); err == nil {
+			kvPairs = append(kvPairs, kv.NewKVPair(GetClusterStatusKVKey(clusterInfo.ClusterAlias), string(statusJSON)))
+		}
+	}
+	return kvPairs
+}
+
+// readClusterRolesKVSources reads all that is needed to compute clusters' roles KV pairs
+func readClusterRolesKVSources(clusterName string) (sources *clusterRolesKVSources, err error) {
+	sources = &clusterRolesKVSources{
+		mastersKeys:   map[string]InstanceKey{},
+		instances:     map[string]([]*Instance){},
+		poolInstances: map[string](map[string]*InstanceKeyMap){},
+		taggedKeys:    map[string]*InstanceKeyMap{},
+	}
+	masters, err := ReadWriteableClustersMasters()
+	if err != nil {
+		return sources, err
+	}
+	for _, master := range masters {
+		sources.mastersKeys[master.ClusterName] = master.Key
+	}
+
+	condition, args := `1=1`, sqlutils.Args()
+	if clusterName != "" {
+		condition, args = `cluster_name = ?`, sqlutils.Args(clusterName)
+	}
+	instances, err := readInstancesByCondition(condition, args, "")
+	if err != nil {
+		return sources, err
+	}
+	for _, instance := range instances {
+		sources.instances[instance.ClusterName] = append(sources.instances[instance.ClusterName], instance)
+	}
+
+	if config.Config.KVPublishReplicas && len(config.Config.KVReplicaPools) > 0 {
+		poolInstances, err := ReadAllClusterPoolInstances()
+		if err != nil {
+			return sources, err
+		}
+		for _, poolInstance := range poolInstances {
+			if _, found := sources.poolInstances[poolInstance.ClusterName]; !found {
+				sources.poolInstances[poolInstance.ClusterName] = map[string]*InstanceKeyMap{}
+			}
+			if _, found := sources.poolInstances[poolInstance.ClusterName][poolInstance.Pool]; !found {
+				sources.poolInstances[poolInstance.ClusterName][poolInstance.Pool] = NewInstanceKeyMap()
+			}
+			sources.poolInstances[poolInstance.ClusterName][poolInstance.Pool].AddKey(InstanceKey{Hostname: poolInstance.Hostname, Port: poolInstance.Port})
+		}
+	}
+	if config.Config.KVPublishReplicas {
+		for _, tagsString := range config.Config.KVReplicaTags {
+			tagged, err := GetInstanceKeysByTags(tagsString)
+			if err != nil {
+				return sources, err
+			}
+			sources.taggedKeys[tagsString] = tagged
+		}
+	}
+	return sources, nil
+}
+
+// GetClustersRolesKVPairs returns the replicas, intermediate masters and status KV pairs of given cluster,
+// or of all clusters when clusterName is empty. Pairs are listed per cluster.
+func GetClustersRolesKVPairs(clusterName string) (clustersKVPairs [][](*kv.KVPair), err error) {
+	if !config.Config.KVPublishReplicas && !config.Config.KVPublishIntermediateMasters && !config.Config.KVPublishClusterStatus {
+		return clustersKVPairs, nil
+	}
+	clustersInfo, err := ReadClustersInfo(clusterName)
+	if err != nil {
+		return clustersKVPairs, err
+	}
+	sources, err := readClusterRolesKVSources(clusterName)
+	if err != nil {
+		return clustersKVPairs, err
+	}
+	for _, clusterInfo := range clustersInfo {
+		clusterInfo := clusterInfo
+		if kvPairs := getClusterRolesKVPairs(&clusterInfo, sources); len(kvPairs) > 0 {
+			clustersKVPairs = append(clustersKVPairs, kvPairs)
+		}
+	}
+	return clustersKVPairs, nil
+}
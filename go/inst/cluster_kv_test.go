@@ -0,0 +1,106 @@
+package inst
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+)
+
+func newTestKVReplica(hostname string, masterHostname string, lag int64) *Instance {
+	replica := newTestReplica(hostname, masterHostname, true, 0, NeutralPromoteRule, ReplicationThreadStateRunning)
+	replica.ClusterName = "db-1:3306"
+	replica.IsRecentlyChecked = true
+	replica.ReplicationLagSeconds = sql.NullInt64{Int64: lag, Valid: true}
+	return replica
+}
+
+func TestGetClusterRolesKVPairs(t *testing.T) {
+	defer func() {
+		config.Config.KVPublishReplicas = false
+		config.Config.KVPublishIntermediateMasters = false
+		config.Config.KVPublishClusterStatus = false
+		config.Config.KVReplicaPools = []string{}
+		config.Config.KVReplicaTags = []string{}
+	}()
+	config.Config.KVPublishReplicas = true
+	config.Config.KVPublishIntermediateMasters = true
+	config.Config.KVPublishClusterStatus = true
+	config.Config.KVClusterReplicasPrefix = "test/replicas/"
+	config.Config.KVClusterIntermediateMastersPrefix = "test/intermediate-masters/"
+	config.Config.KVClusterStatusPrefix = "test/status/"
+	config.Config.KVReplicasMaxLagSeconds = 10
+	config.Config.KVReplicaPools = []string{"reporting"}
+	config.Config.KVReplicaTags = []string{"role=reader"}
+
+	master := &Instance{Key: InstanceKey{Hostname: "db-1", Port: 3306}, ClusterName: "db-1:3306", IsLastCheckValid: true}
+	replica2 := newTestKVReplica("db-2", "db-1", 0)
+	replica3 := newTestKVReplica("db-3", "db-1", 1)
+	replica3.Replicas = *NewInstanceKeyMap()
+	replica3.Replicas.AddKey(InstanceKey{Hostname: "db-6", Port: 3306})
+	laggingReplica := newTestKVReplica("db-4", "db-1", 20)
+	downtimedReplica := newTestKVReplica("db-5", "db-1", 0)
+	downtimedReplica.IsDowntimed = true
+	replica6 := newTestKVReplica("db-6", "db-3", 0)
+
+	reportingPool := NewInstanceKeyMap()
+	reportingPool.AddKey(replica6.Key)
+	reportingPool.AddKey(laggingReplica.Key)
+	readers := NewInstanceKeyMap()
+	readers.AddKey(replica2.Key)
+
+	sources := &clusterRolesKVSources{
+		mastersKeys:   map[string]InstanceKey{"db-1:3306": master.Key},
+		instances:     map[string]([]*Instance){"db-1:3306": {master, replica6, replica3, replica2, laggingReplica, downtimedReplica}},
+		poolInstances: map[string](map[string]*InstanceKeyMap){"db-1:3306": {"reporting": reportingPool}},
+		taggedKeys:    map[string]*InstanceKeyMap{"role=reader": readers},
+	}
+	kvPairs := getClusterRolesKVPairs(&ClusterInfo{ClusterName: "db-1:3306", ClusterAlias: "mycluster"}, sources)
+	test.S(t).ExpectEquals(len(kvPairs), 5)
+
+	test.S(t).ExpectEquals(kvPairs[0].Key, "test/replicas/mycluster")
+	test.S(t).ExpectEquals(kvPairs[0].Value, "db-2:3306,db-3:3306,db-6:3306")
+	test.S(t).ExpectEquals(kvPairs[1].Key, "test/replicas/mycluster/pool/reporting")
+	test.S(t).ExpectEquals(kvPairs[1].Value, "db-6:3306")
+	test.S(t).ExpectEquals(kvPairs[2].Key, "test/replicas/mycluster/tag/role=reader")
+	test.S(t).ExpectEquals(kvPairs[2].Value, "db-2:3306")
+	test.S(t).ExpectEquals(kvPairs[3].Key, "test/intermediate-masters/mycluster")
+	test.S(t).ExpectEquals(kvPairs[3].Value, "db-3:3306")
+	test.S(t).ExpectEquals(kvPairs[4].Key, "test/status/mycluster")
+
+	status := &ClusterKVStatus{}
+	test.S(t).ExpectNil(json.Unmarshal([]byte(kvPairs[4].Value), status))
+	test.S(t).ExpectEquals(status.Status, ClusterKVStatusDegraded)
+	test.S(t).ExpectEquals(status.Master, "db-1:3306")
+	test.S(t).ExpectEquals(status.CountInstances, 6)
+	test.S(t).ExpectEquals(status.CountDowntimedInstances, 1)
+	test.S(t).ExpectEquals(status.CountUnhealthyInstances, 1)
+	test.S(t).ExpectEquals(len(status.Replicas), 3)
+
+	// Replicas leaving the set are removed from it
+	replica2.IsLastCheckValid = false
+	kvPairs = getClusterRolesKVPairs(&ClusterInfo{ClusterName: "db-1:3306", ClusterAlias: "mycluster"}, sources)
+	test.S(t).ExpectEquals(kvPairs[0].Value, "db-3:3306,db-6:3306")
+	test.S(t).ExpectEquals(kvPairs[2].Value, "")
+}
+
+func TestGetClusterRolesKVPairsNoMaster(t *testing.T) {
+	defer func() { config.Config.KVPublishClusterStatus = false }()
+	config.Config.KVPublishClusterStatus = true
+	config.Config.KVClusterStatusPrefix = "test/status/"
+
+	sources := &clusterRolesKVSources{
+		mastersKeys: map[string]InstanceKey{},
+		instances:   map[string]([]*Instance){"db-1:3306": {newTestKVReplica("db-2", "db-1", 0)}},
+	}
+	kvPairs := getClusterRolesKVPairs(&ClusterInfo{ClusterName: "db-1:3306", ClusterAlias: "mycluster"}, sources)
+	test.S(t).ExpectEquals(len(kvPairs), 1)
+	status := &ClusterKVStatus{}
+	test.S(t).ExpectNil(json.Unmarshal([]byte(kvPairs[0].Value), status))
+	test.S(t).ExpectEquals(status.Status, ClusterKVStatusNoMaster)
+
+	kvPairs = getClusterRolesKVPairs(&ClusterInfo{ClusterName: "db-1:3306"}, sources)
+	test.S(t).ExpectEquals(len(kvPairs), 0)
+}
@@ -0,0 +1,284 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/openark/orchestrator/go/config"
+)
+
+// RecoveryPolicyOverrides lists the recovery settings a cluster recovery policy overrides. A nil field
+// is not overridden, and the global configuration (or a lower priority policy) applies.
+type RecoveryPolicyOverrides struct {
+	RecoveryPeriodBlockSeconds                 *int
+	FailMasterPromotionOnLagMinutes            *uint
+	PreventCrossDataCenterMasterFailover       *bool
+	DelayMasterPromotionIfSQLThreadNotUpToDate *bool
+	OnFailureDetectionProcesses                []string
+	PreGracefulTakeoverProcesses               []string
+	PreFailoverProcesses                       []string
+	PostFailoverProcesses                      []string
+	PostUnsuccessfulFailoverProcesses          []string
+	PostMasterFailoverProcesses                []string
+	PostIntermediateMasterFailoverProcesses    []string
+	PostGracefulTakeoverProcesses              []string
+}
+
+// ClusterRecoveryPolicy overrides recovery settings for the clusters it matches. A cluster matches when
+// its name or alias matches ClusterPattern (if given), and it has instances tagged with each of Tags (if given).
+// When multiple policies match a cluster, they are applied by ascending Priority, higher priority overriding lower.
+type ClusterRecoveryPolicy struct {
+	PolicyName     string
+	ClusterPattern string
+	Tags           string
+	Priority       int
+	Overrides      RecoveryPolicyOverrides
+}
+
+// RecoveryPolicy is the effective recovery configuration of a cluster: the global configuration,
+// with any matching cluster recovery policies applied
+type RecoveryPolicy struct {
+	AppliedPolicies                            []string
+	RecoveryPeriodBlockSeconds                 int
+	FailMasterPromotionOnLagMinutes            uint
+	PreventCrossDataCenterMasterFailover       bool
+	DelayMasterPromotionIfSQLThreadNotUpToDate bool
+	OnFailureDetectionProcesses                []string
+	PreGracefulTakeoverProcesses               []string
+	PreFailoverProcesses                       []string
+	PostFailoverProcesses                      []string
+	PostUnsuccessfulFailoverProcesses          []string
+	PostMasterFailoverProcesses                []string
+	PostIntermediateMasterFailoverProcesses    []string
+	PostGracefulTakeoverProcesses              []string
+}
+
+// NewGlobalRecoveryPolicy returns the recovery policy as set by the global configuration
+func NewGlobalRecoveryPolicy() *RecoveryPolicy {
+	return &RecoveryPolicy{
+		AppliedPolicies:                            []string{},
+		RecoveryPeriodBlockSeconds:                 config.Config.RecoveryPeriodBlockSeconds,
+		FailMasterPromotionOnLagMinutes:            config.Config.FailMasterPromotionOnLagMinutes,
+		PreventCrossDataCenterMasterFailover:       config.Config.PreventCrossDataCenterMasterFailover,
+		DelayMasterPromotionIfSQLThreadNotUpToDate: config.Config.DelayMasterPromotionIfSQLThreadNotUpToDate,
+		OnFailureDetectionProcesses:                config.Config.OnFailureDetectionProcesses,
+		PreGracefulTakeoverProcesses:               config.Config.PreGracefulTakeoverProcesses,
+		PreFailoverProcesses:                       config.Config.PreFailoverProcesses,
+		PostFailoverProcesses:                      config.Config.PostFailoverProcesses,
+		PostUnsuccessfulFailoverProcesses:          config.Config.PostUnsuccessfulFailoverProcesses,
+		PostMasterFailoverProcesses:                config.Config.PostMasterFailoverProcesses,
+		PostIntermediateMasterFailoverProcesses:    config.Config.PostIntermediateMasterFailoverProcesses,
+		PostGracefulTakeoverProcesses:              config.Config.PostGracefulTakeoverProcesses,
+	}
+}
+
+// apply overrides this policy's settings with those of given cluster recovery policy
+func (this *RecoveryPolicy) apply(policy *ClusterRecoveryPolicy) {
+	overrides := &policy.Overrides
+	this.AppliedPolicies = append(this.AppliedPolicies, policy.PolicyName)
+	if overrides.RecoveryPeriodBlockSeconds != nil {
+		this.RecoveryPeriodBlockSeconds = *overrides.RecoveryPeriodBlockSeconds
+	}
+	if overrides.FailMasterPromotionOnLagMinutes != nil {
+		this.FailMasterPromotionOnLagMinutes = *overrides.FailMasterPromotionOnLagMinutes
+	}
+	if overrides.PreventCrossDataCenterMasterFailover != nil {
+		this.PreventCrossDataCenterMasterFailover = *overrides.PreventCrossDataCenterMasterFailover
+	}
+	if overrides.DelayMasterPromotionIfSQLThreadNotUpToDate != nil {
+		this.DelayMasterPromotionIfSQLThreadNotUpToDate = *overrides.DelayMasterPromotionIfSQLThreadNotUpToDate
+	}
+	overrideProcesses := func(processes *[]string, override []string) {
+		if override != nil && config.Config.AllowRecoveryPolicyHookOverrides {
+			*processes = override
+		}
+	}
+	overrideProcesses(&this.OnFailureDetectionProcesses, overrides.OnFailureDetectionProcesses)
+	overrideProcesses(&this.PreGracefulTakeoverProcesses, overrides.PreGracefulTakeoverProcesses)
+	overrideProcesses(&this.PreFailoverProcesses, overrides.PreFailoverProcesses)
+	overrideProcesses(&this.PostFailoverProcesses, overrides.PostFailoverProcesses)
+	overrideProcesses(&this.PostUnsuccessfulFailoverProcesses, overrides.PostUnsuccessfulFailoverProcesses)
+	overrideProcesses(&this.PostMasterFailoverProcesses, overrides.PostMasterFailoverProcesses)
+	overrideProcesses(&this.PostIntermediateMasterFailoverProcesses, overrides.PostIntermediateMasterFailoverProcesses)
+	overrideProcesses(&this.PostGracefulTakeoverProcesses, overrides.PostGracefulTakeoverProcesses)
+}
+
+// OverridesProcesses returns true when these overrides set any of the hook process lists
+func (this *RecoveryPolicyOverrides) OverridesProcesses() bool {
+	return this.OnFailureDetectionProcesses != nil ||
+		this.PreGracefulTakeoverProcesses != nil ||
+		this.PreFailoverProcesses != nil ||
+		this.PostFailoverProcesses != nil ||
+		this.PostUnsuccessfulFailoverProcesses != nil ||
+		this.PostMasterFailoverProcesses != nil ||
+		this.PostIntermediateMasterFailoverProcesses != nil ||
+		this.PostGracefulTakeoverProcesses != nil
+}
+
+// Validate checks the policy is well formed: it has a name, its pattern compiles, its tags parse
+// and do not negate, and it selects clusters by at least one of pattern or tags.
+func (this *ClusterRecoveryPolicy) Validate() error {
+	if strings.TrimSpace(this.PolicyName) == "" {
+		return fmt.Errorf("cluster recovery policy: empty policy name")
+	}
+	if this.ClusterPattern == "" && this.Tags == "" {
+		return fmt.Errorf("cluster recovery policy %s: either cluster pattern or tags must be given", this.PolicyName)
+	}
+	if this.ClusterPattern != "" {
+		if _, err := regexp.Compile(this.ClusterPattern); err != nil {
+			return fmt.Errorf("cluster recovery policy %s: invalid cluster pattern: %+v", this.PolicyName, err)
+		}
+	}
+	if _, err := this.ParseTags(); err != nil {
+		return fmt.Errorf("cluster recovery policy %s: %+v", this.PolicyName, err)
+	}
+	if blockSeconds := this.Overrides.RecoveryPeriodBlockSeconds; blockSeconds != nil && *blockSeconds < 0 {
+		return fmt.Errorf("cluster recovery policy %s: RecoveryPeriodBlockSeconds must not be negative", this.PolicyName)
+	}
+	if lagMinutes := this.Overrides.FailMasterPromotionOnLagMinutes; lagMinutes != nil && *lagMinutes > 0 && config.Config.ReplicationLagQuery == "" {
+		return fmt.Errorf("cluster recovery policy %s: nonzero FailMasterPromotionOnLagMinutes requires ReplicationLagQuery to be set", this.PolicyName)
+	}
+	if delay := this.Overrides.DelayMasterPromotionIfSQLThreadNotUpToDate; delay != nil && *delay && config.Config.FailMasterPromotionIfSQLThreadNotUpToDate {
+		return fmt.Errorf("cluster recovery policy %s: cannot enable DelayMasterPromotionIfSQLThreadNotUpToDate as FailMasterPromotionIfSQLThreadNotUpToDate is enabled", this.PolicyName)
+	}
+	return nil
+}
+
+// ParseTags parses the policy's comma delimited tags
+func (this *ClusterRecoveryPolicy) ParseTags() (tags [](*Tag), err error) {
+	if this.Tags == "" {
+		return tags, nil
+	}
+	if tags, err = ParseIntersectTags(this.Tags); err != nil {
+		return tags, err
+	}
+	for _, tag := range tags {
+		if tag.Negate {
+			return tags, fmt.Errorf("negated tag %s not supported", tag.TagName)
+		}
+	}
+	return tags, nil
+}
+
+// String returns a one line description of the policy's selection criteria
+func (this *ClusterRecoveryPolicy) String() string {
+	selectors := []string{}
+	if this.ClusterPattern != "" {
+		selectors = append(selectors, fmt.Sprintf("cluster~=%s", this.ClusterPattern))
+	}
+	if this.Tags != "" {
+		selectors = append(selectors, fmt.Sprintf("tags=%s", this.Tags))
+	}
+	return fmt.Sprintf("%s (priority %d): %s", this.PolicyName, this.Priority, strings.Join(selectors, ", "))
+}
+
+// matchesClusterPattern checks whether the policy's cluster pattern, if any, matches the cluster's name or alias
+func (this *ClusterRecoveryPolicy) matchesClusterPattern(clusterName string, clusterAlias string) bool {
+	if this.ClusterPattern == "" {
+		return true
+	}
+	if matched, _ := regexp.MatchString(this.ClusterPattern, clusterName); matched {
+		return true
+	}
+	if clusterAlias == "" {
+		return false
+	}
+	matched, _ := regexp.MatchString(this.ClusterPattern, clusterAlias)
+	return matched
+}
+
+// ParseRecoveryPolicyOverrides reads overrides from URL query values named after the configuration settings,
+// e.g. `RecoveryPeriodBlockSeconds=600&PreFailoverProcesses=cmd1&PreFailoverProcesses=cmd2`.
+// A single empty value of a hook list overrides it with an empty list.
+func ParseRecoveryPolicyOverrides(values url.Values) (overrides RecoveryPolicyOverrides, err error) {
+	if value := values.Get("RecoveryPeriodBlockSeconds"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return overrides, fmt.Errorf("Invalid RecoveryPeriodBlockSeconds: %s", value)
+		}
+		overrides.RecoveryPeriodBlockSeconds = &seconds
+	}
+	if value := values.Get("FailMasterPromotionOnLagMinutes"); value != "" {
+		minutes, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			return overrides, fmt.Errorf("Invalid FailMasterPromotionOnLagMinutes: %s", value)
+		}
+		lagMinutes := uint(minutes)
+		overrides.FailMasterPromotionOnLagMinutes = &lagMinutes
+	}
+	parseBool := func(name string) (*bool, error) {
+		value := values.Get(name)
+		if value == "" {
+			return nil, nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s: %s", name, value)
+		}
+		return &b, nil
+	}
+	if overrides.PreventCrossDataCenterMasterFailover, err = parseBool("PreventCrossDataCenterMasterFailover"); err != nil {
+		return overrides, err
+	}
+	if overrides.DelayMasterPromotionIfSQLThreadNotUpToDate, err = parseBool("DelayMasterPromotionIfSQLThreadNotUpToDate"); err != nil {
+		return overrides, err
+	}
+	parseProcesses := func(name string) []string {
+		processes, ok := values[name]
+		if !ok {
+			return nil
+		}
+		result := []string{}
+		for _, process := range processes {
+			if process != "" {
+				result = append(result, process)
+			}
+		}
+		return result
+	}
+	overrides.OnFailureDetectionProcesses = parseProcesses("OnFailureDetectionProcesses")
+	overrides.PreGracefulTakeoverProcesses = parseProcesses("PreGracefulTakeoverProcesses")
+	overrides.PreFailoverProcesses = parseProcesses("PreFailoverProcesses")
+	overrides.PostFailoverProcesses = parseProcesses("PostFailoverProcesses")
+	overrides.PostUnsuccessfulFailoverProcesses = parseProcesses("PostUnsuccessfulFailoverProcesses")
+	overrides.PostMasterFailoverProcesses = parseProcesses("PostMasterFailoverProcesses")
+	overrides.PostIntermediateMasterFailoverProcesses = parseProcesses("PostIntermediateMasterFailoverProcesses")
+	overrides.PostGracefulTakeoverProcesses = parseProcesses("PostGracefulTakeoverProcesses")
+	return overrides, nil
+}
+
+// resolveRecoveryPolicy applies given policies, expected sorted by ascending priority, on top of
+// the global configuration. clusterTagged tells whether the cluster has instances tagged with a policy's tags.
+func resolveRecoveryPolicy(clusterName string, clusterAlias string, policies []ClusterRecoveryPolicy, clusterTagged func(policy *ClusterRecoveryPolicy) bool) *RecoveryPolicy {
+	recoveryPolicy := NewGlobalRecoveryPolicy()
+	for i := range policies {
+		policy := &policies[i]
+		if !policy.matchesClusterPattern(clusterName, clusterAlias) {
+			continue
+		}
+		if policy.Tags != "" && !clusterTagged(policy) {
+			continue
+		}
+		recoveryPolicy.apply(policy)
+	}
+	return recoveryPolicy
+}
@@ -0,0 +1,210 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/openark/orchestrator/go/db"
+	"github.com/patrickmn/go-cache"
+)
+
+// clusterRecoveryPoliciesCache holds the policies and the clusters matched by policy tags. Policies are resolved
+// whenever ClusterInfo is built, including once per analysis entry, and change rarely.
+var clusterRecoveryPoliciesCache = cache.New(10*time.Second, time.Second)
+
+const clusterRecoveryPoliciesCacheKey = "policies"
+
+// WriteClusterRecoveryPolicy creates or replaces a cluster recovery policy
+func WriteClusterRecoveryPolicy(policy *ClusterRecoveryPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return log.Errore(err)
+	}
+	overrides, err := json.Marshal(policy.Overrides)
+	if err != nil {
+		return log.Errore(err)
+	}
+	_, err = db.ExecOrchestrator(`
+			insert
+				into cluster_recovery_policy (
+					policy_name, cluster_pattern, tags, priority, overrides, last_updated
+				) values (
+					?, ?, ?, ?, ?, NOW()
+				) on duplicate key update
+					cluster_pattern=values(cluster_pattern),
+					tags=values(tags),
+					priority=values(priority),
+					overrides=values(overrides),
+					last_updated=values(last_updated)
+			`,
+		policy.PolicyName, policy.ClusterPattern, policy.Tags, policy.Priority, string(overrides),
+	)
+	clusterRecoveryPoliciesCache.Flush()
+	if err != nil {
+		return log.Errore(err)
+	}
+	AuditOperation("set-cluster-recovery-policy", nil, fmt.Sprintf("policy: %s, cluster pattern: %s, tags: %s, priority: %d, overrides: %s", policy.PolicyName, policy.ClusterPattern, policy.Tags, policy.Priority, string(overrides)))
+	return nil
+}
+
+// DeleteClusterRecoveryPolicy removes a cluster recovery policy
+func DeleteClusterRecoveryPolicy(policyName string) error {
+	sqlResult, err := db.ExecOrchestrator(`
+			delete from cluster_recovery_policy where policy_name = ?
+			`,
+		policyName,
+	)
+	clusterRecoveryPoliciesCache.Flush()
+	if err != nil {
+		return log.Errore(err)
+	}
+	if rows, err := sqlResult.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("cluster recovery policy not found: %s", policyName)
+	}
+	AuditOperation("delete-cluster-recovery-policy", nil, fmt.Sprintf("policy: %s", policyName))
+	return nil
+}
+
+func readClusterRecoveryPolicies(whereClause string, args []interface{}) (policies []ClusterRecoveryPolicy, err error) {
+	policies = []ClusterRecoveryPolicy{}
+	query := fmt.Sprintf(`
+		select
+			policy_name,
+			cluster_pattern,
+			tags,
+			priority,
+			overrides
+		from
+			cluster_recovery_policy
+		%s
+		order by
+			priority, policy_name
+		`, whereClause)
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		policy := ClusterRecoveryPolicy{
+			PolicyName:     m.GetString("policy_name"),
+			ClusterPattern: m.GetString("cluster_pattern"),
+			Tags:           m.GetString("tags"),
+			Priority:       m.GetInt("priority"),
+		}
+		if err := json.Unmarshal([]byte(m.GetString("overrides")), &policy.Overrides); err != nil {
+			return log.Errorf("cluster recovery policy %s: cannot parse overrides: %+v", policy.PolicyName, err)
+		}
+		policies = append(policies, policy)
+		return nil
+	})
+	return policies, log.Errore(err)
+}
+
+// ReadClusterRecoveryPolicies reads all cluster recovery policies, by ascending priority
+func ReadClusterRecoveryPolicies() (policies []ClusterRecoveryPolicy, err error) {
+	return readClusterRecoveryPolicies("", sqlutils.Args())
+}
+
+// ReadClusterRecoveryPolicy reads a single cluster recovery policy by name
+func ReadClusterRecoveryPolicy(policyName string) (*ClusterRecoveryPolicy, error) {
+	policies, err := readClusterRecoveryPolicies("where policy_name = ?", sqlutils.Args(policyName))
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		return nil, fmt.Errorf("cluster recovery policy not found: %s", policyName)
+	}
+	return &policies[0], nil
+}
+
+// readCachedClusterRecoveryPolicies reads all cluster recovery policies, preferably from cache
+func readCachedClusterRecoveryPolicies() (policies []ClusterRecoveryPolicy, err error) {
+	if policies, found := clusterRecoveryPoliciesCache.Get(clusterRecoveryPoliciesCacheKey); found {
+		return policies.([]ClusterRecoveryPolicy), nil
+	}
+	if policies, err = ReadClusterRecoveryPolicies(); err != nil {
+		return policies, err
+	}
+	clusterRecoveryPoliciesCache.Set(clusterRecoveryPoliciesCacheKey, policies, cache.DefaultExpiration)
+	return policies, nil
+}
+
+// readClusterNamesByTag returns the names of clusters which have at least one instance tagged with given tag
+func readClusterNamesByTag(tag *Tag) (clusterNames map[string]bool, err error) {
+	clusterNames = make(map[string]bool)
+	clause := `database_instance_tags.tag_name = ?`
+	args := sqlutils.Args(tag.TagName)
+	if tag.HasValue {
+		clause = `database_instance_tags.tag_name = ? and database_instance_tags.tag_value = ?`
+		args = append(args, tag.TagValue)
+	}
+	query := fmt.Sprintf(`
+		select
+			distinct database_instance.cluster_name
+		from
+			database_instance_tags
+			join database_instance on (
+				database_instance_tags.hostname = database_instance.hostname
+				and database_instance_tags.port = database_instance.port
+			)
+		where
+			%s
+		`, clause)
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		clusterNames[m.GetString("cluster_name")] = true
+		return nil
+	})
+	return clusterNames, log.Errore(err)
+}
+
+// clusterHasTags checks whether given cluster has instances tagged with each of the policy's tags
+func clusterHasTags(clusterName string, policy *ClusterRecoveryPolicy) bool {
+	tags, err := policy.ParseTags()
+	if err != nil {
+		log.Errore(err)
+		return false
+	}
+	for _, tag := range tags {
+		cacheKey := fmt.Sprintf("tag:%s:%t:%s", tag.TagName, tag.HasValue, tag.TagValue)
+		var clusterNames map[string]bool
+		if cached, found := clusterRecoveryPoliciesCache.Get(cacheKey); found {
+			clusterNames = cached.(map[string]bool)
+		} else {
+			if clusterNames, err = readClusterNamesByTag(tag); err != nil {
+				return false
+			}
+			clusterRecoveryPoliciesCache.Set(cacheKey, clusterNames, cache.DefaultExpiration)
+		}
+		if !clusterNames[clusterName] {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveClusterRecoveryPolicy returns the effective recovery policy of given cluster. Should policies
+// fail to read, the global configuration applies.
+func ResolveClusterRecoveryPolicy(clusterName string, clusterAlias string) *RecoveryPolicy {
+	policies, err := readCachedClusterRecoveryPolicies()
+	if err != nil {
+		log.Errorf("ResolveClusterRecoveryPolicy: cannot read policies; using global configuration for %s: %+v", clusterName, err)
+		return NewGlobalRecoveryPolicy()
+	}
+	return resolveRecoveryPolicy(clusterName, clusterAlias, policies, func(policy *ClusterRecoveryPolicy) bool {
+		return clusterHasTags(clusterName, policy)
+	})
+}
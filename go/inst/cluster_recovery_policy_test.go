@@ -0,0 +1,105 @@
+package inst
+
+import (
+	"net/url"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+)
+
+func TestParseRecoveryPolicyOverrides(t *testing.T) {
+	{
+		overrides, err := ParseRecoveryPolicyOverrides(url.Values{})
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(overrides.RecoveryPeriodBlockSeconds == nil)
+		test.S(t).ExpectTrue(overrides.PreventCrossDataCenterMasterFailover == nil)
+		test.S(t).ExpectTrue(overrides.PreFailoverProcesses == nil)
+		test.S(t).ExpectFalse(overrides.OverridesProcesses())
+	}
+	{
+		values, _ := url.ParseQuery("RecoveryPeriodBlockSeconds=600&FailMasterPromotionOnLagMinutes=2&PreventCrossDataCenterMasterFailover=true&PreFailoverProcesses=cmd1&PreFailoverProcesses=cmd2&PostFailoverProcesses=")
+		overrides, err := ParseRecoveryPolicyOverrides(values)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(*overrides.RecoveryPeriodBlockSeconds, 600)
+		test.S(t).ExpectEquals(*overrides.FailMasterPromotionOnLagMinutes, uint(2))
+		test.S(t).ExpectTrue(*overrides.PreventCrossDataCenterMasterFailover)
+		test.S(t).ExpectTrue(overrides.DelayMasterPromotionIfSQLThreadNotUpToDate == nil)
+		test.S(t).ExpectEquals(len(overrides.PreFailoverProcesses), 2)
+		test.S(t).ExpectNotNil(overrides.PostFailoverProcesses)
+		test.S(t).ExpectEquals(len(overrides.PostFailoverProcesses), 0)
+		test.S(t).ExpectTrue(overrides.PostMasterFailoverProcesses == nil)
+		test.S(t).ExpectTrue(overrides.OverridesProcesses())
+	}
+	{
+		values, _ := url.ParseQuery("PreventCrossDataCenterMasterFailover=maybe")
+		_, err := ParseRecoveryPolicyOverrides(values)
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestClusterRecoveryPolicyValidate(t *testing.T) {
+	test.S(t).ExpectNil((&ClusterRecoveryPolicy{PolicyName: "payments", ClusterPattern: "^payments"}).Validate())
+	test.S(t).ExpectNil((&ClusterRecoveryPolicy{PolicyName: "payments", Tags: "team=payments"}).Validate())
+	test.S(t).ExpectNotNil((&ClusterRecoveryPolicy{ClusterPattern: "^payments"}).Validate())
+	test.S(t).ExpectNotNil((&ClusterRecoveryPolicy{PolicyName: "payments"}).Validate())
+	test.S(t).ExpectNotNil((&ClusterRecoveryPolicy{PolicyName: "payments", ClusterPattern: "("}).Validate())
+	test.S(t).ExpectNotNil((&ClusterRecoveryPolicy{PolicyName: "payments", Tags: "~team=payments"}).Validate())
+}
+
+func TestResolveRecoveryPolicy(t *testing.T) {
+	defer func(allowHookOverrides bool) { config.Config.AllowRecoveryPolicyHookOverrides = allowHookOverrides }(config.Config.AllowRecoveryPolicyHookOverrides)
+	config.Config.AllowRecoveryPolicyHookOverrides = true
+	blockSeconds := 60
+	preventCrossDataCenter := true
+	policies := []ClusterRecoveryPolicy{
+		{
+			PolicyName:     "analytics",
+			ClusterPattern: "^analytics",
+			Overrides:      RecoveryPolicyOverrides{PostFailoverProcesses: []string{}},
+		},
+		{
+			PolicyName: "payments-tagged",
+			Tags:       "team=payments",
+			Overrides:  RecoveryPolicyOverrides{PreventCrossDataCenterMasterFailover: &preventCrossDataCenter},
+		},
+		{
+			PolicyName:     "payments",
+			ClusterPattern: "^payments",
+			Priority:       10,
+			Overrides:      RecoveryPolicyOverrides{RecoveryPeriodBlockSeconds: &blockSeconds, PreFailoverProcesses: []string{"fence-payments"}},
+		},
+	}
+	tagged := func(clusterName string) func(policy *ClusterRecoveryPolicy) bool {
+		return func(policy *ClusterRecoveryPolicy) bool {
+			return clusterName == "db-payments-1:3306"
+		}
+	}
+	{
+		recoveryPolicy := resolveRecoveryPolicy("db-other-1:3306", "other", policies, tagged("db-other-1:3306"))
+		test.S(t).ExpectEquals(len(recoveryPolicy.AppliedPolicies), 0)
+		test.S(t).ExpectEquals(recoveryPolicy.RecoveryPeriodBlockSeconds, config.Config.RecoveryPeriodBlockSeconds)
+	}
+	{
+		recoveryPolicy := resolveRecoveryPolicy("db-analytics-1:3306", "analytics-main", policies, tagged("db-analytics-1:3306"))
+		test.S(t).ExpectEquals(len(recoveryPolicy.AppliedPolicies), 1)
+		test.S(t).ExpectNotNil(recoveryPolicy.PostFailoverProcesses)
+		test.S(t).ExpectEquals(len(recoveryPolicy.PostFailoverProcesses), 0)
+	}
+	{
+		recoveryPolicy := resolveRecoveryPolicy("db-payments-1:3306", "payments-main", policies, tagged("db-payments-1:3306"))
+		test.S(t).ExpectEquals(len(recoveryPolicy.AppliedPolicies), 2)
+		test.S(t).ExpectEquals(recoveryPolicy.AppliedPolicies[1], "payments")
+		test.S(t).ExpectEquals(recoveryPolicy.RecoveryPeriodBlockSeconds, 60)
+		test.S(t).ExpectTrue(recoveryPolicy.PreventCrossDataCenterMasterFailover)
+		test.S(t).ExpectEquals(len(recoveryPolicy.PreFailoverProcesses), 1)
+		test.S(t).ExpectEquals(recoveryPolicy.PreFailoverProcesses[0], "fence-payments")
+	}
+	{
+		// Hook overrides are ignored unless allowed
+		config.Config.AllowRecoveryPolicyHookOverrides = false
+		recoveryPolicy := resolveRecoveryPolicy("db-payments-1:3306", "payments-main", policies, tagged("db-payments-1:3306"))
+		test.S(t).ExpectEquals(recoveryPolicy.RecoveryPeriodBlockSeconds, 60)
+		test.S(t).ExpectEquals(len(recoveryPolicy.PreFailoverProcesses), len(config.Config.PreFailoverProcesses))
+	}
+}
@@ -0,0 +1,370 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/orchestrator/go/config"
+	orcraft "github.com/openark/orchestrator/go/raft"
+	"github.com/patrickmn/go-cache"
+)
+
+const (
+	ErrantGTIDRemediationObserved   = "observed"
+	ErrantGTIDRemediationRemediated = "remediated"
+	ErrantGTIDRemediationFailed     = "failed"
+	ErrantGTIDRemediationSuperseded = "superseded"
+)
+
+var (
+	binlogEventGTIDNextRegexp     = regexp.MustCompile(`GTID_NEXT\s*=\s*'([^']+)'`)
+	binlogEventTableMapRegexp     = regexp.MustCompile(`^table_id: [0-9]+ [(]([^.]+)[.]`)
+	binlogEventLeadingComment     = regexp.MustCompile(`^/[*].*?[*]/\s*`)
+	binlogEventTransactionControl = regexp.MustCompile(`(?i)^(BEGIN|COMMIT|ROLLBACK|XA [A-Z]+)\b`)
+)
+
+// errantGTIDInspectionCache holds failed binlog inspections, by instance and errant GTID set
+var errantGTIDInspectionCache = cache.New(10*time.Minute, time.Minute)
+
+// errantGTIDRemediationEntrance makes sure errant GTIDs are only remediated by one goroutine at a time
+var errantGTIDRemediationEntrance int64
+
+// ErrantGTIDRemediation tracks an errant GTID set observed on a replica, from the time it is first observed,
+// through its verification, to its automated remediation.
+type ErrantGTIDRemediation struct {
+	Id                int64
+	Key               InstanceKey
+	ClusterName       string
+	GtidErrant        string
+	CountTransactions int64
+	FirstSeen         time.Time
+	LastEvaluated     time.Time
+	Status            string
+	StatusMessage     string
+	MasterKey         InstanceKey
+	CountInjected     int64
+}
+
+// errantGTIDBinlogInspection collects the schemas touched by errant transactions, as it goes through binlog events.
+// Schemas are only told by row events: a statement, be it DDL or statement based DML, may touch any schema
+// regardless of its default schema, and is therefore of unknown schema.
+type errantGTIDBinlogInspection struct {
+	errantGtidSet          *OracleGtidSet
+	inErrantTransaction    bool
+	CountTransactionsFound int64
+	Schemas                map[string]bool
+	UnknownSchemaQueries   []string
+}
+
+func newErrantGTIDBinlogInspection(gtidErrant string) (*errantGTIDBinlogInspection, error) {
+	errantGtidSet, err := NewOracleGtidSet(gtidErrant)
+	if err != nil {
+		return nil, err
+	}
+	return &errantGTIDBinlogInspection{
+		errantGtidSet:        errantGtidSet,
+		Schemas:              make(map[string]bool),
+		UnknownSchemaQueries: []string{},
+	}, nil
+}
+
+// inspect follows a single binlog event: a GTID event begins a transaction, which may or may not be errant, and
+// the Table_map events of an errant transaction tell the schemas it touches. Query events of an errant transaction,
+// other than transaction control, are of unknown schema.
+func (this *errantGTIDBinlogInspection) inspect(event *BinlogEvent) error {
+	switch event.EventType {
+	case "Gtid", "Gtid_tagged":
+		submatch := binlogEventGTIDNextRegexp.FindStringSubmatch(event.Info)
+		if len(submatch) == 0 {
+			return fmt.Errorf("cannot parse GTID event at %+v: %s", event.Coordinates, event.Info)
+		}
+		transactionGtidSet, err := NewOracleGtidSet(submatch[1])
+		if err != nil {
+			return err
+		}
+		if this.inErrantTransaction, err = this.errantGtidSet.Contains(transactionGtidSet); err != nil {
+			return err
+		}
+		if this.inErrantTransaction {
+			this.CountTransactionsFound++
+		}
+	case "Anonymous_Gtid":
+		this.inErrantTransaction = false
+	case "Query":
+		if !this.inErrantTransaction {
+			return nil
+		}
+		query := binlogEventLeadingComment.ReplaceAllString(strings.TrimSpace(event.Info), "")
+		if binlogEventTransactionControl.MatchString(query) {
+			return nil
+		}
+		this.UnknownSchemaQueries = append(this.UnknownSchemaQueries, query)
+	case "Table_map":
+		if !this.inErrantTransaction {
+			return nil
+		}
+		if submatch := binlogEventTableMapRegexp.FindStringSubmatch(event.Info); len(submatch) > 0 {
+			this.Schemas[submatch[1]] = true
+			return nil
+		}
+		this.UnknownSchemaQueries = append(this.UnknownSchemaQueries, event.Info)
+	}
+	return nil
+}
+
+// verifySchemas checks all errant transactions were found, and that they only touch given schemas
+func (this *errantGTIDBinlogInspection) verifySchemas(allowedSchemas []string) error {
+	if expected := this.errantGtidSet.Count(); this.CountTransactionsFound != expected {
+		return fmt.Errorf("found %d out of %d errant transactions in binary logs", this.CountTransactionsFound, expected)
+	}
+	if len(this.UnknownSchemaQueries) > 0 {
+		return fmt.Errorf("cannot tell schema of: %s", this.UnknownSchemaQueries[0])
+	}
+	allowed := make(map[string]bool)
+	for _, schema := range allowedSchemas {
+		allowed[schema] = true
+	}
+	disallowed := []string{}
+	for schema := range this.Schemas {
+		if !allowed[schema] {
+			disallowed = append(disallowed, schema)
+		}
+	}
+	if len(disallowed) > 0 {
+		sort.Strings(disallowed)
+		return fmt.Errorf("errant transactions touch schemas: %s", strings.Join(disallowed, ", "))
+	}
+	return nil
+}
+
+// inspectErrantGTIDBinlogs reads the binary logs of an instance which contain its errant transactions
+func inspectErrantGTIDBinlogs(instanceKey *InstanceKey, gtidErrant string) (*errantGTIDBinlogInspection, error) {
+	inspection, err := newErrantGTIDBinlogInspection(gtidErrant)
+	if err != nil {
+		return nil, err
+	}
+	errantBinlogs, err := LocateErrantGTID(instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	inspected := make(map[string]bool)
+	for _, binlog := range errantBinlogs {
+		if inspected[binlog] {
+			continue
+		}
+		inspected[binlog] = true
+		coordinates := BinlogCoordinates{LogFile: binlog, LogPos: 0, Type: BinaryLog}
+		for {
+			events, err := readBinlogEventsChunk(instanceKey, coordinates)
+			if err != nil {
+				return nil, err
+			}
+			if len(events) == 0 {
+				break
+			}
+			for i := range events {
+				if err := inspection.inspect(&events[i]); err != nil {
+					return nil, err
+				}
+			}
+			nextPos := events[len(events)-1].NextEventPos
+			if nextPos <= coordinates.LogPos {
+				break
+			}
+			coordinates.LogPos = nextPos
+		}
+	}
+	return inspection, nil
+}
+
+// verifyErrantGTIDHarmless checks whether the errant transactions may be safely remediated: they have been
+// observed for long enough, or they only touch allowed schemas. It returns the criterion which verified them.
+func verifyErrantGTIDHarmless(remediation *ErrantGTIDRemediation, now time.Time) (verification string, err error) {
+	reasons := []string{}
+	if minAgeMinutes := config.Config.ErrantGTIDAutoRemediationMinAgeMinutes; minAgeMinutes > 0 {
+		age := now.Sub(remediation.FirstSeen).Round(time.Second)
+		if age >= time.Duration(minAgeMinutes)*time.Minute {
+			return fmt.Sprintf("observed for %v, at least %d minutes", age, minAgeMinutes), nil
+		}
+		reasons = append(reasons, fmt.Sprintf("observed for %v, less than %d minutes", age, minAgeMinutes))
+	}
+	if allowedSchemas := config.Config.ErrantGTIDAutoRemediationSchemas; len(allowedSchemas) > 0 {
+		// Binlog contents of a given errant set do not change, and inspection is expensive; only inspect once in a while
+		cacheKey := fmt.Sprintf("%s/%s", remediation.Key.StringCode(), remediation.GtidErrant)
+		var err error
+		if cached, found := errantGTIDInspectionCache.Get(cacheKey); found {
+			err = cached.(error)
+		} else {
+			inspection, inspectErr := inspectErrantGTIDBinlogs(&remediation.Key, remediation.GtidErrant)
+			if err = inspectErr; err == nil {
+				err = inspection.verifySchemas(allowedSchemas)
+			}
+			if err != nil {
+				errantGTIDInspectionCache.Set(cacheKey, err, cache.DefaultExpiration)
+			}
+		}
+		if err == nil {
+			return fmt.Sprintf("binlog inspection: errant transactions only touch allowed schemas (%s)", strings.Join(allowedSchemas, ", ")), nil
+		}
+		reasons = append(reasons, fmt.Sprintf("binlog inspection: %+v", err))
+	}
+	return "", fmt.Errorf("not verified harmless: %s", strings.Join(reasons, "; "))
+}
+
+// errantGTIDAutoRemediationApplies checks whether errant GTIDs on given instance are to be remediated automatically.
+// Remediation injects empty transactions by GTID_NEXT, hence requires Oracle GTID.
+func errantGTIDAutoRemediationApplies(instance *Instance) bool {
+	if !instance.IsReplica() || instance.IsDowntimed || !instance.SupportsOracleGTID {
+		return false
+	}
+	if len(config.Config.ErrantGTIDAutoRemediationInstanceFilters) > 0 && !FiltersMatchInstanceKey(&instance.Key, config.Config.ErrantGTIDAutoRemediationInstanceFilters) {
+		return false
+	}
+	clusterInfo, err := ReadClusterInfo(instance.ClusterName)
+	if err != nil {
+		return false
+	}
+	return clusterInfo.filtersMatchCluster(config.Config.ErrantGTIDAutoRemediationClusterFilters)
+}
+
+// persistErrantGTIDRemediation writes the remediation entry, via raft when enabled
+func persistErrantGTIDRemediation(remediation *ErrantGTIDRemediation) error {
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("write-errant-gtid-remediation", remediation)
+		return err
+	}
+	return WriteErrantGTIDRemediation(remediation)
+}
+
+// supersedeErrantGTIDRemediations marks observed errant GTID sets which their replicas no longer have as superseded,
+// via raft when enabled
+func supersedeErrantGTIDRemediations() error {
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("supersede-errant-gtid-remediations", "")
+		return err
+	}
+	return SupersedeErrantGTIDRemediations()
+}
+
+// evaluateErrantGTIDRemediation updates the status message of an observed errant GTID set, auditing changes only,
+// as errant GTIDs are re-evaluated routinely
+func evaluateErrantGTIDRemediation(remediation *ErrantGTIDRemediation, message string, now time.Time) {
+	changed := (message != remediation.StatusMessage)
+	remediation.StatusMessage = message
+	remediation.LastEvaluated = now
+	persistErrantGTIDRemediation(remediation)
+	if changed {
+		AuditOperation("errant-gtid-remediation", &remediation.Key, fmt.Sprintf("%s: %s", remediation.GtidErrant, message))
+	}
+}
+
+// remediateErrantGTID tracks the errant GTID set of given replica, and once verified harmless, injects empty
+// transactions on the master for each of its errant transactions
+func remediateErrantGTID(instance *Instance, now time.Time) error {
+	remediation, err := readErrantGTIDRemediation(&instance.Key, instance.GtidErrant)
+	if err != nil {
+		return err
+	}
+	if remediation == nil {
+		gtidSet, err := NewOracleGtidSet(instance.GtidErrant)
+		if err != nil {
+			return err
+		}
+		remediation = &ErrantGTIDRemediation{
+			Key:               instance.Key,
+			ClusterName:       instance.ClusterName,
+			GtidErrant:        instance.GtidErrant,
+			CountTransactions: gtidSet.Count(),
+			FirstSeen:         now,
+			Status:            ErrantGTIDRemediationObserved,
+		}
+		evaluateErrantGTIDRemediation(remediation, fmt.Sprintf("observed %d errant transactions", remediation.CountTransactions), now)
+	}
+	if remediation.Status != ErrantGTIDRemediationObserved {
+		return nil
+	}
+	if maxTransactions := config.Config.ErrantGTIDAutoRemediationMaxTransactions; remediation.CountTransactions > int64(maxTransactions) {
+		evaluateErrantGTIDRemediation(remediation, fmt.Sprintf("%d errant transactions exceed ErrantGTIDAutoRemediationMaxTransactions (%d); will not remediate automatically", remediation.CountTransactions, maxTransactions), now)
+		return nil
+	}
+	verification, err := verifyErrantGTIDHarmless(remediation, now)
+	if err != nil {
+		evaluateErrantGTIDRemediation(remediation, err.Error(), now)
+		return nil
+	}
+	// Errant transactions are verified. Make sure they are the very same ones, as of now
+	instance, err = ReadTopologyInstance(&remediation.Key)
+	if err != nil {
+		return err
+	}
+	if instance.GtidErrant != remediation.GtidErrant {
+		evaluateErrantGTIDRemediation(remediation, fmt.Sprintf("errant GTID set changed to %s since observed; will re-evaluate", instance.GtidErrant), now)
+		return nil
+	}
+	clusterMaster, countInjected, err := injectEmptyErrantGTIDTransactions(instance)
+	remediation.CountInjected = countInjected
+	if clusterMaster != nil {
+		remediation.MasterKey = clusterMaster.Key
+	}
+	remediation.LastEvaluated = now
+	if err != nil {
+		remediation.Status = ErrantGTIDRemediationFailed
+		remediation.StatusMessage = fmt.Sprintf("verified harmless (%s); injected %d out of %d empty transactions on %+v, then failed: %+v", verification, countInjected, remediation.CountTransactions, remediation.MasterKey, err)
+	} else {
+		remediation.Status = ErrantGTIDRemediationRemediated
+		remediation.StatusMessage = fmt.Sprintf("verified harmless (%s); injected %d empty transactions on %+v", verification, countInjected, remediation.MasterKey)
+	}
+	persistErrantGTIDRemediation(remediation)
+	AuditOperation("errant-gtid-remediation", &remediation.Key, fmt.Sprintf("%s: %s", remediation.GtidErrant, remediation.StatusMessage))
+	return err
+}
+
+// RemediateErrantGTIDs remediates errant GTIDs on replicas of clusters configured for automated remediation.
+// It is expected to run on the leader only.
+func RemediateErrantGTIDs() {
+	if len(config.Config.ErrantGTIDAutoRemediationClusterFilters) == 0 {
+		return
+	}
+	// This function is non re-entrant (it can only be running once at any point in time)
+	if atomic.CompareAndSwapInt64(&errantGTIDRemediationEntrance, 0, 1) {
+		defer atomic.StoreInt64(&errantGTIDRemediationEntrance, 0)
+	} else {
+		return
+	}
+	if err := supersedeErrantGTIDRemediations(); err != nil {
+		return
+	}
+	instances, err := readErrantGTIDReplicas()
+	if err != nil {
+		return
+	}
+	for _, instance := range instances {
+		if !errantGTIDAutoRemediationApplies(instance) {
+			continue
+		}
+		if err := remediateErrantGTID(instance, time.Now()); err != nil {
+			log.Errorf("RemediateErrantGTIDs: %+v: %+v", instance.Key, err)
+		}
+	}
+}
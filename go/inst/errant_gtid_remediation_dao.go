@@ -0,0 +1,197 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/db"
+)
+
+func unixtimeOf(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func timeOfUnixtime(unixtime int64) time.Time {
+	if unixtime == 0 {
+		return time.Time{}
+	}
+	return time.Unix(unixtime, 0)
+}
+
+// WriteErrantGTIDRemediation creates or updates the entry of an errant GTID set on an instance. Entries are
+// identified by instance and errant GTID set, rather than by id, which is local to each backend database.
+func WriteErrantGTIDRemediation(remediation *ErrantGTIDRemediation) error {
+	existing, err := readErrantGTIDRemediation(&remediation.Key, remediation.GtidErrant)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		sqlResult, err := db.ExecOrchestrator(`
+				insert
+					into errant_gtid_remediation (
+						hostname, port, cluster_name, gtid_errant, count_transactions,
+						first_seen_unixtime, last_evaluated_unixtime, status, status_message,
+						master_hostname, master_port, count_injected, last_updated
+					) values (
+						?, ?, ?, ?, ?,
+						?, ?, ?, ?,
+						?, ?, ?, NOW()
+					)
+				`,
+			remediation.Key.Hostname, remediation.Key.Port, remediation.ClusterName, remediation.GtidErrant, remediation.CountTransactions,
+			unixtimeOf(remediation.FirstSeen), unixtimeOf(remediation.LastEvaluated), remediation.Status, remediation.StatusMessage,
+			remediation.MasterKey.Hostname, remediation.MasterKey.Port, remediation.CountInjected,
+		)
+		if err != nil {
+			return log.Errore(err)
+		}
+		remediation.Id, err = sqlResult.LastInsertId()
+		return log.Errore(err)
+	}
+	remediation.Id = existing.Id
+	_, err = db.ExecOrchestrator(`
+			update
+				errant_gtid_remediation
+			set
+				last_evaluated_unixtime = ?,
+				status = ?,
+				status_message = ?,
+				master_hostname = ?,
+				master_port = ?,
+				count_injected = ?,
+				last_updated = NOW()
+			where
+				remediation_id = ?
+			`,
+		unixtimeOf(remediation.LastEvaluated), remediation.Status, remediation.StatusMessage,
+		remediation.MasterKey.Hostname, remediation.MasterKey.Port, remediation.CountInjected,
+		existing.Id,
+	)
+	return log.Errore(err)
+}
+
+// SupersedeErrantGTIDRemediations marks observed errant GTID sets which their replicas no longer have, e.g. having
+// been remediated manually, or having changed, as superseded
+func SupersedeErrantGTIDRemediations() error {
+	_, err := db.ExecOrchestrator(`
+			update
+				errant_gtid_remediation
+			set
+				status = ?,
+				last_updated = NOW()
+			where
+				status = ?
+				and not exists (
+					select 1 from database_instance
+					where
+						database_instance.hostname = errant_gtid_remediation.hostname
+						and database_instance.port = errant_gtid_remediation.port
+						and database_instance.gtid_errant = errant_gtid_remediation.gtid_errant
+				)
+			`,
+		ErrantGTIDRemediationSuperseded, ErrantGTIDRemediationObserved,
+	)
+	return log.Errore(err)
+}
+
+func readErrantGTIDRemediations(whereClause string, args []interface{}) (remediations [](*ErrantGTIDRemediation), err error) {
+	remediations = [](*ErrantGTIDRemediation){}
+	query := fmt.Sprintf(`
+		select
+			remediation_id,
+			hostname,
+			port,
+			cluster_name,
+			gtid_errant,
+			count_transactions,
+			first_seen_unixtime,
+			last_evaluated_unixtime,
+			status,
+			status_message,
+			master_hostname,
+			master_port,
+			count_injected
+		from
+			errant_gtid_remediation
+		%s
+		order by
+			remediation_id desc
+		`, whereClause)
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		remediation := &ErrantGTIDRemediation{
+			Id:                m.GetInt64("remediation_id"),
+			Key:               InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")},
+			ClusterName:       m.GetString("cluster_name"),
+			GtidErrant:        m.GetString("gtid_errant"),
+			CountTransactions: m.GetInt64("count_transactions"),
+			FirstSeen:         timeOfUnixtime(m.GetInt64("first_seen_unixtime")),
+			LastEvaluated:     timeOfUnixtime(m.GetInt64("last_evaluated_unixtime")),
+			Status:            m.GetString("status"),
+			StatusMessage:     m.GetString("status_message"),
+			MasterKey:         InstanceKey{Hostname: m.GetString("master_hostname"), Port: m.GetInt("master_port")},
+			CountInjected:     m.GetInt64("count_injected"),
+		}
+		remediations = append(remediations, remediation)
+		return nil
+	})
+	return remediations, log.Errore(err)
+}
+
+// readErrantGTIDRemediation reads the entry of given errant GTID set on given instance; nil when not found
+func readErrantGTIDRemediation(instanceKey *InstanceKey, gtidErrant string) (*ErrantGTIDRemediation, error) {
+	remediations, err := readErrantGTIDRemediations(`where hostname = ? and port = ? and gtid_errant = ?`, sqlutils.Args(instanceKey.Hostname, instanceKey.Port, gtidErrant))
+	if err != nil || len(remediations) == 0 {
+		return nil, err
+	}
+	return remediations[0], nil
+}
+
+// ReadErrantGTIDRemediations reads errant GTID remediation entries of given cluster, or of all clusters when
+// clusterName is empty
+func ReadErrantGTIDRemediations(clusterName string) ([](*ErrantGTIDRemediation), error) {
+	if clusterName == "" {
+		return readErrantGTIDRemediations("", sqlutils.Args())
+	}
+	return readErrantGTIDRemediations("where cluster_name = ?", sqlutils.Args(clusterName))
+}
+
+// readErrantGTIDReplicas reads the instances which have errant GTIDs
+func readErrantGTIDReplicas() ([](*Instance), error) {
+	return readInstancesByCondition(`gtid_errant != ''`, sqlutils.Args(), "")
+}
+
+// ExpireErrantGTIDRemediations removes old entries of errant GTID sets which are done with
+func ExpireErrantGTIDRemediations() error {
+	_, err := db.ExecOrchestrator(`
+			delete
+				from errant_gtid_remediation
+			where
+				status != ?
+				and last_updated < NOW() - INTERVAL ? DAY
+			`,
+		ErrantGTIDRemediationObserved, config.Config.AuditPurgeDays,
+	)
+	return log.Errore(err)
+}
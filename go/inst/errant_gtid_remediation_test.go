@@ -0,0 +1,144 @@
+package inst
+
+import (
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+)
+
+const errantTestUUID = "00020194-3333-3333-3333-333333333333"
+
+func errantTestEvents() []BinlogEvent {
+	return []BinlogEvent{
+		{EventType: "Previous_gtids", Info: ""},
+		{EventType: "Gtid", Info: "SET @@SESSION.GTID_NEXT= '00020192-1111-1111-1111-111111111111:7'"},
+		{EventType: "Query", Info: "BEGIN"},
+		{EventType: "Table_map", Info: "table_id: 108 (app.users)"},
+		{EventType: "Write_rows", Info: "table_id: 108 flags: STMT_END_F"},
+		{EventType: "Xid", Info: "COMMIT /* xid=22 */"},
+		{EventType: "Gtid", Info: "SET @@SESSION.GTID_NEXT= '" + errantTestUUID + ":1'"},
+		{EventType: "Query", Info: "BEGIN"},
+		{EventType: "Table_map", Info: "table_id: 109 (meta.heartbeat)"},
+		{EventType: "Update_rows", Info: "table_id: 109 flags: STMT_END_F"},
+		{EventType: "Xid", Info: "COMMIT /* xid=22 */"},
+		{EventType: "Gtid", Info: "SET @@SESSION.GTID_NEXT= '" + errantTestUUID + ":2'"},
+		{EventType: "Query", Info: "BEGIN"},
+		{EventType: "Table_map", Info: "table_id: 109 (meta.heartbeat)"},
+		{EventType: "Write_rows", Info: "table_id: 109 flags: STMT_END_F"},
+		{EventType: "Xid", Info: "COMMIT /* xid=23 */"},
+	}
+}
+
+func TestErrantGTIDBinlogInspection(t *testing.T) {
+	{
+		inspection, err := newErrantGTIDBinlogInspection(errantTestUUID + ":1-2")
+		test.S(t).ExpectNil(err)
+		for _, event := range errantTestEvents() {
+			test.S(t).ExpectNil(inspection.inspect(&event))
+		}
+		test.S(t).ExpectEquals(inspection.CountTransactionsFound, int64(2))
+		test.S(t).ExpectEquals(len(inspection.Schemas), 1)
+		test.S(t).ExpectTrue(inspection.Schemas["meta"])
+		test.S(t).ExpectNil(inspection.verifySchemas([]string{"meta"}))
+		test.S(t).ExpectNotNil(inspection.verifySchemas([]string{"app"}))
+	}
+	{
+		// Third errant transaction not found in binlogs
+		inspection, err := newErrantGTIDBinlogInspection(errantTestUUID + ":1-3")
+		test.S(t).ExpectNil(err)
+		for _, event := range errantTestEvents() {
+			test.S(t).ExpectNil(inspection.inspect(&event))
+		}
+		test.S(t).ExpectEquals(inspection.CountTransactionsFound, int64(2))
+		test.S(t).ExpectNotNil(inspection.verifySchemas([]string{"meta"}))
+	}
+	{
+		inspection, err := newErrantGTIDBinlogInspection(errantTestUUID + ":1")
+		test.S(t).ExpectNil(err)
+		events := []BinlogEvent{
+			{EventType: "Gtid", Info: "SET @@SESSION.GTID_NEXT= '" + errantTestUUID + ":1'"},
+			{EventType: "Query", Info: "create database scratch"},
+		}
+		for _, event := range events {
+			test.S(t).ExpectNil(inspection.inspect(&event))
+		}
+		test.S(t).ExpectEquals(len(inspection.UnknownSchemaQueries), 1)
+		test.S(t).ExpectNotNil(inspection.verifySchemas([]string{"scratch"}))
+	}
+	{
+		// The default schema of a statement does not tell the schema it touches
+		for _, events := range [][]BinlogEvent{
+			{
+				{EventType: "Gtid", Info: "SET @@SESSION.GTID_NEXT= '" + errantTestUUID + ":1'"},
+				{EventType: "Query", Info: "use `meta`; DROP TABLE `app`.`users` /* generated by server */"},
+			},
+			{
+				// Statement based replication
+				{EventType: "Gtid", Info: "SET @@SESSION.GTID_NEXT= '" + errantTestUUID + ":1'"},
+				{EventType: "Query", Info: "BEGIN"},
+				{EventType: "Query", Info: "use `meta`; insert into app.x values (1)"},
+				{EventType: "Xid", Info: "COMMIT /* xid=24 */"},
+			},
+		} {
+			inspection, err := newErrantGTIDBinlogInspection(errantTestUUID + ":1")
+			test.S(t).ExpectNil(err)
+			for _, event := range events {
+				test.S(t).ExpectNil(inspection.inspect(&event))
+			}
+			test.S(t).ExpectEquals(inspection.CountTransactionsFound, int64(1))
+			test.S(t).ExpectEquals(len(inspection.Schemas), 0)
+			test.S(t).ExpectEquals(len(inspection.UnknownSchemaQueries), 1)
+			test.S(t).ExpectNotNil(inspection.verifySchemas([]string{"meta"}))
+			test.S(t).ExpectNotNil(inspection.verifySchemas([]string{"meta", "app"}))
+		}
+	}
+	{
+		// Transaction control is of no schema
+		inspection, err := newErrantGTIDBinlogInspection(errantTestUUID + ":1")
+		test.S(t).ExpectNil(err)
+		events := []BinlogEvent{
+			{EventType: "Gtid", Info: "SET @@SESSION.GTID_NEXT= '" + errantTestUUID + ":1'"},
+			{EventType: "Query", Info: "XA START X'7831',X'',1"},
+			{EventType: "Table_map", Info: "table_id: 109 (meta.heartbeat)"},
+			{EventType: "Write_rows", Info: "table_id: 109 flags: STMT_END_F"},
+			{EventType: "Query", Info: "XA END X'7831',X'',1"},
+			{EventType: "XA_prepare", Info: "XA PREPARE X'7831',X'',1"},
+		}
+		for _, event := range events {
+			test.S(t).ExpectNil(inspection.inspect(&event))
+		}
+		test.S(t).ExpectEquals(len(inspection.UnknownSchemaQueries), 0)
+		test.S(t).ExpectNil(inspection.verifySchemas([]string{"meta"}))
+	}
+}
+
+func TestErrantGTIDAutoRemediationApplies(t *testing.T) {
+	instance := NewInstance()
+	instance.Key = InstanceKey{Hostname: "replica", Port: 3306}
+	instance.MasterKey = InstanceKey{Hostname: "master", Port: 3306}
+	instance.ReadBinlogCoordinates = BinlogCoordinates{LogFile: "mysql-bin.000001", LogPos: 4}
+	test.S(t).ExpectTrue(instance.IsReplica())
+	// Not using Oracle GTID
+	test.S(t).ExpectFalse(errantGTIDAutoRemediationApplies(instance))
+
+	instance.SupportsOracleGTID = true
+	instance.IsDowntimed = true
+	test.S(t).ExpectFalse(errantGTIDAutoRemediationApplies(instance))
+}
+
+func TestVerifyErrantGTIDHarmlessByAge(t *testing.T) {
+	defer func(minAge uint) { config.Config.ErrantGTIDAutoRemediationMinAgeMinutes = minAge }(config.Config.ErrantGTIDAutoRemediationMinAgeMinutes)
+	config.Config.ErrantGTIDAutoRemediationMinAgeMinutes = 30
+
+	now := time.Now()
+	remediation := &ErrantGTIDRemediation{GtidErrant: errantTestUUID + ":1", FirstSeen: now.Add(-10 * time.Minute)}
+	_, err := verifyErrantGTIDHarmless(remediation, now)
+	test.S(t).ExpectNotNil(err)
+
+	remediation.FirstSeen = now.Add(-30 * time.Minute)
+	verification, err := verifyErrantGTIDHarmless(remediation, now)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(verification != "")
+}
@@ -0,0 +1,71 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"github.com/openark/golib/sqlutils"
+)
+
+// ReadGaleraClusterNodes returns all known nodes sharing given wsrep_cluster_state_uuid
+func ReadGaleraClusterNodes(wsrepClusterStateUUID string) ([](*Instance), error) {
+	condition := `
+		wsrep_cluster_state_uuid = ?
+	`
+	return readInstancesByCondition(condition, sqlutils.Args(wsrepClusterStateUUID), "")
+}
+
+// GetGaleraClusterSize returns the number of given nodes, which are all known to orchestrator as members
+// of the same Galera cluster, along with the largest wsrep_cluster_size reported by a reachable node of the
+// primary component. A reported size smaller than the known size means nodes have left the cluster.
+// A zero reported size means no node of the primary component could be reached.
+func GetGaleraClusterSize(nodes [](*Instance)) (knownSize uint, reportedSize uint) {
+	for _, node := range nodes {
+		knownSize++
+		if !node.IsLastCheckValid || !node.IsGaleraPrimaryComponent() {
+			continue
+		}
+		if node.WsrepClusterSize > reportedSize {
+			reportedSize = node.WsrepClusterSize
+		}
+	}
+	return knownSize, reportedSize
+}
+
+// GetGaleraReplicaRelocationTarget picks, among given nodes of a Galera cluster, a node to take over the async
+// replicas of a failed node: a reachable, synced node of the primary component. Nodes in the data center
+// of the failed node are preferred. Returns nil when there is no such node.
+func GetGaleraReplicaRelocationTarget(nodes [](*Instance), failedNode *Instance) *Instance {
+	var target *Instance
+	for _, node := range nodes {
+		if node.Key.Equals(&failedNode.Key) {
+			continue
+		}
+		if !node.IsLastCheckValid || !node.IsGaleraSynced() {
+			continue
+		}
+		if node.IsDowntimed {
+			continue
+		}
+		if target == nil {
+			target = node
+		}
+		if node.DataCenter == failedNode.DataCenter && target.DataCenter != failedNode.DataCenter {
+			target = node
+		}
+	}
+	return target
+}
@@ -0,0 +1,112 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func newTestGaleraNode(port int, status string, localState uint, clusterSize uint, dataCenter string) *Instance {
+	instance := NewInstance()
+	instance.Key = InstanceKey{Hostname: "pxc", Port: port}
+	instance.IsLastCheckValid = true
+	instance.WsrepClusterStateUUID = "6c0e5ef2-3f5f-11ef-a4c6-6f8d3b2c9a10"
+	instance.WsrepClusterStatus = status
+	instance.WsrepLocalState = localState
+	instance.WsrepClusterSize = clusterSize
+	instance.DataCenter = dataCenter
+	return instance
+}
+
+func TestGaleraNodeState(t *testing.T) {
+	{
+		node := newTestGaleraNode(1, GaleraClusterStatusPrimary, GaleraLocalStateSynced, 3, "dc1")
+		test.S(t).ExpectTrue(node.IsGaleraNode())
+		test.S(t).ExpectTrue(node.IsGaleraSynced())
+		test.S(t).ExpectFalse(node.IsGaleraDesynced())
+		node.WsrepDesync = true
+		test.S(t).ExpectFalse(node.IsGaleraSynced())
+		test.S(t).ExpectTrue(node.IsGaleraDesynced())
+	}
+	{
+		node := newTestGaleraNode(1, GaleraClusterStatusPrimary, GaleraLocalStateDonorDesynced, 3, "dc1")
+		test.S(t).ExpectFalse(node.IsGaleraSynced())
+		test.S(t).ExpectTrue(node.IsGaleraDesynced())
+	}
+	{
+		node := newTestGaleraNode(1, "non-Primary", GaleraLocalStateSynced, 1, "dc1")
+		test.S(t).ExpectFalse(node.IsGaleraPrimaryComponent())
+		test.S(t).ExpectFalse(node.IsGaleraSynced())
+	}
+	{
+		node := NewInstance()
+		test.S(t).ExpectFalse(node.IsGaleraNode())
+		test.S(t).ExpectFalse(node.IsGaleraDesynced())
+	}
+}
+
+func TestGetGaleraClusterSize(t *testing.T) {
+	{
+		nodes := [](*Instance){
+			newTestGaleraNode(1, GaleraClusterStatusPrimary, GaleraLocalStateSynced, 3, "dc1"),
+			newTestGaleraNode(2, GaleraClusterStatusPrimary, GaleraLocalStateSynced, 3, "dc1"),
+			newTestGaleraNode(3, GaleraClusterStatusPrimary, GaleraLocalStateSynced, 3, "dc2"),
+		}
+		knownSize, reportedSize := GetGaleraClusterSize(nodes)
+		test.S(t).ExpectEquals(knownSize, uint(3))
+		test.S(t).ExpectEquals(reportedSize, uint(3))
+	}
+	{
+		// Third node is gone, and its stale row says nothing
+		nodes := [](*Instance){
+			newTestGaleraNode(1, GaleraClusterStatusPrimary, GaleraLocalStateSynced, 2, "dc1"),
+			newTestGaleraNode(2, GaleraClusterStatusPrimary, GaleraLocalStateSynced, 2, "dc1"),
+			newTestGaleraNode(3, GaleraClusterStatusPrimary, GaleraLocalStateSynced, 3, "dc2"),
+		}
+		nodes[2].IsLastCheckValid = false
+		knownSize, reportedSize := GetGaleraClusterSize(nodes)
+		test.S(t).ExpectEquals(knownSize, uint(3))
+		test.S(t).ExpectEquals(reportedSize, uint(2))
+	}
+	{
+		// Split brain: no primary component
+		nodes := [](*Instance){
+			newTestGaleraNode(1, "non-Primary", GaleraLocalStateSynced, 1, "dc1"),
+			newTestGaleraNode(2, "non-Primary", GaleraLocalStateSynced, 1, "dc2"),
+		}
+		_, reportedSize := GetGaleraClusterSize(nodes)
+		test.S(t).ExpectEquals(reportedSize, uint(0))
+	}
+}
+
+func TestGetGaleraReplicaRelocationTarget(t *testing.T) {
+	failedNode := newTestGaleraNode(1, GaleraClusterStatusPrimary, GaleraLocalStateSynced, 3, "dc2")
+	failedNode.IsLastCheckValid = false
+	{
+		nodes := [](*Instance){
+			failedNode,
+			newTestGaleraNode(2, GaleraClusterStatusPrimary, GaleraLocalStateSynced, 2, "dc1"),
+			newTestGaleraNode(3, GaleraClusterStatusPrimary, GaleraLocalStateSynced, 2, "dc2"),
+		}
+		target := GetGaleraReplicaRelocationTarget(nodes, failedNode)
+		test.S(t).ExpectNotNil(target)
+		test.S(t).ExpectEquals(target.Key.Port, 3)
+	}
+	{
+		nodes := [](*Instance){
+			failedNode,
+			newTestGaleraNode(2, GaleraClusterStatusPrimary, GaleraLocalStateSynced, 2, "dc1"),
+			newTestGaleraNode(3, GaleraClusterStatusPrimary, GaleraLocalStateDonorDesynced, 2, "dc2"),
+		}
+		target := GetGaleraReplicaRelocationTarget(nodes, failedNode)
+		test.S(t).ExpectNotNil(target)
+		test.S(t).ExpectEquals(target.Key.Port, 2)
+	}
+	{
+		nodes := [](*Instance){
+			failedNode,
+			newTestGaleraNode(2, "non-Primary", GaleraLocalStateSynced, 1, "dc1"),
+		}
+		test.S(t).ExpectTrue(GetGaleraReplicaRelocationTarget(nodes, failedNode) == nil)
+	}
+}
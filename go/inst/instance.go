@@ -77,11 +77,25 @@ type Instance struct {
 	ExecutedGtidSet       string
 	GtidPurged            string
 	GtidErrant            string
-
-	masterExecutedGtidSet string // Not exported
-
-	SlaveLagSeconds                   sql.NullInt64 // for API backwards compatibility. Equals `ReplicationLagSeconds`
-	ReplicationLagSeconds             sql.NullInt64
+	MariaDBGtidDomainID   uint32
+	MariaDBGtidBinlogPos  string
+	MariaDBGtidSlavePos   string
+
+	// Named channels of a multi-source replica. The default channel, or the first named channel
+	// where there is no default channel, is the one depicted by the replication fields above.
+	ReplicationChannels ReplicationChannels
+
+	masterExecutedGtidSet      string // Not exported
+	masterMariaDBGtidBinlogPos string // Not exported
+	masterServerID             uint   // Not exported
+
+	SlaveLagSeconds       sql.NullInt64 // for API backwards compatibility. Equals `ReplicationLagSeconds`
+	ReplicationLagSeconds sql.NullInt64
+	// Lag as measured from performance_schema on MySQL 8.0 replicas (see ReplicationLagFromPerformanceSchema):
+	// true lag is that of the slowest applier worker, queue lag is the time span of received yet unapplied transactions
+	ReplicationTrueLagSeconds         sql.NullInt64
+	ReplicationQueueLagSeconds        sql.NullInt64
+	ReplicationApplyRate              float64        // transactions applied per second, between the two latest polls
 	SlaveHosts                        InstanceKeyMap // for API backwards compatibility. Equals `Replicas`
 	Replicas                          InstanceKeyMap
 	ClusterName                       string
@@ -129,6 +143,7 @@ type Instance struct {
 	Problems []string
 
 	LastDiscoveryLatency time.Duration
+	PollIntervalSeconds  uint // effective interval at which this instance is polled
 
 	seed bool // Means we force this instance to be written to backend, even if it's invalid, empty or forgotten
 
@@ -146,9 +161,29 @@ type Instance struct {
 	// List of all known members of the same group
 	ReplicationGroupMembers InstanceKeyMap
 
+	// Members of the same group which this instance sees as UNREACHABLE
+	ReplicationGroupUnreachableMembers InstanceKeyMap
+
+	// group_replication_local_address, the address this member uses for group communication
+	ReplicationGroupLocalAddress string
+
 	// Primary of the replication group
 	ReplicationGroupPrimaryInstanceKey InstanceKey
 
+	/* All things Galera (Percona XtraDB Cluster, MariaDB Galera Cluster) below */
+
+	// wsrep_cluster_state_uuid is shared by all nodes of the same Galera cluster
+	WsrepClusterStateUUID string
+	// wsrep_cluster_status: "Primary" when the node is part of the primary component
+	WsrepClusterStatus string
+	// wsrep_local_state and its human readable wsrep_local_state_comment (e.g. "Synced", "Donor/Desynced")
+	WsrepLocalState        uint
+	WsrepLocalStateComment string
+	// wsrep_cluster_size is the number of nodes in the component this node is part of
+	WsrepClusterSize uint
+	// @@wsrep_desync
+	WsrepDesync bool
+
 	// Query string provider
 	QSP QueryStringProvider
 }
@@ -157,9 +192,11 @@ type Instance struct {
 
 func NewInstance() *Instance {
 	return &Instance{
-		Replicas:                make(map[InstanceKey]bool),
-		ReplicationGroupMembers: make(map[InstanceKey]bool),
-		Problems:                []string{},
+		Replicas:                           make(map[InstanceKey]bool),
+		ReplicationGroupMembers:            make(map[InstanceKey]bool),
+		ReplicationGroupUnreachableMembers: make(map[InstanceKey]bool),
+		ReplicationChannels:                ReplicationChannels{},
+		Problems:                           []string{},
 	}
 }
 
@@ -267,6 +304,48 @@ func (this *Instance) IsReplicationGroupSecondary() bool {
 	return this.IsReplicationGroupMember() && !this.ReplicationGroupPrimaryInstanceKey.Equals(&this.Key)
 }
 
+// ReplicationGroupReachableMembers returns the members of the group this instance can communicate with, as seen
+// by this instance, and including this instance itself. This is the partition the instance belongs to.
+func (this *Instance) ReplicationGroupReachableMembers() *InstanceKeyMap {
+	reachableMembers := NewInstanceKeyMap()
+	reachableMembers.AddKey(this.Key)
+	for memberKey := range this.ReplicationGroupMembers {
+		if !this.ReplicationGroupUnreachableMembers.HasKey(memberKey) {
+			reachableMembers.AddKey(memberKey)
+		}
+	}
+	return reachableMembers
+}
+
+// ReplicationGroupHasQuorum returns true when, as seen by this instance, a majority of the group members are
+// reachable. Without quorum the group blocks writes.
+func (this *Instance) ReplicationGroupHasQuorum() bool {
+	groupSize := len(this.ReplicationGroupMembers) + 1
+	return 2*len(*this.ReplicationGroupReachableMembers()) > groupSize
+}
+
+// IsGaleraNode checks whether this instance is a node in a Galera cluster (Percona XtraDB Cluster, MariaDB Galera Cluster)
+func (this *Instance) IsGaleraNode() bool {
+	return this.WsrepClusterStateUUID != ""
+}
+
+// IsGaleraPrimaryComponent returns true when this Galera node is part of the primary component, and is thus
+// able to serve reads and writes
+func (this *Instance) IsGaleraPrimaryComponent() bool {
+	return this.IsGaleraNode() && this.WsrepClusterStatus == GaleraClusterStatusPrimary
+}
+
+// IsGaleraDesynced returns true when this Galera node is donating a state snapshot, or was explicitly desynced.
+// Either way it lags behind the cluster and does not take part in flow control.
+func (this *Instance) IsGaleraDesynced() bool {
+	return this.IsGaleraNode() && (this.WsrepLocalState == GaleraLocalStateDonorDesynced || this.WsrepDesync)
+}
+
+// IsGaleraSynced returns true when this Galera node is fully synced with a primary component
+func (this *Instance) IsGaleraSynced() bool {
+	return this.IsGaleraPrimaryComponent() && this.WsrepLocalState == GaleraLocalStateSynced && !this.WsrepDesync
+}
+
 // IsBinlogServer checks whether this is any type of a binlog server (currently only maxscale)
 func (this *Instance) IsBinlogServer() bool {
 	if this.isMaxScale() {
@@ -377,6 +456,23 @@ func (this *Instance) UsingGTID() bool {
 	return this.UsingOracleGTID || this.UsingMariaDBGTID
 }
 
+// CompareMariaDBGtidSlavePos compares the replication progress of this instance and other instance by their
+// MariaDB gtid_slave_pos. See MariadbGtidPosition.Compare() for return values.
+func (this *Instance) CompareMariaDBGtidSlavePos(other *Instance) (comparison int, comparable bool) {
+	if this.MariaDBGtidSlavePos == "" || other.MariaDBGtidSlavePos == "" {
+		return 0, false
+	}
+	thisPos, err := NewMariadbGtidPosition(this.MariaDBGtidSlavePos)
+	if err != nil {
+		return 0, false
+	}
+	otherPos, err := NewMariadbGtidPosition(other.MariaDBGtidSlavePos)
+	if err != nil {
+		return 0, false
+	}
+	return thisPos.Compare(otherPos)
+}
+
 // NextGTID returns the next (Oracle) GTID to be executed. Useful for skipping queries
 func (this *Instance) NextGTID() (string, error) {
 	if this.ExecutedGtidSet == "" {
@@ -581,6 +677,15 @@ func (this *Instance) LagStatusString() string {
 	return fmt.Sprintf("%+vs", this.ReplicationLagSeconds.Int64)
 }
 
+// EffectiveReplicationLagSeconds returns the true, per-worker lag when known, and otherwise the lag as
+// read from SHOW SLAVE STATUS or ReplicationLagQuery
+func (this *Instance) EffectiveReplicationLagSeconds() sql.NullInt64 {
+	if this.ReplicationTrueLagSeconds.Valid {
+		return this.ReplicationTrueLagSeconds
+	}
+	return this.ReplicationLagSeconds
+}
+
 func (this *Instance) descriptionTokens() (tokens []string) {
 	tokens = append(tokens, this.LagStatusString())
 	tokens = append(tokens, this.StatusString())
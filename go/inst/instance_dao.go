@@ -86,7 +86,7 @@ func (this InstancesByDc) Swap(i, j int) { this[i], this[j] = this[j], this[i] }
 func (this InstancesByDc) Less(i, j int) bool {
 	if this[i].DataCenter == this[j].DataCenter {
 		if len(this[i].Replicas) == 0 && len(this[j].Replicas) == 0 {
-			return this[i].ReplicationLagSeconds.Int64 < this[j].ReplicationLagSeconds.Int64
+			return this[i].EffectiveReplicationLagSeconds().Int64 < this[j].EffectiveReplicationLagSeconds().Int64
 		}
 		return len(this[i].Replicas) < len(this[j].Replicas)
 	}
@@ -100,10 +100,23 @@ const (
 	GroupReplicationMemberRolePrimary   = "PRIMARY"
 	GroupReplicationMemberRoleSecondary = "SECONDARY"
 	// Group member states
-	GroupReplicationMemberStateOnline     = "ONLINE"
-	GroupReplicationMemberStateRecovering = "RECOVERING"
-	GroupReplicationMemberStateOffline    = "OFFLINE"
-	GroupReplicationMemberStateError      = "ERROR"
+	GroupReplicationMemberStateOnline      = "ONLINE"
+	GroupReplicationMemberStateRecovering  = "RECOVERING"
+	GroupReplicationMemberStateOffline     = "OFFLINE"
+	GroupReplicationMemberStateError       = "ERROR"
+	GroupReplicationMemberStateUnreachable = "UNREACHABLE"
+)
+
+// Constants for Galera cluster information
+// See https://galeracluster.com/library/documentation/node-states.html for additional information.
+const (
+	// wsrep_cluster_status of a node in the primary component
+	GaleraClusterStatusPrimary = "Primary"
+	// wsrep_local_state values
+	GaleraLocalStateJoining       uint = 1
+	GaleraLocalStateDonorDesynced uint = 2
+	GaleraLocalStateJoined        uint = 3
+	GaleraLocalStateSynced        uint = 4
 )
 
 // We use this map to identify whether the query failed because the server does not support group replication or due
@@ -475,6 +488,30 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 			return err
 		}
 
+		if channelName := m.GetStringD("Channel_Name", DefaultReplicationChannelName); channelName != DefaultReplicationChannelName {
+			channel := &ReplicationChannel{
+				ChannelName:               channelName,
+				MasterKey:                 InstanceKey{Hostname: m.GetString(instance.QSP.master_host()), Port: m.GetInt(instance.QSP.master_port())},
+				MasterUUID:                m.GetStringD(instance.QSP.master_uuid(), "No"),
+				ReplicationIOThreadState:  ReplicationThreadStateFromStatus(m.GetString(instance.QSP.slave_io_running())),
+				ReplicationSQLThreadState: ReplicationThreadStateFromStatus(m.GetString(instance.QSP.slave_sql_running())),
+				ReadBinlogCoordinates:     BinlogCoordinates{LogFile: m.GetString(instance.QSP.master_log_file()), LogPos: m.GetInt64(instance.QSP.read_master_log_pos())},
+				ExecBinlogCoordinates:     BinlogCoordinates{LogFile: m.GetString(instance.QSP.relay_master_log_file()), LogPos: m.GetInt64(instance.QSP.relay_master_log_position())},
+				LastIOError:               emptyQuotesRegexp.ReplaceAllString(strconv.QuoteToASCII(m.GetString("Last_IO_Error")), ""),
+				LastSQLError:              emptyQuotesRegexp.ReplaceAllString(strconv.QuoteToASCII(m.GetString("Last_SQL_Error")), ""),
+				SecondsBehindMaster:       m.GetNullInt64(instance.QSP.seconds_behind_master()),
+				UsingOracleGTID:           (m.GetIntD("Auto_Position", 0) == 1),
+				UsingMariaDBGTID:          (m.GetStringD("Using_Gtid", "No") != "No"),
+				IsTopologyChannel:         !slaveStatusFound,
+			}
+			instance.ReplicationChannels = append(instance.ReplicationChannels, channel)
+		}
+		if slaveStatusFound {
+			// Multi-source replication. Replication fields depict the first channel listed, which is the
+			// default channel if there is one. Other channels are only listed in ReplicationChannels.
+			return nil
+		}
+
 		instance.HasReplicationCredentials = (user != "")
 		instance.ReplicationIOThreadState = ReplicationThreadStateFromStatus(m.GetString(instance.QSP.slave_io_running()))
 		instance.ReplicationSQLThreadState = ReplicationThreadStateFromStatus(m.GetString(instance.QSP.slave_sql_running()))
@@ -704,6 +741,49 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 				}
 			}()
 		}
+		if instance.IsMariaDB() && !instance.IsSmallerMajorVersionByString("10.0") {
+			waitGroup.Add(1)
+			go func() {
+				defer waitGroup.Done()
+				// MariaDB GTID positions are per replication domain. @@gtid_slave_pos is what replicas resume from
+				// when using master_use_gtid=slave_pos.
+				err := db.QueryRow("select @@global.gtid_domain_id, @@global.gtid_binlog_pos, @@global.gtid_slave_pos").Scan(
+					&instance.MariaDBGtidDomainID, &instance.MariaDBGtidBinlogPos, &instance.MariaDBGtidSlavePos)
+				if err != nil {
+					logReadTopologyInstanceError(instanceKey, "MariaDB GTID positions", err)
+				}
+				errorChan <- err
+			}()
+		}
+		if instance.IsMariaDB() || instance.IsPercona() || strings.Contains(strings.ToLower(instance.VersionComment), "wsrep") {
+			waitGroup.Add(1)
+			go func() {
+				defer waitGroup.Done()
+				// Galera status. Non-Galera servers have no wsrep_* status variables and return an empty result.
+				err := sqlutils.QueryRowsMap(db, "show global status like 'wsrep_%'", func(m sqlutils.RowMap) error {
+					switch m.GetString("Variable_name") {
+					case "wsrep_cluster_state_uuid":
+						instance.WsrepClusterStateUUID = m.GetString("Value")
+					case "wsrep_cluster_status":
+						instance.WsrepClusterStatus = m.GetString("Value")
+					case "wsrep_local_state":
+						instance.WsrepLocalState = m.GetUint("Value")
+					case "wsrep_local_state_comment":
+						instance.WsrepLocalStateComment = m.GetString("Value")
+					case "wsrep_cluster_size":
+						instance.WsrepClusterSize = m.GetUint("Value")
+					}
+					return nil
+				})
+				if err == nil && instance.WsrepClusterStateUUID != "" {
+					err = db.QueryRow("select @@global.wsrep_desync").Scan(&instance.WsrepDesync)
+				}
+				if err != nil {
+					logReadTopologyInstanceError(instanceKey, "Galera status", err)
+				}
+				errorChan <- err
+			}()
+		}
 	}
 	log.Infof("Instance %+v, resolvedHostname %+v", instance.Key, resolvedHostname)
 	if resolvedHostname != instance.Key.Hostname {
@@ -757,6 +837,19 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}
 		instance.MasterKey = *masterKey
 		instance.IsDetachedMaster = instance.MasterKey.IsDetached()
+
+		for _, channel := range instance.ReplicationChannels {
+			channelMasterKey, err := NewResolveInstanceKey(channel.MasterKey.Hostname, channel.MasterKey.Port)
+			if err != nil {
+				logReadTopologyInstanceError(instanceKey, fmt.Sprintf("NewResolveInstanceKey: channel %s", channel.ChannelName), err)
+				continue
+			}
+			channelMasterKey.Hostname, resolveErr = ResolveHostname(channelMasterKey.Hostname)
+			if resolveErr != nil {
+				logReadTopologyInstanceError(instanceKey, fmt.Sprintf("ResolveHostname(%q)", channelMasterKey.Hostname), resolveErr)
+			}
+			channel.MasterKey = *channelMasterKey
+		}
 	}
 
 	// Populate GR information for the instance in Oracle MySQL 8.0+ or Percona Server 8.0+. To do this we need to wait
@@ -792,6 +885,21 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}()
 	}
 
+	if config.Config.ReplicationLagFromPerformanceSchema && slaveStatusFound && !isMaxScale && !instance.IsMariaDB() && !instance.IsSmallerMajorVersionByString("8.0") {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			if err := readReplicationLagFromPerformanceSchema(instance, db); err != nil {
+				logReadTopologyInstanceError(instanceKey, "readReplicationLagFromPerformanceSchema", err)
+			}
+			if instance.ExecutedGtidSet != "" {
+				if err := updateReplicationApplyRate(instance); err != nil {
+					logReadTopologyInstanceError(instanceKey, "updateReplicationApplyRate", err)
+				}
+			}
+		}()
+	}
+
 	instanceFound = true
 
 	// -------------------------------------------------------------------------
@@ -1105,12 +1213,34 @@ Cleanup:
 					redactedExecutedGtidSet.RemoveUUID(uuid)
 				}
 			}
-			// Avoid querying the database if there's no point:
+			// Avoid computing if there's no point:
 			if !redactedExecutedGtidSet.IsEmpty() {
 				redactedMasterExecutedGtidSet, _ := NewOracleGtidSet(instance.masterExecutedGtidSet)
 				redactedMasterExecutedGtidSet.RemoveUUID(instance.MasterUUID)
 
-				db.QueryRow("select gtid_subtract(?, ?)", redactedExecutedGtidSet.String(), redactedMasterExecutedGtidSet.String()).Scan(&instance.GtidErrant)
+				if gtidErrant, err := redactedExecutedGtidSet.Subtract(redactedMasterExecutedGtidSet); err == nil {
+					instance.GtidErrant = gtidErrant.String()
+				} else {
+					logReadTopologyInstanceError(instanceKey, "GtidErrant", err)
+				}
+			}
+		}
+		if instance.MariaDBGtidBinlogPos != "" && instance.masterMariaDBGtidBinlogPos != "" {
+			// MariaDB GTID: compare domain by domain. GTIDs originating on the master are ignored for the same
+			// stale-probe reason as above; so are this server's own GTIDs when it is a co-master.
+			ignoreServerIDs := []uint32{uint32(instance.masterServerID)}
+			if instance.IsCoMaster {
+				ignoreServerIDs = append(ignoreServerIDs, uint32(instance.ServerID))
+			}
+			binlogPos, err := NewMariadbGtidPosition(instance.MariaDBGtidBinlogPos)
+			if err == nil {
+				var masterBinlogPos *MariadbGtidPosition
+				if masterBinlogPos, err = NewMariadbGtidPosition(instance.masterMariaDBGtidBinlogPos); err == nil {
+					instance.GtidErrant = binlogPos.ErrantAgainst(masterBinlogPos, ignoreServerIDs...).String()
+				}
+			}
+			if err != nil {
+				logReadTopologyInstanceError(instanceKey, "GtidErrant", err)
 			}
 		}
 	}
@@ -1123,6 +1253,7 @@ Cleanup:
 		instance.IsLastCheckValid = true
 		instance.IsRecentlyChecked = true
 		instance.IsUpToDate = true
+		instance.PollIntervalSeconds = RegisterInstancePoll(instance)
 		latency.Start("backend")
 		if bufferWrites {
 			enqueueInstanceWrite(instance, instanceFound, err)
@@ -1145,7 +1276,7 @@ Cleanup:
 	// rather than invalid.
 	if !instanceDiscoverySkipped {
 		latency.Start("backend")
-		_ = UpdateInstanceLastChecked(&instance.Key, partialSuccess)
+		_ = UpdateInstanceLastChecked(&instance.Key, partialSuccess, RegisterInstancePollFailure(&instance.Key))
 		latency.Stop("backend")
 	}
 
@@ -1210,6 +1341,8 @@ func ReadInstanceClusterAttributes(instance *Instance) (err error) {
 	var masterOrGroupPrimaryReplicationDepth uint
 	var ancestryUUID string
 	var masterOrGroupPrimaryExecutedGtidSet string
+	var masterOrGroupPrimaryMariaDBGtidBinlogPos string
+	var masterOrGroupPrimaryServerID uint
 	masterOrGroupPrimaryDataFound := false
 
 	// Read the cluster_name of the _master_ or _group_primary_ of our instance, derive it from there.
@@ -1221,7 +1354,9 @@ func ReadInstanceClusterAttributes(instance *Instance) (err error) {
 					master_host,
 					master_port,
 					ancestry_uuid,
-					executed_gtid_set
+					executed_gtid_set,
+					mariadb_gtid_binlog_pos,
+					server_id
 				from database_instance
 				where hostname=? and port=?
 	`
@@ -1242,6 +1377,8 @@ func ReadInstanceClusterAttributes(instance *Instance) (err error) {
 		masterOrGroupPrimaryInstanceKey.Port = m.GetInt("master_port")
 		ancestryUUID = m.GetString("ancestry_uuid")
 		masterOrGroupPrimaryExecutedGtidSet = m.GetString("executed_gtid_set")
+		masterOrGroupPrimaryMariaDBGtidBinlogPos = m.GetString("mariadb_gtid_binlog_pos")
+		masterOrGroupPrimaryServerID = m.GetUint("server_id")
 		masterOrGroupPrimaryDataFound = true
 		return nil
 	})
@@ -1277,15 +1414,67 @@ func ReadInstanceClusterAttributes(instance *Instance) (err error) {
 			ancestryUUID = ""
 		} // While the other stays "1"
 	}
+	if instance.IsGaleraNode() && !instance.IsReplica() {
+		// All nodes of a Galera cluster are writable peers, and belong in the same orchestrator cluster. If one
+		// of the nodes replicates asynchronously from outside, that's where the cluster name comes from.
+		// Otherwise the nodes agree on the smallest of their names.
+		galeraClusterName, galeraSuggestedClusterAlias, galeraReplicationDepth, err := readGaleraPeerClusterAttributes(instance)
+		if err != nil {
+			return log.Errore(err)
+		}
+		if galeraClusterName != "" {
+			clusterName = galeraClusterName
+			masterOrGroupPrimarySuggestedClusterAlias = galeraSuggestedClusterAlias
+			replicationDepth = galeraReplicationDepth
+		}
+	}
 	instance.ClusterName = clusterName
 	instance.SuggestedClusterAlias = masterOrGroupPrimarySuggestedClusterAlias
 	instance.ReplicationDepth = replicationDepth
 	instance.IsCoMaster = isCoMaster
 	instance.AncestryUUID = ancestryUUID
 	instance.masterExecutedGtidSet = masterOrGroupPrimaryExecutedGtidSet
+	instance.masterMariaDBGtidBinlogPos = masterOrGroupPrimaryMariaDBGtidBinlogPos
+	instance.masterServerID = masterOrGroupPrimaryServerID
 	return nil
 }
 
+// readGaleraPeerClusterAttributes returns the cluster name a non-replicating Galera node should adopt from its
+// peers (other nodes sharing its wsrep_cluster_state_uuid). An empty name means the node should keep its own.
+func readGaleraPeerClusterAttributes(instance *Instance) (clusterName string, suggestedClusterAlias string, replicationDepth uint, err error) {
+	query := `
+			select
+					cluster_name,
+					suggested_cluster_alias,
+					replication_depth,
+					master_host != '' as is_replica
+				from database_instance
+				where
+					wsrep_cluster_state_uuid = ?
+					and cluster_name != ''
+					and not (hostname = ? and port = ?)
+				order by
+					is_replica desc,
+					cluster_name asc
+				limit 1
+	`
+	args := sqlutils.Args(instance.WsrepClusterStateUUID, instance.Key.Hostname, instance.Key.Port)
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		peerClusterName := m.GetString("cluster_name")
+		if m.GetBool("is_replica") {
+			clusterName = peerClusterName
+			replicationDepth = m.GetUint("replication_depth")
+		} else if peerClusterName < instance.Key.StringCode() {
+			clusterName = peerClusterName
+		}
+		if clusterName != "" {
+			suggestedClusterAlias = m.GetString("suggested_cluster_alias")
+		}
+		return nil
+	})
+	return clusterName, suggestedClusterAlias, replicationDepth, err
+}
+
 type byNamePort [](*InstanceKey)
 
 func (this byNamePort) Len() int      { return len(this) }
@@ -1377,6 +1566,10 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.GtidPurged = m.GetString("gtid_purged")
 	instance.GtidErrant = m.GetString("gtid_errant")
 	instance.UsingMariaDBGTID = m.GetBool("mariadb_gtid")
+	instance.MariaDBGtidDomainID = uint32(m.GetUint("mariadb_gtid_domain_id"))
+	instance.MariaDBGtidBinlogPos = m.GetString("mariadb_gtid_binlog_pos")
+	instance.MariaDBGtidSlavePos = m.GetString("mariadb_gtid_slave_pos")
+	instance.ReplicationChannels.ReadJson(m.GetString("replication_channels"))
 	instance.UsingPseudoGTID = m.GetBool("pseudo_gtid")
 	instance.SelfBinlogCoordinates.LogFile = m.GetString("binary_log_file")
 	instance.SelfBinlogCoordinates.LogPos = m.GetInt64("binary_log_pos")
@@ -1392,6 +1585,9 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.LastIOError = m.GetString("last_io_error")
 	instance.SecondsBehindMaster = m.GetNullInt64("seconds_behind_master")
 	instance.ReplicationLagSeconds = m.GetNullInt64("slave_lag_seconds")
+	instance.ReplicationTrueLagSeconds = m.GetNullInt64("replication_true_lag_seconds")
+	instance.ReplicationQueueLagSeconds = m.GetNullInt64("replication_queue_lag_seconds")
+	instance.ReplicationApplyRate, _ = strconv.ParseFloat(m.GetString("replication_apply_rate"), 64)
 	instance.SQLDelay = m.GetUint("sql_delay")
 	replicasJSON := m.GetString("slave_hosts")
 	instance.ClusterName = m.GetString("cluster_name")
@@ -1412,8 +1608,13 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.IsCoMaster = m.GetBool("is_co_master")
 	instance.ReplicationCredentialsAvailable = m.GetBool("replication_credentials_available")
 	instance.HasReplicationCredentials = m.GetBool("has_replication_credentials")
-	instance.IsUpToDate = (m.GetUint("seconds_since_last_checked") <= config.Config.InstancePollSeconds)
-	instance.IsRecentlyChecked = (m.GetUint("seconds_since_last_checked") <= config.Config.InstancePollSeconds*5)
+	instance.PollIntervalSeconds = effectivePollSeconds(m.GetUint("poll_interval_seconds"))
+	instance.IsUpToDate = (m.GetUint("seconds_since_last_checked") <= instance.PollIntervalSeconds)
+	recentlyCheckedSeconds := config.Config.InstancePollSeconds * 5
+	if instance.PollIntervalSeconds*5 > recentlyCheckedSeconds {
+		recentlyCheckedSeconds = instance.PollIntervalSeconds * 5
+	}
+	instance.IsRecentlyChecked = (m.GetUint("seconds_since_last_checked") <= recentlyCheckedSeconds)
 	instance.LastSeenTimestamp = m.GetString("last_seen")
 	instance.IsLastCheckValid = m.GetBool("is_last_check_valid")
 	instance.SecondsSinceLastSeen = m.GetNullInt64("seconds_since_last_seen")
@@ -1439,9 +1640,19 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.ReplicationGroupMemberRole = m.GetString("replication_group_member_role")
 	instance.ReplicationGroupPrimaryInstanceKey = InstanceKey{Hostname: m.GetString("replication_group_primary_host"),
 		Port: m.GetInt("replication_group_primary_port")}
+	instance.ReplicationGroupLocalAddress = m.GetString("replication_group_local_address")
+	instance.ReplicationGroupUnreachableMembers.ReadJson(m.GetString("replication_group_unreachable_members"))
 	instance.ReplicationGroupMembers.ReadJson(m.GetString("replication_group_members"))
 	//instance.ReplicationGroup = m.GetString("replication_group_")
 
+	/* Read Galera variables below */
+	instance.WsrepClusterStateUUID = m.GetString("wsrep_cluster_state_uuid")
+	instance.WsrepClusterStatus = m.GetString("wsrep_cluster_status")
+	instance.WsrepLocalState = m.GetUint("wsrep_local_state")
+	instance.WsrepLocalStateComment = m.GetString("wsrep_local_state_comment")
+	instance.WsrepClusterSize = m.GetUint("wsrep_cluster_size")
+	instance.WsrepDesync = m.GetBool("wsrep_desync")
+
 	// problems
 	if !instance.IsLastCheckValid {
 		instance.Problems = append(instance.Problems, "last_check_invalid")
@@ -1449,7 +1660,7 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 		instance.Problems = append(instance.Problems, "not_recently_checked")
 	} else if instance.ReplicationThreadsExist() && !instance.ReplicaRunning() {
 		instance.Problems = append(instance.Problems, "not_replicating")
-	} else if instance.ReplicationLagSeconds.Valid && math.AbsInt64(instance.ReplicationLagSeconds.Int64-int64(instance.SQLDelay)) > int64(config.Config.ReasonableReplicationLagSeconds) {
+	} else if lag := instance.EffectiveReplicationLagSeconds(); lag.Valid && math.AbsInt64(lag.Int64-int64(instance.SQLDelay)) > int64(config.Config.ReasonableReplicationLagSeconds) {
 		instance.Problems = append(instance.Problems, "replication_lag")
 	}
 	if instance.GtidErrant != "" {
@@ -1551,6 +1762,14 @@ func ReadClusterInstances(clusterName string) ([](*Instance), error) {
 	return readInstancesByCondition(condition, sqlutils.Args(clusterName), "")
 }
 
+// ReadReplicationGroupInstances reads all known members of a replication group
+func ReadReplicationGroupInstances(replicationGroupName string) ([](*Instance), error) {
+	condition := `
+			replication_group_name = ?
+		`
+	return readInstancesByCondition(condition, sqlutils.Args(replicationGroupName), "")
+}
+
 // ReadClusterWriteableMaster returns the/a writeable master of this cluster
 // Typically, the cluster name indicates the master of the cluster. However, in circular
 // master-master replication one master can assume the name of the cluster, and it is
@@ -1647,17 +1866,18 @@ func ReadProblemInstances(clusterName string) ([](*Instance), error) {
 			cluster_name LIKE (CASE WHEN ? = '' THEN '%' ELSE ? END)
 			and (
 				(last_seen < last_checked)
-				or (unix_timestamp() - unix_timestamp(last_checked) > ?)
+				or (unix_timestamp() - unix_timestamp(last_checked) > 5 * (case when poll_interval_seconds > ? then poll_interval_seconds else ? end))
 				or (replication_sql_thread_state not in (-1 ,1))
 				or (replication_io_thread_state not in (-1 ,1))
 				or (abs(cast(seconds_behind_master as signed) - cast(sql_delay as signed)) > ?)
 				or (abs(cast(slave_lag_seconds as signed) - cast(sql_delay as signed)) > ?)
+				or (abs(cast(replication_true_lag_seconds as signed) - cast(sql_delay as signed)) > ?)
 				or (gtid_errant != '')
 				or (replication_group_name != '' and replication_group_member_state != 'ONLINE')
 			)
 		`
 
-	args := sqlutils.Args(clusterName, clusterName, config.Config.InstancePollSeconds*5, config.Config.ReasonableReplicationLagSeconds, config.Config.ReasonableReplicationLagSeconds)
+	args := sqlutils.Args(clusterName, clusterName, config.Config.InstancePollSeconds, config.Config.InstancePollSeconds, config.Config.ReasonableReplicationLagSeconds, config.Config.ReasonableReplicationLagSeconds, config.Config.ReasonableReplicationLagSeconds)
 	instances, err := readInstancesByCondition(condition, args, "")
 	if err != nil {
 		return instances, err
@@ -1985,8 +2205,8 @@ func GetInstancesMaxLag(instances [](*Instance)) (maxLag int64, err error) {
 		return 0, log.Errorf("No instances found in GetInstancesMaxLag")
 	}
 	for _, clusterInstance := range instances {
-		if clusterInstance.ReplicationLagSeconds.Valid && clusterInstance.ReplicationLagSeconds.Int64 > maxLag {
-			maxLag = clusterInstance.ReplicationLagSeconds.Int64
+		if lag := clusterInstance.EffectiveReplicationLagSeconds(); lag.Valid && lag.Int64 > maxLag {
+			maxLag = lag.Int64
 		}
 	}
 	return maxLag, nil
@@ -2590,34 +2810,49 @@ func ReadAllMinimalInstances() ([]MinimalInstance, error) {
 }
 
 // ReadOutdatedInstanceKeys reads and returns keys for all instances that are not up to date (i.e.
-// pre-configured time has passed since they were last checked)
+// their poll interval has passed since they were last checked). The poll interval is InstancePollSeconds,
+// or, with AdaptiveInstancePoll, the instance's own adaptive interval.
 // But we also check for the case where an attempt at instance checking has been made, that hasn't
 // resulted in an actual check! This can happen when TCP/IP connections are hung, in which case the "check"
 // never returns. In such case we multiply interval by a factor, so as not to open too many connections on
 // the instance.
-func ReadOutdatedInstanceKeys() ([]InstanceKey, error) {
+// Also returned are the keys of those outdated instances known to have replicas, i.e. masters and
+// intermediate masters.
+func ReadOutdatedInstanceKeys() ([]InstanceKey, *InstanceKeyMap, error) {
 	res := []InstanceKey{}
+	masterKeys := NewInstanceKeyMap()
 	query := `
 		select
-			hostname, port
+			hostname,
+			port,
+			num_slave_hosts,
+			poll_interval_seconds,
+			unix_timestamp() - unix_timestamp(last_checked) as seconds_since_last_checked,
+			last_attempted_check <= last_checked as check_completed
 		from
 			database_instance
 		where
-			case
-				when last_attempted_check <= last_checked
-				then last_checked < now() - interval ? second
-				else last_checked < now() - interval ? second
-			end
+			last_checked < now() - interval ? second
 			`
-	args := sqlutils.Args(config.Config.InstancePollSeconds, 2*config.Config.InstancePollSeconds)
+	args := sqlutils.Args(MinimalInstancePollSeconds())
 
 	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		pollSeconds := effectivePollSeconds(m.GetUint("poll_interval_seconds"))
+		if !m.GetBool("check_completed") {
+			pollSeconds = 2 * pollSeconds
+		}
+		if m.GetUint("seconds_since_last_checked") < pollSeconds {
+			return nil
+		}
 		instanceKey, merr := NewResolveInstanceKey(m.GetString("hostname"), m.GetInt("port"))
 		if merr != nil {
 			log.Errore(merr)
 		} else if !InstanceIsForgotten(instanceKey) {
 			// only if not in "forget" cache
 			res = append(res, *instanceKey)
+			if m.GetInt("num_slave_hosts") > 0 {
+				masterKeys.AddKey(*instanceKey)
+			}
 		}
 		// We don;t return an error because we want to keep filling the outdated instances list.
 		return nil
@@ -2626,7 +2861,7 @@ func ReadOutdatedInstanceKeys() ([]InstanceKey, error) {
 	if err != nil {
 		log.Errore(err)
 	}
-	return res, err
+	return res, masterKeys, err
 
 }
 
@@ -2761,6 +2996,22 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		"replication_group_members",
 		"replication_group_primary_host",
 		"replication_group_primary_port",
+		"replication_group_local_address",
+		"replication_group_unreachable_members",
+		"mariadb_gtid_domain_id",
+		"mariadb_gtid_binlog_pos",
+		"mariadb_gtid_slave_pos",
+		"replication_channels",
+		"wsrep_cluster_state_uuid",
+		"wsrep_cluster_status",
+		"wsrep_local_state",
+		"wsrep_local_state_comment",
+		"wsrep_cluster_size",
+		"wsrep_desync",
+		"replication_true_lag_seconds",
+		"replication_queue_lag_seconds",
+		"replication_apply_rate",
+		"poll_interval_seconds",
 	}
 
 	var values []string = make([]string, len(columns), len(columns))
@@ -2854,6 +3105,22 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		args = append(args, instance.ReplicationGroupMembers.ToJSONString())
 		args = append(args, instance.ReplicationGroupPrimaryInstanceKey.Hostname)
 		args = append(args, instance.ReplicationGroupPrimaryInstanceKey.Port)
+		args = append(args, instance.ReplicationGroupLocalAddress)
+		args = append(args, instance.ReplicationGroupUnreachableMembers.ToJSONString())
+		args = append(args, instance.MariaDBGtidDomainID)
+		args = append(args, instance.MariaDBGtidBinlogPos)
+		args = append(args, instance.MariaDBGtidSlavePos)
+		args = append(args, instance.ReplicationChannels.ToJSONString())
+		args = append(args, instance.WsrepClusterStateUUID)
+		args = append(args, instance.WsrepClusterStatus)
+		args = append(args, instance.WsrepLocalState)
+		args = append(args, instance.WsrepLocalStateComment)
+		args = append(args, instance.WsrepClusterSize)
+		args = append(args, instance.WsrepDesync)
+		args = append(args, instance.ReplicationTrueLagSeconds)
+		args = append(args, instance.ReplicationQueueLagSeconds)
+		args = append(args, instance.ReplicationApplyRate)
+		args = append(args, instance.PollIntervalSeconds)
 	}
 
 	sql, err := mkInsertOdku("database_instance", columns, values, len(instances), insertIgnore)
@@ -3009,18 +3276,20 @@ func WriteInstance(instance *Instance, instanceWasActuallyFound bool, lastError
 
 // UpdateInstanceLastChecked updates the last_check timestamp in the orchestrator backed database
 // for a given instance
-func UpdateInstanceLastChecked(instanceKey *InstanceKey, partialSuccess bool) error {
+func UpdateInstanceLastChecked(instanceKey *InstanceKey, partialSuccess bool, pollIntervalSeconds uint) error {
 	writeFunc := func() error {
 		_, err := db.ExecOrchestrator(`
         	update
         		database_instance
         	set
 						last_checked = NOW(),
-						last_check_partial_success = ?
+						last_check_partial_success = ?,
+						poll_interval_seconds = ?
 			where
 				hostname = ?
 				and port = ?`,
 			partialSuccess,
+			pollIntervalSeconds,
 			instanceKey.Hostname,
 			instanceKey.Port,
 		)
@@ -3087,6 +3356,7 @@ func ForgetInstance(instanceKey *InstanceKey) error {
 	}
 	AuditOperation("forget", instanceKey, "")
 	DeadInstancesFilter.UnregisterInstance(instanceKey)
+	ForgetInstancePollInterval(instanceKey)
 	return nil
 }
 
@@ -3104,6 +3374,7 @@ func ForgetCluster(clusterName string) error {
 		forgetInstanceKeys.Set(instance.Key.StringCode(), true, cache.DefaultExpiration)
 		AuditOperation("forget", &instance.Key, "")
 		DeadInstancesFilter.UnregisterInstance(&instance.Key)
+		ForgetInstancePollInterval(&instance.Key)
 	}
 	_, err = db.ExecOrchestrator(`
 			delete
@@ -3115,8 +3386,29 @@ func ForgetCluster(clusterName string) error {
 	return err
 }
 
+// readLongUnseenInstanceKeys reads the keys of instances ForgetLongUnseenInstances removes
+func readLongUnseenInstanceKeys() (instanceKeys []InstanceKey, err error) {
+	query := `
+		select
+			hostname, port
+		from
+			database_instance
+		where
+			last_seen < NOW() - interval ? hour
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(config.Config.UnseenInstanceForgetHours), func(m sqlutils.RowMap) error {
+		instanceKeys = append(instanceKeys, InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")})
+		return nil
+	})
+	return instanceKeys, log.Errore(err)
+}
+
 // ForgetLongUnseenInstances will remove entries of all instacnes that have long since been last seen.
 func ForgetLongUnseenInstances() error {
+	unseenInstanceKeys, err := readLongUnseenInstanceKeys()
+	if err != nil {
+		return err
+	}
 	sqlResult, err := db.ExecOrchestrator(`
 			delete
 				from database_instance
@@ -3132,6 +3424,9 @@ func ForgetLongUnseenInstances() error {
 		return log.Errore(err)
 	}
 	AuditOperation("forget-unseen", nil, fmt.Sprintf("Forgotten instances: %d", rows))
+	for _, instanceKey := range unseenInstanceKeys {
+		ForgetInstancePollInterval(&instanceKey)
+	}
 	return err
 }
 
@@ -3427,7 +3722,8 @@ func PopulateGroupReplicationInformation(instance *Instance, db *sql.DB) error {
 		MEMBER_STATE,
 		MEMBER_ROLE,
 		@@global.group_replication_group_name,
-		@@global.group_replication_single_primary_mode
+		@@global.group_replication_single_primary_mode,
+		@@global.group_replication_local_address
 	FROM
 		performance_schema.replication_group_members
 	WHERE
@@ -3458,8 +3754,9 @@ func PopulateGroupReplicationInformation(instance *Instance, db *sql.DB) error {
 			role               string
 			groupName          string
 			singlePrimaryGroup bool
+			localAddress       string
 		)
-		err := rows.Scan(&uuid, &host, &port, &state, &role, &groupName, &singlePrimaryGroup)
+		err := rows.Scan(&uuid, &host, &port, &state, &role, &groupName, &singlePrimaryGroup, &localAddress)
 		if err == nil {
 			// ToDo: add support for multi primary groups.
 			if !singlePrimaryGroup {
@@ -3483,8 +3780,13 @@ func PopulateGroupReplicationInformation(instance *Instance, db *sql.DB) error {
 				instance.ReplicationGroupIsSinglePrimary = singlePrimaryGroup
 				instance.ReplicationGroupMemberRole = role
 				instance.ReplicationGroupMemberState = state
+				instance.ReplicationGroupLocalAddress = localAddress
 			} else {
 				instance.AddGroupMemberKey(groupMemberKey) // This helps us keep info on all members of the same group as the instance
+				if state == GroupReplicationMemberStateUnreachable {
+					// As seen by this instance. Members of a minority partition see the majority as unreachable
+					instance.ReplicationGroupUnreachableMembers.AddKey(*groupMemberKey)
+				}
 			}
 		} else {
 			log.Errorf("Unable to scan row  group replication information while processing %+v, skipping the "+
@@ -585,16 +585,38 @@ Cleanup:
 	return instance, err
 }
 
+// canReplicateAssumingOracleGTID checks whether the master's purged GTID entries are all executed on the instance.
+// The check is made in-process, based on the GTID sets as read by orchestrator.
 func canReplicateAssumingOracleGTID(instance, masterInstance *Instance) (canReplicate bool, err error) {
-	subtract, err := GTIDSubtract(&instance.Key, masterInstance.GtidPurged, instance.ExecutedGtidSet)
+	executedGtidSet, err := NewOracleGtidSet(instance.ExecutedGtidSet)
 	if err != nil {
 		return false, err
 	}
-	subtractGtidSet, err := NewOracleGtidSet(subtract)
+	masterPurgedGtidSet, err := NewOracleGtidSet(masterInstance.GtidPurged)
 	if err != nil {
 		return false, err
 	}
-	return subtractGtidSet.IsEmpty(), nil
+	return executedGtidSet.Contains(masterPurgedGtidSet)
+}
+
+// canReplicateAssumingMariaDBGTID checks whether the master's binary logs cover every replication domain the
+// instance requests from its gtid_slave_pos, which it does with either master_use_gtid mode. MariaDB refuses
+// to serve a replica asking for a domain the master never logged.
+func canReplicateAssumingMariaDBGTID(instance, masterInstance *Instance) (canReplicate bool, err error) {
+	slavePos, err := NewMariadbGtidPosition(instance.MariaDBGtidSlavePos)
+	if err != nil {
+		return false, err
+	}
+	masterBinlogPos, err := NewMariadbGtidPosition(masterInstance.MariaDBGtidBinlogPos)
+	if err != nil {
+		return false, err
+	}
+	for _, gtid := range slavePos.Gtids {
+		if masterBinlogPos.GtidForDomain(gtid.DomainID) == nil {
+			return false, nil
+		}
+	}
+	return true, nil
 }
 
 func instancesAreGTIDAndCompatible(instance, otherInstance *Instance) (isOracleGTID bool, isMariaDBGTID, compatible bool) {
@@ -605,7 +627,7 @@ func instancesAreGTIDAndCompatible(instance, otherInstance *Instance) (isOracleG
 }
 
 func CheckMoveViaGTID(instance, otherInstance *Instance) (err error) {
-	isOracleGTID, _, moveCompatible := instancesAreGTIDAndCompatible(instance, otherInstance)
+	isOracleGTID, isMariaDBGTID, moveCompatible := instancesAreGTIDAndCompatible(instance, otherInstance)
 	if !moveCompatible {
 		return fmt.Errorf("Instances %+v, %+v not GTID compatible or not using GTID", instance.Key, otherInstance.Key)
 	}
@@ -618,6 +640,15 @@ func CheckMoveViaGTID(instance, otherInstance *Instance) (err error) {
 			return fmt.Errorf("Instance %+v has purged GTID entries not found on %+v", otherInstance.Key, instance.Key)
 		}
 	}
+	if isMariaDBGTID {
+		canReplicate, err := canReplicateAssumingMariaDBGTID(instance, otherInstance)
+		if err != nil {
+			return err
+		}
+		if !canReplicate {
+			return fmt.Errorf("Instance %+v has MariaDB GTID domains in gtid_slave_pos (%s) not found in gtid_binlog_pos of %+v (%s)", instance.Key, instance.MariaDBGtidSlavePos, otherInstance.Key, otherInstance.MariaDBGtidBinlogPos)
+		}
+	}
 
 	return nil
 }
@@ -1254,12 +1285,18 @@ func LocateErrantGTID(instanceKey *InstanceKey) (errantBinlogs []string, err err
 	if errantSearch == "" {
 		return errantBinlogs, log.Errorf("locate-errant-gtid: no errant-gtid on %+v", *instanceKey)
 	}
-	subtract, err := GTIDSubtract(instanceKey, errantSearch, instance.GtidPurged)
+	errantGtidSet, err := NewOracleGtidSet(errantSearch)
 	if err != nil {
 		return errantBinlogs, err
 	}
-	if subtract != errantSearch {
-		return errantBinlogs, fmt.Errorf("locate-errant-gtid: %+v is already purged on %+v", subtract, *instanceKey)
+	purgedGtidSet, err := NewOracleGtidSet(instance.GtidPurged)
+	if err != nil {
+		return errantBinlogs, err
+	}
+	if purgedErrant, err := errantGtidSet.Intersect(purgedGtidSet); err != nil {
+		return errantBinlogs, err
+	} else if !purgedErrant.IsEmpty() {
+		return errantBinlogs, fmt.Errorf("locate-errant-gtid: %+v is already purged on %+v", purgedErrant.String(), *instanceKey)
 	}
 	binlogs, err := ShowBinaryLogs(instanceKey)
 	if err != nil {
@@ -1274,22 +1311,22 @@ func LocateErrantGTID(instanceKey *InstanceKey) (errantBinlogs []string, err err
 		previousGTIDs[binlog] = oracleGTIDSet
 	}
 	for i, binlog := range binlogs {
-		if errantSearch == "" {
+		if errantGtidSet.IsEmpty() {
 			break
 		}
 		previousGTID := previousGTIDs[binlog]
-		subtract, err := GTIDSubtract(instanceKey, errantSearch, previousGTID.String())
+		subtract, err := errantGtidSet.Subtract(previousGTID)
 		if err != nil {
 			return errantBinlogs, err
 		}
-		if subtract != errantSearch {
+		if subtract.Count() != errantGtidSet.Count() {
 			// binlogs[i-1] is safe to use when i==0. because that implies GTIDs have been purged,
 			// which covered by an earlier assertion
 			errantBinlogs = append(errantBinlogs, binlogs[i-1])
-			errantSearch = subtract
+			errantGtidSet = subtract
 		}
 	}
-	if errantSearch != "" {
+	if !errantGtidSet.IsEmpty() {
 		// then it's in the last binary log
 		errantBinlogs = append(errantBinlogs, binlogs[len(binlogs)-1])
 	}
@@ -1343,7 +1380,7 @@ func ErrantGTIDResetMaster(instanceKey *InstanceKey) (instance *Instance, err er
 		}
 	}
 
-	gtidSubtract, err = GTIDSubtract(instanceKey, instance.ExecutedGtidSet, instance.GtidErrant)
+	gtidSubtract, err = GTIDSubtract(instance.ExecutedGtidSet, instance.GtidErrant)
 	if err != nil {
 		goto Cleanup
 	}
@@ -1412,43 +1449,53 @@ func ErrantGTIDInjectEmpty(instanceKey *InstanceKey) (instance *Instance, cluste
 	if err != nil {
 		return instance, clusterMaster, countInjectedTransactions, err
 	}
+	clusterMaster, countInjectedTransactions, err = injectEmptyErrantGTIDTransactions(instance)
+	if err != nil {
+		return instance, clusterMaster, countInjectedTransactions, err
+	}
+
+	// and we're done (pending deferred functions)
+	AuditOperation("gtid-errant-inject-empty", instanceKey, fmt.Sprintf("injected %+v empty transactions on %+v", countInjectedTransactions, clusterMaster.Key))
+
+	return instance, clusterMaster, countInjectedTransactions, err
+}
+
+// injectEmptyErrantGTIDTransactions injects an empty transaction on the cluster's master for each of the
+// instance's errant transactions, as last read
+func injectEmptyErrantGTIDTransactions(instance *Instance) (clusterMaster *Instance, countInjectedTransactions int64, err error) {
 	if instance.GtidErrant == "" {
-		return instance, clusterMaster, countInjectedTransactions, log.Errorf("gtid-errant-inject-empty will not operate on %+v because no errant GTID is found", *instanceKey)
+		return clusterMaster, countInjectedTransactions, log.Errorf("gtid-errant-inject-empty will not operate on %+v because no errant GTID is found", instance.Key)
 	}
 	if !instance.SupportsOracleGTID {
-		return instance, clusterMaster, countInjectedTransactions, log.Errorf("gtid-errant-inject-empty requested for %+v but it does not support oracle-gtid", *instanceKey)
+		return clusterMaster, countInjectedTransactions, log.Errorf("gtid-errant-inject-empty requested for %+v but it does not support oracle-gtid", instance.Key)
 	}
 
 	masters, err := ReadClusterWriteableMaster(instance.ClusterName)
 	if err != nil {
-		return instance, clusterMaster, countInjectedTransactions, err
+		return clusterMaster, countInjectedTransactions, err
 	}
 	if len(masters) == 0 {
-		return instance, clusterMaster, countInjectedTransactions, log.Errorf("gtid-errant-inject-empty found no writabel master for %+v cluster", instance.ClusterName)
+		return clusterMaster, countInjectedTransactions, log.Errorf("gtid-errant-inject-empty found no writabel master for %+v cluster", instance.ClusterName)
 	}
 	clusterMaster = masters[0]
 
 	if !clusterMaster.SupportsOracleGTID {
-		return instance, clusterMaster, countInjectedTransactions, log.Errorf("gtid-errant-inject-empty requested for %+v but the cluster's master %+v does not support oracle-gtid", *instanceKey, clusterMaster.Key)
+		return clusterMaster, countInjectedTransactions, log.Errorf("gtid-errant-inject-empty requested for %+v but the cluster's master %+v does not support oracle-gtid", instance.Key, clusterMaster.Key)
 	}
 
 	gtidSet, err := NewOracleGtidSet(instance.GtidErrant)
 	if err != nil {
-		return instance, clusterMaster, countInjectedTransactions, err
+		return clusterMaster, countInjectedTransactions, err
 	}
 	explodedEntries := gtidSet.Explode()
 	log.Infof("gtid-errant-inject-empty: about to inject %+v empty transactions %+v on cluster master %+v", len(explodedEntries), gtidSet.String(), clusterMaster.Key)
 	for _, entry := range explodedEntries {
 		if err := injectEmptyGTIDTransaction(&clusterMaster.Key, entry); err != nil {
-			return instance, clusterMaster, countInjectedTransactions, err
+			return clusterMaster, countInjectedTransactions, err
 		}
 		countInjectedTransactions++
 	}
-
-	// and we're done (pending deferred functions)
-	AuditOperation("gtid-errant-inject-empty", instanceKey, fmt.Sprintf("injected %+v empty transactions on %+v", countInjectedTransactions, clusterMaster.Key))
-
-	return instance, clusterMaster, countInjectedTransactions, err
+	return clusterMaster, countInjectedTransactions, nil
 }
 
 // FindLastPseudoGTIDEntry will search an instance's binary logs or relay logs for the last pseudo-GTID entry,
@@ -2207,6 +2254,15 @@ func getPriorityBinlogFormatForCandidate(replicas [](*Instance)) (priorityBinlog
 	return sorted.First(), nil
 }
 
+// isValidCandidateReplicaOf tells whether chooseCandidateReplica may choose given replica to master its siblings,
+// given the most common major version and binlog format among them
+func isValidCandidateReplicaOf(replica *Instance, priorityMajorVersion string, priorityBinlogFormat string) bool {
+	return isGenerallyValidAsCandidateReplica(replica) &&
+		!IsBannedFromBeingCandidateReplica(replica) &&
+		!IsSmallerMajorVersion(priorityMajorVersion, replica.MajorVersionString()) &&
+		!IsSmallerBinlogFormat(priorityBinlogFormat, replica.Binlog_format)
+}
+
 // chooseCandidateReplica
 func chooseCandidateReplica(replicas [](*Instance)) (candidateReplica *Instance, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas [](*Instance), err error) {
 	if len(replicas) == 0 {
@@ -2217,10 +2273,7 @@ func chooseCandidateReplica(replicas [](*Instance)) (candidateReplica *Instance,
 
 	for _, replica := range replicas {
 		replica := replica
-		if isGenerallyValidAsCandidateReplica(replica) &&
-			!IsBannedFromBeingCandidateReplica(replica) &&
-			!IsSmallerMajorVersion(priorityMajorVersion, replica.MajorVersionString()) &&
-			!IsSmallerBinlogFormat(priorityBinlogFormat, replica.Binlog_format) {
+		if isValidCandidateReplicaOf(replica, priorityMajorVersion, priorityBinlogFormat) {
 			// this is the one
 			candidateReplica = replica
 			break
@@ -2780,6 +2833,61 @@ func RelocateBelow(instanceKey, otherKey *InstanceKey) (*Instance, error) {
 	return instance, err
 }
 
+// RelocateChannelBelow points a named replication channel of a multi-source replica at another instance.
+// Channels are not part of the replication tree orchestrator models, hence relocation only supports
+// channels using GTID auto-positioning, where no coordinates need be computed on the new master.
+func RelocateChannelBelow(instanceKey *InstanceKey, channelName string, otherKey *InstanceKey) (*Instance, error) {
+	if err := ValidateReplicationChannelName(channelName); err != nil {
+		return nil, log.Errore(err)
+	}
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return instance, log.Errore(err)
+	}
+	channel := instance.ReplicationChannels.GetChannel(channelName)
+	if channel == nil {
+		return instance, log.Errorf("relocate: no replication channel %s found on %+v", channelName, *instanceKey)
+	}
+	if !channel.UsingOracleGTID {
+		return instance, log.Errorf("relocate: channel %s on %+v does not use GTID auto-positioning", channelName, *instanceKey)
+	}
+	if instance.Key.Equals(otherKey) {
+		return instance, log.Errorf("relocate: cannot relocate channel %s of %+v below itself", channelName, *instanceKey)
+	}
+	other, err := ReadTopologyInstance(otherKey)
+	if err != nil {
+		return instance, log.Errore(err)
+	}
+	if !other.SupportsOracleGTID {
+		return instance, log.Errorf("relocate: %+v does not support GTID", *otherKey)
+	}
+	if canReplicate, err := canReplicateAssumingOracleGTID(instance, other); err != nil {
+		return instance, log.Errore(err)
+	} else if !canReplicate {
+		return instance, log.Errorf("relocate: %+v has purged GTID entries not executed on %+v", *otherKey, *instanceKey)
+	}
+
+	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), fmt.Sprintf("relocate channel %s below %+v", channelName, *otherKey)); merr != nil {
+		return instance, fmt.Errorf("Cannot begin maintenance on %+v: %v", *instanceKey, merr)
+	} else {
+		defer EndMaintenance(maintenanceToken)
+	}
+
+	instance, err = StopReplicationChannel(instanceKey, channelName)
+	if err != nil {
+		return instance, log.Errore(err)
+	}
+	if err := changeChannelMasterTo(instance, channelName, otherKey); err != nil {
+		return instance, log.Errore(err)
+	}
+	instance, err = StartReplicationChannel(instanceKey, channelName)
+	if err != nil {
+		return instance, log.Errore(err)
+	}
+	AuditOperation("relocate-below", instanceKey, fmt.Sprintf("relocated channel %s of %+v below %+v", channelName, *instanceKey, *otherKey))
+	return instance, nil
+}
+
 // relocateReplicasInternal is a protentially recursive function which chooses how to relocate
 // replicas of an instance below another.
 // It may choose to use Pseudo-GTID, or normal binlog positions, or take advantage of binlog servers,
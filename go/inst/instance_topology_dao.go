@@ -20,6 +20,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 	"time"
@@ -500,6 +501,84 @@ func RestartReplication(instanceKey *InstanceKey) (instance *Instance, err error
 	return instance, log.Errore(err)
 }
 
+// StopReplicationChannel stops replication of a single named channel on a multi-source replica.
+// Other channels keep replicating.
+func StopReplicationChannel(instanceKey *InstanceKey, channelName string) (*Instance, error) {
+	if err := ValidateReplicationChannelName(channelName); err != nil {
+		return nil, log.Errore(err)
+	}
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return instance, log.Errore(err)
+	}
+	if instance.ReplicationChannels.GetChannel(channelName) == nil {
+		return instance, fmt.Errorf("no replication channel %s found on %+v", channelName, *instanceKey)
+	}
+	if _, err := ExecInstance(instanceKey, forChannel(instance.QSP.stop_slave(), channelName)); err != nil {
+		return instance, log.Errore(err)
+	}
+	instance, err = ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return instance, log.Errore(err)
+	}
+	log.Infof("Stopped replication channel %s on %+v", channelName, *instanceKey)
+	return instance, nil
+}
+
+// StartReplicationChannel starts replication of a single named channel on a multi-source replica.
+func StartReplicationChannel(instanceKey *InstanceKey, channelName string) (*Instance, error) {
+	if err := ValidateReplicationChannelName(channelName); err != nil {
+		return nil, log.Errore(err)
+	}
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return instance, log.Errore(err)
+	}
+	if instance.ReplicationChannels.GetChannel(channelName) == nil {
+		return instance, fmt.Errorf("no replication channel %s found on %+v", channelName, *instanceKey)
+	}
+	if _, err := ExecInstance(instanceKey, forChannel(instance.QSP.start_slave(), channelName)); err != nil {
+		return instance, log.Errore(err)
+	}
+	log.Infof("Started replication channel %s on %+v", channelName, *instanceKey)
+
+	instance, err = ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return instance, log.Errore(err)
+	}
+	if channel := instance.ReplicationChannels.GetChannel(channelName); channel == nil || !channel.ReplicationRunning() {
+		return instance, ReplicationNotRunningError
+	}
+	return instance, nil
+}
+
+// changeChannelMasterTo points a stopped named channel at a new master, using GTID auto-positioning.
+func changeChannelMasterTo(instance *Instance, channelName string, masterKey *InstanceKey) error {
+	channel := instance.ReplicationChannels.GetChannel(channelName)
+	if channel == nil {
+		return fmt.Errorf("no replication channel %s found on %+v", channelName, instance.Key)
+	}
+	if !channel.ReplicationStopped() {
+		return fmt.Errorf("Cannot change master of channel %s on %+v because replication threads are not stopped", channelName, instance.Key)
+	}
+	changeToMasterKey, nameUnresolved, err := UnresolveHostname(masterKey)
+	if err != nil {
+		return err
+	}
+	if nameUnresolved {
+		log.Debugf("changeChannelMasterTo: Unresolved %+v into %+v", *masterKey, changeToMasterKey)
+	}
+	if *config.RuntimeCLIFlags.Noop {
+		return fmt.Errorf("noop: aborting CHANGE MASTER TO operation on %+v channel %s; signalling error but nothing went wrong.", instance.Key, channelName)
+	}
+	_, err = ExecInstance(&instance.Key, forChannel(instance.QSP.change_master_to_master_host_port(), channelName), changeToMasterKey.Hostname, changeToMasterKey.Port)
+	if err != nil {
+		return err
+	}
+	log.Infof("Changed master of channel %s on %+v to: %+v", channelName, instance.Key, *masterKey)
+	return nil
+}
+
 // StartReplicas will do concurrent start-replica
 func StartReplicas(replicas [](*Instance)) {
 	// use concurrency but wait for all to complete
@@ -974,9 +1053,15 @@ func ChangeMasterTo(instanceKey *InstanceKey, masterKey *InstanceKey, masterBinl
 	var changeMasterFunc func() error
 	changedViaGTID := false
 	if instance.UsingMariaDBGTID && gtidHint != GTIDHintDeny {
-		// Keep on using GTID
+		// Keep on using GTID, in the instance's existing master_use_gtid mode. A replica with errant transactions
+		// is explicitly switched to slave_pos, which only reflects replicated transactions; current_pos would
+		// also include the errant ones.
+		changeMasterQuery := instance.QSP.change_master_to_master_host_port()
+		if instance.GtidErrant != "" && instance.ReplicationThreadsExist() {
+			changeMasterQuery = instance.QSP.change_master_to_master_host_port_gtid_slave_pos()
+		}
 		changeMasterFunc = func() error {
-			_, err := ExecInstance(instanceKey, instance.QSP.change_master_to_master_host_port(),
+			_, err := ExecInstance(instanceKey, changeMasterQuery,
 				changeToMasterKey.Hostname, changeToMasterKey.Port)
 			return err
 		}
@@ -1142,6 +1227,34 @@ func ResetMaster(instanceKey *InstanceKey) (*Instance, error) {
 	return instance, err
 }
 
+// ForceReplicationGroupMembers sets group_replication_force_members on a group member, forcing a new group
+// membership made of given group communication addresses. This unblocks a group which has lost quorum.
+// The variable is cleared right after, as MySQL recommends.
+func ForceReplicationGroupMembers(instanceKey *InstanceKey, localAddresses []string) (*Instance, error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return instance, log.Errore(err)
+	}
+	if !instance.IsReplicationGroupMember() {
+		return instance, fmt.Errorf("ForceReplicationGroupMembers: %+v is not a replication group member", *instanceKey)
+	}
+	if len(localAddresses) == 0 {
+		return instance, fmt.Errorf("ForceReplicationGroupMembers: no members given for %+v", *instanceKey)
+	}
+	if *config.RuntimeCLIFlags.Noop {
+		return instance, fmt.Errorf("noop: aborting force-members operation on %+v; signalling error but nothing went wrong.", *instanceKey)
+	}
+	forceMembers := strings.Join(localAddresses, ",")
+	if _, err := ExecInstance(instanceKey, `set global group_replication_force_members = ?`, forceMembers); err != nil {
+		return instance, log.Errore(err)
+	}
+	if _, err := ExecInstance(instanceKey, `set global group_replication_force_members = ''`); err != nil {
+		log.Errore(err)
+	}
+	AuditOperation("force-group-members", instanceKey, fmt.Sprintf("forced group members: %s", forceMembers))
+	return ReadTopologyInstance(instanceKey)
+}
+
 // skipQueryClassic skips a query in normal binlog file:pos replication
 func setGTIDPurged(instance *Instance, gtidPurged string) error {
 	if *config.RuntimeCLIFlags.Noop {
@@ -1372,6 +1485,92 @@ func SetReadOnly(instanceKey *InstanceKey, readOnly bool) (*Instance, error) {
 	return instance, err
 }
 
+// isSuperReadOnlySupported checks whether the instance has a super_read_only variable. It is only available on
+// MySQL 5.7.8 and Percona Server 5.6.21-70, and not on MariaDB.
+func isSuperReadOnlySupported(ctx context.Context, db *sql.DB) (supported bool, err error) {
+	rows, err := db.QueryContext(ctx, "show global variables like 'super_read_only'")
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	supported = rows.Next()
+	return supported, rows.Err()
+}
+
+// FenceInstanceWrites makes an instance refuse further writes: it sets read_only and super_read_only,
+// then kills client connections, other than orchestrator's own, replication and system threads.
+// Unlike SetReadOnly, failing to set super_read_only is an error, as the instance is then not fenced; unless the
+// instance does not support super_read_only at all, in which case read_only and killing connections is the best
+// that can be done, and superReadOnly is returned false.
+// All statements are bound by given context, which is expected to carry the fencing deadline.
+func FenceInstanceWrites(ctx context.Context, instanceKey *InstanceKey) (killedConnections int, superReadOnly bool, err error) {
+	if *config.RuntimeCLIFlags.Noop {
+		return killedConnections, superReadOnly, fmt.Errorf("noop: aborting fence operation on %+v; signalling error but nothing went wrong.", *instanceKey)
+	}
+	// Opening the connection pool may itself talk to the instance; bound it by the deadline, too
+	readTimeoutSeconds := config.Config.MySQLTopologyReadTimeoutSeconds
+	if deadline, ok := ctx.Deadline(); ok {
+		readTimeoutSeconds = int(math.Ceil(time.Until(deadline).Seconds()))
+		if readTimeoutSeconds < 1 {
+			readTimeoutSeconds = 1
+		}
+	}
+	db, err := db.OpenTopologyWithReadTimeout(instanceKey.Hostname, instanceKey.Port, readTimeoutSeconds)
+	if err != nil {
+		return killedConnections, superReadOnly, log.Errore(err)
+	}
+	if _, err := db.ExecContext(ctx, "set global read_only = 1"); err != nil {
+		return killedConnections, superReadOnly, log.Errore(err)
+	}
+	if _, err := db.ExecContext(ctx, "set global super_read_only = 1"); err == nil {
+		superReadOnly = true
+	} else if supported, checkErr := isSuperReadOnlySupported(ctx, db); checkErr != nil || supported {
+		return killedConnections, superReadOnly, log.Errore(err)
+	} else {
+		log.Warningf("FenceInstanceWrites: %+v does not support super_read_only; users with SUPER privilege may still write", *instanceKey)
+	}
+
+	query := `
+		select
+			id
+		from
+			information_schema.processlist
+		where
+			id != connection_id()
+			and user != substring_index(user(), '@', 1)
+			and user not in ('system user', 'event_scheduler')
+			and command not in ('Binlog Dump', 'Binlog Dump GTID', 'Daemon')
+		`
+	connectionIds := []int64{}
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return killedConnections, superReadOnly, log.Errore(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var connectionId int64
+		if err := rows.Scan(&connectionId); err != nil {
+			return killedConnections, superReadOnly, log.Errore(err)
+		}
+		connectionIds = append(connectionIds, connectionId)
+	}
+	if err := rows.Err(); err != nil {
+		return killedConnections, superReadOnly, log.Errore(err)
+	}
+	for _, connectionId := range connectionIds {
+		// The connection may have gone away in the meantime
+		if _, err := db.ExecContext(ctx, "kill ?", connectionId); err == nil {
+			killedConnections++
+		} else if ctx.Err() != nil {
+			return killedConnections, superReadOnly, log.Errore(ctx.Err())
+		}
+	}
+
+	log.Infof("Fenced %+v; super_read_only: %t; killed %d connections", *instanceKey, superReadOnly, killedConnections)
+	AuditOperation("fence", instanceKey, fmt.Sprintf("set read_only; super_read_only: %t; killed %d connections", superReadOnly, killedConnections))
+	return killedConnections, superReadOnly, nil
+}
+
 // KillQuery stops replication on a given instance
 func KillQuery(instanceKey *InstanceKey, process int64) (*Instance, error) {
 	instance, err := ReadTopologyInstance(instanceKey)
@@ -1495,15 +1694,6 @@ func CheckAndInjectPseudoGTIDOnWriter(instance *Instance) (injected bool, err er
 	return injected, nil
 }
 
-func GTIDSubtract(instanceKey *InstanceKey, gtidSet string, gtidSubset string) (gtidSubtract string, err error) {
-	db, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
-	if err != nil {
-		return gtidSubtract, err
-	}
-	err = db.QueryRow("select gtid_subtract(?, ?)", gtidSet, gtidSubset).Scan(&gtidSubtract)
-	return gtidSubtract, err
-}
-
 func ShowMasterStatus(instance *Instance, instanceKey *InstanceKey) (masterStatusFound bool, executedGtidSet string, err error) {
 	db, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
 	if err != nil {
@@ -121,6 +121,12 @@ func (this *InstancesSorterByExec) Less(i, j int) bool {
 	if this.instances[j] == nil {
 		return true
 	}
+	if this.instances[i].UsingMariaDBGTID && this.instances[j].UsingMariaDBGTID {
+		// With MariaDB GTID, gtid_slave_pos tells which replica applied more, domain by domain
+		if comparison, comparable := this.instances[i].CompareMariaDBGtidSlavePos(this.instances[j]); comparable && comparison != 0 {
+			return comparison < 0
+		}
+	}
 	if this.instances[i].ExecBinlogCoordinates.Equals(&this.instances[j].ExecBinlogCoordinates) {
 		// Secondary sorting: "smaller" if not logging replica updates
 		if this.instances[j].LogReplicationUpdatesEnabled && !this.instances[i].LogReplicationUpdatesEnabled {
@@ -150,6 +156,11 @@ func (this *InstancesSorterByExec) Less(i, j int) bool {
 		if this.instances[j].PromotionRule.BetterThan(this.instances[i].PromotionRule) {
 			return true
 		}
+		// Prefer smaller relay log backlog, as measured by performance_schema: sooner to catch up and serve
+		if this.instances[i].ReplicationQueueLagSeconds.Valid && this.instances[j].ReplicationQueueLagSeconds.Valid &&
+			this.instances[j].ReplicationQueueLagSeconds.Int64 < this.instances[i].ReplicationQueueLagSeconds.Int64 {
+			return true
+		}
 	}
 	return this.instances[i].ExecBinlogCoordinates.SmallerThan(&this.instances[j].ExecBinlogCoordinates)
 }
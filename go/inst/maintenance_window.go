@@ -0,0 +1,135 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var maintenanceWindowRegexp = regexp.MustCompile(`^(?:([A-Za-z,]+)\s+)?([0-9]{1,2}):([0-9]{2})-([0-9]{1,2}):([0-9]{2})$`)
+
+var weekdaysByName = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// MaintenanceWindow is a recurring daily or weekly time window, in UTC, e.g. "Sat,Sun 03:00-05:00".
+// A window whose end is not after its start crosses midnight, and belongs to the day it starts on.
+type MaintenanceWindow struct {
+	Days        []time.Weekday // empty means every day
+	StartMinute int            // minute of day
+	EndMinute   int            // minute of day
+}
+
+// ParseMaintenanceWindow parses a window spec: an optional comma delimited list of three letter weekday names,
+// followed by a HH:MM-HH:MM time range in UTC. Examples: "03:00-04:00", "Sat,Sun 23:00-01:30"
+func ParseMaintenanceWindow(spec string) (*MaintenanceWindow, error) {
+	submatch := maintenanceWindowRegexp.FindStringSubmatch(strings.TrimSpace(spec))
+	if len(submatch) == 0 {
+		return nil, fmt.Errorf("Unable to parse maintenance window: %s. Expected e.g. \"Sat,Sun 03:00-05:00\"", spec)
+	}
+	window := &MaintenanceWindow{Days: []time.Weekday{}}
+	if submatch[1] != "" {
+		for _, dayName := range strings.Split(submatch[1], ",") {
+			day, ok := weekdaysByName[strings.ToLower(dayName)]
+			if !ok {
+				return nil, fmt.Errorf("Unable to parse maintenance window: %s. Unknown day: %s", spec, dayName)
+			}
+			window.Days = append(window.Days, day)
+		}
+	}
+	parseMinute := func(hours string, minutes string) (int, error) {
+		var h, m int
+		fmt.Sscanf(hours, "%d", &h)
+		fmt.Sscanf(minutes, "%d", &m)
+		if h > 23 || m > 59 {
+			return 0, fmt.Errorf("Unable to parse maintenance window: %s. Invalid time %s:%s", spec, hours, minutes)
+		}
+		return h*60 + m, nil
+	}
+	var err error
+	if window.StartMinute, err = parseMinute(submatch[2], submatch[3]); err != nil {
+		return nil, err
+	}
+	if window.EndMinute, err = parseMinute(submatch[4], submatch[5]); err != nil {
+		return nil, err
+	}
+	return window, nil
+}
+
+func (this *MaintenanceWindow) includesDay(day time.Weekday) bool {
+	if len(this.Days) == 0 {
+		return true
+	}
+	for _, windowDay := range this.Days {
+		if windowDay == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains checks whether given time falls within the window
+func (this *MaintenanceWindow) Contains(t time.Time) bool {
+	t = t.UTC()
+	minute := t.Hour()*60 + t.Minute()
+	if this.StartMinute < this.EndMinute {
+		return this.includesDay(t.Weekday()) && minute >= this.StartMinute && minute < this.EndMinute
+	}
+	// Crosses midnight
+	if this.includesDay(t.Weekday()) && minute >= this.StartMinute {
+		return true
+	}
+	return this.includesDay(t.AddDate(0, 0, -1).Weekday()) && minute < this.EndMinute
+}
+
+// NextOpening returns the earliest time, not before given time, at which the window is open
+func (this *MaintenanceWindow) NextOpening(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute)
+	if this.Contains(t) {
+		return t
+	}
+	for i := 0; i <= 7; i++ {
+		day := time.Date(t.Year(), t.Month(), t.Day()+i, 0, 0, 0, 0, time.UTC)
+		opening := day.Add(time.Duration(this.StartMinute) * time.Minute)
+		if this.includesDay(day.Weekday()) && !opening.Before(t) {
+			return opening
+		}
+	}
+	return t
+}
+
+// String returns the window spec
+func (this *MaintenanceWindow) String() string {
+	dayNames := []string{}
+	for _, day := range this.Days {
+		dayNames = append(dayNames, day.String()[0:3])
+	}
+	timeRange := fmt.Sprintf("%02d:%02d-%02d:%02d", this.StartMinute/60, this.StartMinute%60, this.EndMinute/60, this.EndMinute%60)
+	if len(dayNames) == 0 {
+		return timeRange
+	}
+	return fmt.Sprintf("%s %s", strings.Join(dayNames, ","), timeRange)
+}
@@ -0,0 +1,75 @@
+package inst
+
+import (
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseMaintenanceWindow(t *testing.T) {
+	{
+		window, err := ParseMaintenanceWindow("03:00-04:30")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(window.Days), 0)
+		test.S(t).ExpectEquals(window.StartMinute, 180)
+		test.S(t).ExpectEquals(window.EndMinute, 270)
+		test.S(t).ExpectEquals(window.String(), "03:00-04:30")
+	}
+	{
+		window, err := ParseMaintenanceWindow("sat,Sun 23:00-1:30")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(window.Days), 2)
+		test.S(t).ExpectEquals(window.Days[0], time.Saturday)
+		test.S(t).ExpectEquals(window.String(), "Sat,Sun 23:00-01:30")
+	}
+	{
+		_, err := ParseMaintenanceWindow("Funday 03:00-04:00")
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := ParseMaintenanceWindow("25:00-26:00")
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := ParseMaintenanceWindow("tonight")
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestMaintenanceWindowContains(t *testing.T) {
+	// 2024-06-01 is a Saturday
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2024, time.June, day, hour, minute, 0, 0, time.UTC)
+	}
+	{
+		window, _ := ParseMaintenanceWindow("03:00-04:00")
+		test.S(t).ExpectTrue(window.Contains(at(3, 3, 0)))
+		test.S(t).ExpectTrue(window.Contains(at(3, 3, 59)))
+		test.S(t).ExpectFalse(window.Contains(at(3, 4, 0)))
+		test.S(t).ExpectFalse(window.Contains(at(3, 2, 59)))
+	}
+	{
+		window, _ := ParseMaintenanceWindow("Sat 23:00-01:00")
+		test.S(t).ExpectTrue(window.Contains(at(1, 23, 30)))
+		test.S(t).ExpectTrue(window.Contains(at(2, 0, 30)))
+		test.S(t).ExpectFalse(window.Contains(at(2, 23, 30)))
+		test.S(t).ExpectFalse(window.Contains(at(1, 0, 30)))
+	}
+}
+
+func TestMaintenanceWindowNextOpening(t *testing.T) {
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2024, time.June, day, hour, minute, 0, 0, time.UTC)
+	}
+	{
+		window, _ := ParseMaintenanceWindow("03:00-04:00")
+		test.S(t).ExpectTrue(window.NextOpening(at(3, 2, 0)).Equal(at(3, 3, 0)))
+		test.S(t).ExpectTrue(window.NextOpening(at(3, 3, 10)).Equal(at(3, 3, 10)))
+		test.S(t).ExpectTrue(window.NextOpening(at(3, 5, 0)).Equal(at(4, 3, 0)))
+	}
+	{
+		window, _ := ParseMaintenanceWindow("Sat 04:00-05:00")
+		test.S(t).ExpectTrue(window.NextOpening(at(2, 12, 0)).Equal(at(8, 4, 0)))
+	}
+}
@@ -0,0 +1,185 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var mariadbGtidRegexp = regexp.MustCompile(`^([0-9]+)-([0-9]+)-([0-9]+)$`)
+
+// MariadbGtid is a single MariaDB GTID, in the form of domain-server-seqno, e.g. 0-101-2938
+type MariadbGtid struct {
+	DomainID       uint32
+	ServerID       uint32
+	SequenceNumber uint64
+}
+
+// ParseMariadbGtid parses a single domain-server-seqno token
+func ParseMariadbGtid(token string) (*MariadbGtid, error) {
+	submatch := mariadbGtidRegexp.FindStringSubmatch(strings.TrimSpace(token))
+	if submatch == nil {
+		return nil, fmt.Errorf("Cannot parse MariaDB GTID from %s", token)
+	}
+	domainID, err := strconv.ParseUint(submatch[1], 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	serverID, err := strconv.ParseUint(submatch[2], 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	sequenceNumber, err := strconv.ParseUint(submatch[3], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &MariadbGtid{DomainID: uint32(domainID), ServerID: uint32(serverID), SequenceNumber: sequenceNumber}, nil
+}
+
+func (this *MariadbGtid) String() string {
+	return fmt.Sprintf("%d-%d-%d", this.DomainID, this.ServerID, this.SequenceNumber)
+}
+
+// MariadbGtidPosition represents a MariaDB GTID position as depicted by @@gtid_binlog_pos, @@gtid_slave_pos
+// or @@gtid_current_pos: a list of GTIDs, at most one per replication domain.
+type MariadbGtidPosition struct {
+	Gtids [](*MariadbGtid)
+}
+
+// Example input: `0-101-2938,1-102-17`
+func NewMariadbGtidPosition(gtidPosition string) (res *MariadbGtidPosition, err error) {
+	res = &MariadbGtidPosition{}
+
+	gtidPosition = strings.TrimSpace(gtidPosition)
+	if gtidPosition == "" {
+		return res, nil
+	}
+	for _, token := range strings.Split(gtidPosition, ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		gtid, err := ParseMariadbGtid(token)
+		if err != nil {
+			return res, err
+		}
+		if res.GtidForDomain(gtid.DomainID) != nil {
+			return res, fmt.Errorf("Duplicate domain %d in MariaDB GTID position %s", gtid.DomainID, gtidPosition)
+		}
+		res.Gtids = append(res.Gtids, gtid)
+	}
+	return res, nil
+}
+
+func (this *MariadbGtidPosition) String() string {
+	tokens := []string{}
+	for _, gtid := range this.Gtids {
+		tokens = append(tokens, gtid.String())
+	}
+	return strings.Join(tokens, ",")
+}
+
+func (this *MariadbGtidPosition) IsEmpty() bool {
+	return len(this.Gtids) == 0
+}
+
+// GtidForDomain returns the GTID of given domain, or nil if this position does not include the domain
+func (this *MariadbGtidPosition) GtidForDomain(domainID uint32) *MariadbGtid {
+	for _, gtid := range this.Gtids {
+		if gtid.DomainID == domainID {
+			return gtid
+		}
+	}
+	return nil
+}
+
+// Contains returns true when, for each domain in other position, this position has reached at least
+// the same sequence number. A position always contains an empty position.
+func (this *MariadbGtidPosition) Contains(other *MariadbGtidPosition) bool {
+	for _, otherGtid := range other.Gtids {
+		gtid := this.GtidForDomain(otherGtid.DomainID)
+		if gtid == nil {
+			return false
+		}
+		if gtid.SequenceNumber < otherGtid.SequenceNumber {
+			return false
+		}
+	}
+	return true
+}
+
+// Equals returns true when both positions have the exact same GTIDs, regardless of order
+func (this *MariadbGtidPosition) Equals(other *MariadbGtidPosition) bool {
+	if len(this.Gtids) != len(other.Gtids) {
+		return false
+	}
+	for _, gtid := range this.Gtids {
+		otherGtid := other.GtidForDomain(gtid.DomainID)
+		if otherGtid == nil || *otherGtid != *gtid {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare compares this position with other position, domain by domain. It returns:
+// -1 when this position is behind other position,
+// 0 when positions are equal,
+// 1 when this position is ahead of other position.
+// When neither position contains the other (e.g. each is ahead on a different domain), positions are
+// not comparable, and comparable is false.
+func (this *MariadbGtidPosition) Compare(other *MariadbGtidPosition) (comparison int, comparable bool) {
+	thisContains := this.Contains(other)
+	otherContains := other.Contains(this)
+	switch {
+	case thisContains && otherContains:
+		return 0, true
+	case thisContains:
+		return 1, true
+	case otherContains:
+		return -1, true
+	}
+	return 0, false
+}
+
+// ErrantAgainst returns the GTIDs in this position which are not accounted for by given master position,
+// one per domain. A domain is errant when the master does not know it at all, when this position is ahead
+// of the master's, or when both are at the same sequence number but originated on different servers.
+// GTIDs originating on any of the given ignoreServerIDs are never considered errant; this is used to
+// tolerate a master's own GTIDs, which may appear ahead merely because the master was probed earlier.
+func (this *MariadbGtidPosition) ErrantAgainst(master *MariadbGtidPosition, ignoreServerIDs ...uint32) *MariadbGtidPosition {
+	ignored := map[uint32]bool{}
+	for _, serverID := range ignoreServerIDs {
+		ignored[serverID] = true
+	}
+	errant := &MariadbGtidPosition{}
+	for _, gtid := range this.Gtids {
+		if ignored[gtid.ServerID] {
+			continue
+		}
+		masterGtid := master.GtidForDomain(gtid.DomainID)
+		if masterGtid == nil ||
+			gtid.SequenceNumber > masterGtid.SequenceNumber ||
+			(gtid.SequenceNumber == masterGtid.SequenceNumber && gtid.ServerID != masterGtid.ServerID) {
+			errant.Gtids = append(errant.Gtids, gtid)
+		}
+	}
+	return errant
+}
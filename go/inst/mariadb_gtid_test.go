@@ -0,0 +1,104 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseMariadbGtid(t *testing.T) {
+	{
+		gtid, err := ParseMariadbGtid("0-101-2938")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(gtid.DomainID, uint32(0))
+		test.S(t).ExpectEquals(gtid.ServerID, uint32(101))
+		test.S(t).ExpectEquals(gtid.SequenceNumber, uint64(2938))
+		test.S(t).ExpectEquals(gtid.String(), "0-101-2938")
+	}
+	{
+		_, err := ParseMariadbGtid("0-101")
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := ParseMariadbGtid("00020192-1111-1111-1111-111111111111:1-5")
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestNewMariadbGtidPosition(t *testing.T) {
+	{
+		pos, err := NewMariadbGtidPosition("0-101-2938, 1-102-17,")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(pos.Gtids), 2)
+		test.S(t).ExpectEquals(pos.String(), "0-101-2938,1-102-17")
+		test.S(t).ExpectEquals(pos.GtidForDomain(1).SequenceNumber, uint64(17))
+		test.S(t).ExpectTrue(pos.GtidForDomain(2) == nil)
+	}
+	{
+		pos, err := NewMariadbGtidPosition("")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(pos.IsEmpty())
+	}
+	{
+		_, err := NewMariadbGtidPosition("0-101-2938,0-102-2940")
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestMariadbGtidPositionCompare(t *testing.T) {
+	pos, _ := NewMariadbGtidPosition("0-101-100,1-102-17")
+	{
+		other, _ := NewMariadbGtidPosition("1-102-17,0-101-100")
+		comparison, comparable := pos.Compare(other)
+		test.S(t).ExpectTrue(comparable)
+		test.S(t).ExpectEquals(comparison, 0)
+		test.S(t).ExpectTrue(pos.Equals(other))
+	}
+	{
+		other, _ := NewMariadbGtidPosition("0-101-90,1-102-17")
+		comparison, comparable := pos.Compare(other)
+		test.S(t).ExpectTrue(comparable)
+		test.S(t).ExpectEquals(comparison, 1)
+		test.S(t).ExpectFalse(pos.Equals(other))
+	}
+	{
+		other, _ := NewMariadbGtidPosition("0-101-100,1-102-17,2-103-5")
+		comparison, comparable := pos.Compare(other)
+		test.S(t).ExpectTrue(comparable)
+		test.S(t).ExpectEquals(comparison, -1)
+	}
+	{
+		other, _ := NewMariadbGtidPosition("0-101-110,1-102-10")
+		_, comparable := pos.Compare(other)
+		test.S(t).ExpectFalse(comparable)
+	}
+}
+
+func TestMariadbGtidPositionErrantAgainst(t *testing.T) {
+	master, _ := NewMariadbGtidPosition("0-101-100,1-102-17")
+	{
+		replica, _ := NewMariadbGtidPosition("0-101-90,1-102-17")
+		test.S(t).ExpectTrue(replica.ErrantAgainst(master).IsEmpty())
+	}
+	{
+		// Local write on the replica, in the master's domain
+		replica, _ := NewMariadbGtidPosition("0-201-101,1-102-17")
+		test.S(t).ExpectEquals(replica.ErrantAgainst(master).String(), "0-201-101")
+	}
+	{
+		// Same sequence number, different origin
+		replica, _ := NewMariadbGtidPosition("0-201-100,1-102-17")
+		test.S(t).ExpectEquals(replica.ErrantAgainst(master).String(), "0-201-100")
+	}
+	{
+		// Domain unknown to the master
+		replica, _ := NewMariadbGtidPosition("0-101-100,5-201-3")
+		test.S(t).ExpectEquals(replica.ErrantAgainst(master).String(), "5-201-3")
+	}
+	{
+		// Master probed before the replica applied its latest transactions
+		replica, _ := NewMariadbGtidPosition("0-101-105,1-102-17")
+		test.S(t).ExpectFalse(replica.ErrantAgainst(master).IsEmpty())
+		test.S(t).ExpectTrue(replica.ErrantAgainst(master, 101).IsEmpty())
+	}
+}
@@ -126,3 +126,132 @@ func (this *OracleGtidSet) String() string {
 func (this *OracleGtidSet) IsEmpty() bool {
 	return len(this.GtidEntries) == 0
 }
+
+// uuidGtidIntervals groups the transactions of a GTID set by UUID, and then by tag, retaining order of appearance.
+// A set may list the same UUID more than once; such entries are merged.
+type uuidGtidIntervals struct {
+	uuids     []string
+	intervals map[string]*taggedGtidIntervals
+}
+
+func (this *OracleGtidSet) uuidIntervals() (*uuidGtidIntervals, error) {
+	result := &uuidGtidIntervals{intervals: make(map[string]*taggedGtidIntervals)}
+	for _, entry := range this.GtidEntries {
+		entryIntervals, err := entry.taggedIntervals()
+		if err != nil {
+			return nil, err
+		}
+		if _, found := result.intervals[entry.UUID]; !found {
+			result.uuids = append(result.uuids, entry.UUID)
+			result.intervals[entry.UUID] = newTaggedGtidIntervals()
+		}
+		for _, tag := range entryIntervals.tags {
+			result.intervals[entry.UUID].add(tag, entryIntervals.intervals[tag])
+		}
+	}
+	return result, nil
+}
+
+// combineOracleGtidSets applies given interval operation on each UUID & tag found in either set,
+// and returns the resulting set. Entries and tags that end up empty are omitted.
+func combineOracleGtidSets(this, other *OracleGtidSet, operation func(a, b gtidIntervals) gtidIntervals) (*OracleGtidSet, error) {
+	thisIntervals, err := this.uuidIntervals()
+	if err != nil {
+		return nil, err
+	}
+	otherIntervals, err := other.uuidIntervals()
+	if err != nil {
+		return nil, err
+	}
+	uuids := append(append([]string{}, thisIntervals.uuids...), otherIntervals.uuids...)
+	result := &OracleGtidSet{}
+	visitedUUIDs := map[string]bool{}
+	for _, uuid := range uuids {
+		if visitedUUIDs[uuid] {
+			continue
+		}
+		visitedUUIDs[uuid] = true
+
+		a, b := thisIntervals.intervals[uuid], otherIntervals.intervals[uuid]
+		if a == nil {
+			a = newTaggedGtidIntervals()
+		}
+		if b == nil {
+			b = newTaggedGtidIntervals()
+		}
+		combined := newTaggedGtidIntervals()
+		for _, tag := range append(append([]string{}, a.tags...), b.tags...) {
+			if _, found := combined.intervals[tag]; found {
+				continue
+			}
+			combined.add(tag, operation(a.intervals[tag], b.intervals[tag]))
+		}
+		if entry := combined.toEntry(uuid); entry != nil {
+			result.GtidEntries = append(result.GtidEntries, entry)
+		}
+	}
+	return result, nil
+}
+
+// Union returns a new set with all transactions found in this set or in other set; equivalent to GTID_UNION()
+func (this *OracleGtidSet) Union(other *OracleGtidSet) (*OracleGtidSet, error) {
+	return combineOracleGtidSets(this, other, gtidIntervals.union)
+}
+
+// Subtract returns a new set with all transactions found in this set but not in other set; equivalent to GTID_SUBTRACT()
+func (this *OracleGtidSet) Subtract(other *OracleGtidSet) (*OracleGtidSet, error) {
+	return combineOracleGtidSets(this, other, gtidIntervals.subtract)
+}
+
+// Intersect returns a new set with all transactions found both in this set and in other set
+func (this *OracleGtidSet) Intersect(other *OracleGtidSet) (*OracleGtidSet, error) {
+	return combineOracleGtidSets(this, other, gtidIntervals.intersect)
+}
+
+// Contains returns true when all transactions in other set are found in this set; equivalent to GTID_SUBSET(other, this)
+func (this *OracleGtidSet) Contains(other *OracleGtidSet) (bool, error) {
+	subtract, err := other.Subtract(this)
+	if err != nil {
+		return false, err
+	}
+	return subtract.IsEmpty(), nil
+}
+
+// Equals returns true when both sets describe the exact same transactions, regardless of textual representation
+func (this *OracleGtidSet) Equals(other *OracleGtidSet) (bool, error) {
+	if contains, err := this.Contains(other); !contains || err != nil {
+		return false, err
+	}
+	return other.Contains(this)
+}
+
+// Count returns the number of transactions in this set
+func (this *OracleGtidSet) Count() (count int64) {
+	uuidIntervals, err := this.uuidIntervals()
+	if err != nil {
+		return 0
+	}
+	for _, taggedIntervals := range uuidIntervals.intervals {
+		for _, intervals := range taggedIntervals.intervals {
+			count += intervals.count()
+		}
+	}
+	return count
+}
+
+// GTIDSubtract subtracts the given subset from given set, and returns the textual representation of the result.
+func GTIDSubtract(gtidSet string, gtidSubset string) (gtidSubtract string, err error) {
+	set, err := NewOracleGtidSet(gtidSet)
+	if err != nil {
+		return gtidSubtract, err
+	}
+	subset, err := NewOracleGtidSet(gtidSubset)
+	if err != nil {
+		return gtidSubtract, err
+	}
+	subtract, err := set.Subtract(subset)
+	if err != nil {
+		return gtidSubtract, err
+	}
+	return subtract.String(), nil
+}
@@ -19,6 +19,7 @@ package inst
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -250,3 +251,243 @@ func (this *OracleGtidSetEntry) Explode() (result [](*OracleGtidSetEntry)) {
 	}
 	return result
 }
+
+// gtidInterval is an inclusive range of transaction sequence numbers, e.g. 1-5 in uuid:1-5
+type gtidInterval struct {
+	start int64
+	end   int64
+}
+
+// gtidIntervals is a list of intervals. Once normalized, intervals are sorted, non-overlapping and non-adjacent
+type gtidIntervals []gtidInterval
+
+// parseGtidIntervals parses interval tokens such as "1-5" or "7" into normalized intervals
+func parseGtidIntervals(tokens []string) (result gtidIntervals, err error) {
+	for _, token := range tokens {
+		if token == "" {
+			continue
+		}
+		if submatch := multiValueInterval.FindStringSubmatch(token); submatch != nil {
+			start, err := strconv.ParseInt(submatch[1], 10, 64)
+			if err != nil {
+				return result, err
+			}
+			end, err := strconv.ParseInt(submatch[2], 10, 64)
+			if err != nil {
+				return result, err
+			}
+			if start > end {
+				return result, fmt.Errorf("Invalid GTID interval: %s", token)
+			}
+			result = append(result, gtidInterval{start: start, end: end})
+		} else if submatch := singleValueInterval.FindStringSubmatch(token); submatch != nil {
+			value, err := strconv.ParseInt(submatch[1], 10, 64)
+			if err != nil {
+				return result, err
+			}
+			result = append(result, gtidInterval{start: value, end: value})
+		} else {
+			return result, fmt.Errorf("Invalid GTID interval: %s", token)
+		}
+	}
+	return result.normalize(), nil
+}
+
+// normalize sorts intervals and merges overlapping or adjacent ones
+func (this gtidIntervals) normalize() (result gtidIntervals) {
+	sorted := append(gtidIntervals{}, this...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start < sorted[j].start })
+	for _, interval := range sorted {
+		if len(result) > 0 && interval.start <= result[len(result)-1].end+1 {
+			if interval.end > result[len(result)-1].end {
+				result[len(result)-1].end = interval.end
+			}
+			continue
+		}
+		result = append(result, interval)
+	}
+	return result
+}
+
+// union returns all transactions found in either this or other
+func (this gtidIntervals) union(other gtidIntervals) gtidIntervals {
+	return append(append(gtidIntervals{}, this...), other...).normalize()
+}
+
+// subtract returns transactions found in this but not in other. Both are expected to be normalized.
+func (this gtidIntervals) subtract(other gtidIntervals) (result gtidIntervals) {
+	for _, interval := range this {
+		remaining := interval
+		consumed := false
+		for _, removed := range other {
+			if removed.end < remaining.start {
+				continue
+			}
+			if removed.start > remaining.end {
+				break
+			}
+			if removed.start > remaining.start {
+				result = append(result, gtidInterval{start: remaining.start, end: removed.start - 1})
+			}
+			if removed.end >= remaining.end {
+				consumed = true
+				break
+			}
+			remaining.start = removed.end + 1
+		}
+		if !consumed {
+			result = append(result, remaining)
+		}
+	}
+	return result
+}
+
+// intersect returns transactions found in both this and other. Both are expected to be normalized.
+func (this gtidIntervals) intersect(other gtidIntervals) (result gtidIntervals) {
+	i, j := 0, 0
+	for i < len(this) && j < len(other) {
+		start := this[i].start
+		if other[j].start > start {
+			start = other[j].start
+		}
+		end := this[i].end
+		if other[j].end < end {
+			end = other[j].end
+		}
+		if start <= end {
+			result = append(result, gtidInterval{start: start, end: end})
+		}
+		if this[i].end < other[j].end {
+			i++
+		} else {
+			j++
+		}
+	}
+	return result
+}
+
+// count returns the number of transactions covered by the intervals
+func (this gtidIntervals) count() (count int64) {
+	for _, interval := range this {
+		count += interval.end - interval.start + 1
+	}
+	return count
+}
+
+func (this gtidIntervals) String() string {
+	tokens := []string{}
+	for _, interval := range this {
+		if interval.start == interval.end {
+			tokens = append(tokens, fmt.Sprintf("%d", interval.start))
+		} else {
+			tokens = append(tokens, fmt.Sprintf("%d-%d", interval.start, interval.end))
+		}
+	}
+	return strings.Join(tokens, ":")
+}
+
+// taggedGtidIntervals maps GTID tags onto their intervals, retaining order of appearance.
+// The untagged (default) intervals use the empty tag.
+type taggedGtidIntervals struct {
+	tags      []string
+	intervals map[string]gtidIntervals
+}
+
+func newTaggedGtidIntervals() *taggedGtidIntervals {
+	return &taggedGtidIntervals{intervals: make(map[string]gtidIntervals)}
+}
+
+// add merges given intervals into the given tag
+func (this *taggedGtidIntervals) add(tag string, intervals gtidIntervals) {
+	if _, found := this.intervals[tag]; !found {
+		this.tags = append(this.tags, tag)
+	}
+	this.intervals[tag] = this.intervals[tag].union(intervals)
+}
+
+// toEntry builds an entry for given UUID, or returns nil when there are no transactions
+func (this *taggedGtidIntervals) toEntry(uuid string) *OracleGtidSetEntry {
+	entry := &OracleGtidSetEntry{UUID: uuid}
+	if defaultIntervals := this.intervals[""]; len(defaultIntervals) > 0 {
+		entry.DefaultIv = defaultIntervals.String()
+	}
+	for _, tag := range this.tags {
+		if tag == "" || len(this.intervals[tag]) == 0 {
+			continue
+		}
+		entry.TaggedIv = append(entry.TaggedIv, tagInterval{Tag: tag, Interval: strings.Split(this.intervals[tag].String(), ":")})
+	}
+	if entry.IsEmpty() {
+		return nil
+	}
+	return entry
+}
+
+// taggedIntervals returns the parsed and normalized intervals of this entry, grouped by tag
+func (this *OracleGtidSetEntry) taggedIntervals() (*taggedGtidIntervals, error) {
+	result := newTaggedGtidIntervals()
+	if this.DefaultIv != "" {
+		intervals, err := parseGtidIntervals(strings.Split(this.DefaultIv, ":"))
+		if err != nil {
+			return nil, err
+		}
+		result.add("", intervals)
+	}
+	for _, v := range this.TaggedIv {
+		intervals, err := parseGtidIntervals(v.Interval)
+		if err != nil {
+			return nil, err
+		}
+		result.add(v.Tag, intervals)
+	}
+	return result, nil
+}
+
+// IsEmpty returns true when this entry does not describe any transaction
+func (this *OracleGtidSetEntry) IsEmpty() bool {
+	if this.DefaultIv != "" {
+		return false
+	}
+	for _, v := range this.TaggedIv {
+		if len(v.Interval) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns the number of transactions described by this entry, tagged and untagged
+func (this *OracleGtidSetEntry) Count() (count int64) {
+	taggedIntervals, err := this.taggedIntervals()
+	if err != nil {
+		return 0
+	}
+	for _, intervals := range taggedIntervals.intervals {
+		count += intervals.count()
+	}
+	return count
+}
+
+// Contains returns true when all transactions described by other entry are described by this entry
+func (this *OracleGtidSetEntry) Contains(other *OracleGtidSetEntry) bool {
+	if other.IsEmpty() {
+		return true
+	}
+	if this.UUID != other.UUID {
+		return false
+	}
+	thisIntervals, err := this.taggedIntervals()
+	if err != nil {
+		return false
+	}
+	otherIntervals, err := other.taggedIntervals()
+	if err != nil {
+		return false
+	}
+	for _, tag := range otherIntervals.tags {
+		if len(otherIntervals.intervals[tag].subtract(thisIntervals.intervals[tag])) > 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -471,3 +471,128 @@ func TestSharedUUIDs(t *testing.T) {
 		}
 	}
 }
+
+func TestOracleGtidSetUnion(t *testing.T) {
+	{
+		gtidSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:1-5:8, 00020194-3333-3333-3333-333333333333:7-8")
+		otherSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:6-7:10, 230ea8ea-81e3-11e4-972a-e25ec4bd140a:1-2")
+		union, err := gtidSet.Union(otherSet)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(union.String(), "00020192-1111-1111-1111-111111111111:1-8:10,00020194-3333-3333-3333-333333333333:7-8,230ea8ea-81e3-11e4-972a-e25ec4bd140a:1-2")
+	}
+	{
+		gtidSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:1-5:tag1:1-3")
+		otherSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:tag1:4:tag2:9, 00020192-1111-1111-1111-111111111111:3-9")
+		union, err := gtidSet.Union(otherSet)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(union.String(), "00020192-1111-1111-1111-111111111111:1-9:tag1:1-4:tag2:9")
+	}
+	{
+		gtidSet, _ := NewOracleGtidSet("")
+		otherSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:1-5")
+		union, err := gtidSet.Union(otherSet)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(union.String(), "00020192-1111-1111-1111-111111111111:1-5")
+	}
+}
+
+func TestOracleGtidSetSubtract(t *testing.T) {
+	{
+		gtidSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:1-10, 00020194-3333-3333-3333-333333333333:7-8")
+		otherSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:3-4:6:9-12")
+		subtract, err := gtidSet.Subtract(otherSet)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(subtract.String(), "00020192-1111-1111-1111-111111111111:1-2:5:7-8,00020194-3333-3333-3333-333333333333:7-8")
+	}
+	{
+		gtidSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:1-10:tag1:1-5")
+		otherSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:1-10:tag1:2")
+		subtract, err := gtidSet.Subtract(otherSet)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(subtract.String(), "00020192-1111-1111-1111-111111111111:tag1:1:3-5")
+	}
+	{
+		gtidSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:1-10")
+		otherSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:1-100")
+		subtract, err := gtidSet.Subtract(otherSet)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(subtract.IsEmpty())
+	}
+	{
+		subtract, err := GTIDSubtract("00020192-1111-1111-1111-111111111111:1-10", "00020192-1111-1111-1111-111111111111:1-3,00020194-3333-3333-3333-333333333333:7-8")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(subtract, "00020192-1111-1111-1111-111111111111:4-10")
+	}
+}
+
+func TestOracleGtidSetIntersect(t *testing.T) {
+	{
+		gtidSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:1-10:15-20, 00020194-3333-3333-3333-333333333333:7-8")
+		otherSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:5-16, 230ea8ea-81e3-11e4-972a-e25ec4bd140a:1-2")
+		intersect, err := gtidSet.Intersect(otherSet)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(intersect.String(), "00020192-1111-1111-1111-111111111111:5-10:15-16")
+	}
+	{
+		gtidSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:1-10")
+		otherSet, _ := NewOracleGtidSet("00020194-3333-3333-3333-333333333333:1-10")
+		intersect, err := gtidSet.Intersect(otherSet)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(intersect.IsEmpty())
+	}
+}
+
+func TestOracleGtidSetContains(t *testing.T) {
+	gtidSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:1-10:tag1:1-3, 00020194-3333-3333-3333-333333333333:7-8")
+	{
+		otherSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:2-4:tag1:3")
+		contains, err := gtidSet.Contains(otherSet)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(contains)
+	}
+	{
+		otherSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:2-4:tag1:4")
+		contains, err := gtidSet.Contains(otherSet)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectFalse(contains)
+	}
+	{
+		otherSet, _ := NewOracleGtidSet("")
+		contains, err := gtidSet.Contains(otherSet)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(contains)
+	}
+	{
+		otherSet, _ := NewOracleGtidSet("00020194-3333-3333-3333-333333333333:7,00020192-1111-1111-1111-111111111111:tag1:1-3:1-5:6-10")
+		equals, err := gtidSet.Equals(otherSet)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectFalse(equals)
+
+		otherSet, _ = NewOracleGtidSet("00020194-3333-3333-3333-333333333333:7-8,00020192-1111-1111-1111-111111111111:1-5:6-10:tag1:1-3")
+		equals, err = gtidSet.Equals(otherSet)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(equals)
+	}
+	{
+		entry, _ := NewOracleGtidSetEntry("00020192-1111-1111-1111-111111111111:1-10:tag1:1-3")
+		otherEntry, _ := NewOracleGtidSetEntry("00020192-1111-1111-1111-111111111111:5:tag1:2-3")
+		test.S(t).ExpectTrue(entry.Contains(otherEntry))
+		test.S(t).ExpectFalse(otherEntry.Contains(entry))
+	}
+}
+
+func TestOracleGtidSetCount(t *testing.T) {
+	{
+		gtidSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:1-10:15:tag1:1-3, 00020194-3333-3333-3333-333333333333:7-8")
+		test.S(t).ExpectEquals(gtidSet.Count(), int64(16))
+		test.S(t).ExpectEquals(gtidSet.GtidEntries[0].Count(), int64(14))
+	}
+	{
+		gtidSet, _ := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:1-10, 00020192-1111-1111-1111-111111111111:5-12")
+		test.S(t).ExpectEquals(gtidSet.Count(), int64(12))
+	}
+	{
+		gtidSet, _ := NewOracleGtidSet("")
+		test.S(t).ExpectEquals(gtidSet.Count(), int64(0))
+	}
+}
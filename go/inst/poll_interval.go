@@ -0,0 +1,153 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/openark/orchestrator/go/config"
+)
+
+// pollIntervalState is what we remember of an instance between polls, for adaptive polling
+type pollIntervalState struct {
+	intervalSeconds uint
+	stateSignature  string
+}
+
+type pollIntervals struct {
+	states      map[InstanceKey]pollIntervalState
+	statesMutex sync.Mutex
+}
+
+var instancePollIntervals = pollIntervals{states: make(map[InstanceKey]pollIntervalState)}
+
+// instanceStateSignature summarizes those attributes of an instance which, when changed, indicate
+// a topology or replication state change
+func instanceStateSignature(instance *Instance) string {
+	return fmt.Sprintf("%s|%d|%d|%t|%d|%s|%s|%s",
+		instance.MasterKey.StringCode(),
+		instance.ReplicationIOThreadState,
+		instance.ReplicationSQLThreadState,
+		instance.ReadOnly,
+		len(instance.Replicas),
+		instance.ReplicationGroupMemberState,
+		instance.ReplicationGroupMemberRole,
+		instance.WsrepClusterStatus,
+	)
+}
+
+// isPollingHotspot returns true when an instance should be polled at the minimal interval:
+// masters, and instances with lag or errors
+func isPollingHotspot(instance *Instance) bool {
+	if instance.IsMaster() {
+		return true
+	}
+	if instance.LastIOError != "" || instance.LastSQLError != "" || instance.GtidErrant != "" {
+		return true
+	}
+	if instance.IsReplica() && !instance.ReplicaRunning() {
+		return true
+	}
+	if instance.IsGaleraNode() && !instance.IsGaleraSynced() {
+		return true
+	}
+	if lag := instance.EffectiveReplicationLagSeconds(); instance.IsReplica() && (!lag.Valid || lag.Int64 > int64(config.Config.ReasonableReplicationLagSeconds)) {
+		return true
+	}
+	return false
+}
+
+// nextPollIntervalSeconds computes the poll interval of an instance given the interval it was polled at
+// and whether its state changed since:
+// - masters, and instances with state changes, lag or errors, are polled at the minimal interval
+// - intermediate masters and group members are polled at InstancePollSeconds
+// - stable leaf replicas see their interval doubled on each poll, up to the maximal interval
+func nextPollIntervalSeconds(instance *Instance, previousIntervalSeconds uint, stateChanged bool) uint {
+	if !config.Config.AdaptiveInstancePoll {
+		return config.Config.InstancePollSeconds
+	}
+	if stateChanged || isPollingHotspot(instance) {
+		return config.Config.AdaptiveInstancePollSecondsMin
+	}
+	if len(instance.Replicas) > 0 || instance.IsReplicationGroupMember() {
+		return config.Config.InstancePollSeconds
+	}
+	if previousIntervalSeconds < config.Config.InstancePollSeconds {
+		return config.Config.InstancePollSeconds
+	}
+	intervalSeconds := 2 * previousIntervalSeconds
+	if intervalSeconds > config.Config.AdaptiveInstancePollSecondsMax {
+		intervalSeconds = config.Config.AdaptiveInstancePollSecondsMax
+	}
+	return intervalSeconds
+}
+
+// RegisterInstancePoll computes and returns the poll interval of an instance which was just successfully
+// polled, taking into account its state in the previous poll.
+func RegisterInstancePoll(instance *Instance) uint {
+	instancePollIntervals.statesMutex.Lock()
+	defer instancePollIntervals.statesMutex.Unlock()
+
+	signature := instanceStateSignature(instance)
+	previous, found := instancePollIntervals.states[instance.Key]
+	stateChanged := found && previous.stateSignature != signature
+
+	intervalSeconds := nextPollIntervalSeconds(instance, previous.intervalSeconds, stateChanged)
+	instancePollIntervals.states[instance.Key] = pollIntervalState{intervalSeconds: intervalSeconds, stateSignature: signature}
+	return intervalSeconds
+}
+
+// RegisterInstancePollFailure computes and returns the poll interval of an instance which could not be polled.
+// Such instance is polled at the minimal interval; dead instances are further subject to DeadInstancesFilter.
+func RegisterInstancePollFailure(instanceKey *InstanceKey) uint {
+	instancePollIntervals.statesMutex.Lock()
+	defer instancePollIntervals.statesMutex.Unlock()
+
+	intervalSeconds := config.Config.InstancePollSeconds
+	if config.Config.AdaptiveInstancePoll {
+		intervalSeconds = config.Config.AdaptiveInstancePollSecondsMin
+	}
+	previous := instancePollIntervals.states[*instanceKey]
+	instancePollIntervals.states[*instanceKey] = pollIntervalState{intervalSeconds: intervalSeconds, stateSignature: previous.stateSignature}
+	return intervalSeconds
+}
+
+// ForgetInstancePollInterval removes adaptive polling state of an instance
+func ForgetInstancePollInterval(instanceKey *InstanceKey) {
+	instancePollIntervals.statesMutex.Lock()
+	defer instancePollIntervals.statesMutex.Unlock()
+
+	delete(instancePollIntervals.states, *instanceKey)
+}
+
+// MinimalInstancePollSeconds returns the shortest interval at which any instance may be polled
+func MinimalInstancePollSeconds() uint {
+	if config.Config.AdaptiveInstancePoll && config.Config.AdaptiveInstancePollSecondsMin < config.Config.InstancePollSeconds {
+		return config.Config.AdaptiveInstancePollSecondsMin
+	}
+	return config.Config.InstancePollSeconds
+}
+
+// effectivePollSeconds returns the interval at which an instance is polled, given its persisted poll interval.
+// Instances not yet polled with a persisted interval are polled every InstancePollSeconds.
+func effectivePollSeconds(pollIntervalSeconds uint) uint {
+	if !config.Config.AdaptiveInstancePoll || pollIntervalSeconds == 0 {
+		return config.Config.InstancePollSeconds
+	}
+	return pollIntervalSeconds
+}
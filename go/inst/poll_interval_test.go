@@ -0,0 +1,80 @@
+package inst
+
+import (
+	"database/sql"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+)
+
+func newTestPollInstance(port int, masterPort int) *Instance {
+	instance := NewInstance()
+	instance.Key = InstanceKey{Hostname: "poll", Port: port}
+	if masterPort > 0 {
+		instance.MasterKey = InstanceKey{Hostname: "poll", Port: masterPort}
+		instance.ReadBinlogCoordinates = BinlogCoordinates{LogFile: "mysql-bin.000001", LogPos: 4}
+		instance.ReplicationIOThreadState = ReplicationThreadStateRunning
+		instance.ReplicationSQLThreadState = ReplicationThreadStateRunning
+		instance.SecondsBehindMaster = sql.NullInt64{Int64: 0, Valid: true}
+		instance.ReplicationLagSeconds = sql.NullInt64{Int64: 0, Valid: true}
+	}
+	return instance
+}
+
+func TestNextPollIntervalSeconds(t *testing.T) {
+	defer func(adaptive bool, min, max uint) {
+		config.Config.AdaptiveInstancePoll = adaptive
+		config.Config.AdaptiveInstancePollSecondsMin = min
+		config.Config.AdaptiveInstancePollSecondsMax = max
+	}(config.Config.AdaptiveInstancePoll, config.Config.AdaptiveInstancePollSecondsMin, config.Config.AdaptiveInstancePollSecondsMax)
+	config.Config.AdaptiveInstancePollSecondsMin = 2
+	config.Config.AdaptiveInstancePollSecondsMax = 30
+
+	leaf := newTestPollInstance(3307, 3306)
+	master := newTestPollInstance(3306, 0)
+	master.Replicas.AddKey(leaf.Key)
+
+	config.Config.AdaptiveInstancePoll = false
+	test.S(t).ExpectEquals(nextPollIntervalSeconds(master, 0, false), uint(5))
+	test.S(t).ExpectEquals(nextPollIntervalSeconds(leaf, 20, false), uint(5))
+
+	config.Config.AdaptiveInstancePoll = true
+	test.S(t).ExpectEquals(nextPollIntervalSeconds(master, 0, false), uint(2))
+	// Stable leaf replica backs off
+	test.S(t).ExpectEquals(nextPollIntervalSeconds(leaf, 0, false), uint(5))
+	test.S(t).ExpectEquals(nextPollIntervalSeconds(leaf, 5, false), uint(10))
+	test.S(t).ExpectEquals(nextPollIntervalSeconds(leaf, 20, false), uint(30))
+	test.S(t).ExpectEquals(nextPollIntervalSeconds(leaf, 30, false), uint(30))
+	// State change
+	test.S(t).ExpectEquals(nextPollIntervalSeconds(leaf, 30, true), uint(2))
+	// Lag
+	leaf.ReplicationLagSeconds = sql.NullInt64{Int64: int64(config.Config.ReasonableReplicationLagSeconds) + 1, Valid: true}
+	test.S(t).ExpectEquals(nextPollIntervalSeconds(leaf, 30, false), uint(2))
+	leaf.ReplicationLagSeconds = sql.NullInt64{Int64: 0, Valid: true}
+	// Errors
+	leaf.LastSQLError = "Duplicate entry"
+	test.S(t).ExpectEquals(nextPollIntervalSeconds(leaf, 30, false), uint(2))
+	leaf.LastSQLError = ""
+	// Intermediate master
+	intermediateMaster := newTestPollInstance(3308, 3306)
+	intermediateMaster.Replicas.AddKey(InstanceKey{Hostname: "poll", Port: 3309})
+	test.S(t).ExpectEquals(nextPollIntervalSeconds(intermediateMaster, 30, false), uint(5))
+}
+
+func TestRegisterInstancePoll(t *testing.T) {
+	defer func(adaptive bool) {
+		config.Config.AdaptiveInstancePoll = adaptive
+	}(config.Config.AdaptiveInstancePoll)
+	config.Config.AdaptiveInstancePoll = true
+
+	leaf := newTestPollInstance(4307, 4306)
+	defer ForgetInstancePollInterval(&leaf.Key)
+
+	test.S(t).ExpectEquals(RegisterInstancePoll(leaf), config.Config.InstancePollSeconds)
+	test.S(t).ExpectEquals(RegisterInstancePoll(leaf), 2*config.Config.InstancePollSeconds)
+	leaf.MasterKey.Port = 4308
+	test.S(t).ExpectEquals(RegisterInstancePoll(leaf), config.Config.AdaptiveInstancePollSecondsMin)
+	test.S(t).ExpectEquals(RegisterInstancePoll(leaf), config.Config.InstancePollSeconds)
+	test.S(t).ExpectEquals(RegisterInstancePollFailure(&leaf.Key), config.Config.AdaptiveInstancePollSecondsMin)
+}
@@ -0,0 +1,23 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseCandidatePromotionRule(t *testing.T) {
+	for _, ruleName := range []string{"must", "prefer", "neutral", "prefer_not", "must_not"} {
+		rule, err := ParseCandidatePromotionRule(ruleName)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(string(rule), ruleName)
+	}
+	_, err := ParseCandidatePromotionRule("should")
+	test.S(t).ExpectNotNil(err)
+}
+
+func TestCandidatePromotionRuleBetterThan(t *testing.T) {
+	rule := MustPromoteRule
+	test.S(t).ExpectTrue(rule.BetterThan(PreferPromoteRule))
+	test.S(t).ExpectFalse(rule.BetterThan(MustPromoteRule))
+}
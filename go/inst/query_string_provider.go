@@ -45,6 +45,7 @@ const (
 	reset_slave
 	change_master_to_master_host_port
 	change_master_to_master_host_port_log_gtid_no
+	change_master_to_master_host_port_gtid_slave_pos
 	change_master_to_master_host_port_log_autoposition_no
 	change_master_to_master_host_port_autoposition_yes
 	change_master_to_master_host_port_log
@@ -198,6 +199,10 @@ func (qps *QueryStringProvider) change_master_to_master_host_port_log_gtid_no()
 	return qps.queries[change_master_to_master_host_port_log_gtid_no]
 }
 
+func (qps *QueryStringProvider) change_master_to_master_host_port_gtid_slave_pos() string {
+	return qps.queries[change_master_to_master_host_port_gtid_slave_pos]
+}
+
 func (qps *QueryStringProvider) change_master_to_master_host_port_log_autoposition_no() string {
 	return qps.queries[change_master_to_master_host_port_log_autoposition_no]
 }
@@ -263,22 +268,23 @@ var queryStrings80 = map[QueryStringKey]string{
 	master_ssl_allowed:        "Master_SSL_Allowed",
 	show_slave_hosts:          "show slave hosts",
 
-	stop_slave_io_thread:                          "stop slave io_thread",
-	stop_slave_sql_thread:                         "stop slave sql_thread",
-	start_slave_sql_thread:                        "start slave sql_thread",
-	start_slave_io_thread:                         "start slave io_thread",
-	stop_slave:                                    "stop slave",
-	start_slave_until_master_log:                  "start slave until master_log_file=?, master_log_pos=?",
-	master_user_param:                             "master_user = ?",
-	master_password_param:                         "master_password = ?",
-	master_ssl_ca_param:                           "master_ssl_ca = ?",
-	master_ssl_cert_param:                         "master_ssl_cert = ?",
-	master_ssl:                                    "master_ssl",
-	master_ssl_key_param:                          "master_ssl_key = ?",
-	change_master_to_master_ssl:                   "change master to master_ssl=1",
-	reset_slave:                                   "reset slave",
-	change_master_to_master_host_port:             "change master to master_host=?, master_port=?",
-	change_master_to_master_host_port_log_gtid_no: "change master to master_host=?, master_port=?, master_log_file=?, master_log_pos=?, master_use_gtid=no",
+	stop_slave_io_thread:                                  "stop slave io_thread",
+	stop_slave_sql_thread:                                 "stop slave sql_thread",
+	start_slave_sql_thread:                                "start slave sql_thread",
+	start_slave_io_thread:                                 "start slave io_thread",
+	stop_slave:                                            "stop slave",
+	start_slave_until_master_log:                          "start slave until master_log_file=?, master_log_pos=?",
+	master_user_param:                                     "master_user = ?",
+	master_password_param:                                 "master_password = ?",
+	master_ssl_ca_param:                                   "master_ssl_ca = ?",
+	master_ssl_cert_param:                                 "master_ssl_cert = ?",
+	master_ssl:                                            "master_ssl",
+	master_ssl_key_param:                                  "master_ssl_key = ?",
+	change_master_to_master_ssl:                           "change master to master_ssl=1",
+	reset_slave:                                           "reset slave",
+	change_master_to_master_host_port:                     "change master to master_host=?, master_port=?",
+	change_master_to_master_host_port_log_gtid_no:         "change master to master_host=?, master_port=?, master_log_file=?, master_log_pos=?, master_use_gtid=no",
+	change_master_to_master_host_port_gtid_slave_pos:      "change master to master_host=?, master_port=?, master_use_gtid=slave_pos",
 	change_master_to_master_host_port_log_autoposition_no: "change master to master_host=?, master_port=?, master_log_file=?, master_log_pos=?, master_auto_position=0",
 	change_master_to_master_host_port_autoposition_yes:    "change master to master_host=?, master_port=?, master_auto_position=1",
 	change_master_to_master_host_port_log:                 "change master to master_host=?, master_port=?, master_log_file=?, master_log_pos=?",
@@ -313,22 +319,23 @@ var queryStrings84 = map[QueryStringKey]string{
 	master_ssl_allowed:        "Source_SSL_Allowed",
 	show_slave_hosts:          "show replicas",
 
-	stop_slave_io_thread:                          "stop replica io_thread",
-	stop_slave_sql_thread:                         "stop replica sql_thread",
-	start_slave_sql_thread:                        "start replica sql_thread",
-	start_slave_io_thread:                         "start replica io_thread",
-	stop_slave:                                    "stop replica",
-	start_slave_until_master_log:                  "start replica until source_log_file=?, source_log_pos=?",
-	master_user_param:                             "source_user = ?",
-	master_password_param:                         "source_password = ?",
-	master_ssl_ca_param:                           "source_ssl_ca = ?",
-	master_ssl_cert_param:                         "source_ssl_cert = ?",
-	master_ssl:                                    "source_ssl",
-	master_ssl_key_param:                          "source_ssl_key = ?",
-	change_master_to_master_ssl:                   "change replication source to source_ssl=1",
-	reset_slave:                                   "reset replica",
-	change_master_to_master_host_port:             "change replication source to source_host=?, source_port=?",
-	change_master_to_master_host_port_log_gtid_no: "change replication source to source_host=?, source_port=?, source_log_file=?, source_log_pos=?, source_use_gtid=no",
+	stop_slave_io_thread:                                  "stop replica io_thread",
+	stop_slave_sql_thread:                                 "stop replica sql_thread",
+	start_slave_sql_thread:                                "start replica sql_thread",
+	start_slave_io_thread:                                 "start replica io_thread",
+	stop_slave:                                            "stop replica",
+	start_slave_until_master_log:                          "start replica until source_log_file=?, source_log_pos=?",
+	master_user_param:                                     "source_user = ?",
+	master_password_param:                                 "source_password = ?",
+	master_ssl_ca_param:                                   "source_ssl_ca = ?",
+	master_ssl_cert_param:                                 "source_ssl_cert = ?",
+	master_ssl:                                            "source_ssl",
+	master_ssl_key_param:                                  "source_ssl_key = ?",
+	change_master_to_master_ssl:                           "change replication source to source_ssl=1",
+	reset_slave:                                           "reset replica",
+	change_master_to_master_host_port:                     "change replication source to source_host=?, source_port=?",
+	change_master_to_master_host_port_log_gtid_no:         "change replication source to source_host=?, source_port=?, source_log_file=?, source_log_pos=?, source_use_gtid=no",
+	change_master_to_master_host_port_gtid_slave_pos:      "change replication source to source_host=?, source_port=?, source_use_gtid=slave_pos",
 	change_master_to_master_host_port_log_autoposition_no: "change replication source to source_host=?, source_port=?, source_log_file=?, source_log_pos=?, source_auto_position=0",
 	change_master_to_master_host_port_autoposition_yes:    "change replication source to source_host=?, source_port=?, source_auto_position=1",
 	change_master_to_master_host_port_log:                 "change replication source to source_host=?, source_port=?, source_log_file=?, source_log_pos=?",
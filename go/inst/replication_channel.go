@@ -0,0 +1,107 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// DefaultReplicationChannelName is the name MySQL gives to the unnamed, default replication channel
+const DefaultReplicationChannelName = ""
+
+var replicationChannelNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.\-]{1,64}$`)
+
+// ReplicationChannel is the replication state of a single named channel on a multi-source replica,
+// as read from one row of SHOW SLAVE STATUS.
+type ReplicationChannel struct {
+	ChannelName               string
+	MasterKey                 InstanceKey
+	MasterUUID                string
+	ReplicationIOThreadState  ReplicationThreadState
+	ReplicationSQLThreadState ReplicationThreadState
+	ReadBinlogCoordinates     BinlogCoordinates
+	ExecBinlogCoordinates     BinlogCoordinates
+	LastIOError               string
+	LastSQLError              string
+	SecondsBehindMaster       sql.NullInt64
+	UsingOracleGTID           bool
+	UsingMariaDBGTID          bool
+	// IsTopologyChannel is set on the channel the instance's master & replication fields depict,
+	// which is the first named channel when there is no default channel
+	IsTopologyChannel bool
+}
+
+// ReplicationRunning returns true when both IO and SQL threads of this channel are running
+func (this *ReplicationChannel) ReplicationRunning() bool {
+	return this.ReplicationIOThreadState.IsRunning() && this.ReplicationSQLThreadState.IsRunning()
+}
+
+// ReplicationStopped returns true when both IO and SQL threads of this channel are stopped
+func (this *ReplicationChannel) ReplicationStopped() bool {
+	return this.ReplicationIOThreadState.IsStopped() && this.ReplicationSQLThreadState.IsStopped()
+}
+
+// ReplicationChannels is the list of named replication channels on a multi-source replica
+type ReplicationChannels [](*ReplicationChannel)
+
+// GetChannel returns the channel by given name, or nil when no such channel exists
+func (this ReplicationChannels) GetChannel(channelName string) *ReplicationChannel {
+	for _, channel := range this {
+		if channel.ChannelName == channelName {
+			return channel
+		}
+	}
+	return nil
+}
+
+// ToJSONString will marshal this list as JSON
+func (this ReplicationChannels) ToJSONString() string {
+	if this == nil {
+		return "[]"
+	}
+	bytes, _ := json.Marshal(this)
+	return string(bytes)
+}
+
+// ReadJson unmarshalls a json into this list
+func (this *ReplicationChannels) ReadJson(jsonString string) error {
+	if jsonString == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(jsonString), this)
+}
+
+// ValidateReplicationChannelName verifies given name is a valid name for a named replication channel.
+// Channel names are interpolated into replication statements, hence the strict validation.
+func ValidateReplicationChannelName(channelName string) error {
+	if !replicationChannelNameRegexp.MatchString(channelName) {
+		return fmt.Errorf("Invalid replication channel name: %q", channelName)
+	}
+	return nil
+}
+
+// forChannel appends a FOR CHANNEL clause to given replication statement. The default channel
+// has no such clause.
+func forChannel(query string, channelName string) string {
+	if channelName == DefaultReplicationChannelName {
+		return query
+	}
+	return fmt.Sprintf("%s for channel '%s'", query, channelName)
+}
@@ -0,0 +1,50 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestValidateReplicationChannelName(t *testing.T) {
+	test.S(t).ExpectNil(ValidateReplicationChannelName("ch1"))
+	test.S(t).ExpectNil(ValidateReplicationChannelName("dc-east.orders_2"))
+	test.S(t).ExpectNotNil(ValidateReplicationChannelName(""))
+	test.S(t).ExpectNotNil(ValidateReplicationChannelName("ch1'; drop table t; --"))
+}
+
+func TestForChannel(t *testing.T) {
+	test.S(t).ExpectEquals(forChannel("stop slave", DefaultReplicationChannelName), "stop slave")
+	test.S(t).ExpectEquals(forChannel("stop slave", "ch1"), "stop slave for channel 'ch1'")
+	test.S(t).ExpectEquals(forChannel("change master to master_host=?, master_port=?", "ch1"), "change master to master_host=?, master_port=? for channel 'ch1'")
+}
+
+func TestReplicationChannelsJson(t *testing.T) {
+	{
+		channels := ReplicationChannels{}
+		test.S(t).ExpectEquals(channels.ToJSONString(), "[]")
+	}
+	{
+		channels := ReplicationChannels{
+			&ReplicationChannel{ChannelName: "ch1", MasterKey: InstanceKey{Hostname: "m1", Port: 3306}, ReplicationIOThreadState: ReplicationThreadStateRunning, ReplicationSQLThreadState: ReplicationThreadStateRunning, IsTopologyChannel: true},
+			&ReplicationChannel{ChannelName: "ch2", MasterKey: InstanceKey{Hostname: "m2", Port: 3306}, LastIOError: "error connecting to master"},
+		}
+		readChannels := ReplicationChannels{}
+		err := readChannels.ReadJson(channels.ToJSONString())
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(readChannels), 2)
+		test.S(t).ExpectTrue(readChannels.GetChannel("ch1").ReplicationRunning())
+		test.S(t).ExpectTrue(readChannels.GetChannel("ch2").ReplicationStopped())
+		test.S(t).ExpectTrue(readChannels.GetChannel("ch1").IsTopologyChannel)
+		test.S(t).ExpectFalse(readChannels.GetChannel("ch2").IsTopologyChannel)
+		test.S(t).ExpectEquals(readChannels.GetChannel("ch2").MasterKey.Hostname, "m2")
+		test.S(t).ExpectEquals(readChannels.GetChannel("ch2").LastIOError, "error connecting to master")
+		test.S(t).ExpectTrue(readChannels.GetChannel("ch3") == nil)
+	}
+	{
+		readChannels := ReplicationChannels{}
+		err := readChannels.ReadJson("")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(readChannels), 0)
+	}
+}
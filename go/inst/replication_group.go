@@ -0,0 +1,192 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openark/orchestrator/go/config"
+)
+
+// ReplicationGroupPartition is a set of group members which, as they see it, can communicate with each other.
+// Only members orchestrator itself can reach are listed.
+type ReplicationGroupPartition struct {
+	Members   [](*Instance)
+	HasQuorum bool
+}
+
+// GetInstanceKeys returns the keys of the partition members
+func (this *ReplicationGroupPartition) GetInstanceKeys() []InstanceKey {
+	keys := []InstanceKey{}
+	for _, member := range this.Members {
+		keys = append(keys, member.Key)
+	}
+	return keys
+}
+
+// HasPrimary returns true when members of this partition see an ONLINE, reachable primary
+func (this *ReplicationGroupPartition) HasPrimary() bool {
+	for _, member := range this.Members {
+		if member.ReplicationGroupMemberRole == GroupReplicationMemberRolePrimary && member.ReplicationGroupMemberState == GroupReplicationMemberStateOnline {
+			return true
+		}
+		primaryKey := member.ReplicationGroupPrimaryInstanceKey
+		if primaryKey.IsValid() && member.ReplicationGroupMembers.HasKey(primaryKey) && !member.ReplicationGroupUnreachableMembers.HasKey(primaryKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// LocalAddresses returns the group communication addresses of the partition members, as used by
+// group_replication_force_members
+func (this *ReplicationGroupPartition) LocalAddresses() (addresses []string, err error) {
+	for _, member := range this.Members {
+		if member.ReplicationGroupLocalAddress == "" {
+			return addresses, fmt.Errorf("Unknown group_replication_local_address on %+v", member.Key)
+		}
+		addresses = append(addresses, member.ReplicationGroupLocalAddress)
+	}
+	return addresses, nil
+}
+
+// GetReplicationGroupPartitions splits members of a replication group into partitions, based on what each
+// member sees as reachable. Members which are not ONLINE nor RECOVERING take no part in any partition.
+func GetReplicationGroupPartitions(members [](*Instance)) (partitions [](*ReplicationGroupPartition)) {
+	partitionsMap := map[string]*ReplicationGroupPartition{}
+	for _, member := range members {
+		switch member.ReplicationGroupMemberState {
+		case GroupReplicationMemberStateOnline, GroupReplicationMemberStateRecovering:
+		default:
+			continue
+		}
+		tokens := []string{}
+		for _, key := range member.ReplicationGroupReachableMembers().GetInstanceKeys() {
+			tokens = append(tokens, key.StringCode())
+		}
+		sort.Strings(tokens)
+		partitionKey := strings.Join(tokens, ",")
+		partition, found := partitionsMap[partitionKey]
+		if !found {
+			partition = &ReplicationGroupPartition{HasQuorum: member.ReplicationGroupHasQuorum()}
+			partitionsMap[partitionKey] = partition
+			partitions = append(partitions, partition)
+		}
+		partition.Members = append(partition.Members, member)
+	}
+	return partitions
+}
+
+// GetLargestReplicationGroupPartition returns the partition with most members. It is an error for
+// two or more partitions to share the largest size, as there is then no telling which should prevail.
+func GetLargestReplicationGroupPartition(partitions [](*ReplicationGroupPartition)) (*ReplicationGroupPartition, error) {
+	var largest *ReplicationGroupPartition
+	ambiguous := false
+	for _, partition := range partitions {
+		if largest == nil || len(partition.Members) > len(largest.Members) {
+			largest = partition
+			ambiguous = false
+		} else if len(partition.Members) == len(largest.Members) {
+			ambiguous = true
+		}
+	}
+	if largest == nil {
+		return nil, fmt.Errorf("No replication group partitions found")
+	}
+	if ambiguous {
+		return nil, fmt.Errorf("Found more than one largest replication group partition, of %d members", len(largest.Members))
+	}
+	return largest, nil
+}
+
+// GetReplicationGroupKnownMemberKeys returns the last known membership of a replication group: given the
+// members as recorded by orchestrator, and the members it was able to probe anew, these are all recorded
+// members, along with all members any probed member lists.
+func GetReplicationGroupKnownMemberKeys(recordedMembers [](*Instance), probedMembers [](*Instance)) *InstanceKeyMap {
+	knownMemberKeys := NewInstanceKeyMap()
+	for _, member := range recordedMembers {
+		knownMemberKeys.AddKey(member.Key)
+	}
+	for _, member := range probedMembers {
+		knownMemberKeys.AddKey(member.Key)
+		for memberKey := range member.ReplicationGroupMembers {
+			knownMemberKeys.AddKey(memberKey)
+		}
+	}
+	return knownMemberKeys
+}
+
+// IsReplicationGroupMemberConfirmedDead checks whether a group member orchestrator cannot reach is known
+// to be dead, as opposed to orchestrator being partitioned away from it. The members orchestrator did
+// reach must all see it as unreachable, and the member's own async replicas, as probed anew, serve as a
+// second opinion: at least one of them must have been reached, and none may be replicating from it.
+// With ReplicationGroupDeadMemberConfirmation set to "members", the view of the reached members suffices
+// in lieu of replicas: a majority of them must list the member as UNREACHABLE.
+func IsReplicationGroupMemberConfirmedDead(memberKey *InstanceKey, probedMembers [](*Instance), probedMemberReplicas [](*Instance)) (confirmedDead bool, reason string) {
+	countUnreachableViews := 0
+	for _, member := range probedMembers {
+		if member.ReplicationGroupReachableMembers().HasKey(*memberKey) {
+			return false, fmt.Sprintf("%+v is reachable by group member %+v", *memberKey, member.Key)
+		}
+		if member.ReplicationGroupUnreachableMembers.HasKey(*memberKey) {
+			countUnreachableViews++
+		}
+	}
+	countOpinions := 0
+	for _, replica := range probedMemberReplicas {
+		if !replica.IsLastCheckValid || !replica.MasterKey.Equals(memberKey) {
+			continue
+		}
+		if replica.ReplicationIOThreadState.IsRunning() {
+			return false, fmt.Sprintf("%+v is replicating from %+v", replica.Key, *memberKey)
+		}
+		countOpinions++
+	}
+	if countOpinions > 0 {
+		return true, ""
+	}
+	if config.Config.ReplicationGroupDeadMemberConfirmation == config.ReplicationGroupDeadMemberConfirmationMembers {
+		if countUnreachableViews*2 > len(probedMembers) {
+			return true, ""
+		}
+		return false, fmt.Sprintf("no replica of %+v was reached, and only %d of %d reached members see it as UNREACHABLE", *memberKey, countUnreachableViews, len(probedMembers))
+	}
+	return false, fmt.Sprintf("no replica of %+v was reached to confirm it is dead", *memberKey)
+}
+
+// ValidateReplicationGroupForceMembers checks whether it is safe to force the membership of a replication
+// group which lost quorum. Each member of the last known membership must be accounted for: either orchestrator
+// reached it anew, or it is confirmed dead (see IsReplicationGroupMemberConfirmedDead). Otherwise, orchestrator
+// may well be partitioned away from a majority which still has quorum, and forcing members on the minority
+// would split the group's brain.
+func ValidateReplicationGroupForceMembers(knownMemberKeys *InstanceKeyMap, probedMembers [](*Instance), probedReplicasByMember map[InstanceKey]([](*Instance))) error {
+	probedMemberKeys := NewInstanceKeyMap()
+	for _, member := range probedMembers {
+		probedMemberKeys.AddKey(member.Key)
+	}
+	for _, memberKey := range knownMemberKeys.GetInstanceKeys() {
+		if probedMemberKeys.HasKey(memberKey) {
+			continue
+		}
+		if confirmedDead, reason := IsReplicationGroupMemberConfirmedDead(&memberKey, probedMembers, probedReplicasByMember[memberKey]); !confirmedDead {
+			return fmt.Errorf("Cannot confirm unreachable group member %+v is dead: %s. orchestrator may be partitioned away from the group's majority; will not force members", memberKey, reason)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+)
+
+func newTestGroupMember(port int, state string, role string, members []int, unreachable []int) *Instance {
+	instance := NewInstance()
+	instance.Key = InstanceKey{Hostname: "gr", Port: port}
+	instance.ReplicationGroupName = "group"
+	instance.ReplicationGroupIsSinglePrimary = true
+	instance.ReplicationGroupMemberState = state
+	instance.ReplicationGroupMemberRole = role
+	instance.ReplicationGroupLocalAddress = instance.Key.StringCode()
+	for _, memberPort := range members {
+		instance.AddGroupMemberKey(&InstanceKey{Hostname: "gr", Port: memberPort})
+	}
+	for _, memberPort := range unreachable {
+		instance.ReplicationGroupUnreachableMembers.AddKey(InstanceKey{Hostname: "gr", Port: memberPort})
+	}
+	return instance
+}
+
+func TestReplicationGroupHasQuorum(t *testing.T) {
+	test.S(t).ExpectTrue(newTestGroupMember(1, "ONLINE", "PRIMARY", []int{2, 3}, nil).ReplicationGroupHasQuorum())
+	test.S(t).ExpectTrue(newTestGroupMember(1, "ONLINE", "PRIMARY", []int{2, 3}, []int{3}).ReplicationGroupHasQuorum())
+	test.S(t).ExpectFalse(newTestGroupMember(1, "ONLINE", "PRIMARY", []int{2, 3}, []int{2, 3}).ReplicationGroupHasQuorum())
+	test.S(t).ExpectFalse(newTestGroupMember(1, "ONLINE", "PRIMARY", []int{2, 3, 4}, []int{3, 4}).ReplicationGroupHasQuorum())
+}
+
+func TestGetReplicationGroupPartitions(t *testing.T) {
+	{
+		// Healthy group
+		members := [](*Instance){
+			newTestGroupMember(1, "ONLINE", "PRIMARY", []int{2, 3}, nil),
+			newTestGroupMember(2, "ONLINE", "SECONDARY", []int{1, 3}, nil),
+			newTestGroupMember(3, "ONLINE", "SECONDARY", []int{1, 2}, nil),
+		}
+		partitions := GetReplicationGroupPartitions(members)
+		test.S(t).ExpectEquals(len(partitions), 1)
+		test.S(t).ExpectTrue(partitions[0].HasQuorum)
+		test.S(t).ExpectTrue(partitions[0].HasPrimary())
+		test.S(t).ExpectEquals(len(partitions[0].Members), 3)
+	}
+	{
+		// 5 member group split 2/2, fifth member gone: no quorum anywhere, no single largest partition
+		members := [](*Instance){
+			newTestGroupMember(1, "ONLINE", "PRIMARY", []int{2, 3, 4, 5}, []int{3, 4, 5}),
+			newTestGroupMember(2, "ONLINE", "SECONDARY", []int{1, 3, 4, 5}, []int{3, 4, 5}),
+			newTestGroupMember(3, "ONLINE", "SECONDARY", []int{1, 2, 4, 5}, []int{1, 2, 5}),
+			newTestGroupMember(4, "ONLINE", "SECONDARY", []int{1, 2, 3, 5}, []int{1, 2, 5}),
+		}
+		partitions := GetReplicationGroupPartitions(members)
+		test.S(t).ExpectEquals(len(partitions), 2)
+		test.S(t).ExpectFalse(partitions[0].HasQuorum)
+		test.S(t).ExpectFalse(partitions[1].HasQuorum)
+		_, err := GetLargestReplicationGroupPartition(partitions)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		// 5 member group split 2/1, two members gone
+		members := [](*Instance){
+			newTestGroupMember(1, "ONLINE", "PRIMARY", []int{2, 3, 4, 5}, []int{3, 4, 5}),
+			newTestGroupMember(2, "ONLINE", "SECONDARY", []int{1, 3, 4, 5}, []int{3, 4, 5}),
+			newTestGroupMember(3, "ONLINE", "SECONDARY", []int{1, 2, 4, 5}, []int{1, 2, 4, 5}),
+			newTestGroupMember(4, "ERROR", "", nil, nil),
+		}
+		partitions := GetReplicationGroupPartitions(members)
+		test.S(t).ExpectEquals(len(partitions), 2)
+		largest, err := GetLargestReplicationGroupPartition(partitions)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(largest.Members), 2)
+		test.S(t).ExpectFalse(largest.HasQuorum)
+		addresses, err := largest.LocalAddresses()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(addresses), 2)
+		test.S(t).ExpectEquals(addresses[0], "gr:1")
+	}
+	{
+		// Primary is unreachable to the majority
+		members := [](*Instance){
+			newTestGroupMember(2, "ONLINE", "SECONDARY", []int{1, 3}, []int{1}),
+			newTestGroupMember(3, "ONLINE", "SECONDARY", []int{1, 2}, []int{1}),
+		}
+		members[0].ReplicationGroupPrimaryInstanceKey = InstanceKey{Hostname: "gr", Port: 1}
+		members[1].ReplicationGroupPrimaryInstanceKey = InstanceKey{Hostname: "gr", Port: 1}
+		partitions := GetReplicationGroupPartitions(members)
+		test.S(t).ExpectEquals(len(partitions), 1)
+		test.S(t).ExpectTrue(partitions[0].HasQuorum)
+		test.S(t).ExpectFalse(partitions[0].HasPrimary())
+	}
+}
+
+func newTestGroupMemberReplica(port int, masterPort int, ioThreadState ReplicationThreadState) *Instance {
+	replica := NewInstance()
+	replica.Key = InstanceKey{Hostname: "replica", Port: port}
+	replica.MasterKey = InstanceKey{Hostname: "gr", Port: masterPort}
+	replica.IsLastCheckValid = true
+	replica.ReplicationIOThreadState = ioThreadState
+	return replica
+}
+
+func TestValidateReplicationGroupForceMembers(t *testing.T) {
+	recordedMembers := [](*Instance){
+		newTestGroupMember(1, "ONLINE", "PRIMARY", []int{2, 3}, nil),
+		newTestGroupMember(2, "ONLINE", "SECONDARY", []int{1, 3}, nil),
+		newTestGroupMember(3, "ONLINE", "SECONDARY", []int{1, 2}, nil),
+	}
+	{
+		// Members 2, 3 crashed: member 1 lost quorum, and a replica of each confirms they are dead
+		probedMembers := [](*Instance){
+			newTestGroupMember(1, "ONLINE", "PRIMARY", []int{2, 3}, []int{2, 3}),
+		}
+		knownMemberKeys := GetReplicationGroupKnownMemberKeys(recordedMembers, probedMembers)
+		test.S(t).ExpectEquals(len(*knownMemberKeys), 3)
+		probedReplicas := map[InstanceKey]([](*Instance)){
+			InstanceKey{Hostname: "gr", Port: 2}: {newTestGroupMemberReplica(1, 2, ReplicationThreadStateStopped)},
+			InstanceKey{Hostname: "gr", Port: 3}: {newTestGroupMemberReplica(2, 3, ReplicationThreadStateOther)},
+		}
+		test.S(t).ExpectNil(ValidateReplicationGroupForceMembers(knownMemberKeys, probedMembers, probedReplicas))
+	}
+	{
+		// orchestrator is partitioned along with member 1 away from members 2, 3, which still have quorum. As
+		// far as member 1 and orchestrator can tell, this is same as members 2, 3 having crashed.
+		probedMembers := [](*Instance){
+			newTestGroupMember(1, "ONLINE", "PRIMARY", []int{2, 3}, []int{2, 3}),
+		}
+		knownMemberKeys := GetReplicationGroupKnownMemberKeys(recordedMembers, probedMembers)
+		// No replica of members 2, 3 could be reached
+		err := ValidateReplicationGroupForceMembers(knownMemberKeys, probedMembers, map[InstanceKey]([](*Instance)){})
+		test.S(t).ExpectNotNil(err)
+		// Replicas of member 3 are reached, but those of member 2 are not
+		probedReplicas := map[InstanceKey]([](*Instance)){
+			InstanceKey{Hostname: "gr", Port: 3}: {newTestGroupMemberReplica(2, 3, ReplicationThreadStateStopped)},
+		}
+		err = ValidateReplicationGroupForceMembers(knownMemberKeys, probedMembers, probedReplicas)
+		test.S(t).ExpectNotNil(err)
+		// A replica of member 2 is reached, and is replicating from it: member 2 is alive
+		probedReplicas[InstanceKey{Hostname: "gr", Port: 2}] = [](*Instance){
+			newTestGroupMemberReplica(1, 2, ReplicationThreadStateStopped),
+			newTestGroupMemberReplica(3, 2, ReplicationThreadStateRunning),
+		}
+		err = ValidateReplicationGroupForceMembers(knownMemberKeys, probedMembers, probedReplicas)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		// A member orchestrator cannot reach is seen as reachable by some other member
+		probedMembers := [](*Instance){
+			newTestGroupMember(1, "ONLINE", "PRIMARY", []int{2, 3}, []int{2, 3}),
+			newTestGroupMember(2, "ONLINE", "SECONDARY", []int{1, 3}, []int{1}),
+		}
+		knownMemberKeys := GetReplicationGroupKnownMemberKeys(recordedMembers, probedMembers)
+		probedReplicas := map[InstanceKey]([](*Instance)){
+			InstanceKey{Hostname: "gr", Port: 3}: {newTestGroupMemberReplica(2, 3, ReplicationThreadStateStopped)},
+		}
+		err := ValidateReplicationGroupForceMembers(knownMemberKeys, probedMembers, probedReplicas)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		// A member which orchestrator never recorded, but which probed members list, must be accounted for, too
+		probedMembers := [](*Instance){
+			newTestGroupMember(1, "ONLINE", "PRIMARY", []int{2, 3, 4}, []int{2, 3, 4}),
+		}
+		knownMemberKeys := GetReplicationGroupKnownMemberKeys(recordedMembers, probedMembers)
+		test.S(t).ExpectEquals(len(*knownMemberKeys), 4)
+		probedReplicas := map[InstanceKey]([](*Instance)){
+			InstanceKey{Hostname: "gr", Port: 2}: {newTestGroupMemberReplica(1, 2, ReplicationThreadStateStopped)},
+			InstanceKey{Hostname: "gr", Port: 3}: {newTestGroupMemberReplica(2, 3, ReplicationThreadStateStopped)},
+		}
+		err := ValidateReplicationGroupForceMembers(knownMemberKeys, probedMembers, probedReplicas)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		// All members reachable
+		knownMemberKeys := GetReplicationGroupKnownMemberKeys(recordedMembers, recordedMembers)
+		test.S(t).ExpectNil(ValidateReplicationGroupForceMembers(knownMemberKeys, recordedMembers, nil))
+	}
+}
+
+func TestValidateReplicationGroupForceMembersWithoutReplicas(t *testing.T) {
+	originalConfirmation := config.Config.ReplicationGroupDeadMemberConfirmation
+	defer func() { config.Config.ReplicationGroupDeadMemberConfirmation = originalConfirmation }()
+
+	// Members 3, 4, 5 crashed; the group has no async replicas
+	recordedMembers := [](*Instance){
+		newTestGroupMember(1, "ONLINE", "PRIMARY", []int{2, 3, 4, 5}, nil),
+		newTestGroupMember(2, "ONLINE", "SECONDARY", []int{1, 3, 4, 5}, nil),
+		newTestGroupMember(3, "ONLINE", "SECONDARY", []int{1, 2, 4, 5}, nil),
+		newTestGroupMember(4, "ONLINE", "SECONDARY", []int{1, 2, 3, 5}, nil),
+		newTestGroupMember(5, "ONLINE", "SECONDARY", []int{1, 2, 3, 4}, nil),
+	}
+	probedMembers := [](*Instance){
+		newTestGroupMember(1, "ONLINE", "PRIMARY", []int{2, 3, 4, 5}, []int{3, 4, 5}),
+		newTestGroupMember(2, "ONLINE", "SECONDARY", []int{1, 3, 4, 5}, []int{3, 4, 5}),
+	}
+	knownMemberKeys := GetReplicationGroupKnownMemberKeys(recordedMembers, probedMembers)
+	{
+		// By default, dead members must be confirmed by their replicas
+		config.Config.ReplicationGroupDeadMemberConfirmation = config.ReplicationGroupDeadMemberConfirmationReplicas
+		err := ValidateReplicationGroupForceMembers(knownMemberKeys, probedMembers, nil)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		// The reached members' view suffices when opted in
+		config.Config.ReplicationGroupDeadMemberConfirmation = config.ReplicationGroupDeadMemberConfirmationMembers
+		test.S(t).ExpectNil(ValidateReplicationGroupForceMembers(knownMemberKeys, probedMembers, nil))
+	}
+	{
+		// Only a minority of reached members sees member 5 as UNREACHABLE; the other does not list it at all
+		config.Config.ReplicationGroupDeadMemberConfirmation = config.ReplicationGroupDeadMemberConfirmationMembers
+		probedMembers := [](*Instance){
+			newTestGroupMember(1, "ONLINE", "PRIMARY", []int{2, 3, 4, 5}, []int{3, 4, 5}),
+			newTestGroupMember(2, "ONLINE", "SECONDARY", []int{1, 3, 4}, []int{3, 4}),
+			newTestGroupMember(6, "ONLINE", "SECONDARY", []int{1, 3, 4}, []int{3, 4}),
+		}
+		err := ValidateReplicationGroupForceMembers(knownMemberKeys, probedMembers, nil)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		// Still, a member some reached member sees as reachable is not dead
+		config.Config.ReplicationGroupDeadMemberConfirmation = config.ReplicationGroupDeadMemberConfirmationMembers
+		probedMembers := [](*Instance){
+			newTestGroupMember(1, "ONLINE", "PRIMARY", []int{2, 3, 4, 5}, []int{3, 4, 5}),
+			newTestGroupMember(2, "ONLINE", "SECONDARY", []int{1, 3, 4, 5}, []int{3, 4, 5}),
+			newTestGroupMember(6, "ONLINE", "SECONDARY", []int{1, 3, 4, 5}, []int{3, 4}),
+		}
+		err := ValidateReplicationGroupForceMembers(knownMemberKeys, probedMembers, nil)
+		test.S(t).ExpectNotNil(err)
+	}
+}
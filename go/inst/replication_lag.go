@@ -0,0 +1,188 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/openark/golib/sqlutils"
+	"github.com/patrickmn/go-cache"
+)
+
+// executed GTID counts of replicas, as sampled on previous discovery, for measuring apply rate
+var replicationApplySamples = cache.New(time.Hour, time.Minute)
+
+type replicationApplySample struct {
+	sampledAt            time.Time
+	executedGtidSetCount int64
+}
+
+// ReplicationWorkerStatus is the state of a single applier worker, as read from
+// performance_schema.replication_applier_status_by_worker. Timestamps are unix epoch seconds, and are zero when unknown.
+type ReplicationWorkerStatus struct {
+	ApplyingTransaction                  string
+	ApplyingTransactionOriginalCommit    float64
+	LastAppliedTransactionOriginalCommit float64
+}
+
+// ReplicationReceiverStatus is the state of a replication receiver (IO thread), as read from
+// performance_schema.replication_connection_status. Timestamps are unix epoch seconds, and are zero when unknown.
+type ReplicationReceiverStatus struct {
+	QueueingTransaction                 string
+	QueueingTransactionOriginalCommit   float64
+	LastQueuedTransactionOriginalCommit float64
+}
+
+// ComputeReplicationLag computes, based on the MySQL 8 original commit timestamps:
+// - trueLag: how long ago the oldest transaction not yet applied by any worker was committed on the original master
+// - queueLag: the time span of transactions received into the relay log yet not applied
+// When all received transactions are applied, trueLag is that of the receiver, which may still be queueing a transaction.
+func ComputeReplicationLag(now float64, receivers []ReplicationReceiverStatus, workers []ReplicationWorkerStatus) (trueLag time.Duration, queueLag time.Duration) {
+	var oldestApplying, lastApplied, lastQueued, oldestQueueing float64
+	for _, worker := range workers {
+		if worker.ApplyingTransaction != "" && worker.ApplyingTransactionOriginalCommit > 0 {
+			if oldestApplying == 0 || worker.ApplyingTransactionOriginalCommit < oldestApplying {
+				oldestApplying = worker.ApplyingTransactionOriginalCommit
+			}
+		}
+		if worker.LastAppliedTransactionOriginalCommit > lastApplied {
+			lastApplied = worker.LastAppliedTransactionOriginalCommit
+		}
+	}
+	for _, receiver := range receivers {
+		if receiver.LastQueuedTransactionOriginalCommit > lastQueued {
+			lastQueued = receiver.LastQueuedTransactionOriginalCommit
+		}
+		if receiver.QueueingTransaction != "" && receiver.QueueingTransactionOriginalCommit > 0 {
+			if oldestQueueing == 0 || receiver.QueueingTransactionOriginalCommit < oldestQueueing {
+				oldestQueueing = receiver.QueueingTransactionOriginalCommit
+			}
+		}
+	}
+	oldestUnapplied := oldestApplying
+	if oldestUnapplied == 0 && lastQueued > lastApplied {
+		// Queued transactions are waiting for the coordinator to pick them up. We do not know when the oldest
+		// of which was committed, but it was no earlier than the last applied one.
+		oldestUnapplied = lastApplied
+	}
+	seconds := func(span float64) time.Duration {
+		if span < 0 {
+			return 0
+		}
+		return time.Duration(span * float64(time.Second))
+	}
+	if oldestUnapplied > 0 {
+		trueLag = seconds(now - oldestUnapplied)
+		if lastQueued > oldestUnapplied {
+			queueLag = seconds(lastQueued - oldestUnapplied)
+		}
+	} else if oldestQueueing > 0 {
+		trueLag = seconds(now - oldestQueueing)
+	}
+	return trueLag, queueLag
+}
+
+// ComputeReplicationApplyRate returns the number of transactions applied per second between two samples of
+// the executed GTID set size
+func ComputeReplicationApplyRate(previousCount int64, previousSampledAt time.Time, count int64, sampledAt time.Time) float64 {
+	elapsed := sampledAt.Sub(previousSampledAt).Seconds()
+	if elapsed <= 0 || count < previousCount {
+		return 0
+	}
+	return float64(count-previousCount) / elapsed
+}
+
+// unixTimestamp parses the output of UNIX_TIMESTAMP(), which is 0 for the zero timestamp, and NULL for NULL
+func unixTimestamp(value string) float64 {
+	timestamp, _ := strconv.ParseFloat(value, 64)
+	return timestamp
+}
+
+// readReplicationLagFromPerformanceSchema reads per-worker applier status and receiver status from performance_schema,
+// and populates the instance's true lag and queue lag. It requires MySQL 8.0 original commit timestamps.
+func readReplicationLagFromPerformanceSchema(instance *Instance, db *sql.DB) error {
+	var now float64
+	if err := db.QueryRow("select unix_timestamp(now(6))").Scan(&now); err != nil {
+		return err
+	}
+	receivers := []ReplicationReceiverStatus{}
+	query := `
+		select
+			queueing_transaction,
+			unix_timestamp(queueing_transaction_original_commit_timestamp) as queueing_transaction_original_commit,
+			unix_timestamp(last_queued_transaction_original_commit_timestamp) as last_queued_transaction_original_commit
+		from
+			performance_schema.replication_connection_status
+	`
+	err := sqlutils.QueryRowsMap(db, query, func(m sqlutils.RowMap) error {
+		receivers = append(receivers, ReplicationReceiverStatus{
+			QueueingTransaction:                 m.GetString("queueing_transaction"),
+			QueueingTransactionOriginalCommit:   unixTimestamp(m.GetString("queueing_transaction_original_commit")),
+			LastQueuedTransactionOriginalCommit: unixTimestamp(m.GetString("last_queued_transaction_original_commit")),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	workers := []ReplicationWorkerStatus{}
+	query = `
+		select
+			applying_transaction,
+			unix_timestamp(applying_transaction_original_commit_timestamp) as applying_transaction_original_commit,
+			unix_timestamp(last_applied_transaction_original_commit_timestamp) as last_applied_transaction_original_commit
+		from
+			performance_schema.replication_applier_status_by_worker
+	`
+	err = sqlutils.QueryRowsMap(db, query, func(m sqlutils.RowMap) error {
+		workers = append(workers, ReplicationWorkerStatus{
+			ApplyingTransaction:                  m.GetString("applying_transaction"),
+			ApplyingTransactionOriginalCommit:    unixTimestamp(m.GetString("applying_transaction_original_commit")),
+			LastAppliedTransactionOriginalCommit: unixTimestamp(m.GetString("last_applied_transaction_original_commit")),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(workers) == 0 {
+		// Not a replica
+		return nil
+	}
+	trueLag, queueLag := ComputeReplicationLag(now, receivers, workers)
+	instance.ReplicationTrueLagSeconds = sql.NullInt64{Int64: int64(trueLag.Seconds()), Valid: true}
+	instance.ReplicationQueueLagSeconds = sql.NullInt64{Int64: int64(queueLag.Seconds()), Valid: true}
+	return nil
+}
+
+// updateReplicationApplyRate samples the size of the instance's executed GTID set, and computes the apply rate
+// since the previous sample
+func updateReplicationApplyRate(instance *Instance) error {
+	executedGtidSet, err := NewOracleGtidSet(instance.ExecutedGtidSet)
+	if err != nil {
+		return err
+	}
+	sample := replicationApplySample{sampledAt: time.Now(), executedGtidSetCount: executedGtidSet.Count()}
+	if previous, found := replicationApplySamples.Get(instance.Key.StringCode()); found {
+		previousSample := previous.(replicationApplySample)
+		instance.ReplicationApplyRate = ComputeReplicationApplyRate(previousSample.executedGtidSetCount, previousSample.sampledAt, sample.executedGtidSetCount, sample.sampledAt)
+	}
+	replicationApplySamples.Set(instance.Key.StringCode(), sample, cache.DefaultExpiration)
+	return nil
+}
@@ -0,0 +1,67 @@
+package inst
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestComputeReplicationLag(t *testing.T) {
+	now := float64(1700000100)
+	{
+		// Idle, fully caught up
+		receivers := []ReplicationReceiverStatus{{LastQueuedTransactionOriginalCommit: now - 50}}
+		workers := []ReplicationWorkerStatus{
+			{LastAppliedTransactionOriginalCommit: now - 50},
+			{LastAppliedTransactionOriginalCommit: now - 60},
+		}
+		trueLag, queueLag := ComputeReplicationLag(now, receivers, workers)
+		test.S(t).ExpectEquals(trueLag, time.Duration(0))
+		test.S(t).ExpectEquals(queueLag, time.Duration(0))
+	}
+	{
+		// Slowest worker is 30 seconds behind; relay log holds 25 seconds worth of transactions
+		receivers := []ReplicationReceiverStatus{{LastQueuedTransactionOriginalCommit: now - 5}}
+		workers := []ReplicationWorkerStatus{
+			{ApplyingTransaction: "a:10", ApplyingTransactionOriginalCommit: now - 30, LastAppliedTransactionOriginalCommit: now - 31},
+			{ApplyingTransaction: "a:12", ApplyingTransactionOriginalCommit: now - 28, LastAppliedTransactionOriginalCommit: now - 29},
+			{},
+		}
+		trueLag, queueLag := ComputeReplicationLag(now, receivers, workers)
+		test.S(t).ExpectEquals(trueLag, 30*time.Second)
+		test.S(t).ExpectEquals(queueLag, 25*time.Second)
+	}
+	{
+		// Nothing applying, but queued transactions wait for the coordinator
+		receivers := []ReplicationReceiverStatus{{LastQueuedTransactionOriginalCommit: now - 5}}
+		workers := []ReplicationWorkerStatus{{LastAppliedTransactionOriginalCommit: now - 20}}
+		trueLag, queueLag := ComputeReplicationLag(now, receivers, workers)
+		test.S(t).ExpectEquals(trueLag, 20*time.Second)
+		test.S(t).ExpectEquals(queueLag, 15*time.Second)
+	}
+	{
+		// Applier caught up, receiver still pulling a large transaction
+		receivers := []ReplicationReceiverStatus{{QueueingTransaction: "a:20", QueueingTransactionOriginalCommit: now - 8, LastQueuedTransactionOriginalCommit: now - 9}}
+		workers := []ReplicationWorkerStatus{{LastAppliedTransactionOriginalCommit: now - 9}}
+		trueLag, queueLag := ComputeReplicationLag(now, receivers, workers)
+		test.S(t).ExpectEquals(trueLag, 8*time.Second)
+		test.S(t).ExpectEquals(queueLag, time.Duration(0))
+	}
+}
+
+func TestComputeReplicationApplyRate(t *testing.T) {
+	sampledAt := time.Now()
+	test.S(t).ExpectEquals(ComputeReplicationApplyRate(1000, sampledAt.Add(-5*time.Second), 1500, sampledAt), float64(100))
+	test.S(t).ExpectEquals(ComputeReplicationApplyRate(1000, sampledAt, 1500, sampledAt), float64(0))
+	test.S(t).ExpectEquals(ComputeReplicationApplyRate(1500, sampledAt.Add(-5*time.Second), 1000, sampledAt), float64(0))
+}
+
+func TestEffectiveReplicationLagSeconds(t *testing.T) {
+	instance := NewInstance()
+	instance.ReplicationLagSeconds = sql.NullInt64{Int64: 3, Valid: true}
+	test.S(t).ExpectEquals(instance.EffectiveReplicationLagSeconds().Int64, int64(3))
+	instance.ReplicationTrueLagSeconds = sql.NullInt64{Int64: 40, Valid: true}
+	test.S(t).ExpectEquals(instance.EffectiveReplicationLagSeconds().Int64, int64(40))
+}
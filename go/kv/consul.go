@@ -20,8 +20,10 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/openark/orchestrator/go/config"
 
@@ -36,6 +38,50 @@ func getConsulKVCacheKey(dc, key string) string {
 	return fmt.Sprintf("%s;%s", dc, key)
 }
 
+// evictConsulKVCache removes cached entries of keys matching given function, in all datacenters
+func evictConsulKVCache(kvCache *cache.Cache, matches func(key string) bool) {
+	for cacheKey := range kvCache.Items() {
+		if tokens := strings.SplitN(cacheKey, ";", 2); len(tokens) == 2 && matches(tokens[1]) {
+			kvCache.Delete(cacheKey)
+		}
+	}
+}
+
+// getConsulDeleteDatacenters returns the datacenters to delete keys from: all datacenters when
+// distributing KVs across datacenters, otherwise just the local one (denoted by an empty string)
+func getConsulDeleteDatacenters(getDatacenters func() ([]string, error)) ([]string, error) {
+	if !config.Config.ConsulCrossDataCenterDistribution {
+		return []string{""}, nil
+	}
+	return getDatacenters()
+}
+
+// Time a blocking query waits for a watched prefix to change, before listing it anyway
+const consulWatchWaitTime = time.Minute
+
+// consulPrefixWatcher watches a prefix with Consul blocking queries: each listing waits for the prefix's
+// index to move past that of the previous listing. list performs such a query.
+type consulPrefixWatcher struct {
+	list      func(waitIndex uint64) (kvPairs [](*KVPair), lastIndex uint64, err error)
+	waitIndex uint64
+}
+
+func (this *consulPrefixWatcher) listChanges(stop <-chan struct{}) (kvPairs [](*KVPair), err error) {
+	kvPairs, lastIndex, err := this.list(this.waitIndex)
+	if err != nil {
+		return kvPairs, err
+	}
+	if lastIndex < this.waitIndex {
+		// The index went backwards, e.g. upon a snapshot restore: the next query does not block
+		lastIndex = 0
+	}
+	this.waitIndex = lastIndex
+	return kvPairs, nil
+}
+
+func (this *consulPrefixWatcher) close() {
+}
+
 // A Consul store based on config's `ConsulAddress`, `ConsulScheme`, and `ConsulKVPrefix`
 type consulStore struct {
 	client                        *consulapi.Client
@@ -170,3 +216,72 @@ func (this *consulStore) DistributePairs(kvPairs [](*KVPair)) (err error) {
 	wg.Wait()
 	return err
 }
+
+func (this *consulStore) DeleteKey(key string) (err error) {
+	if this.client == nil {
+		return nil
+	}
+	datacenters, err := getConsulDeleteDatacenters(this.client.Catalog().Datacenters)
+	if err != nil {
+		return err
+	}
+	for _, datacenter := range datacenters {
+		if _, err := this.client.KV().Delete(key, &consulapi.WriteOptions{Datacenter: datacenter}); err != nil {
+			return err
+		}
+	}
+	evictConsulKVCache(this.kvCache, func(cachedKey string) bool { return cachedKey == key })
+	return nil
+}
+
+func (this *consulStore) DeleteKeyPrefix(prefix string) (err error) {
+	if this.client == nil {
+		return nil
+	}
+	datacenters, err := getConsulDeleteDatacenters(this.client.Catalog().Datacenters)
+	if err != nil {
+		return err
+	}
+	for _, datacenter := range datacenters {
+		if _, err := this.client.KV().DeleteTree(prefix, &consulapi.WriteOptions{Datacenter: datacenter}); err != nil {
+			return err
+		}
+	}
+	evictConsulKVCache(this.kvCache, func(cachedKey string) bool { return strings.HasPrefix(cachedKey, prefix) })
+	return nil
+}
+
+func (this *consulStore) ListKeyPrefix(prefix string) (kvPairs [](*KVPair), err error) {
+	if this.client == nil {
+		return kvPairs, nil
+	}
+	pairs, _, err := this.client.KV().List(prefix, nil)
+	if err != nil {
+		return kvPairs, err
+	}
+	for _, pair := range pairs {
+		kvPairs = append(kvPairs, NewKVPair(pair.Key, string(pair.Value)))
+	}
+	return kvPairs, nil
+}
+
+// WatchKeyPrefix subscribes to the Consul KVs beginning with given prefix, in the local datacenter. Closing
+// the subscription takes effect on the pending blocking query's return, within consulWatchWaitTime.
+func (this *consulStore) WatchKeyPrefix(prefix string) (*KVSubscription, error) {
+	if this.client == nil {
+		return nil, fmt.Errorf("consul KV store: ConsulAddress is not configured")
+	}
+	watcher := &consulPrefixWatcher{
+		list: func(waitIndex uint64) (kvPairs [](*KVPair), lastIndex uint64, err error) {
+			pairs, meta, err := this.client.KV().List(prefix, &consulapi.QueryOptions{WaitIndex: waitIndex, WaitTime: consulWatchWaitTime})
+			if err != nil {
+				return kvPairs, lastIndex, err
+			}
+			for _, pair := range pairs {
+				kvPairs = append(kvPairs, NewKVPair(pair.Key, string(pair.Value)))
+			}
+			return kvPairs, meta.LastIndex, nil
+		},
+	}
+	return watchKeyPrefixListings(prefix, watcher)
+}
@@ -8,10 +8,15 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 
 	consulapi "github.com/hashicorp/consul/api"
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/patrickmn/go-cache"
 )
 
 const consulTestDefaultDatacenter = "dc1"
@@ -80,3 +85,186 @@ func buildConsulTestServer(t *testing.T, testOps []consulTestServerOp) *httptest
 	})
 	return httptest.NewServer(handlerFunc)
 }
+
+func TestConsulStoresListKeyPrefix(t *testing.T) {
+	server := buildConsulTestServer(t, []consulTestServerOp{
+		{
+			Method: "GET",
+			URL:    "/v1/kv/mysql/master/foo/?recurse=",
+			Response: consulapi.KVPairs{
+				{Key: "mysql/master/foo/hostname", Value: []byte("db-1")},
+				{Key: "mysql/master/foo/port", Value: []byte("3306")},
+			},
+		},
+		{
+			Method:       "GET",
+			URL:          "/v1/kv/no/such/prefix/?recurse=",
+			Response:     "",
+			ResponseCode: http.StatusNotFound,
+		},
+	})
+	defer server.Close()
+	config.Config.ConsulAddress = server.Listener.Addr().String()
+	defer func() { config.Config.ConsulAddress = "" }()
+
+	for _, store := range []KVStore{NewConsulStore(), NewConsulTxnStore()} {
+		kvPairs, err := store.ListKeyPrefix("mysql/master/foo/")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(kvPairs), 2)
+		test.S(t).ExpectEquals(kvPairs[0].Key, "mysql/master/foo/hostname")
+		test.S(t).ExpectEquals(kvPairs[0].Value, "db-1")
+
+		kvPairs, err = store.ListKeyPrefix("no/such/prefix/")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(kvPairs), 0)
+	}
+}
+
+func TestConsulStoresDelete(t *testing.T) {
+	server := buildConsulTestServer(t, []consulTestServerOp{
+		{
+			Method:   "GET",
+			URL:      "/v1/catalog/datacenters",
+			Response: []string{"dc1", "dc2"},
+		},
+		{Method: "DELETE", URL: "/v1/kv/mysql/master/foo", Response: true},
+		{Method: "DELETE", URL: "/v1/kv/mysql/master/foo/?recurse=", Response: true},
+		{Method: "DELETE", URL: "/v1/kv/mysql/master/foo?dc=dc1", Response: true},
+		{Method: "DELETE", URL: "/v1/kv/mysql/master/foo?dc=dc2", Response: true},
+		{Method: "DELETE", URL: "/v1/kv/mysql/master/foo/?dc=dc1&recurse=", Response: true},
+		{Method: "DELETE", URL: "/v1/kv/mysql/master/foo/?dc=dc2&recurse=", Response: true},
+	})
+	defer server.Close()
+	config.Config.ConsulAddress = server.Listener.Addr().String()
+	defer func() {
+		config.Config.ConsulAddress = ""
+		config.Config.ConsulCrossDataCenterDistribution = false
+	}()
+
+	for _, crossDataCenterDistribution := range []bool{false, true} {
+		config.Config.ConsulCrossDataCenterDistribution = crossDataCenterDistribution
+		consul := NewConsulStore().(*consulStore)
+		consulTxn := NewConsulTxnStore().(*consulTxnStore)
+		for _, storeCache := range []struct {
+			store   KVStore
+			kvCache *cache.Cache
+		}{
+			{consul, consul.kvCache},
+			{consulTxn, consulTxn.kvCache},
+		} {
+			for _, dc := range []string{"dc1", "dc2"} {
+				storeCache.kvCache.SetDefault(getConsulKVCacheKey(dc, "mysql/master/foo"), "db-1:3306")
+				storeCache.kvCache.SetDefault(getConsulKVCacheKey(dc, "mysql/master/foo/hostname"), "db-1")
+				storeCache.kvCache.SetDefault(getConsulKVCacheKey(dc, "mysql/master/foobar"), "db-2:3306")
+			}
+			// Deleted keys are evicted from cache, in all datacenters
+			test.S(t).ExpectNil(storeCache.store.DeleteKeyPrefix("mysql/master/foo/"))
+			test.S(t).ExpectEquals(storeCache.kvCache.ItemCount(), 4)
+			test.S(t).ExpectNil(storeCache.store.DeleteKey("mysql/master/foo"))
+			test.S(t).ExpectEquals(storeCache.kvCache.ItemCount(), 2)
+			_, found := storeCache.kvCache.Get(getConsulKVCacheKey("dc2", "mysql/master/foobar"))
+			test.S(t).ExpectTrue(found)
+		}
+	}
+}
+
+// consulTestWatchServer serves listings of KVs as Consul's blocking queries do: a query with an index waits
+// for the index to move past it
+type consulTestWatchServer struct {
+	mutex   sync.Mutex
+	index   uint64
+	pairs   map[string]string
+	changed chan struct{}
+}
+
+func newConsulTestWatchServer() *consulTestWatchServer {
+	return &consulTestWatchServer{index: 1, pairs: map[string]string{}, changed: make(chan struct{})}
+}
+
+func (this *consulTestWatchServer) set(key string, value string, deleted bool) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if deleted {
+		delete(this.pairs, key)
+	} else {
+		this.pairs[key] = value
+	}
+	this.index++
+	close(this.changed)
+	this.changed = make(chan struct{})
+}
+
+func (this *consulTestWatchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	waitIndex, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+	for {
+		this.mutex.Lock()
+		index, changed := this.index, this.changed
+		pairs := consulapi.KVPairs{}
+		for key, value := range this.pairs {
+			if strings.HasPrefix(key, prefix) {
+				pairs = append(pairs, &consulapi.KVPair{Key: key, Value: []byte(value)})
+			}
+		}
+		this.mutex.Unlock()
+
+		if index > waitIndex {
+			w.Header().Set("X-Consul-Index", strconv.FormatUint(index, 10))
+			if len(pairs) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(pairs)
+			return
+		}
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func TestConsulStoresWatchKeyPrefix(t *testing.T) {
+	watchServer := newConsulTestWatchServer()
+	server := httptest.NewServer(watchServer)
+	defer func() {
+		// Pending blocking queries would otherwise hold on to the server
+		server.CloseClientConnections()
+		server.Close()
+	}()
+	config.Config.ConsulAddress = server.Listener.Addr().String()
+	defer func() { config.Config.ConsulAddress = "" }()
+
+	for _, store := range []KVStore{NewConsulStore(), NewConsulTxnStore()} {
+		watchServer.set("mysql/master/c1", "db-1:3306", false)
+		watchServer.set("mysql/replicas/c1", "db-2:3306", false)
+
+		subscription, err := store.WatchKeyPrefix("mysql/master/")
+		test.S(t).ExpectNil(err)
+		event := <-subscription.Events
+		test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventPut, Key: "mysql/master/c1", Value: "db-1:3306"})
+		event = <-subscription.Events
+		test.S(t).ExpectEquals(event.Op, KVEventSynced)
+
+		// Changes outside the prefix are not notified
+		watchServer.set("mysql/replicas/c1", "db-3:3306", false)
+		watchServer.set("mysql/master/c1", "db-2:3306", false)
+		event = <-subscription.Events
+		test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventPut, Key: "mysql/master/c1", Value: "db-2:3306"})
+		watchServer.set("mysql/master/c1", "", true)
+		event = <-subscription.Events
+		test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventDelete, Key: "mysql/master/c1"})
+
+		subscription.Close()
+		_, open := <-subscription.Events
+		test.S(t).ExpectFalse(open)
+	}
+	{
+		// Not configured
+		config.Config.ConsulAddress = ""
+		_, err := NewConsulStore().WatchKeyPrefix("mysql/master/")
+		test.S(t).ExpectNotNil(err)
+	}
+}
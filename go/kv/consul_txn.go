@@ -320,3 +320,76 @@ func (this *consulTxnStore) DistributePairs(kvPairs [](*KVPair)) (err error) {
 	wg.Wait()
 	return err
 }
+
+// DeleteKey deletes a Consul KV, in all datacenters when distributing KVs across datacenters
+func (this *consulTxnStore) DeleteKey(key string) (err error) {
+	if this.client == nil {
+		return nil
+	}
+	datacenters, err := getConsulDeleteDatacenters(this.client.Catalog().Datacenters)
+	if err != nil {
+		return err
+	}
+	for _, datacenter := range datacenters {
+		if _, err := this.client.KV().Delete(key, &consulapi.WriteOptions{Datacenter: datacenter}); err != nil {
+			return err
+		}
+	}
+	evictConsulKVCache(this.kvCache, func(cachedKey string) bool { return cachedKey == key })
+	return nil
+}
+
+// DeleteKeyPrefix deletes all Consul KVs beginning with given prefix, in all datacenters when
+// distributing KVs across datacenters
+func (this *consulTxnStore) DeleteKeyPrefix(prefix string) (err error) {
+	if this.client == nil {
+		return nil
+	}
+	datacenters, err := getConsulDeleteDatacenters(this.client.Catalog().Datacenters)
+	if err != nil {
+		return err
+	}
+	for _, datacenter := range datacenters {
+		if _, err := this.client.KV().DeleteTree(prefix, &consulapi.WriteOptions{Datacenter: datacenter}); err != nil {
+			return err
+		}
+	}
+	evictConsulKVCache(this.kvCache, func(cachedKey string) bool { return strings.HasPrefix(cachedKey, prefix) })
+	return nil
+}
+
+// ListKeyPrefix lists the Consul KVs beginning with given prefix
+func (this *consulTxnStore) ListKeyPrefix(prefix string) (kvPairs [](*KVPair), err error) {
+	if this.client == nil {
+		return kvPairs, nil
+	}
+	pairs, _, err := this.client.KV().List(prefix, nil)
+	if err != nil {
+		return kvPairs, err
+	}
+	for _, pair := range pairs {
+		kvPairs = append(kvPairs, NewKVPair(pair.Key, string(pair.Value)))
+	}
+	return kvPairs, nil
+}
+
+// WatchKeyPrefix subscribes to the Consul KVs beginning with given prefix, in the local datacenter, see
+// consulStore's
+func (this *consulTxnStore) WatchKeyPrefix(prefix string) (*KVSubscription, error) {
+	if this.client == nil {
+		return nil, fmt.Errorf("consul-txn KV store: ConsulAddress is not configured")
+	}
+	watcher := &consulPrefixWatcher{
+		list: func(waitIndex uint64) (kvPairs [](*KVPair), lastIndex uint64, err error) {
+			pairs, meta, err := this.client.KV().List(prefix, &consulapi.QueryOptions{WaitIndex: waitIndex, WaitTime: consulWatchWaitTime})
+			if err != nil {
+				return kvPairs, lastIndex, err
+			}
+			for _, pair := range pairs {
+				kvPairs = append(kvPairs, NewKVPair(pair.Key, string(pair.Value)))
+			}
+			return kvPairs, meta.LastIndex, nil
+		},
+	}
+	return watchKeyPrefixListings(prefix, watcher)
+}
@@ -0,0 +1,758 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kv
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/ssl"
+	"github.com/patrickmn/go-cache"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// etcd's default limit of operations in a single transaction (--max-txn-ops)
+const etcdMaxTxnOps = 128
+const etcdRequestTimeout = 5 * time.Second
+
+// newEtcdTLSConfig creates a TLS config for etcd clients, based on config's `EtcdSSL*` settings. It is only
+// in effect for `https://` endpoints.
+func newEtcdTLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.Config.EtcdSSLSkipVerify}
+	if config.Config.EtcdSSLCAFile != "" {
+		caPool, err := ssl.ReadCAFile(config.Config.EtcdSSLCAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = caPool
+	}
+	if config.Config.EtcdSSLCertFile != "" {
+		if err := ssl.AppendKeyPair(tlsConfig, config.Config.EtcdSSLCertFile, config.Config.EtcdSSLPrivateKeyFile); err != nil {
+			return nil, err
+		}
+	}
+	return tlsConfig, nil
+}
+
+// isEtcdLeasedKey returns true for keys which are attached to the master keys lease, i.e. clusters' master entries
+func isEtcdLeasedKey(key string) bool {
+	if config.Config.EtcdMasterKeysLeaseSeconds == 0 {
+		return false
+	}
+	return strings.HasPrefix(key, config.Config.KVClusterMasterPrefix)
+}
+
+// groupEtcdKVPairsByKeyPrefix groups KV pairs by cluster, such that each cluster's KVs are written in a
+// single etcd transaction, and no transaction exceeds etcd's limit of operations
+func groupEtcdKVPairsByKeyPrefix(kvPairs []*KVPair) (groups [][]*KVPair) {
+	clusterMasterPrefix := strings.TrimRight(config.Config.KVClusterMasterPrefix, "/") + "/"
+	groupsMap := map[string][]*KVPair{}
+	prefixes := []string{}
+	for _, pair := range kvPairs {
+		prefix := pair.Key
+		if strings.HasPrefix(prefix, clusterMasterPrefix) {
+			prefix = strings.Split(strings.TrimPrefix(prefix, clusterMasterPrefix), "/")[0]
+		}
+		if _, found := groupsMap[prefix]; !found {
+			prefixes = append(prefixes, prefix)
+		}
+		groupsMap[prefix] = append(groupsMap[prefix], pair)
+	}
+	sort.Strings(prefixes)
+
+	pairsBuf := []*KVPair{}
+	for _, prefix := range prefixes {
+		group := groupsMap[prefix]
+		if len(pairsBuf) > 0 && len(pairsBuf)+len(group) > etcdMaxTxnOps {
+			groups = append(groups, pairsBuf)
+			pairsBuf = []*KVPair{}
+		}
+		pairsBuf = append(pairsBuf, group...)
+	}
+	if len(pairsBuf) > 0 {
+		groups = append(groups, pairsBuf)
+	}
+	return groups
+}
+
+// filterEtcdOwnedKVPairs returns given pairs less the leased keys, unless this node is the leader. Leased keys
+// are owned by the leader: with `orchestrator/raft`, followers apply the same writes as the leader does, and
+// a follower attaching master keys to a lease of its own, which it does not keep alive, would have them
+// expire along with that lease.
+func filterEtcdOwnedKVPairs(kvPairs []*KVPair) (ownedPairs []*KVPair) {
+	if config.Config.EtcdMasterKeysLeaseSeconds == 0 || isLeader() {
+		return kvPairs
+	}
+	for _, pair := range kvPairs {
+		if !isEtcdLeasedKey(pair.Key) {
+			ownedPairs = append(ownedPairs, pair)
+		}
+	}
+	if skipped := len(kvPairs) - len(ownedPairs); skipped > 0 {
+		log.Debugf("etcd: not the leader; skipping %d leased keys", skipped)
+	}
+	return ownedPairs
+}
+
+// etcdLeasedValue is the value of a leased key as written by this node, and the revision it was written at
+type etcdLeasedValue struct {
+	value       string
+	modRevision int64
+}
+
+// etcdCluster is a single etcd cluster, accessed via any of its endpoints. It owns the lease which
+// master keys written to this cluster are attached to.
+type etcdCluster struct {
+	endpoints []string
+	client    *clientv3.Client
+	kvCache   *cache.Cache
+
+	leaseMutex   sync.Mutex
+	leaseId      clientv3.LeaseID
+	leasedPairs  map[string]etcdLeasedValue
+	keepingAlive bool
+}
+
+func newEtcdCluster(endpoints []string, tlsConfig *tls.Config) (*etcdCluster, error) {
+	cluster := &etcdCluster{
+		kvCache:     cache.New(cache.NoExpiration, cache.DefaultExpiration),
+		leasedPairs: map[string]etcdLeasedValue{},
+	}
+	for _, endpoint := range endpoints {
+		cluster.endpoints = append(cluster.endpoints, strings.TrimRight(strings.TrimSpace(endpoint), "/"))
+	}
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   cluster.endpoints,
+		DialTimeout: etcdRequestTimeout,
+		TLS:         tlsConfig,
+		// errors are returned to, and logged by, the callers
+		Logger: zap.NewNop(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	cluster.client = client
+	return cluster, nil
+}
+
+func (this *etcdCluster) String() string {
+	return strings.Join(this.endpoints, ",")
+}
+
+func (this *etcdCluster) requestContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), etcdRequestTimeout)
+}
+
+func (this *etcdCluster) cacheKey(key string) string {
+	return fmt.Sprintf("%s;%s", this.String(), key)
+}
+
+// cacheValue is the cached representation of a KV: its value, and its lease, if any
+func (this *etcdCluster) cacheValue(value string, leaseId clientv3.LeaseID) string {
+	return fmt.Sprintf("%d;%s", leaseId, value)
+}
+
+// grantLease grants a new lease. As leader, it re-attaches previously leased keys to it; otherwise
+// they are forgotten, as the master keys are owned by another node.
+func (this *etcdCluster) grantLease() (leaseId clientv3.LeaseID, err error) {
+	ctx, cancel := this.requestContext()
+	defer cancel()
+	lease, err := this.client.Grant(ctx, int64(config.Config.EtcdMasterKeysLeaseSeconds))
+	if err != nil {
+		return 0, err
+	}
+	leaseId = lease.ID
+	log.Debugf("etcd %s: granted lease %d", this.String(), leaseId)
+
+	this.kvCache.Flush()
+	if isLeader() {
+		if err := this.restoreLeasedPairs(leaseId); err != nil {
+			return 0, err
+		}
+	} else {
+		this.leasedPairs = map[string]etcdLeasedValue{}
+	}
+	this.leaseId = leaseId
+	return leaseId, nil
+}
+
+// restoreLeasedPairs re-attaches previously leased keys to given lease. A key is only written if it is
+// unchanged since this node wrote it: either gone along with the expired lease, or still at the
+// revision this node wrote it at. This is verified within the writing transaction. Keys since written
+// by another node are left as they are, and forgotten.
+func (this *etcdCluster) restoreLeasedPairs(leaseId clientv3.LeaseID) error {
+	kvPairs := []*KVPair{}
+	for key, leasedValue := range this.leasedPairs {
+		kvPairs = append(kvPairs, NewKVPair(key, leasedValue.value))
+	}
+	for _, group := range groupEtcdKVPairsByKeyPrefix(kvPairs) {
+		modRevisions, err := this.readModRevisions(group)
+		if err != nil {
+			return err
+		}
+
+		compares := []clientv3.Cmp{}
+		ops := []clientv3.Op{}
+		restorePairs := []*KVPair{}
+		for _, pair := range group {
+			modRevision := modRevisions[pair.Key]
+			if modRevision != 0 && modRevision != this.leasedPairs[pair.Key].modRevision {
+				log.Infof("etcd %s: %s was since written by another node; not restoring", this.String(), pair.Key)
+				delete(this.leasedPairs, pair.Key)
+				continue
+			}
+			compares = append(compares, clientv3.Compare(clientv3.ModRevision(pair.Key), "=", modRevision))
+			ops = append(ops, clientv3.OpPut(pair.Key, pair.Value, clientv3.WithLease(leaseId)))
+			restorePairs = append(restorePairs, pair)
+		}
+		if len(ops) == 0 {
+			continue
+		}
+		response, err := this.txn(compares, ops)
+		if err != nil {
+			return err
+		}
+		for _, pair := range restorePairs {
+			if response.Succeeded {
+				this.leasedPairs[pair.Key] = etcdLeasedValue{value: pair.Value, modRevision: response.Header.Revision}
+			} else {
+				delete(this.leasedPairs, pair.Key)
+			}
+		}
+		if !response.Succeeded {
+			log.Warningf("etcd %s: %d keys were written by another node while being restored; not restoring", this.String(), len(restorePairs))
+		}
+	}
+	return nil
+}
+
+// readModRevisions reads, in a single transaction, the revisions given keys were last modified at.
+// Keys which do not exist are absent from the result, which is the same as a revision of 0.
+func (this *etcdCluster) readModRevisions(kvPairs []*KVPair) (modRevisions map[string]int64, err error) {
+	modRevisions = map[string]int64{}
+	keyValues, err := this.readKeyValues(kvPairs)
+	for _, keyValue := range keyValues {
+		modRevisions[string(keyValue.Key)] = keyValue.ModRevision
+	}
+	return modRevisions, err
+}
+
+// readKeyValues reads given keys in a single transaction
+func (this *etcdCluster) readKeyValues(kvPairs []*KVPair) (keyValues []*mvccpb.KeyValue, err error) {
+	if len(kvPairs) == 0 {
+		return keyValues, nil
+	}
+	ops := []clientv3.Op{}
+	for _, pair := range kvPairs {
+		ops = append(ops, clientv3.OpGet(pair.Key))
+	}
+	response, err := this.txn(nil, ops)
+	if err != nil {
+		return keyValues, err
+	}
+	for _, responseOp := range response.Responses {
+		if rangeResponse := responseOp.GetResponseRange(); rangeResponse != nil {
+			keyValues = append(keyValues, rangeResponse.Kvs...)
+		}
+	}
+	return keyValues, nil
+}
+
+// getLease returns the current lease, granting one if there is none
+func (this *etcdCluster) getLease() (leaseId clientv3.LeaseID, err error) {
+	this.leaseMutex.Lock()
+	defer this.leaseMutex.Unlock()
+
+	if this.leaseId != 0 {
+		return this.leaseId, nil
+	}
+	if leaseId, err = this.grantLease(); err != nil {
+		return 0, err
+	}
+	if !this.keepingAlive {
+		this.keepingAlive = true
+		go this.keepLeaseAlive(time.Duration(config.Config.EtcdMasterKeysLeaseSeconds) * time.Second / 3)
+	}
+	return leaseId, nil
+}
+
+// refreshLease keeps the lease alive. A lease which expired (e.g. orchestrator was unable to reach etcd
+// for longer than the TTL) is replaced by a new one.
+func (this *etcdCluster) refreshLease() error {
+	this.leaseMutex.Lock()
+	defer this.leaseMutex.Unlock()
+
+	if this.leaseId == 0 {
+		return nil
+	}
+	ctx, cancel := this.requestContext()
+	defer cancel()
+	_, err := this.client.KeepAliveOnce(ctx, this.leaseId)
+	if err == nil {
+		return nil
+	}
+	if err != rpctypes.ErrLeaseNotFound {
+		return err
+	}
+	log.Warningf("etcd %s: lease %d expired; granting a new lease", this.String(), this.leaseId)
+	this.leaseId = 0
+	_, err = this.grantLease()
+	return err
+}
+
+// keepLeaseAlive keeps the lease alive for as long as this node is the leader. Once leadership is lost
+// it stops, and the lease is left to expire along with whichever keys the new leader has not since
+// attached to its own lease.
+func (this *etcdCluster) keepLeaseAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !isLeader() {
+			this.releaseLease()
+			return
+		}
+		if err := this.refreshLease(); err != nil {
+			log.Errorf("etcd %s: lease keepalive: %+v", this.String(), err)
+		}
+	}
+}
+
+// releaseLease forgets the lease and its keys, such that a new lease is granted, and kept alive, upon next use
+func (this *etcdCluster) releaseLease() {
+	this.leaseMutex.Lock()
+	defer this.leaseMutex.Unlock()
+
+	log.Infof("etcd %s: not the leader; no longer keeping lease %d alive", this.String(), this.leaseId)
+	this.leaseId = 0
+	this.leasedPairs = map[string]etcdLeasedValue{}
+	this.keepingAlive = false
+}
+
+// txn runs given operations if, and only if, all given comparisons hold
+func (this *etcdCluster) txn(compares []clientv3.Cmp, ops []clientv3.Op) (*clientv3.TxnResponse, error) {
+	ctx, cancel := this.requestContext()
+	defer cancel()
+	return this.client.Txn(ctx).If(compares...).Then(ops...).Commit()
+}
+
+func (this *etcdCluster) getKeyValue(key string) (value string, found bool, err error) {
+	ctx, cancel := this.requestContext()
+	defer cancel()
+	response, err := this.client.Get(ctx, key)
+	if err != nil {
+		return value, found, err
+	}
+	if len(response.Kvs) == 0 {
+		return value, false, nil
+	}
+	return string(response.Kvs[0].Value), true, nil
+}
+
+// deleteRange deletes given key, or, with clientv3.WithPrefix(), all keys beginning with it, and forgets them
+// as leased or cached
+func (this *etcdCluster) deleteRange(key string, matches func(key string) bool, opts ...clientv3.OpOption) error {
+	ctx, cancel := this.requestContext()
+	defer cancel()
+	if _, err := this.client.Delete(ctx, key, opts...); err != nil {
+		return err
+	}
+	this.leaseMutex.Lock()
+	defer this.leaseMutex.Unlock()
+	for key := range this.leasedPairs {
+		if matches(key) {
+			delete(this.leasedPairs, key)
+		}
+	}
+	for cacheKey := range this.kvCache.Items() {
+		if matches(strings.TrimPrefix(cacheKey, this.cacheKey(""))) {
+			this.kvCache.Delete(cacheKey)
+		}
+	}
+	return nil
+}
+
+func (this *etcdCluster) deleteKey(key string) error {
+	return this.deleteRange(key, func(k string) bool { return k == key })
+}
+
+func (this *etcdCluster) deleteKeyPrefix(prefix string) error {
+	return this.deleteRange(prefix, func(k string) bool { return strings.HasPrefix(k, prefix) }, clientv3.WithPrefix())
+}
+
+func (this *etcdCluster) listKeyPrefix(prefix string) (kvPairs [](*KVPair), err error) {
+	ctx, cancel := this.requestContext()
+	defer cancel()
+	response, err := this.client.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return kvPairs, err
+	}
+	for _, keyValue := range response.Kvs {
+		kvPairs = append(kvPairs, NewKVPair(string(keyValue.Key), string(keyValue.Value)))
+	}
+	return kvPairs, nil
+}
+
+// invalidateLease forgets given lease, such that a new one is granted upon next use
+func (this *etcdCluster) invalidateLease(leaseId clientv3.LeaseID) {
+	this.leaseMutex.Lock()
+	defer this.leaseMutex.Unlock()
+
+	if this.leaseId == leaseId {
+		this.leaseId = 0
+	}
+}
+
+// putKVPairs writes given pairs in a single, atomic etcd transaction. Leased keys are only written by the leader.
+func (this *etcdCluster) putKVPairs(kvPairs []*KVPair) (err error) {
+	kvPairs = filterEtcdOwnedKVPairs(kvPairs)
+	if len(kvPairs) == 0 {
+		return nil
+	}
+	leaseId, err := this.doPutKVPairs(kvPairs)
+	if err == rpctypes.ErrLeaseNotFound && leaseId != 0 {
+		// The lease expired before the keepalive noticed; retry with a new lease
+		this.invalidateLease(leaseId)
+		_, err = this.doPutKVPairs(kvPairs)
+	}
+	return err
+}
+
+func (this *etcdCluster) doPutKVPairs(kvPairs []*KVPair) (leaseId clientv3.LeaseID, err error) {
+	ops := []clientv3.Op{}
+	for _, pair := range kvPairs {
+		if !isEtcdLeasedKey(pair.Key) {
+			ops = append(ops, clientv3.OpPut(pair.Key, pair.Value))
+			continue
+		}
+		if leaseId == 0 {
+			if leaseId, err = this.getLease(); err != nil {
+				return leaseId, err
+			}
+		}
+		ops = append(ops, clientv3.OpPut(pair.Key, pair.Value, clientv3.WithLease(leaseId)))
+	}
+	response, err := this.txn(nil, ops)
+	if err != nil {
+		return leaseId, err
+	}
+	this.leaseMutex.Lock()
+	defer this.leaseMutex.Unlock()
+	for _, pair := range kvPairs {
+		if isEtcdLeasedKey(pair.Key) {
+			this.leasedPairs[pair.Key] = etcdLeasedValue{value: pair.Value, modRevision: response.Header.Revision}
+			this.kvCache.SetDefault(this.cacheKey(pair.Key), this.cacheValue(pair.Value, leaseId))
+		} else {
+			this.kvCache.SetDefault(this.cacheKey(pair.Key), this.cacheValue(pair.Value, 0))
+		}
+	}
+	return leaseId, nil
+}
+
+// etcdDistributionResponse summarizes a distribution of KV pairs onto a single etcd cluster
+type etcdDistributionResponse struct {
+	skipped  int
+	existing int
+	written  int
+	failed   int
+}
+
+// distributePairs writes given pairs which are not already in place. Current values are read in a single
+// transaction per cluster group, and necessary updates are written in a second transaction.
+func (this *etcdCluster) distributePairs(kvPairs []*KVPair) (resp etcdDistributionResponse, err error) {
+	kvPairs = filterEtcdOwnedKVPairs(kvPairs)
+	var leaseId clientv3.LeaseID
+	for _, pair := range kvPairs {
+		if isEtcdLeasedKey(pair.Key) {
+			if leaseId, err = this.getLease(); err != nil {
+				return resp, err
+			}
+			break
+		}
+	}
+	leaseIdOf := func(key string) clientv3.LeaseID {
+		if isEtcdLeasedKey(key) {
+			return leaseId
+		}
+		return 0
+	}
+	for _, group := range groupEtcdKVPairsByKeyPrefix(kvPairs) {
+		possibleSetPairs := []*KVPair{}
+		for _, pair := range group {
+			if value, found := this.kvCache.Get(this.cacheKey(pair.Key)); found && value == this.cacheValue(pair.Value, leaseIdOf(pair.Key)) {
+				resp.skipped++
+				continue
+			}
+			possibleSetPairs = append(possibleSetPairs, pair)
+		}
+		existing := map[string]string{}
+		keyValues, err := this.readKeyValues(possibleSetPairs)
+		if err != nil {
+			log.Errorf("etcd %s: DistributePairs(): %+v", this.String(), err)
+		}
+		for _, keyValue := range keyValues {
+			existing[string(keyValue.Key)] = this.cacheValue(string(keyValue.Value), clientv3.LeaseID(keyValue.Lease))
+		}
+
+		setPairs := []*KVPair{}
+		for _, pair := range possibleSetPairs {
+			if existing[pair.Key] == this.cacheValue(pair.Value, leaseIdOf(pair.Key)) {
+				this.kvCache.SetDefault(this.cacheKey(pair.Key), existing[pair.Key])
+				resp.existing++
+				continue
+			}
+			setPairs = append(setPairs, pair)
+		}
+		if len(setPairs) == 0 {
+			continue
+		}
+		if e := this.putKVPairs(setPairs); e != nil {
+			log.Errorf("etcd %s: DistributePairs(): failed writing %d pairs: %+v", this.String(), len(setPairs), e)
+			resp.failed += len(setPairs)
+			err = e
+		} else {
+			resp.written += len(setPairs)
+		}
+	}
+	return resp, err
+}
+
+// An etcd v3 store based on config's `EtcdEndpoints`
+type etcdStore struct {
+	cluster             *etcdCluster
+	crossClusters       []*etcdCluster
+	distributionReentry int64
+}
+
+// NewEtcdStore creates a new etcd store. It is possible that the cluster for this store is nil,
+// which is the case if no etcd config is provided.
+func NewEtcdStore() KVStore {
+	store := &etcdStore{}
+
+	if len(config.Config.EtcdEndpoints) > 0 {
+		tlsConfig, err := newEtcdTLSConfig()
+		if err != nil {
+			log.Errore(err)
+			return store
+		}
+		crossClusters := []*etcdCluster{}
+		for _, endpoints := range config.Config.EtcdCrossClusterEndpoints {
+			crossCluster, err := newEtcdCluster(strings.Split(endpoints, ","), tlsConfig)
+			if err != nil {
+				log.Errore(err)
+				return store
+			}
+			crossClusters = append(crossClusters, crossCluster)
+		}
+		cluster, err := newEtcdCluster(config.Config.EtcdEndpoints, tlsConfig)
+		if err != nil {
+			log.Errore(err)
+			return store
+		}
+		store.cluster = cluster
+		store.crossClusters = crossClusters
+	}
+	return store
+}
+
+func (this *etcdStore) PutKeyValue(key string, value string) (err error) {
+	if this.cluster == nil {
+		return nil
+	}
+	return this.cluster.putKVPairs([]*KVPair{NewKVPair(key, value)})
+}
+
+func (this *etcdStore) GetKeyValue(key string) (value string, found bool, err error) {
+	if this.cluster == nil {
+		return value, found, nil
+	}
+	return this.cluster.getKeyValue(key)
+}
+
+// PutKVPairs updates one or more KV pairs in a single, atomic etcd transaction
+func (this *etcdStore) PutKVPairs(kvPairs []*KVPair) (err error) {
+	if this.cluster == nil {
+		return nil
+	}
+	return this.cluster.putKVPairs(kvPairs)
+}
+
+// DistributePairs updates the local etcd cluster and all of `EtcdCrossClusterEndpoints` clusters
+// with one or more KV pairs
+func (this *etcdStore) DistributePairs(kvPairs [](*KVPair)) (err error) {
+	// This function is non re-entrant (it can only be running once at any point in time)
+	if atomic.CompareAndSwapInt64(&this.distributionReentry, 0, 1) {
+		defer atomic.StoreInt64(&this.distributionReentry, 0)
+	} else {
+		return
+	}
+
+	if this.cluster == nil || len(this.crossClusters) == 0 {
+		return nil
+	}
+
+	clusters := this.allClusters()
+	log.Debugf("etcdStore.DistributePairs(): distributing %d pairs to %d clusters", len(kvPairs), len(clusters))
+	var errMutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, cluster := range clusters {
+		cluster := cluster
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			resp, e := cluster.distributePairs(kvPairs)
+			if e != nil {
+				errMutex.Lock()
+				err = e
+				errMutex.Unlock()
+			}
+			log.Debugf("etcdStore.DistributePairs(): cluster: %s; skipped: %d, existing: %d, written: %d, failed: %d", cluster.String(), resp.skipped, resp.existing, resp.written, resp.failed)
+		}()
+	}
+	wg.Wait()
+	return err
+}
+
+// allClusters returns the local cluster followed by `EtcdCrossClusterEndpoints` clusters
+func (this *etcdStore) allClusters() []*etcdCluster {
+	return append([]*etcdCluster{this.cluster}, this.crossClusters...)
+}
+
+// DeleteKey deletes a key from the local cluster as well as from all `EtcdCrossClusterEndpoints` clusters
+func (this *etcdStore) DeleteKey(key string) (err error) {
+	if this.cluster == nil {
+		return nil
+	}
+	for _, cluster := range this.allClusters() {
+		if err := cluster.deleteKey(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteKeyPrefix deletes all keys beginning with given prefix from the local cluster as well as from
+// all `EtcdCrossClusterEndpoints` clusters
+func (this *etcdStore) DeleteKeyPrefix(prefix string) (err error) {
+	if this.cluster == nil {
+		return nil
+	}
+	for _, cluster := range this.allClusters() {
+		if err := cluster.deleteKeyPrefix(prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *etcdStore) ListKeyPrefix(prefix string) (kvPairs [](*KVPair), err error) {
+	if this.cluster == nil {
+		return kvPairs, nil
+	}
+	return this.cluster.listKeyPrefix(prefix)
+}
+
+// WatchKeyPrefix subscribes to the keys beginning with given prefix, in the local etcd cluster, see
+// etcdPrefixWatcher
+func (this *etcdStore) WatchKeyPrefix(prefix string) (*KVSubscription, error) {
+	if this.cluster == nil {
+		return nil, fmt.Errorf("etcd KV store: EtcdEndpoints is not configured")
+	}
+	return watchKeyPrefixListings(prefix, &etcdPrefixWatcher{cluster: this.cluster, prefix: prefix})
+}
+
+// etcdPrefixWatcher watches a prefix with etcd's watch API: the prefix is listed at some revision, and the
+// events of the revisions which follow are applied onto that listing. Should the watch fail, e.g. upon
+// compaction of those revisions, the prefix is listed anew.
+type etcdPrefixWatcher struct {
+	cluster *etcdCluster
+	prefix  string
+
+	cancelWatch context.CancelFunc
+	watchChan   clientv3.WatchChan
+	values      map[string]string
+}
+
+func (this *etcdPrefixWatcher) list() (kvPairs [](*KVPair)) {
+	for key, value := range this.values {
+		kvPairs = append(kvPairs, NewKVPair(key, value))
+	}
+	sort.Slice(kvPairs, func(i, j int) bool {
+		return kvPairs[i].Key < kvPairs[j].Key
+	})
+	return kvPairs
+}
+
+func (this *etcdPrefixWatcher) listChanges(stop <-chan struct{}) (kvPairs [](*KVPair), err error) {
+	if this.watchChan == nil {
+		ctx, cancel := this.cluster.requestContext()
+		response, err := this.cluster.client.Get(ctx, this.prefix, clientv3.WithPrefix())
+		cancel()
+		if err != nil {
+			return kvPairs, err
+		}
+		this.values = map[string]string{}
+		for _, keyValue := range response.Kvs {
+			this.values[string(keyValue.Key)] = string(keyValue.Value)
+		}
+		var watchCtx context.Context
+		watchCtx, this.cancelWatch = context.WithCancel(clientv3.WithRequireLeader(context.Background()))
+		this.watchChan = this.cluster.client.Watch(watchCtx, this.prefix, clientv3.WithPrefix(), clientv3.WithRev(response.Header.Revision+1))
+		return this.list(), nil
+	}
+	select {
+	case response, ok := <-this.watchChan:
+		if !ok || response.Err() != nil {
+			err = response.Err()
+			if err == nil {
+				err = fmt.Errorf("etcd KV store: watch on %s ended", this.prefix)
+			}
+			this.close()
+			return kvPairs, err
+		}
+		for _, event := range response.Events {
+			switch event.Type {
+			case mvccpb.PUT:
+				this.values[string(event.Kv.Key)] = string(event.Kv.Value)
+			case mvccpb.DELETE:
+				delete(this.values, string(event.Kv.Key))
+			}
+		}
+	case <-stop:
+		return kvPairs, nil
+	}
+	return this.list(), nil
+}
+
+func (this *etcdPrefixWatcher) close() {
+	if this.cancelWatch != nil {
+		this.cancelWatch()
+	}
+	this.cancelWatch = nil
+	this.watchChan = nil
+}
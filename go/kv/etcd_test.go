@@ -0,0 +1,568 @@
+package kv
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/client/pkg/v3/transport"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+// etcdTestServer is an embedded, single member etcd cluster, along with a client of its own
+type etcdTestServer struct {
+	*embed.Etcd
+	URL    string
+	client *clientv3.Client
+}
+
+// freeEtcdTestURL returns a URL on a local port which is currently free
+func freeEtcdTestURL(t *testing.T, scheme string) url.URL {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return url.URL{Scheme: scheme, Host: listener.Addr().String()}
+}
+
+// newEtcdTestServer starts an embedded etcd server. With a non empty clientTLSInfo, clients are served over TLS.
+func newEtcdTestServer(t *testing.T, clientTLSInfo ...transport.TLSInfo) *etcdTestServer {
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	clientScheme := "http"
+	if len(clientTLSInfo) > 0 {
+		cfg.ClientTLSInfo = clientTLSInfo[0]
+		clientScheme = "https"
+	}
+	clientURL := freeEtcdTestURL(t, clientScheme)
+	peerURL := freeEtcdTestURL(t, "http")
+	cfg.ListenClientUrls, cfg.AdvertiseClientUrls = []url.URL{clientURL}, []url.URL{clientURL}
+	cfg.ListenPeerUrls, cfg.AdvertisePeerUrls = []url.URL{peerURL}, []url.URL{peerURL}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+
+	etcd, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-etcd.Server.ReadyNotify():
+	case <-time.After(30 * time.Second):
+		etcd.Close()
+		t.Fatal("embedded etcd did not start")
+	}
+	server := &etcdTestServer{Etcd: etcd, URL: clientURL.String()}
+	if len(clientTLSInfo) == 0 {
+		if server.client, err = clientv3.New(clientv3.Config{Endpoints: []string{server.URL}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (this *etcdTestServer) Close() {
+	if this.client != nil {
+		this.client.Close()
+	}
+	this.Etcd.Close()
+}
+
+// newEtcdTestCluster returns a cluster of given test servers, accessed in the clear
+func newEtcdTestCluster(t *testing.T, servers ...*etcdTestServer) *etcdCluster {
+	endpoints := []string{}
+	for _, server := range servers {
+		endpoints = append(endpoints, server.URL)
+	}
+	cluster, err := newEtcdCluster(endpoints, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cluster.client.Close() })
+	return cluster
+}
+
+func (this *etcdTestServer) revokeLease(leaseId clientv3.LeaseID) {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+	if _, err := this.client.Revoke(ctx, leaseId); err != nil {
+		panic(err)
+	}
+}
+
+// put writes a key as another node would
+func (this *etcdTestServer) put(key string, value string) {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+	if _, err := this.client.Put(ctx, key, value); err != nil {
+		panic(err)
+	}
+}
+
+func (this *etcdTestServer) get(key string) (keyValue *mvccpb.KeyValue, found bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+	response, err := this.client.Get(ctx, key)
+	if err != nil {
+		panic(err)
+	}
+	if len(response.Kvs) == 0 {
+		return &mvccpb.KeyValue{}, false
+	}
+	return response.Kvs[0], true
+}
+
+// revision returns the cluster's current revision, which any write increments
+func (this *etcdTestServer) revision() int64 {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+	response, err := this.client.Get(ctx, "some/key")
+	if err != nil {
+		panic(err)
+	}
+	return response.Header.Revision
+}
+
+func etcdTestClusterPairs(clusterName string, hostname string) []*KVPair {
+	prefix := fmt.Sprintf("%s/%s", config.Config.KVClusterMasterPrefix, clusterName)
+	return []*KVPair{
+		NewKVPair(prefix, fmt.Sprintf("%s:3306", hostname)),
+		NewKVPair(prefix+"/hostname", hostname),
+		NewKVPair(prefix+"/port", "3306"),
+	}
+}
+
+func TestGroupEtcdKVPairsByKeyPrefix(t *testing.T) {
+	config.Config.KVClusterMasterPrefix = "mysql/master"
+
+	kvPairs := []*KVPair{}
+	for i := 0; i < 50; i++ {
+		kvPairs = append(kvPairs, etcdTestClusterPairs(fmt.Sprintf("cluster%02d", i), "mysql.example.com")...)
+	}
+	groups := groupEtcdKVPairsByKeyPrefix(kvPairs)
+	test.S(t).ExpectEquals(len(groups), 2)
+	test.S(t).ExpectEquals(len(groups[0]), 126)
+	test.S(t).ExpectEquals(len(groups[1]), 24)
+	test.S(t).ExpectEquals(groups[0][125].Key, "mysql/master/cluster41/port")
+	test.S(t).ExpectEquals(groups[1][0].Key, "mysql/master/cluster42")
+}
+
+func TestEtcdStorePutKVPairs(t *testing.T) {
+	config.Config.KVClusterMasterPrefix = "mysql/master"
+	server := newEtcdTestServer(t)
+	store := &etcdStore{cluster: newEtcdTestCluster(t, server)}
+
+	revision := server.revision()
+	err := store.PutKVPairs(etcdTestClusterPairs("cluster1", "db-1"))
+	test.S(t).ExpectNil(err)
+	// a single transaction
+	test.S(t).ExpectEquals(server.revision(), revision+1)
+
+	value, found, err := store.GetKeyValue("mysql/master/cluster1/hostname")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(found)
+	test.S(t).ExpectEquals(value, "db-1")
+
+	_, found, err = store.GetKeyValue("mysql/master/cluster2/hostname")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(found)
+
+	keyValue, _ := server.get("mysql/master/cluster1")
+	test.S(t).ExpectEquals(keyValue.Lease, int64(0))
+}
+
+func TestEtcdStoreMasterKeysLease(t *testing.T) {
+	config.Config.KVClusterMasterPrefix = "mysql/master"
+	config.Config.EtcdMasterKeysLeaseSeconds = 60
+	defer func() { config.Config.EtcdMasterKeysLeaseSeconds = 0 }()
+	defer SetLeaderCheck(isLeader)
+	SetLeaderCheck(func() bool { return true })
+	server := newEtcdTestServer(t)
+	cluster := newEtcdTestCluster(t, server)
+	store := &etcdStore{cluster: cluster}
+
+	err := store.PutKVPairs(append(etcdTestClusterPairs("cluster1", "db-1"), NewKVPair("some/other/key", "value")))
+	test.S(t).ExpectNil(err)
+	keyValue, found := server.get("mysql/master/cluster1")
+	test.S(t).ExpectTrue(found)
+	leaseId := clientv3.LeaseID(keyValue.Lease)
+	test.S(t).ExpectTrue(leaseId != 0)
+	keyValue, _ = server.get("some/other/key")
+	test.S(t).ExpectEquals(keyValue.Lease, int64(0))
+
+	// a live lease is kept as is
+	test.S(t).ExpectNil(cluster.refreshLease())
+	test.S(t).ExpectEquals(cluster.leaseId, leaseId)
+
+	// an expired lease is replaced, and its keys are restored
+	server.revokeLease(leaseId)
+	_, found = server.get("mysql/master/cluster1/hostname")
+	test.S(t).ExpectFalse(found)
+	test.S(t).ExpectNil(cluster.refreshLease())
+	test.S(t).ExpectTrue(cluster.leaseId != leaseId)
+	keyValue, found = server.get("mysql/master/cluster1/hostname")
+	test.S(t).ExpectTrue(found)
+	test.S(t).ExpectEquals(string(keyValue.Value), "db-1")
+	test.S(t).ExpectEquals(clientv3.LeaseID(keyValue.Lease), cluster.leaseId)
+
+	// a lease which expired unnoticed is replaced upon write
+	server.revokeLease(cluster.leaseId)
+	err = store.PutKVPairs(etcdTestClusterPairs("cluster2", "db-2"))
+	test.S(t).ExpectNil(err)
+	_, found = server.get("mysql/master/cluster1")
+	test.S(t).ExpectTrue(found)
+	_, found = server.get("mysql/master/cluster2")
+	test.S(t).ExpectTrue(found)
+}
+
+func TestEtcdStoreMasterKeysLeaseRestore(t *testing.T) {
+	config.Config.KVClusterMasterPrefix = "mysql/master"
+	config.Config.EtcdMasterKeysLeaseSeconds = 60
+	defer func() { config.Config.EtcdMasterKeysLeaseSeconds = 0 }()
+	defer SetLeaderCheck(isLeader)
+	server := newEtcdTestServer(t)
+	{
+		// Keys written by another node since the lease expired are left as they are, and forgotten
+		SetLeaderCheck(func() bool { return true })
+		cluster := newEtcdTestCluster(t, server)
+		test.S(t).ExpectNil(cluster.putKVPairs(etcdTestClusterPairs("cluster1", "db-1")))
+		server.revokeLease(cluster.leaseId)
+		server.put("mysql/master/cluster1/hostname", "db-2")
+
+		test.S(t).ExpectNil(cluster.refreshLease())
+		keyValue, _ := server.get("mysql/master/cluster1/hostname")
+		test.S(t).ExpectEquals(string(keyValue.Value), "db-2")
+		test.S(t).ExpectEquals(keyValue.Lease, int64(0))
+		keyValue, _ = server.get("mysql/master/cluster1/port")
+		test.S(t).ExpectEquals(clientv3.LeaseID(keyValue.Lease), cluster.leaseId)
+		_, found := cluster.leasedPairs["mysql/master/cluster1/hostname"]
+		test.S(t).ExpectFalse(found)
+		test.S(t).ExpectEquals(cluster.leasedPairs["mysql/master/cluster1/port"].modRevision, keyValue.ModRevision)
+	}
+	{
+		// Keys still at the revision this node wrote them at are moved to the new lease
+		cluster := newEtcdTestCluster(t, server)
+		test.S(t).ExpectNil(cluster.putKVPairs(etcdTestClusterPairs("cluster2", "db-1")))
+		leaseId := cluster.leaseId
+		cluster.invalidateLease(leaseId)
+		_, err := cluster.getLease()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(cluster.leaseId != leaseId)
+		keyValue, _ := server.get("mysql/master/cluster2/hostname")
+		test.S(t).ExpectEquals(string(keyValue.Value), "db-1")
+		test.S(t).ExpectEquals(clientv3.LeaseID(keyValue.Lease), cluster.leaseId)
+		test.S(t).ExpectEquals(len(cluster.leasedPairs), 3)
+	}
+	{
+		// Not the leader: keys are not restored
+		SetLeaderCheck(func() bool { return true })
+		cluster := newEtcdTestCluster(t, server)
+		test.S(t).ExpectNil(cluster.putKVPairs(etcdTestClusterPairs("cluster3", "db-1")))
+		SetLeaderCheck(func() bool { return false })
+		server.revokeLease(cluster.leaseId)
+
+		test.S(t).ExpectNil(cluster.refreshLease())
+		_, found := server.get("mysql/master/cluster3/hostname")
+		test.S(t).ExpectFalse(found)
+		test.S(t).ExpectEquals(len(cluster.leasedPairs), 0)
+	}
+}
+
+func TestEtcdStoreMasterKeysNotLeader(t *testing.T) {
+	config.Config.KVClusterMasterPrefix = "mysql/master"
+	config.Config.EtcdMasterKeysLeaseSeconds = 60
+	defer func() { config.Config.EtcdMasterKeysLeaseSeconds = 0 }()
+	defer SetLeaderCheck(isLeader)
+	server := newEtcdTestServer(t)
+
+	SetLeaderCheck(func() bool { return true })
+	leader := newEtcdTestCluster(t, server)
+	test.S(t).ExpectNil(leader.putKVPairs(etcdTestClusterPairs("cluster1", "db-1")))
+
+	// With raft, a follower applies the same write. Leased keys are left to the leader and its lease;
+	// other keys are written.
+	SetLeaderCheck(func() bool { return false })
+	follower := newEtcdTestCluster(t, server)
+	store := &etcdStore{cluster: follower}
+	test.S(t).ExpectNil(store.PutKVPairs(append(etcdTestClusterPairs("cluster1", "db-1"), NewKVPair("some/other/key", "value"))))
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/master/cluster2", "db-2:3306"))
+	test.S(t).ExpectNil(store.PutKVPairs(etcdTestClusterPairs("cluster1", "db-1")))
+
+	keyValue, found := server.get("mysql/master/cluster1/hostname")
+	test.S(t).ExpectTrue(found)
+	test.S(t).ExpectEquals(clientv3.LeaseID(keyValue.Lease), leader.leaseId)
+	_, found = server.get("mysql/master/cluster2")
+	test.S(t).ExpectFalse(found)
+	_, found = server.get("some/other/key")
+	test.S(t).ExpectTrue(found)
+	test.S(t).ExpectEquals(follower.leaseId, clientv3.LeaseID(0))
+	test.S(t).ExpectFalse(follower.keepingAlive)
+	test.S(t).ExpectEquals(len(follower.leasedPairs), 0)
+}
+
+func TestEtcdKeepLeaseAliveStopsOnLostLeadership(t *testing.T) {
+	config.Config.KVClusterMasterPrefix = "mysql/master"
+	config.Config.EtcdMasterKeysLeaseSeconds = 60
+	defer func() { config.Config.EtcdMasterKeysLeaseSeconds = 0 }()
+	defer SetLeaderCheck(isLeader)
+	SetLeaderCheck(func() bool { return true })
+	server := newEtcdTestServer(t)
+
+	cluster := newEtcdTestCluster(t, server)
+	test.S(t).ExpectNil(cluster.putKVPairs(etcdTestClusterPairs("cluster1", "db-1")))
+	test.S(t).ExpectTrue(cluster.keepingAlive)
+
+	SetLeaderCheck(func() bool { return false })
+	stopped := make(chan struct{})
+	go func() {
+		cluster.keepLeaseAlive(time.Millisecond)
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("keepLeaseAlive did not stop")
+	}
+	test.S(t).ExpectEquals(cluster.leaseId, clientv3.LeaseID(0))
+	test.S(t).ExpectEquals(len(cluster.leasedPairs), 0)
+	test.S(t).ExpectFalse(cluster.keepingAlive)
+}
+
+func TestEtcdStoreDistributePairs(t *testing.T) {
+	config.Config.KVClusterMasterPrefix = "mysql/master"
+	localServer := newEtcdTestServer(t)
+	remoteServer := newEtcdTestServer(t)
+
+	kvPairs := append(etcdTestClusterPairs("cluster1", "db-1"), etcdTestClusterPairs("cluster2", "db-2")...)
+	remoteServer.put("mysql/master/cluster1", "db-1:3306")
+	remoteServer.put("mysql/master/cluster1/hostname", "db-0")
+	remoteServer.put("mysql/master/cluster1/port", "3306")
+
+	store := &etcdStore{
+		cluster:       newEtcdTestCluster(t, localServer),
+		crossClusters: []*etcdCluster{newEtcdTestCluster(t, remoteServer)},
+	}
+	err := store.DistributePairs(kvPairs)
+	test.S(t).ExpectNil(err)
+	for _, key := range []string{"mysql/master/cluster1/hostname", "mysql/master/cluster2"} {
+		_, found := localServer.get(key)
+		test.S(t).ExpectTrue(found)
+	}
+	keyValue, _ := remoteServer.get("mysql/master/cluster1/hostname")
+	test.S(t).ExpectEquals(string(keyValue.Value), "db-1")
+	// in place entries are not rewritten
+	keyValue, _ = remoteServer.get("mysql/master/cluster1")
+	test.S(t).ExpectEquals(keyValue.Version, int64(1))
+	keyValue, _ = remoteServer.get("mysql/master/cluster1/hostname")
+	test.S(t).ExpectEquals(keyValue.Version, int64(2))
+
+	// All in place and cached: nothing is written
+	localRevision := localServer.revision()
+	remoteRevision := remoteServer.revision()
+	err = store.DistributePairs(kvPairs)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(localServer.revision(), localRevision)
+	test.S(t).ExpectEquals(remoteServer.revision(), remoteRevision)
+}
+
+func TestEtcdStoreDeleteAndList(t *testing.T) {
+	config.Config.KVClusterMasterPrefix = "mysql/master"
+	config.Config.EtcdMasterKeysLeaseSeconds = 60
+	defer func() { config.Config.EtcdMasterKeysLeaseSeconds = 0 }()
+	defer SetLeaderCheck(isLeader)
+	SetLeaderCheck(func() bool { return true })
+	localServer := newEtcdTestServer(t)
+	remoteServer := newEtcdTestServer(t)
+
+	cluster := newEtcdTestCluster(t, localServer)
+	remoteCluster := newEtcdTestCluster(t, remoteServer)
+	store := &etcdStore{cluster: cluster, crossClusters: []*etcdCluster{remoteCluster}}
+
+	kvPairs := append(etcdTestClusterPairs("foo", "db-1"), etcdTestClusterPairs("foobar", "db-2")...)
+	test.S(t).ExpectNil(store.DistributePairs(kvPairs))
+
+	listed, err := store.ListKeyPrefix("mysql/master/foo/")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(listed), 2)
+	test.S(t).ExpectEquals(listed[0].Key, "mysql/master/foo/hostname")
+	test.S(t).ExpectEquals(listed[0].Value, "db-1")
+	listed, err = store.ListKeyPrefix("mysql/master/foo")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(listed), 6)
+
+	test.S(t).ExpectNil(store.DeleteKeyPrefix("mysql/master/foo/"))
+	test.S(t).ExpectNil(store.DeleteKey("mysql/master/foo"))
+	for _, server := range []*etcdTestServer{localServer, remoteServer} {
+		_, found := server.get("mysql/master/foo")
+		test.S(t).ExpectFalse(found)
+		_, found = server.get("mysql/master/foo/hostname")
+		test.S(t).ExpectFalse(found)
+		_, found = server.get("mysql/master/foobar/hostname")
+		test.S(t).ExpectTrue(found)
+	}
+	// deleted keys are no longer leased, nor cached
+	_, found := cluster.leasedPairs["mysql/master/foo/port"]
+	test.S(t).ExpectFalse(found)
+	_, found = cluster.leasedPairs["mysql/master/foobar/port"]
+	test.S(t).ExpectTrue(found)
+	_, found = remoteCluster.kvCache.Get(remoteCluster.cacheKey("mysql/master/foo"))
+	test.S(t).ExpectFalse(found)
+	test.S(t).ExpectNil(store.DistributePairs(kvPairs))
+	_, found = remoteServer.get("mysql/master/foo/hostname")
+	test.S(t).ExpectTrue(found)
+}
+
+func TestEtcdStoreWatchKeyPrefix(t *testing.T) {
+	server := newEtcdTestServer(t)
+	store := &etcdStore{cluster: newEtcdTestCluster(t, server)}
+	test.S(t).ExpectNil(store.PutKVPairs([]*KVPair{
+		NewKVPair("mysql/master/c1", "db-1:3306"),
+		NewKVPair("mysql/master/c1/hostname", "db-1"),
+		NewKVPair("mysql/replicas/c1", "db-2:3306"),
+	}))
+	{
+		subscription, err := store.WatchKeyPrefix("mysql/master/c1")
+		test.S(t).ExpectNil(err)
+		event := <-subscription.Events
+		test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventPut, Key: "mysql/master/c1", Value: "db-1:3306"})
+		event = <-subscription.Events
+		test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventPut, Key: "mysql/master/c1/hostname", Value: "db-1"})
+		event = <-subscription.Events
+		test.S(t).ExpectEquals(event.Op, KVEventSynced)
+
+		// Writes by other clients are watched just the same; unchanged values and other prefixes are not notified
+		server.put("mysql/replicas/c1", "db-3:3306")
+		server.put("mysql/master/c1/hostname", "db-1")
+		server.put("mysql/master/c1", "db-2:3306")
+		event = <-subscription.Events
+		test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventPut, Key: "mysql/master/c1", Value: "db-2:3306"})
+		test.S(t).ExpectNil(store.DeleteKeyPrefix("mysql/master/c1/"))
+		event = <-subscription.Events
+		test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventDelete, Key: "mysql/master/c1/hostname"})
+
+		subscription.Close()
+		_, open := <-subscription.Events
+		test.S(t).ExpectFalse(open)
+	}
+	{
+		// Not configured
+		_, err := (&etcdStore{}).WatchKeyPrefix("mysql/master/")
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestEtcdClusterEndpointFailover(t *testing.T) {
+	downURL := freeEtcdTestURL(t, "http")
+	server := newEtcdTestServer(t)
+
+	cluster, err := newEtcdCluster([]string{downURL.String(), server.URL + "/"}, nil)
+	test.S(t).ExpectNil(err)
+	defer cluster.client.Close()
+	err = cluster.putKVPairs([]*KVPair{NewKVPair("some/key", "value")})
+	test.S(t).ExpectNil(err)
+	value, found, err := cluster.getKeyValue("some/key")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(found)
+	test.S(t).ExpectEquals(value, "value")
+
+	cluster, err = newEtcdCluster([]string{downURL.String()}, nil)
+	test.S(t).ExpectNil(err)
+	defer cluster.client.Close()
+	_, _, err = cluster.getKeyValue("some/key")
+	test.S(t).ExpectNotNil(err)
+}
+
+// writeEtcdTestPEM writes a PEM block into given directory, returning its path
+func writeEtcdTestPEM(t *testing.T, dir string, name string, blockType string, bytes []byte) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: bytes}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeEtcdTestKeyPair writes a self signed certificate and its key into given directory, returning their paths
+func writeEtcdTestKeyPair(t *testing.T, dir string, name string, template *x509.Certificate) (certFile string, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.S(t).ExpectNil(err)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	test.S(t).ExpectNil(err)
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	test.S(t).ExpectNil(err)
+	return writeEtcdTestPEM(t, dir, name+".pem", "CERTIFICATE", cert), writeEtcdTestPEM(t, dir, name+".key", "EC PRIVATE KEY", keyBytes)
+}
+
+func TestEtcdStoreTLS(t *testing.T) {
+	dir := t.TempDir()
+	serverCertFile, serverKeyFile := writeEtcdTestKeyPair(t, dir, "server", &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "etcd"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	})
+	clientCertFile, clientKeyFile := writeEtcdTestKeyPair(t, dir, "client", &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "orchestrator"},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	})
+	server := newEtcdTestServer(t, transport.TLSInfo{
+		CertFile:       serverCertFile,
+		KeyFile:        serverKeyFile,
+		TrustedCAFile:  clientCertFile,
+		ClientCertAuth: true,
+	})
+
+	defer func() {
+		config.Config.EtcdSSLCAFile = ""
+		config.Config.EtcdSSLCertFile = ""
+		config.Config.EtcdSSLPrivateKeyFile = ""
+	}()
+	config.Config.EtcdSSLCAFile = serverCertFile
+
+	// No client certificate: rejected by server
+	tlsConfig, err := newEtcdTLSConfig()
+	test.S(t).ExpectNil(err)
+	cluster, err := newEtcdCluster([]string{server.URL}, tlsConfig)
+	test.S(t).ExpectNil(err)
+	defer cluster.client.Close()
+	_, _, err = cluster.getKeyValue("some/key")
+	test.S(t).ExpectNotNil(err)
+
+	config.Config.EtcdSSLCertFile = clientCertFile
+	config.Config.EtcdSSLPrivateKeyFile = clientKeyFile
+	tlsConfig, err = newEtcdTLSConfig()
+	test.S(t).ExpectNil(err)
+	cluster, err = newEtcdCluster([]string{server.URL}, tlsConfig)
+	test.S(t).ExpectNil(err)
+	defer cluster.client.Close()
+	test.S(t).ExpectNil(cluster.putKVPairs([]*KVPair{NewKVPair("some/key", "value")}))
+	value, found, err := cluster.getKeyValue("some/key")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(found)
+	test.S(t).ExpectEquals(value, "value")
+}
@@ -0,0 +1,578 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kv
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/orchestrator/go/config"
+)
+
+// Temporary files are written alongside their target file, then renamed onto it
+const fileSinkTempFilePrefix = ".orchestrator-kv-"
+
+// Time a socket subscriber has to send its prefix line
+const fileSinkSocketPrefixTimeout = 10 * time.Second
+
+var envVariableNameInvalidCharsRegexp = regexp.MustCompile("[^A-Z0-9_]")
+
+// File key-value store: a tree of files, one file per key, for local agents to read or subscribe to. Each file
+// is replaced atomically, but a write of multiple keys is not: see clusterDocumentsDirectory.
+type fileKVStore struct {
+	directory   string
+	format      string
+	mutex       sync.Mutex
+	subscribers *kvSubscribers
+
+	// When set, the pairs of each cluster are also written together, in a single file per cluster
+	clusterDocumentsDirectory string
+}
+
+// NewFileKVStore creates a file store writing under KVFileSinkDirectory. Key "mysql/master/mycluster" is
+// written to "mysql/master/mycluster.json" (or ".env") under that directory.
+func NewFileKVStore() KVStore {
+	store := newFileKVStore(config.Config.KVFileSinkDirectory, config.Config.KVFileSinkFormat)
+	store.clusterDocumentsDirectory = config.Config.KVFileSinkClusterDocumentsDirectory
+	return store
+}
+
+func newFileKVStore(directory string, format string) *fileKVStore {
+	return &fileKVStore{
+		directory:   directory,
+		format:      format,
+		subscribers: newKVSubscribers(),
+	}
+}
+
+// normalizeFileSinkKey strips leading and trailing slashes, such that "/mysql/master/c1" and
+// "mysql/master/c1" are the same key
+func normalizeFileSinkKey(key string) string {
+	return strings.Trim(key, "/")
+}
+
+// validateFileSinkKey makes sure a (normalized) key or prefix does not map outside the store's directory,
+// nor onto a temporary file
+func validateFileSinkKey(key string) error {
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "." || segment == ".." || strings.HasPrefix(segment, fileSinkTempFilePrefix) {
+			return fmt.Errorf("file KV store: invalid key: %s", key)
+		}
+	}
+	return nil
+}
+
+func (this *fileKVStore) extension() string {
+	return "." + this.format
+}
+
+// keyPath returns the path of the file holding given key
+func (this *fileKVStore) keyPath(key string) (string, error) {
+	key = normalizeFileSinkKey(key)
+	if key == "" || strings.Contains(key, "//") {
+		return "", fmt.Errorf("file KV store: invalid key: %s", key)
+	}
+	if err := validateFileSinkKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(this.directory, filepath.FromSlash(key)) + this.extension(), nil
+}
+
+// envVariableName turns a key into an environment variable name, e.g. "mysql/master/my-cluster" into
+// MYSQL_MASTER_MY_CLUSTER
+func envVariableName(key string) string {
+	return envVariableNameInvalidCharsRegexp.ReplaceAllString(strings.ToUpper(key), "_")
+}
+
+// envQuote single quotes a value, shell style
+func envQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+func envUnquote(quoted string) (string, error) {
+	if len(quoted) < 2 || !strings.HasPrefix(quoted, "'") || !strings.HasSuffix(quoted, "'") {
+		return "", fmt.Errorf("file KV store: unexpected env value: %s", quoted)
+	}
+	return strings.Replace(quoted[1:len(quoted)-1], `'\''`, "'", -1), nil
+}
+
+// encode formats a file's content: a JSON object with Key and Value, or a single NAME='value' line
+func (this *fileKVStore) encode(key string, value string) ([]byte, error) {
+	if this.format == "env" {
+		return []byte(fmt.Sprintf("%s=%s\n", envVariableName(key), envQuote(value))), nil
+	}
+	content, err := json.Marshal(NewKVPair(key, value))
+	if err != nil {
+		return content, err
+	}
+	return append(content, '\n'), nil
+}
+
+func (this *fileKVStore) decode(content []byte) (value string, err error) {
+	if this.format == "env" {
+		tokens := strings.SplitN(strings.TrimRight(string(content), "\n"), "=", 2)
+		if len(tokens) != 2 {
+			return "", fmt.Errorf("file KV store: unexpected env content: %s", string(content))
+		}
+		return envUnquote(tokens[1])
+	}
+	kvPair := &KVPair{}
+	if err := json.Unmarshal(content, kvPair); err != nil {
+		return "", err
+	}
+	return kvPair.Value, nil
+}
+
+// encodeClusterDocument formats a cluster document's content: a JSON object mapping keys to values, or a
+// NAME='value' line per key. Pairs are expected sorted by key.
+func (this *fileKVStore) encodeClusterDocument(kvPairs [](*KVPair)) ([]byte, error) {
+	if this.format == "env" {
+		content := []byte{}
+		for _, kvPair := range kvPairs {
+			line, err := this.encode(kvPair.Key, kvPair.Value)
+			if err != nil {
+				return content, err
+			}
+			content = append(content, line...)
+		}
+		return content, nil
+	}
+	values := map[string]string{}
+	for _, kvPair := range kvPairs {
+		values[kvPair.Key] = kvPair.Value
+	}
+	content, err := json.Marshal(values)
+	if err != nil {
+		return content, err
+	}
+	return append(content, '\n'), nil
+}
+
+// getClusterKey returns the key of the cluster given key belongs to, i.e. KVClusterMasterPrefix followed by
+// the cluster's alias. found is false for keys which do not belong to any cluster, and when cluster documents
+// are not written.
+func (this *fileKVStore) getClusterKey(key string) (clusterKey string, found bool) {
+	if this.clusterDocumentsDirectory == "" {
+		return "", false
+	}
+	clusterMasterPrefix := normalizeFileSinkKey(config.Config.KVClusterMasterPrefix) + "/"
+	key = normalizeFileSinkKey(key)
+	if !strings.HasPrefix(key, clusterMasterPrefix) {
+		return "", false
+	}
+	clusterAlias := strings.Split(strings.TrimPrefix(key, clusterMasterPrefix), "/")[0]
+	return clusterMasterPrefix + clusterAlias, true
+}
+
+// writeFileAtomically writes a file such that readers see either the previous or the new content, never
+// a partial write
+func writeFileAtomically(fileName string, content []byte) (err error) {
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return err
+	}
+	tempFile, err := ioutil.TempFile(filepath.Dir(fileName), fileSinkTempFilePrefix)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tempFile.Name())
+		}
+	}()
+	if _, err = tempFile.Write(content); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err = tempFile.Sync(); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err = tempFile.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tempFile.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), fileName)
+}
+
+func (this *fileKVStore) putKeyValue(key string, value string) (err error) {
+	fileName, err := this.keyPath(key)
+	if err != nil {
+		return err
+	}
+	content, err := this.encode(normalizeFileSinkKey(key), value)
+	if err != nil {
+		return err
+	}
+	if currentContent, err := ioutil.ReadFile(fileName); err == nil && string(currentContent) == string(content) {
+		// Unchanged: subscribers only hear of actual changes
+		return nil
+	}
+	if err := writeFileAtomically(fileName, content); err != nil {
+		return err
+	}
+	this.subscribers.notify(&KVEvent{Op: KVEventPut, Key: normalizeFileSinkKey(key), Value: value})
+	return nil
+}
+
+func (this *fileKVStore) PutKeyValue(key string, value string) (err error) {
+	return this.PutKVPairs([]*KVPair{NewKVPair(key, value)})
+}
+
+// PutKVPairs writes each pair to its own file. Each file is replaced atomically, but the pairs are not written
+// all at once: a reader may see some pairs updated and others not yet. Cluster documents, where configured,
+// are then each replaced at once.
+func (this *fileKVStore) PutKVPairs(kvPairs []*KVPair) (err error) {
+	if this.directory == "" {
+		return nil
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	clusterKeys := map[string]bool{}
+	for _, kvPair := range kvPairs {
+		if err = this.putKeyValue(kvPair.Key, kvPair.Value); err != nil {
+			break
+		}
+		if clusterKey, found := this.getClusterKey(kvPair.Key); found {
+			clusterKeys[clusterKey] = true
+		}
+	}
+	if documentsErr := this.writeClusterDocuments(clusterKeys); err == nil {
+		err = documentsErr
+	}
+	return log.Errore(err)
+}
+
+// getKeyValue reads a key's file. It expects the mutex to be held.
+func (this *fileKVStore) getKeyValue(key string) (value string, found bool, err error) {
+	fileName, err := this.keyPath(key)
+	if err != nil {
+		return value, found, err
+	}
+	content, err := ioutil.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return value, false, nil
+	}
+	if err != nil {
+		return value, found, err
+	}
+	value, err = this.decode(content)
+	return value, (err == nil), err
+}
+
+func (this *fileKVStore) GetKeyValue(key string) (value string, found bool, err error) {
+	if this.directory == "" {
+		return value, found, nil
+	}
+	value, found, err = this.getKeyValue(key)
+	return value, found, log.Errore(err)
+}
+
+// writeClusterDocuments replaces the documents of given clusters, each with the cluster's current pairs, in a
+// single rename. The document of a cluster which has no pairs left is removed. It expects the mutex to be held.
+func (this *fileKVStore) writeClusterDocuments(clusterKeys map[string]bool) (err error) {
+	if this.clusterDocumentsDirectory == "" {
+		return nil
+	}
+	for clusterKey := range clusterKeys {
+		kvPairs, err := this.listKeyPrefix(clusterKey + "/")
+		if err != nil {
+			return err
+		}
+		value, found, err := this.getKeyValue(clusterKey)
+		if err != nil {
+			return err
+		}
+		if found {
+			kvPairs = append([](*KVPair){NewKVPair(clusterKey, value)}, kvPairs...)
+		}
+		fileName := filepath.Join(this.clusterDocumentsDirectory, path.Base(clusterKey)) + this.extension()
+		if len(kvPairs) == 0 {
+			if err := os.Remove(fileName); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		content, err := this.encodeClusterDocument(kvPairs)
+		if err != nil {
+			return err
+		}
+		if currentContent, err := ioutil.ReadFile(fileName); err == nil && string(currentContent) == string(content) {
+			continue
+		}
+		if err := writeFileAtomically(fileName, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *fileKVStore) DistributePairs(kvPairs [](*KVPair)) (err error) {
+	return nil
+}
+
+// deleteKey removes a key's file, along with directories it leaves empty
+func (this *fileKVStore) deleteKey(key string) (err error) {
+	fileName, err := this.keyPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(fileName); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for dir := filepath.Dir(fileName); dir != filepath.Clean(this.directory); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			// Not empty
+			break
+		}
+	}
+	this.subscribers.notify(&KVEvent{Op: KVEventDelete, Key: normalizeFileSinkKey(key)})
+	return nil
+}
+
+func (this *fileKVStore) DeleteKey(key string) (err error) {
+	if this.directory == "" {
+		return nil
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	err = this.deleteKey(key)
+	if clusterKey, found := this.getClusterKey(key); found && err == nil {
+		err = this.writeClusterDocuments(map[string]bool{clusterKey: true})
+	}
+	return log.Errore(err)
+}
+
+func (this *fileKVStore) DeleteKeyPrefix(prefix string) (err error) {
+	if this.directory == "" {
+		return nil
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	kvPairs, err := this.listKeyPrefix(prefix)
+	if err != nil {
+		return log.Errore(err)
+	}
+	clusterKeys := map[string]bool{}
+	for _, kvPair := range kvPairs {
+		if err = this.deleteKey(kvPair.Key); err != nil {
+			break
+		}
+		if clusterKey, found := this.getClusterKey(kvPair.Key); found {
+			clusterKeys[clusterKey] = true
+		}
+	}
+	if documentsErr := this.writeClusterDocuments(clusterKeys); err == nil {
+		err = documentsErr
+	}
+	return log.Errore(err)
+}
+
+// listKeyPrefix walks the files under the deepest directory given prefix implies
+func (this *fileKVStore) listKeyPrefix(prefix string) (kvPairs [](*KVPair), err error) {
+	prefix = strings.TrimLeft(prefix, "/")
+	if err := validateFileSinkKey(prefix); err != nil {
+		return kvPairs, err
+	}
+	walkRoot := filepath.Join(this.directory, filepath.FromSlash(path.Dir(prefix)))
+	err = filepath.Walk(walkRoot, func(fileName string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(fileName, this.extension()) || strings.HasPrefix(info.Name(), fileSinkTempFilePrefix) {
+			return nil
+		}
+		relativeName, err := filepath.Rel(this.directory, fileName)
+		if err != nil {
+			return err
+		}
+		key := strings.TrimSuffix(filepath.ToSlash(relativeName), this.extension())
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		content, err := ioutil.ReadFile(fileName)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		value, err := this.decode(content)
+		if err != nil {
+			return err
+		}
+		kvPairs = append(kvPairs, NewKVPair(key, value))
+		return nil
+	})
+	sort.Slice(kvPairs, func(i, j int) bool {
+		return kvPairs[i].Key < kvPairs[j].Key
+	})
+	return kvPairs, err
+}
+
+func (this *fileKVStore) ListKeyPrefix(prefix string) (kvPairs [](*KVPair), err error) {
+	if this.directory == "" {
+		return kvPairs, nil
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	kvPairs, err = this.listKeyPrefix(prefix)
+	return kvPairs, log.Errore(err)
+}
+
+// WatchKeyPrefix subscribes to the pairs beginning with given prefix: the pairs which exist are queued first,
+// followed by a synced event; changes follow as they are written.
+func (this *fileKVStore) WatchKeyPrefix(prefix string) (*KVSubscription, error) {
+	if this.directory == "" {
+		return nil, fmt.Errorf("file KV store: KVFileSinkDirectory is not configured")
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	kvPairs, err := this.listKeyPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return this.subscribers.subscribe(strings.TrimLeft(prefix, "/"), kvPairs), nil
+}
+
+// WriteKVEvents writes a subscription's events as newline delimited JSON, until the subscription ends or a
+// write fails. flush, when non nil, is called whenever there are no further events queued.
+func WriteKVEvents(writer io.Writer, subscription *KVSubscription, flush func()) error {
+	encoder := json.NewEncoder(writer)
+	for event := range subscription.Events {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+		if flush != nil && len(subscription.Events) == 0 {
+			flush()
+		}
+	}
+	return nil
+}
+
+// serveConnection streams to a socket subscriber. The subscriber first sends a single line: the prefix of
+// keys to stream, same as /api/kv-subscribe's ?prefix=, or an empty line for all keys.
+func (this *fileKVStore) serveConnection(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(fileSinkSocketPrefixTimeout))
+	prefix, err := reader.ReadString('\n')
+	if err != nil {
+		log.Errorf("file KV store: reading subscriber's prefix: %+v", err)
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	subscription, err := this.WatchKeyPrefix(strings.TrimSpace(prefix))
+	if err != nil {
+		log.Errore(err)
+		return
+	}
+	defer subscription.Close()
+	go func() {
+		// Subscribers only listen. Reading returns once the peer goes away.
+		io.Copy(ioutil.Discard, reader)
+		subscription.Close()
+	}()
+	WriteKVEvents(conn, subscription, nil)
+}
+
+// listenKVFileSinkSocket listens on given socket file, then sets its permissions. A socket file left behind by
+// a previous run fails listening, and is removed first; anything other than a socket is left be.
+func listenKVFileSinkSocket(socketFile string, socketMode os.FileMode) (listener net.Listener, err error) {
+	if fileInfo, err := os.Lstat(socketFile); err == nil {
+		if fileInfo.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("file KV store: %s exists and is not a socket", socketFile)
+		}
+		if err := os.Remove(socketFile); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	listener, err = net.Listen("unix", socketFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(socketFile, socketMode); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// ServeKVFileSinkSocket listens on KVFileSinkSocket, and streams to each connection the file store's pairs and
+// then their changes, as newline delimited JSON events. It only returns should listening fail.
+func ServeKVFileSinkSocket() error {
+	socketFile := config.Config.KVFileSinkSocket
+	if socketFile == "" {
+		return nil
+	}
+	socketMode, err := strconv.ParseUint(config.Config.KVFileSinkSocketMode, 8, 32)
+	if err != nil {
+		return log.Errore(err)
+	}
+	InitKVStores()
+	store, err := getKVStore(FileKVStoreName)
+	if err != nil {
+		return log.Errore(err)
+	}
+	fileStore, ok := store.(*fileKVStore)
+	if !ok {
+		return log.Errorf("unexpected file KV store type: %T", store)
+	}
+	listener, err := listenKVFileSinkSocket(socketFile, os.FileMode(socketMode))
+	if err != nil {
+		return log.Errore(err)
+	}
+	defer listener.Close()
+	log.Infof("file KV store: streaming on %s", socketFile)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return log.Errore(err)
+		}
+		go fileStore.serveConnection(conn)
+	}
+}
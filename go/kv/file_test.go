@@ -0,0 +1,295 @@
+package kv
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+)
+
+func newTestFileKVStore(t *testing.T, format string) (store *fileKVStore, cleanup func()) {
+	dir, err := ioutil.TempDir("", "orchestrator-file-kv-test")
+	test.S(t).ExpectNil(err)
+	return newFileKVStore(dir, format), func() { os.RemoveAll(dir) }
+}
+
+func TestFileKVStoreJSON(t *testing.T) {
+	store, cleanup := newTestFileKVStore(t, "json")
+	defer cleanup()
+
+	err := store.PutKVPairs([]*KVPair{
+		NewKVPair("mysql/master/c1", "db-1:3306"),
+		NewKVPair("mysql/master/c1/hostname", "db-1"),
+		NewKVPair("/mysql/master/c2", "db-7:3306"),
+	})
+	test.S(t).ExpectNil(err)
+
+	content, err := ioutil.ReadFile(filepath.Join(store.directory, "mysql", "master", "c1.json"))
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(string(content), `{"Key":"mysql/master/c1","Value":"db-1:3306"}`+"\n")
+
+	value, found, err := store.GetKeyValue("mysql/master/c2")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(found)
+	test.S(t).ExpectEquals(value, "db-7:3306")
+	_, found, err = store.GetKeyValue("mysql/master/c3")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(found)
+
+	kvPairs, err := store.ListKeyPrefix("mysql/master/c1")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(kvPairs), 2)
+	test.S(t).ExpectEquals(kvPairs[0].Key, "mysql/master/c1")
+	test.S(t).ExpectEquals(kvPairs[1].Key, "mysql/master/c1/hostname")
+	test.S(t).ExpectEquals(kvPairs[1].Value, "db-1")
+
+	test.S(t).ExpectNil(store.DeleteKeyPrefix("mysql/master/c1"))
+	kvPairs, err = store.ListKeyPrefix("")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(kvPairs), 1)
+	test.S(t).ExpectEquals(kvPairs[0].Key, "mysql/master/c2")
+	// Directories left empty are removed
+	_, err = os.Stat(filepath.Join(store.directory, "mysql", "master", "c1"))
+	test.S(t).ExpectTrue(os.IsNotExist(err))
+
+	test.S(t).ExpectNil(store.DeleteKey("mysql/master/c2"))
+	test.S(t).ExpectNil(store.DeleteKey("mysql/master/c2"))
+	kvPairs, err = store.ListKeyPrefix("")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(kvPairs), 0)
+}
+
+func TestFileKVStoreEnv(t *testing.T) {
+	store, cleanup := newTestFileKVStore(t, "env")
+	defer cleanup()
+
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/master/my-cluster", "db-1:3306"))
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/status/my-cluster", `{"Status":"it's ok"}`))
+
+	content, err := ioutil.ReadFile(filepath.Join(store.directory, "mysql", "master", "my-cluster.env"))
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(string(content), "MYSQL_MASTER_MY_CLUSTER='db-1:3306'\n")
+
+	value, found, err := store.GetKeyValue("mysql/status/my-cluster")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(found)
+	test.S(t).ExpectEquals(value, `{"Status":"it's ok"}`)
+}
+
+func TestFileKVStoreClusterDocuments(t *testing.T) {
+	originalClusterMasterPrefix := config.Config.KVClusterMasterPrefix
+	defer func() { config.Config.KVClusterMasterPrefix = originalClusterMasterPrefix }()
+	config.Config.KVClusterMasterPrefix = "/mysql/master"
+
+	{
+		store, cleanup := newTestFileKVStore(t, "json")
+		defer cleanup()
+		store.clusterDocumentsDirectory = filepath.Join(store.directory, "..", filepath.Base(store.directory)+"-clusters")
+		defer os.RemoveAll(store.clusterDocumentsDirectory)
+		documentPath := func(clusterAlias string) string {
+			return filepath.Join(store.clusterDocumentsDirectory, clusterAlias+".json")
+		}
+
+		test.S(t).ExpectNil(store.PutKVPairs([]*KVPair{
+			NewKVPair("mysql/master/c1", "db-1:3306"),
+			NewKVPair("mysql/master/c1/hostname", "db-1"),
+			NewKVPair("mysql/master/c1/port", "3306"),
+			NewKVPair("mysql/master/c2", "db-7:3306"),
+			NewKVPair("mysql/status/c1", "ok"),
+		}))
+		content, err := ioutil.ReadFile(documentPath("c1"))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(string(content), `{"mysql/master/c1":"db-1:3306","mysql/master/c1/hostname":"db-1","mysql/master/c1/port":"3306"}`+"\n")
+		content, err = ioutil.ReadFile(documentPath("c2"))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(string(content), `{"mysql/master/c2":"db-7:3306"}`+"\n")
+
+		// A master change replaces the whole document
+		test.S(t).ExpectNil(store.PutKVPairs([]*KVPair{
+			NewKVPair("mysql/master/c1", "db-2:3306"),
+			NewKVPair("mysql/master/c1/hostname", "db-2"),
+		}))
+		content, err = ioutil.ReadFile(documentPath("c1"))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(string(content), `{"mysql/master/c1":"db-2:3306","mysql/master/c1/hostname":"db-2","mysql/master/c1/port":"3306"}`+"\n")
+
+		test.S(t).ExpectNil(store.DeleteKey("mysql/master/c1/port"))
+		content, err = ioutil.ReadFile(documentPath("c1"))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(string(content), `{"mysql/master/c1":"db-2:3306","mysql/master/c1/hostname":"db-2"}`+"\n")
+
+		// Removed along with the cluster's last key
+		test.S(t).ExpectNil(store.DeleteKeyPrefix("mysql/master/c1"))
+		_, err = os.Stat(documentPath("c1"))
+		test.S(t).ExpectTrue(os.IsNotExist(err))
+		_, err = os.Stat(documentPath("c2"))
+		test.S(t).ExpectNil(err)
+
+		files, err := ioutil.ReadDir(store.clusterDocumentsDirectory)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(files), 1)
+	}
+	{
+		store, cleanup := newTestFileKVStore(t, "env")
+		defer cleanup()
+		store.clusterDocumentsDirectory = filepath.Join(store.directory, "..", filepath.Base(store.directory)+"-clusters")
+		defer os.RemoveAll(store.clusterDocumentsDirectory)
+
+		test.S(t).ExpectNil(store.PutKVPairs([]*KVPair{
+			NewKVPair("mysql/master/my-cluster/hostname", "db-1"),
+			NewKVPair("mysql/master/my-cluster", "db-1:3306"),
+		}))
+		content, err := ioutil.ReadFile(filepath.Join(store.clusterDocumentsDirectory, "my-cluster.env"))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(string(content), "MYSQL_MASTER_MY_CLUSTER='db-1:3306'\nMYSQL_MASTER_MY_CLUSTER_HOSTNAME='db-1'\n")
+	}
+	{
+		// Not written unless configured
+		store, cleanup := newTestFileKVStore(t, "json")
+		defer cleanup()
+		test.S(t).ExpectNil(store.PutKeyValue("mysql/master/c1", "db-1:3306"))
+		test.S(t).ExpectEquals(store.clusterDocumentsDirectory, "")
+	}
+}
+
+func TestFileKVStoreInvalidKeys(t *testing.T) {
+	store, cleanup := newTestFileKVStore(t, "json")
+	defer cleanup()
+
+	test.S(t).ExpectNotNil(store.PutKeyValue("mysql/../../etc/passwd", "x"))
+	test.S(t).ExpectNotNil(store.PutKeyValue("mysql//master", "x"))
+	test.S(t).ExpectNotNil(store.PutKeyValue("/", "x"))
+	test.S(t).ExpectNotNil(store.PutKeyValue("mysql/"+fileSinkTempFilePrefix+"1", "x"))
+	_, err := store.ListKeyPrefix("../")
+	test.S(t).ExpectNotNil(err)
+}
+
+func TestFileKVStoreUnconfigured(t *testing.T) {
+	store := newFileKVStore("", "json")
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/master/c1", "db-1:3306"))
+	_, found, err := store.GetKeyValue("mysql/master/c1")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(found)
+	_, err = store.WatchKeyPrefix("")
+	test.S(t).ExpectNotNil(err)
+}
+
+func TestFileKVStoreSubscribe(t *testing.T) {
+	store, cleanup := newTestFileKVStore(t, "json")
+	defer cleanup()
+
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/master/c1", "db-1:3306"))
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/replicas/c1", "db-2:3306"))
+
+	subscription, err := store.WatchKeyPrefix("mysql/master/")
+	test.S(t).ExpectNil(err)
+	event := <-subscription.Events
+	test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventPut, Key: "mysql/master/c1", Value: "db-1:3306"})
+	event = <-subscription.Events
+	test.S(t).ExpectEquals(event.Op, KVEventSynced)
+
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/replicas/c1", "db-3:3306"))
+	// Unchanged values are not notified
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/master/c1", "db-1:3306"))
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/master/c1", "db-2:3306"))
+	test.S(t).ExpectNil(store.DeleteKey("mysql/master/c1"))
+	event = <-subscription.Events
+	test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventPut, Key: "mysql/master/c1", Value: "db-2:3306"})
+	event = <-subscription.Events
+	test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventDelete, Key: "mysql/master/c1"})
+	test.S(t).ExpectEquals(len(subscription.Events), 0)
+
+	subscription.Close()
+	subscription.Close()
+	_, open := <-subscription.Events
+	test.S(t).ExpectFalse(open)
+}
+
+func TestFileKVStoreSlowSubscriberDropped(t *testing.T) {
+	store, cleanup := newTestFileKVStore(t, "json")
+	defer cleanup()
+
+	subscription, err := store.WatchKeyPrefix("")
+	test.S(t).ExpectNil(err)
+	defer subscription.Close()
+	for i := 0; i <= kvSubscriberBufferSize; i++ {
+		test.S(t).ExpectNil(store.PutKeyValue("mysql/master/c1", string(rune('a'+i%2))))
+	}
+	countEvents := 0
+	for range subscription.Events {
+		countEvents++
+	}
+	test.S(t).ExpectEquals(countEvents, kvSubscriberBufferSize)
+	test.S(t).ExpectEquals(store.subscribers.count(), 0)
+}
+
+func TestFileKVStoreServeConnection(t *testing.T) {
+	store, cleanup := newTestFileKVStore(t, "json")
+	defer cleanup()
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/master/c1", "db-1:3306"))
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go store.serveConnection(serverConn)
+
+	// The subscriber first sends its prefix
+	_, err := clientConn.Write([]byte("mysql/master/\n"))
+	test.S(t).ExpectNil(err)
+
+	scanner := bufio.NewScanner(clientConn)
+	readEvent := func() *KVEvent {
+		test.S(t).ExpectTrue(scanner.Scan())
+		event := &KVEvent{}
+		test.S(t).ExpectNil(json.Unmarshal(scanner.Bytes(), event))
+		return event
+	}
+	test.S(t).ExpectEquals(*readEvent(), KVEvent{Op: KVEventPut, Key: "mysql/master/c1", Value: "db-1:3306"})
+	test.S(t).ExpectEquals(readEvent().Op, KVEventSynced)
+
+	// Keys outside the prefix are not streamed
+	go func() {
+		store.PutKeyValue("mysql/replicas/c1", "db-2:3306")
+		store.PutKeyValue("mysql/master/c1", "db-2:3306")
+	}()
+	test.S(t).ExpectEquals(*readEvent(), KVEvent{Op: KVEventPut, Key: "mysql/master/c1", Value: "db-2:3306"})
+}
+
+func TestListenKVFileSinkSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "orchestrator-file-kv-socket-test")
+	test.S(t).ExpectNil(err)
+	defer os.RemoveAll(dir)
+	socketFile := filepath.Join(dir, "kv.sock")
+	{
+		listener, err := listenKVFileSinkSocket(socketFile, 0600)
+		test.S(t).ExpectNil(err)
+		fileInfo, err := os.Lstat(socketFile)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(fileInfo.Mode().Perm(), os.FileMode(0600))
+		listener.(*net.UnixListener).SetUnlinkOnClose(false)
+		listener.Close()
+	}
+	{
+		// A socket left behind is replaced
+		listener, err := listenKVFileSinkSocket(socketFile, 0660)
+		test.S(t).ExpectNil(err)
+		fileInfo, err := os.Lstat(socketFile)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(fileInfo.Mode().Perm(), os.FileMode(0660))
+		listener.Close()
+	}
+	{
+		// Anything other than a socket is not removed
+		test.S(t).ExpectNil(ioutil.WriteFile(socketFile, []byte("data"), 0644))
+		_, err := listenKVFileSinkSocket(socketFile, 0660)
+		test.S(t).ExpectNotNil(err)
+		content, err := ioutil.ReadFile(socketFile)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(string(content), "data")
+	}
+}
@@ -17,20 +17,32 @@
 package kv
 
 import (
+	"sync"
+
 	"github.com/openark/golib/log"
 	"github.com/openark/golib/sqlutils"
 	"github.com/openark/orchestrator/go/db"
 )
 
-// Internal key-value store, based on relational backend
+// Internal key-value store, based on relational backend. Subscribers hear of changes written by this node:
+// writes by other nodes sharing the backend go unnoticed.
 type internalKVStore struct {
+	mutex       sync.Mutex
+	subscribers *kvSubscribers
 }
 
 func NewInternalKVStore() KVStore {
-	return &internalKVStore{}
+	return &internalKVStore{subscribers: newKVSubscribers()}
 }
 
 func (this *internalKVStore) PutKeyValue(key string, value string) (err error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	currentValue, found, err := this.GetKeyValue(key)
+	if err != nil {
+		return err
+	}
 	_, err = db.ExecOrchestrator(`
 		replace
 			into kv_store (
@@ -40,7 +52,13 @@ func (this *internalKVStore) PutKeyValue(key string, value string) (err error) {
 			)
 		`, key, value,
 	)
-	return log.Errore(err)
+	if err != nil {
+		return log.Errore(err)
+	}
+	if !found || currentValue != value {
+		this.subscribers.notify(&KVEvent{Op: KVEventPut, Key: key, Value: value})
+	}
+	return nil
 }
 
 func (this *internalKVStore) GetKeyValue(key string) (value string, found bool, err error) {
@@ -74,3 +92,73 @@ func (this *internalKVStore) PutKVPairs(kvPairs []*KVPair) (err error) {
 func (this *internalKVStore) DistributePairs(kvPairs [](*KVPair)) (err error) {
 	return nil
 }
+
+func (this *internalKVStore) DeleteKey(key string) (err error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	sqlResult, err := db.ExecOrchestrator(`
+		delete
+			from kv_store
+		where
+			store_key = ?
+		`, key,
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	if rows, err := sqlResult.RowsAffected(); err == nil && rows > 0 {
+		this.subscribers.notify(&KVEvent{Op: KVEventDelete, Key: key})
+	}
+	return nil
+}
+
+func (this *internalKVStore) DeleteKeyPrefix(prefix string) (err error) {
+	kvPairs, err := this.ListKeyPrefix(prefix)
+	if err != nil {
+		return err
+	}
+	for _, kvPair := range kvPairs {
+		if err := this.DeleteKey(kvPair.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *internalKVStore) ListKeyPrefix(prefix string) (kvPairs [](*KVPair), err error) {
+	// "like" treats "_" and "%" in prefix as wildcards, and so lists a superset of the keys; we filter precisely below
+	likePattern := prefix + "%"
+	query := `
+		select
+			store_key,
+			store_value
+		from
+			kv_store
+		where
+			store_key like ?
+		order by
+			store_key
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(likePattern), func(m sqlutils.RowMap) error {
+		kvPairs = append(kvPairs, NewKVPair(m.GetString("store_key"), m.GetString("store_value")))
+		return nil
+	})
+	if err != nil {
+		return kvPairs, log.Errore(err)
+	}
+	return filterKeyPrefix(kvPairs, prefix), nil
+}
+
+// WatchKeyPrefix subscribes to the pairs beginning with given prefix: the pairs which exist are queued first,
+// followed by a synced event; changes follow as this node writes them.
+func (this *internalKVStore) WatchKeyPrefix(prefix string) (*KVSubscription, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	kvPairs, err := this.ListKeyPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return this.subscribers.subscribe(prefix, kvPairs), nil
+}
@@ -0,0 +1,101 @@
+package kv
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/db"
+)
+
+// newTestInternalKVStore returns an internal store over an in-memory sqlite backend, with an empty kv_store table
+func newTestInternalKVStore(t *testing.T) KVStore {
+	config.Config.BackendDB = "sqlite"
+	config.Config.SQLite3DataFile = ":memory:"
+	_, err := db.OpenOrchestrator()
+	test.S(t).ExpectNil(err)
+	_, err = db.ExecOrchestrator("delete from kv_store")
+	test.S(t).ExpectNil(err)
+	return NewInternalKVStore()
+}
+
+func TestInternalKVStoreListKeyPrefix(t *testing.T) {
+	config.Config.KVClusterMasterPrefix = "mysql/master"
+	store := newTestInternalKVStore(t)
+	test.S(t).ExpectNil(store.PutKVPairs(append(etcdTestClusterPairs("foo", "db-1"), etcdTestClusterPairs("foobar", "db-2")...)))
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/master/fooXbar", "db-3"))
+	{
+		kvPairs, err := store.ListKeyPrefix("mysql/master/foo/")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(kvPairs), 2)
+		test.S(t).ExpectEquals(kvPairs[0].Key, "mysql/master/foo/hostname")
+		test.S(t).ExpectEquals(kvPairs[0].Value, "db-1")
+		test.S(t).ExpectEquals(kvPairs[1].Key, "mysql/master/foo/port")
+	}
+	{
+		kvPairs, err := store.ListKeyPrefix("mysql/master/foo")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(kvPairs), 7)
+	}
+	{
+		// "_" is a wildcard to "like", but not to the listing
+		kvPairs, err := store.ListKeyPrefix("mysql/master/foo_")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(kvPairs), 0)
+	}
+}
+
+func TestInternalKVStoreDelete(t *testing.T) {
+	config.Config.KVClusterMasterPrefix = "mysql/master"
+	store := newTestInternalKVStore(t)
+	test.S(t).ExpectNil(store.PutKVPairs(append(etcdTestClusterPairs("foo", "db-1"), etcdTestClusterPairs("foobar", "db-2")...)))
+
+	test.S(t).ExpectNil(store.DeleteKeyPrefix("mysql/master/foo/"))
+	kvPairs, err := store.ListKeyPrefix("mysql/master/foo")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(kvPairs), 4)
+	test.S(t).ExpectEquals(kvPairs[0].Key, "mysql/master/foo")
+
+	test.S(t).ExpectNil(store.DeleteKey("mysql/master/foo"))
+	_, found, err := store.GetKeyValue("mysql/master/foo")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(found)
+	value, found, err := store.GetKeyValue("mysql/master/foobar/hostname")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(found)
+	test.S(t).ExpectEquals(value, "db-2")
+
+	// Deleting what does not exist is not an error
+	test.S(t).ExpectNil(store.DeleteKey("mysql/master/foo"))
+	test.S(t).ExpectNil(store.DeleteKeyPrefix("no/such/prefix/"))
+}
+
+func TestInternalKVStoreWatchKeyPrefix(t *testing.T) {
+	store := newTestInternalKVStore(t).(*internalKVStore)
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/master/c1", "db-1:3306"))
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/replicas/c1", "db-2:3306"))
+
+	subscription, err := store.WatchKeyPrefix("mysql/master/")
+	test.S(t).ExpectNil(err)
+	event := <-subscription.Events
+	test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventPut, Key: "mysql/master/c1", Value: "db-1:3306"})
+	event = <-subscription.Events
+	test.S(t).ExpectEquals(event.Op, KVEventSynced)
+
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/replicas/c1", "db-3:3306"))
+	// Unchanged values are not notified, nor are keys which do not exist deleted
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/master/c1", "db-1:3306"))
+	test.S(t).ExpectNil(store.DeleteKey("mysql/master/c2"))
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/master/c1", "db-2:3306"))
+	test.S(t).ExpectNil(store.DeleteKeyPrefix("mysql/master/"))
+	event = <-subscription.Events
+	test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventPut, Key: "mysql/master/c1", Value: "db-2:3306"})
+	event = <-subscription.Events
+	test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventDelete, Key: "mysql/master/c1"})
+	test.S(t).ExpectEquals(len(subscription.Events), 0)
+
+	subscription.Close()
+	_, open := <-subscription.Events
+	test.S(t).ExpectFalse(open)
+	test.S(t).ExpectEquals(store.subscribers.count(), 0)
+}
@@ -18,6 +18,8 @@ package kv
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/openark/orchestrator/go/config"
@@ -41,11 +43,38 @@ type KVStore interface {
 	PutKVPairs(kvPairs []*KVPair) (err error)
 	GetKeyValue(key string) (value string, found bool, err error)
 	DistributePairs(kvPairs [](*KVPair)) (err error)
+	DeleteKey(key string) (err error)
+	DeleteKeyPrefix(prefix string) (err error)
+	ListKeyPrefix(prefix string) (kvPairs [](*KVPair), err error)
+	// WatchKeyPrefix subscribes to the pairs beginning with given prefix: existing pairs are received as put
+	// events, followed by a synced event, and then changes as they happen. A store which is not configured
+	// returns an error.
+	WatchKeyPrefix(prefix string) (subscription *KVSubscription, err error)
 }
 
+const (
+	InternalKVStoreName  = "internal"
+	ZkKVStoreName        = "zk"
+	EtcdKVStoreName      = "etcd"
+	FileKVStoreName      = "file"
+	ConsulKVStoreName    = "consul"
+	ConsulTxnKVStoreName = "consul-txn"
+)
+
 var kvMutex sync.Mutex
 var kvInitOnce sync.Once
 var kvStores = []KVStore{}
+var kvStoreNames = []string{}
+
+// isLeader tells whether this node is the leader, and thereby owns the master keys. The kv package
+// cannot tell on its own; see SetLeaderCheck
+var isLeader = func() bool { return true }
+
+// SetLeaderCheck sets the function which tells whether this node is the leader. It is to be called
+// before InitKVStores.
+func SetLeaderCheck(leaderCheck func() bool) {
+	isLeader = leaderCheck
+}
 
 // InitKVStores initializes the KV stores (duh), once in the lifetime of this app.
 // Configuration reload does not affect a running instance.
@@ -57,6 +86,8 @@ func InitKVStores() {
 		kvStores = []KVStore{
 			NewInternalKVStore(),
 			NewZkStore(),
+			NewEtcdStore(),
+			NewFileKVStore(),
 		}
 		switch config.Config.ConsulKVStoreProvider {
 		case "consul-txn", "consul_txn":
@@ -64,6 +95,7 @@ func InitKVStores() {
 		default:
 			kvStores = append(kvStores, NewConsulStore())
 		}
+		kvStoreNames = getKVStoreNames()
 	})
 }
 
@@ -75,6 +107,104 @@ func getKVStores() (stores []KVStore) {
 	return stores
 }
 
+// getKVStoreNames returns the names of the stores InitKVStores initializes, in same order
+func getKVStoreNames() []string {
+	storeNames := []string{
+		InternalKVStoreName,
+		ZkKVStoreName,
+		EtcdKVStoreName,
+		FileKVStoreName,
+	}
+	switch config.Config.ConsulKVStoreProvider {
+	case "consul-txn", "consul_txn":
+		return append(storeNames, ConsulTxnKVStoreName)
+	default:
+		return append(storeNames, ConsulKVStoreName)
+	}
+}
+
+// getKVStore returns the store of given name, once stores are initialized
+func getKVStore(storeName string) (KVStore, error) {
+	kvMutex.Lock()
+	defer kvMutex.Unlock()
+
+	for i, name := range kvStoreNames {
+		if name == storeName {
+			return kvStores[i], nil
+		}
+	}
+	return nil, fmt.Errorf("KV store not found: %s", storeName)
+}
+
+// isKVStoreConfigured returns true when given store is set up to write anywhere. Stores which are not
+// configured are still initialized, and silently ignore writes.
+func isKVStoreConfigured(storeName string) bool {
+	switch storeName {
+	case InternalKVStoreName:
+		return true
+	case ZkKVStoreName:
+		return config.Config.ZkAddress != ""
+	case EtcdKVStoreName:
+		return len(config.Config.EtcdEndpoints) > 0
+	case FileKVStoreName:
+		return config.Config.KVFileSinkDirectory != ""
+	case ConsulKVStoreName, ConsulTxnKVStoreName:
+		return config.Config.ConsulAddress != ""
+	}
+	return false
+}
+
+// GetConfiguredKVStoreNames returns the names of the stores which are set up to write anywhere.
+// It does not depend on stores being initialized.
+func GetConfiguredKVStoreNames() (storeNames []string) {
+	for _, storeName := range getKVStoreNames() {
+		if isKVStoreConfigured(storeName) {
+			storeNames = append(storeNames, storeName)
+		}
+	}
+	return storeNames
+}
+
+// IsKVStoreDistributing returns true when given store distributes pairs beyond its local cluster, i.e.
+// to all consul datacenters or to cross etcd clusters
+func IsKVStoreDistributing(storeName string) bool {
+	switch storeName {
+	case EtcdKVStoreName:
+		return isKVStoreConfigured(storeName) && len(config.Config.EtcdCrossClusterEndpoints) > 0
+	case ConsulKVStoreName, ConsulTxnKVStoreName:
+		return isKVStoreConfigured(storeName) && config.Config.ConsulCrossDataCenterDistribution
+	}
+	return false
+}
+
+// PutKVPairsToStore writes pairs to a single store, by name
+func PutKVPairsToStore(storeName string, kvPairs [](*KVPair)) (err error) {
+	store, err := getKVStore(storeName)
+	if err != nil {
+		return err
+	}
+	return store.PutKVPairs(kvPairs)
+}
+
+// DistributePairsToStore distributes pairs via a single store, by name
+func DistributePairsToStore(storeName string, kvPairs [](*KVPair)) (err error) {
+	store, err := getKVStore(storeName)
+	if err != nil {
+		return err
+	}
+	return store.DistributePairs(kvPairs)
+}
+
+// WatchKeyPrefix subscribes to the pairs beginning with given prefix in a single store, by name. The
+// subscription's Events is closed upon Close(), or should the subscriber fall behind.
+func WatchKeyPrefix(storeName string, prefix string) (subscription *KVSubscription, err error) {
+	store, err := getKVStore(storeName)
+	if err != nil {
+		return nil, err
+	}
+	return store.WatchKeyPrefix(prefix)
+}
+
 func GetValue(key string) (value string, found bool, err error) {
 	for _, store := range getKVStores() {
 		// It's really only the first (internal) that matters here
@@ -112,3 +242,55 @@ func DistributePairs(kvPairs [](*KVPair)) (err error) {
 	}
 	return nil
 }
+
+func DeleteKey(key string) (err error) {
+	for _, store := range getKVStores() {
+		if err := store.DeleteKey(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteKeyPrefix deletes all keys beginning with given prefix. Keys are matched as strings, not as paths:
+// "mysql/master/foo" matches "mysql/master/foobar". Use a trailing "/" to only match a key's descendants.
+func DeleteKeyPrefix(prefix string) (err error) {
+	for _, store := range getKVStores() {
+		if err := store.DeleteKeyPrefix(prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListKeyPrefix lists the pairs beginning with given prefix in all stores, sorted by key. Where stores
+// disagree on the value of a key, that of the first store (the internal store) is listed.
+func ListKeyPrefix(prefix string) (kvPairs [](*KVPair), err error) {
+	listedKeys := map[string]bool{}
+	for _, store := range getKVStores() {
+		storePairs, err := store.ListKeyPrefix(prefix)
+		if err != nil {
+			return kvPairs, err
+		}
+		for _, kvPair := range storePairs {
+			if !listedKeys[kvPair.Key] {
+				listedKeys[kvPair.Key] = true
+				kvPairs = append(kvPairs, kvPair)
+			}
+		}
+	}
+	sort.Slice(kvPairs, func(i, j int) bool {
+		return kvPairs[i].Key < kvPairs[j].Key
+	})
+	return kvPairs, nil
+}
+
+// filterKeyPrefix returns the pairs beginning with given prefix
+func filterKeyPrefix(kvPairs [](*KVPair), prefix string) (filtered [](*KVPair)) {
+	for _, kvPair := range kvPairs {
+		if strings.HasPrefix(kvPair.Key, prefix) {
+			filtered = append(filtered, kvPair)
+		}
+	}
+	return filtered
+}
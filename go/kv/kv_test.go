@@ -0,0 +1,52 @@
+package kv
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+)
+
+func resetKVStoresConfig() {
+	config.Config.ConsulAddress = ""
+	config.Config.ConsulKVStoreProvider = ""
+	config.Config.ConsulCrossDataCenterDistribution = false
+	config.Config.ZkAddress = ""
+	config.Config.KVFileSinkDirectory = ""
+	config.Config.EtcdEndpoints = []string{}
+	config.Config.EtcdCrossClusterEndpoints = []string{}
+}
+
+func TestGetConfiguredKVStoreNames(t *testing.T) {
+	resetKVStoresConfig()
+	defer resetKVStoresConfig()
+	test.S(t).ExpectTrue(len(GetConfiguredKVStoreNames()) == 1)
+	test.S(t).ExpectEquals(GetConfiguredKVStoreNames()[0], InternalKVStoreName)
+
+	config.Config.ConsulAddress = "127.0.0.1:8500"
+	config.Config.ConsulKVStoreProvider = "consul-txn"
+	config.Config.EtcdEndpoints = []string{"http://127.0.0.1:2379"}
+	storeNames := GetConfiguredKVStoreNames()
+	test.S(t).ExpectTrue(len(storeNames) == 3)
+	test.S(t).ExpectEquals(storeNames[1], EtcdKVStoreName)
+	test.S(t).ExpectEquals(storeNames[2], ConsulTxnKVStoreName)
+	config.Config.KVFileSinkDirectory = "/tmp/orchestrator-kv"
+	storeNames = GetConfiguredKVStoreNames()
+	test.S(t).ExpectTrue(len(storeNames) == 4)
+	test.S(t).ExpectEquals(storeNames[2], FileKVStoreName)
+	test.S(t).ExpectFalse(IsKVStoreDistributing(FileKVStoreName))
+
+	test.S(t).ExpectFalse(IsKVStoreDistributing(InternalKVStoreName))
+	test.S(t).ExpectFalse(IsKVStoreDistributing(ConsulTxnKVStoreName))
+	test.S(t).ExpectFalse(IsKVStoreDistributing(EtcdKVStoreName))
+	config.Config.ConsulCrossDataCenterDistribution = true
+	config.Config.EtcdCrossClusterEndpoints = []string{"http://127.0.0.2:2379"}
+	test.S(t).ExpectTrue(IsKVStoreDistributing(ConsulTxnKVStoreName))
+	test.S(t).ExpectTrue(IsKVStoreDistributing(EtcdKVStoreName))
+	test.S(t).ExpectFalse(IsKVStoreDistributing(ZkKVStoreName))
+}
+
+func TestPutKVPairsToUnknownStore(t *testing.T) {
+	err := PutKVPairsToStore("no-such-store", []*KVPair{NewKVPair("k", "v")})
+	test.S(t).ExpectNotNil(err)
+}
@@ -0,0 +1,205 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kv
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openark/golib/log"
+)
+
+const (
+	KVEventPut    = "put"
+	KVEventDelete = "delete"
+	KVEventSynced = "synced"
+)
+
+// Number of events a subscriber may fall behind by before being dropped
+const kvSubscriberBufferSize = 1024
+
+// Time to wait before listing a watched prefix again, following a failure to list it
+const kvWatchRetryInterval = time.Second
+
+// KVEvent is a change of a pair, as streamed to subscribers. Upon subscribing, existing pairs are streamed
+// as put events, followed by a single synced event.
+type KVEvent struct {
+	Op    string
+	Key   string
+	Value string
+}
+
+type kvSubscriber struct {
+	prefix string
+	events chan *KVEvent
+}
+
+// KVSubscription streams a store's pairs beginning with some prefix, and their changes; see KVStore's WatchKeyPrefix
+type KVSubscription struct {
+	Events    <-chan *KVEvent
+	closeOnce sync.Once
+	close     func()
+}
+
+// Close ends the subscription, closing its Events channel. It is safe to call more than once.
+func (this *KVSubscription) Close() {
+	this.closeOnce.Do(this.close)
+}
+
+// kvSubscribers are the subscribers to a store's changes, each to keys beginning with a prefix of its own
+type kvSubscribers struct {
+	mutex       sync.Mutex
+	subscribers map[*kvSubscriber]bool
+}
+
+func newKVSubscribers() *kvSubscribers {
+	return &kvSubscribers{subscribers: map[*kvSubscriber]bool{}}
+}
+
+// subscribe registers a subscriber to pairs beginning with given prefix. Given pairs, which are those
+// which exist, are queued first, followed by a synced event; changes follow.
+func (this *kvSubscribers) subscribe(prefix string, kvPairs []*KVPair) *KVSubscription {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	subscriber := &kvSubscriber{
+		prefix: prefix,
+		events: make(chan *KVEvent, len(kvPairs)+kvSubscriberBufferSize),
+	}
+	for _, kvPair := range kvPairs {
+		subscriber.events <- &KVEvent{Op: KVEventPut, Key: kvPair.Key, Value: kvPair.Value}
+	}
+	subscriber.events <- &KVEvent{Op: KVEventSynced}
+	this.subscribers[subscriber] = true
+	return &KVSubscription{
+		Events: subscriber.events,
+		close:  func() { this.unsubscribe(subscriber) },
+	}
+}
+
+func (this *kvSubscribers) unsubscribe(subscriber *kvSubscriber) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.subscribers[subscriber] {
+		delete(this.subscribers, subscriber)
+		close(subscriber.events)
+	}
+}
+
+// notify passes an event to interested subscribers. A subscriber which falls behind is dropped, its events
+// channel closed; it may subscribe again, getting a fresh snapshot.
+func (this *kvSubscribers) notify(event *KVEvent) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	for subscriber := range this.subscribers {
+		if !strings.HasPrefix(event.Key, subscriber.prefix) {
+			continue
+		}
+		select {
+		case subscriber.events <- event:
+		default:
+			log.Warningf("KV store: dropping subscriber which fell behind")
+			delete(this.subscribers, subscriber)
+			close(subscriber.events)
+		}
+	}
+}
+
+func (this *kvSubscribers) count() int {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return len(this.subscribers)
+}
+
+// diffKVPairs returns the events which turn one listing of pairs into another
+func diffKVPairs(fromPairs []*KVPair, toPairs []*KVPair) (events []*KVEvent) {
+	fromValues := map[string]string{}
+	for _, kvPair := range fromPairs {
+		fromValues[kvPair.Key] = kvPair.Value
+	}
+	toKeys := map[string]bool{}
+	for _, kvPair := range toPairs {
+		toKeys[kvPair.Key] = true
+		if value, found := fromValues[kvPair.Key]; !found || value != kvPair.Value {
+			events = append(events, &KVEvent{Op: KVEventPut, Key: kvPair.Key, Value: kvPair.Value})
+		}
+	}
+	for _, kvPair := range fromPairs {
+		if !toKeys[kvPair.Key] {
+			events = append(events, &KVEvent{Op: KVEventDelete, Key: kvPair.Key})
+		}
+	}
+	return events
+}
+
+// kvPrefixWatcher watches a prefix of a store which tells that something under the prefix changed, but not
+// necessarily what: each listing is compared with the one before it.
+type kvPrefixWatcher interface {
+	// listChanges lists the pairs beginning with the prefix once they may have changed since the previous
+	// listing; the first time, right away. It gives up (returning whatever) once stop is closed.
+	listChanges(stop <-chan struct{}) ([]*KVPair, error)
+	// close releases the watcher's resources, once it is no longer listed
+	close()
+}
+
+// watchKeyPrefixListings subscribes to a prefix watched by given watcher. A failure to list is retried, and
+// so is only returned on the first listing.
+func watchKeyPrefixListings(prefix string, watcher kvPrefixWatcher) (*KVSubscription, error) {
+	stop := make(chan struct{})
+	listed, err := watcher.listChanges(stop)
+	if err != nil {
+		watcher.close()
+		return nil, err
+	}
+	subscribers := newKVSubscribers()
+	subscription := subscribers.subscribe(prefix, listed)
+	unsubscribe := subscription.close
+	subscription.close = func() {
+		close(stop)
+		unsubscribe()
+	}
+
+	go func() {
+		defer watcher.close()
+		for subscribers.count() > 0 {
+			kvPairs, err := watcher.listChanges(stop)
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err != nil {
+				log.Errorf("KV store: watching %s: %+v", prefix, err)
+				select {
+				case <-stop:
+					return
+				case <-time.After(kvWatchRetryInterval):
+				}
+				continue
+			}
+			for _, event := range diffKVPairs(listed, kvPairs) {
+				subscribers.notify(event)
+			}
+			listed = kvPairs
+		}
+	}()
+	return subscription, nil
+}
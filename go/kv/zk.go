@@ -19,6 +19,8 @@ package kv
 import (
 	"fmt"
 	"math/rand"
+	"path"
+	"sort"
 	"strings"
 	"time"
 
@@ -27,9 +29,31 @@ import (
 	zkconstants "github.com/samuel/go-zookeeper/zk"
 )
 
-// Internal key-value store, based on relational backend
+// zkClient is the subset of ZooKeeper operations the store uses
+type zkClient interface {
+	Get(path string) ([]byte, error)
+	Set(path string, data []byte) (*zkconstants.Stat, error)
+	Create(path string, data []byte, aclstr string, force bool) (string, error)
+	Delete(path string) error
+	ChildrenRecursive(path string) ([]string, error)
+}
+
+// zkWatchClient is the subset of ZooKeeper operations watching a prefix uses. zookeepercli connects anew for
+// each operation, and so cannot watch: a connection of its own is kept for as long as a prefix is watched.
+type zkWatchClient interface {
+	ChildrenW(path string) ([]string, *zkconstants.Stat, <-chan zkconstants.Event, error)
+	GetW(path string) ([]byte, *zkconstants.Stat, <-chan zkconstants.Event, error)
+	ExistsW(path string) (bool, *zkconstants.Stat, <-chan zkconstants.Event, error)
+	Close()
+}
+
+// Session timeout of connections watching a prefix
+const zkWatchSessionTimeout = 10 * time.Second
+
+// A ZooKeeper store based on config's `ZkAddress`
 type zkStore struct {
-	zook *zk.ZooKeeper
+	zook         zkClient
+	connectWatch func() (zkWatchClient, error)
 }
 
 func normalizeKey(key string) (normalizedKey string) {
@@ -48,6 +72,10 @@ func NewZkStore() KVStore {
 		zook := zk.NewZooKeeper()
 		zook.SetServers(serversArray)
 		store.zook = zook
+		store.connectWatch = func() (zkWatchClient, error) {
+			conn, _, err := zkconstants.Connect(serversArray, zkWatchSessionTimeout, zkconstants.WithLogInfo(false))
+			return conn, err
+		}
 	}
 	return store
 }
@@ -90,3 +118,262 @@ func (this *zkStore) PutKVPairs(kvPairs []*KVPair) (err error) {
 func (this *zkStore) DistributePairs(kvPairs [](*KVPair)) (err error) {
 	return nil
 }
+
+func (this *zkStore) DeleteKey(key string) (err error) {
+	if this.zook == nil {
+		return nil
+	}
+	if err = this.zook.Delete(normalizeKey(key)); err == zkconstants.ErrNoNode {
+		return nil
+	}
+	return err
+}
+
+// DeleteKeyPrefix deletes znodes whose path begins with given prefix, descendants first
+func (this *zkStore) DeleteKeyPrefix(prefix string) (err error) {
+	if this.zook == nil {
+		return nil
+	}
+	kvPairs, err := this.ListKeyPrefix(prefix)
+	if err != nil {
+		return err
+	}
+	// A znode sorts before its descendants; delete in reverse order
+	sort.Slice(kvPairs, func(i, j int) bool {
+		return kvPairs[i].Key > kvPairs[j].Key
+	})
+	for _, kvPair := range kvPairs {
+		if err := this.DeleteKey(kvPair.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListKeyPrefix lists znodes whose path begins with given prefix. ZooKeeper is hierarchical, and so the
+// prefix's parent path is listed recursively, then filtered.
+func (this *zkStore) ListKeyPrefix(prefix string) (kvPairs [](*KVPair), err error) {
+	if this.zook == nil {
+		return kvPairs, nil
+	}
+	normalizedPrefix := normalizeKey(prefix)
+	parentPath := path.Dir(normalizedPrefix)
+	if strings.HasSuffix(normalizedPrefix, "/") {
+		parentPath = path.Clean(normalizedPrefix)
+	}
+	children, err := this.zook.ChildrenRecursive(parentPath)
+	if err == zkconstants.ErrNoNode {
+		return kvPairs, nil
+	}
+	if err != nil {
+		return kvPairs, err
+	}
+	for _, child := range children {
+		childPath := path.Join(parentPath, child)
+		if !strings.HasPrefix(childPath, normalizedPrefix) {
+			continue
+		}
+		value, err := this.zook.Get(childPath)
+		if err == zkconstants.ErrNoNode {
+			continue
+		}
+		if err != nil {
+			return kvPairs, err
+		}
+		key := childPath
+		if !strings.HasPrefix(prefix, "/") {
+			key = strings.TrimLeft(key, "/")
+		}
+		kvPairs = append(kvPairs, NewKVPair(key, string(value)))
+	}
+	return kvPairs, nil
+}
+
+// WatchKeyPrefix subscribes to the znodes whose path begins with given prefix, see zkPrefixWatcher
+func (this *zkStore) WatchKeyPrefix(prefix string) (*KVSubscription, error) {
+	if this.connectWatch == nil {
+		return nil, fmt.Errorf("zk KV store: ZkAddress is not configured")
+	}
+	return watchKeyPrefixListings(prefix, newZkPrefixWatcher(prefix, this.connectWatch))
+}
+
+// zkPrefixWatcher watches the znodes whose path begins with a prefix, same as listed by ListKeyPrefix. The
+// children of the prefix's parent path, and of each matching znode, are watched, as is the data of each
+// matching znode; a watch is set anew once it fires. Should the session be lost, the watcher connects and
+// lists anew.
+type zkPrefixWatcher struct {
+	prefix           string
+	normalizedPrefix string
+	parentPath       string
+	connect          func() (zkWatchClient, error)
+
+	conn            zkWatchClient
+	events          chan zkconstants.Event
+	stopForwarding  chan struct{}
+	childrenWatched map[string]bool
+	values          map[string][]byte
+}
+
+func newZkPrefixWatcher(prefix string, connect func() (zkWatchClient, error)) *zkPrefixWatcher {
+	normalizedPrefix := normalizeKey(prefix)
+	parentPath := path.Dir(normalizedPrefix)
+	if strings.HasSuffix(normalizedPrefix, "/") {
+		parentPath = path.Clean(normalizedPrefix)
+	}
+	return &zkPrefixWatcher{
+		prefix:           prefix,
+		normalizedPrefix: normalizedPrefix,
+		parentPath:       parentPath,
+		connect:          connect,
+	}
+}
+
+// forward passes a watch's event on to the watcher's events. A watch which ends without firing is forwarded
+// as not watching.
+func (this *zkPrefixWatcher) forward(watch <-chan zkconstants.Event) {
+	events, stopForwarding := this.events, this.stopForwarding
+	go func() {
+		var event zkconstants.Event
+		select {
+		case watchEvent, ok := <-watch:
+			event = watchEvent
+			if !ok {
+				event = zkconstants.Event{Type: zkconstants.EventNotWatching, Err: zkconstants.ErrClosing}
+			}
+		case <-stopForwarding:
+			return
+		}
+		select {
+		case events <- event:
+		case <-stopForwarding:
+		}
+	}()
+}
+
+// watchChildren watches the children of given znode, and in turn those children which are not watched yet. A
+// znode which does not exist is watched for its creation.
+func (this *zkPrefixWatcher) watchChildren(znodePath string) error {
+	children, _, watch, err := this.conn.ChildrenW(znodePath)
+	if err == zkconstants.ErrNoNode {
+		exists, _, watch, err := this.conn.ExistsW(znodePath)
+		if err != nil {
+			return err
+		}
+		this.forward(watch)
+		if exists {
+			// Created meanwhile
+			return this.watchChildren(znodePath)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	this.forward(watch)
+	this.childrenWatched[znodePath] = true
+	for _, child := range children {
+		childPath := path.Join(znodePath, child)
+		if !strings.HasPrefix(childPath, this.normalizedPrefix) {
+			continue
+		}
+		if _, found := this.values[childPath]; !found {
+			if err := this.watchData(childPath); err != nil {
+				return err
+			}
+		}
+		if !this.childrenWatched[childPath] {
+			if err := this.watchChildren(childPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// watchData reads and watches the data of given znode
+func (this *zkPrefixWatcher) watchData(znodePath string) error {
+	data, _, watch, err := this.conn.GetW(znodePath)
+	if err == zkconstants.ErrNoNode {
+		// Deleted meanwhile; its parent's children watch tells
+		delete(this.values, znodePath)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	this.forward(watch)
+	this.values[znodePath] = data
+	return nil
+}
+
+// handle sets anew the watch which fired given event
+func (this *zkPrefixWatcher) handle(event zkconstants.Event) error {
+	switch event.Type {
+	case zkconstants.EventNodeDataChanged:
+		return this.watchData(event.Path)
+	case zkconstants.EventNodeChildrenChanged:
+		return this.watchChildren(event.Path)
+	case zkconstants.EventNodeCreated:
+		return this.watchChildren(event.Path)
+	case zkconstants.EventNodeDeleted:
+		delete(this.values, event.Path)
+		delete(this.childrenWatched, event.Path)
+		if event.Path == this.parentPath {
+			// Watched for its creation
+			return this.watchChildren(event.Path)
+		}
+		return nil
+	}
+	return fmt.Errorf("zk KV store: lost watch on %s: %+v", event.Path, event.Err)
+}
+
+func (this *zkPrefixWatcher) list() (kvPairs [](*KVPair)) {
+	for znodePath, data := range this.values {
+		key := znodePath
+		if !strings.HasPrefix(this.prefix, "/") {
+			key = strings.TrimLeft(key, "/")
+		}
+		kvPairs = append(kvPairs, NewKVPair(key, string(data)))
+	}
+	sort.Slice(kvPairs, func(i, j int) bool {
+		return kvPairs[i].Key < kvPairs[j].Key
+	})
+	return kvPairs
+}
+
+func (this *zkPrefixWatcher) listChanges(stop <-chan struct{}) (kvPairs [](*KVPair), err error) {
+	if this.conn == nil {
+		if this.conn, err = this.connect(); err != nil {
+			this.conn = nil
+			return kvPairs, err
+		}
+		this.events = make(chan zkconstants.Event)
+		this.stopForwarding = make(chan struct{})
+		this.childrenWatched = map[string]bool{}
+		this.values = map[string][]byte{}
+		if err := this.watchChildren(this.parentPath); err != nil {
+			this.close()
+			return kvPairs, err
+		}
+		return this.list(), nil
+	}
+	select {
+	case event := <-this.events:
+		if err := this.handle(event); err != nil {
+			this.close()
+			return kvPairs, err
+		}
+	case <-stop:
+		return kvPairs, nil
+	}
+	return this.list(), nil
+}
+
+func (this *zkPrefixWatcher) close() {
+	if this.conn == nil {
+		return
+	}
+	close(this.stopForwarding)
+	this.conn.Close()
+	this.conn = nil
+}
@@ -0,0 +1,331 @@
+package kv
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+	zkconstants "github.com/samuel/go-zookeeper/zk"
+)
+
+// zkTestClient mimics a ZooKeeper ensemble, keeping znodes in memory
+type zkTestClient struct {
+	znodes map[string][]byte
+}
+
+func newZkTestClient() *zkTestClient {
+	return &zkTestClient{znodes: map[string][]byte{"/": nil}}
+}
+
+// children returns the names of direct children of given znode, sorted
+func (this *zkTestClient) children(znodePath string) (children []string) {
+	for p := range this.znodes {
+		if p != "/" && path.Dir(p) == znodePath {
+			children = append(children, path.Base(p))
+		}
+	}
+	sort.Strings(children)
+	return children
+}
+
+func (this *zkTestClient) Get(znodePath string) ([]byte, error) {
+	data, found := this.znodes[znodePath]
+	if !found {
+		return nil, zkconstants.ErrNoNode
+	}
+	return data, nil
+}
+
+func (this *zkTestClient) Set(znodePath string, data []byte) (*zkconstants.Stat, error) {
+	if _, found := this.znodes[znodePath]; !found {
+		return nil, zkconstants.ErrNoNode
+	}
+	this.znodes[znodePath] = data
+	return &zkconstants.Stat{}, nil
+}
+
+func (this *zkTestClient) Create(znodePath string, data []byte, aclstr string, force bool) (string, error) {
+	if _, found := this.znodes[znodePath]; found {
+		return "", zkconstants.ErrNodeExists
+	}
+	if _, found := this.znodes[path.Dir(znodePath)]; !found {
+		if !force {
+			return "", zkconstants.ErrNoNode
+		}
+		if _, err := this.Create(path.Dir(znodePath), []byte("zookeepercli auto-generated"), aclstr, force); err != nil {
+			return "", err
+		}
+	}
+	this.znodes[znodePath] = data
+	return znodePath, nil
+}
+
+func (this *zkTestClient) Delete(znodePath string) error {
+	if _, found := this.znodes[znodePath]; !found {
+		return zkconstants.ErrNoNode
+	}
+	if len(this.children(znodePath)) > 0 {
+		return zkconstants.ErrNotEmpty
+	}
+	delete(this.znodes, znodePath)
+	return nil
+}
+
+func (this *zkTestClient) ChildrenRecursive(znodePath string) (descendants []string, err error) {
+	if _, found := this.znodes[znodePath]; !found {
+		return nil, zkconstants.ErrNoNode
+	}
+	for _, child := range this.children(znodePath) {
+		descendants = append(descendants, child)
+		childDescendants, err := this.ChildrenRecursive(path.Join(znodePath, child))
+		if err != nil {
+			return descendants, err
+		}
+		for _, descendant := range childDescendants {
+			descendants = append(descendants, path.Join(child, descendant))
+		}
+	}
+	return descendants, nil
+}
+
+// zkTestWatchClient adds watches to a zkTestClient, firing them upon writes as ZooKeeper does
+type zkTestWatchClient struct {
+	mutex   sync.Mutex
+	client  *zkTestClient
+	watches map[string][]chan zkconstants.Event
+}
+
+func newZkTestWatchClient() *zkTestWatchClient {
+	return &zkTestWatchClient{client: newZkTestClient(), watches: map[string][]chan zkconstants.Event{}}
+}
+
+func (this *zkTestWatchClient) watch(kind string, znodePath string) <-chan zkconstants.Event {
+	watch := make(chan zkconstants.Event, 1)
+	this.watches[kind+":"+znodePath] = append(this.watches[kind+":"+znodePath], watch)
+	return watch
+}
+
+// fire fires, and thereby removes, the watches of given kind on given znode
+func (this *zkTestWatchClient) fire(kind string, znodePath string, eventType zkconstants.EventType) {
+	for _, watch := range this.watches[kind+":"+znodePath] {
+		watch <- zkconstants.Event{Type: eventType, Path: znodePath}
+	}
+	delete(this.watches, kind+":"+znodePath)
+}
+
+func (this *zkTestWatchClient) Get(znodePath string) ([]byte, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.client.Get(znodePath)
+}
+
+func (this *zkTestWatchClient) Set(znodePath string, data []byte) (*zkconstants.Stat, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	stat, err := this.client.Set(znodePath, data)
+	if err == nil {
+		this.fire("data", znodePath, zkconstants.EventNodeDataChanged)
+		this.fire("exists", znodePath, zkconstants.EventNodeDataChanged)
+	}
+	return stat, err
+}
+
+func (this *zkTestWatchClient) Create(znodePath string, data []byte, aclstr string, force bool) (string, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	existing := map[string]bool{}
+	for p := range this.client.znodes {
+		existing[p] = true
+	}
+	createdPath, err := this.client.Create(znodePath, data, aclstr, force)
+	for p := range this.client.znodes {
+		if !existing[p] {
+			this.fire("exists", p, zkconstants.EventNodeCreated)
+			this.fire("children", path.Dir(p), zkconstants.EventNodeChildrenChanged)
+		}
+	}
+	return createdPath, err
+}
+
+func (this *zkTestWatchClient) Delete(znodePath string) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	err := this.client.Delete(znodePath)
+	if err == nil {
+		this.fire("data", znodePath, zkconstants.EventNodeDeleted)
+		this.fire("exists", znodePath, zkconstants.EventNodeDeleted)
+		this.fire("children", znodePath, zkconstants.EventNodeDeleted)
+		this.fire("children", path.Dir(znodePath), zkconstants.EventNodeChildrenChanged)
+	}
+	return err
+}
+
+func (this *zkTestWatchClient) ChildrenRecursive(znodePath string) ([]string, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.client.ChildrenRecursive(znodePath)
+}
+
+func (this *zkTestWatchClient) ChildrenW(znodePath string) ([]string, *zkconstants.Stat, <-chan zkconstants.Event, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if _, found := this.client.znodes[znodePath]; !found {
+		return nil, nil, nil, zkconstants.ErrNoNode
+	}
+	return this.client.children(znodePath), &zkconstants.Stat{}, this.watch("children", znodePath), nil
+}
+
+func (this *zkTestWatchClient) GetW(znodePath string) ([]byte, *zkconstants.Stat, <-chan zkconstants.Event, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	data, err := this.client.Get(znodePath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return data, &zkconstants.Stat{}, this.watch("data", znodePath), nil
+}
+
+func (this *zkTestWatchClient) ExistsW(znodePath string) (bool, *zkconstants.Stat, <-chan zkconstants.Event, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	_, found := this.client.znodes[znodePath]
+	return found, &zkconstants.Stat{}, this.watch("exists", znodePath), nil
+}
+
+// Close ends all watches without firing them, as closing a connection does
+func (this *zkTestWatchClient) Close() {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	for _, watches := range this.watches {
+		for _, watch := range watches {
+			close(watch)
+		}
+	}
+	this.watches = map[string][]chan zkconstants.Event{}
+}
+
+func TestZkStoreListKeyPrefix(t *testing.T) {
+	config.Config.KVClusterMasterPrefix = "mysql/master"
+	store := &zkStore{zook: newZkTestClient()}
+	test.S(t).ExpectNil(store.PutKVPairs(append(etcdTestClusterPairs("foo", "db-1"), etcdTestClusterPairs("foobar", "db-2")...)))
+	{
+		kvPairs, err := store.ListKeyPrefix("mysql/master/foo/")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(kvPairs), 2)
+		test.S(t).ExpectEquals(kvPairs[0].Key, "mysql/master/foo/hostname")
+		test.S(t).ExpectEquals(kvPairs[0].Value, "db-1")
+		test.S(t).ExpectEquals(kvPairs[1].Key, "mysql/master/foo/port")
+	}
+	{
+		// Not only children of the prefix's parent path: all keys beginning with the prefix
+		kvPairs, err := store.ListKeyPrefix("mysql/master/foo")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(kvPairs), 6)
+		for _, kvPair := range kvPairs {
+			test.S(t).ExpectTrue(strings.HasPrefix(kvPair.Key, "mysql/master/foo"))
+		}
+	}
+	{
+		kvPairs, err := store.ListKeyPrefix("/mysql/master/foobar/")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(kvPairs), 2)
+		test.S(t).ExpectEquals(kvPairs[0].Key, "/mysql/master/foobar/hostname")
+	}
+	{
+		kvPairs, err := store.ListKeyPrefix("no/such/prefix/")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(kvPairs), 0)
+	}
+}
+
+func TestZkStoreDelete(t *testing.T) {
+	config.Config.KVClusterMasterPrefix = "mysql/master"
+	zook := newZkTestClient()
+	store := &zkStore{zook: zook}
+	test.S(t).ExpectNil(store.PutKVPairs(append(etcdTestClusterPairs("foo", "db-1"), etcdTestClusterPairs("foobar", "db-2")...)))
+
+	// A znode which has children is not deleted
+	test.S(t).ExpectEquals(store.DeleteKey("mysql/master/foo"), zkconstants.ErrNotEmpty)
+
+	// Descendants are deleted first
+	test.S(t).ExpectNil(store.DeleteKeyPrefix("mysql/master/foo/"))
+	test.S(t).ExpectNil(store.DeleteKey("mysql/master/foo"))
+	_, found := zook.znodes["/mysql/master/foo"]
+	test.S(t).ExpectFalse(found)
+	_, found = zook.znodes["/mysql/master/foo/port"]
+	test.S(t).ExpectFalse(found)
+	value, found, err := store.GetKeyValue("mysql/master/foobar/hostname")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(found)
+	test.S(t).ExpectEquals(value, "db-2")
+
+	// Deleting what does not exist is not an error
+	test.S(t).ExpectNil(store.DeleteKey("mysql/master/foo"))
+	test.S(t).ExpectNil(store.DeleteKeyPrefix("no/such/prefix/"))
+
+	test.S(t).ExpectNil(store.DeleteKeyPrefix("mysql/master/foo"))
+	kvPairs, err := store.ListKeyPrefix("mysql/")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(kvPairs), 1)
+	test.S(t).ExpectEquals(kvPairs[0].Key, "mysql/master")
+}
+
+func TestZkStoreWatchKeyPrefix(t *testing.T) {
+	zook := newZkTestWatchClient()
+	store := &zkStore{zook: zook, connectWatch: func() (zkWatchClient, error) { return zook, nil }}
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/master/c1", "db-1:3306"))
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/master/c1/hostname", "db-1"))
+	test.S(t).ExpectNil(store.PutKeyValue("mysql/master/d1", "db-7:3306"))
+	{
+		subscription, err := store.WatchKeyPrefix("mysql/master/c1")
+		test.S(t).ExpectNil(err)
+		event := <-subscription.Events
+		test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventPut, Key: "mysql/master/c1", Value: "db-1:3306"})
+		event = <-subscription.Events
+		test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventPut, Key: "mysql/master/c1/hostname", Value: "db-1"})
+		event = <-subscription.Events
+		test.S(t).ExpectEquals(event.Op, KVEventSynced)
+
+		test.S(t).ExpectNil(store.PutKeyValue("mysql/master/c1", "db-2:3306"))
+		event = <-subscription.Events
+		test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventPut, Key: "mysql/master/c1", Value: "db-2:3306"})
+		// Children of the prefix's parent path, and descendants of matching znodes, are watched
+		test.S(t).ExpectNil(store.PutKeyValue("mysql/master/c10", "db-3:3306"))
+		event = <-subscription.Events
+		test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventPut, Key: "mysql/master/c10", Value: "db-3:3306"})
+		test.S(t).ExpectNil(store.PutKeyValue("mysql/master/c1/port", "3306"))
+		event = <-subscription.Events
+		test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventPut, Key: "mysql/master/c1/port", Value: "3306"})
+		// Not matching the prefix
+		test.S(t).ExpectNil(store.PutKeyValue("mysql/master/d1", "db-8:3306"))
+		test.S(t).ExpectNil(store.PutKeyValue("mysql/master/d2", "db-9:3306"))
+		test.S(t).ExpectNil(store.DeleteKey("mysql/master/c1/hostname"))
+		event = <-subscription.Events
+		test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventDelete, Key: "mysql/master/c1/hostname"})
+
+		subscription.Close()
+		_, open := <-subscription.Events
+		test.S(t).ExpectFalse(open)
+	}
+	{
+		// The prefix's parent path is watched for its creation
+		subscription, err := store.WatchKeyPrefix("/no/such/prefix/")
+		test.S(t).ExpectNil(err)
+		event := <-subscription.Events
+		test.S(t).ExpectEquals(event.Op, KVEventSynced)
+
+		test.S(t).ExpectNil(store.PutKeyValue("no/such/prefix/key", "value"))
+		event = <-subscription.Events
+		test.S(t).ExpectEquals(*event, KVEvent{Op: KVEventPut, Key: "/no/such/prefix/key", Value: "value"})
+		subscription.Close()
+	}
+	{
+		// Not configured
+		_, err := (&zkStore{}).WatchKeyPrefix("mysql/master/")
+		test.S(t).ExpectNotNil(err)
+	}
+}
@@ -0,0 +1,27 @@
+package logic
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/db"
+)
+
+// initTestBackend sets up an in-memory sqlite backend database, shared by all tests of this package.
+// Tests using it clean up the tables they use.
+func initTestBackend(t *testing.T) {
+	config.Config.BackendDB = "sqlite"
+	config.Config.SQLite3DataFile = ":memory:"
+	_, err := db.OpenOrchestrator()
+	test.S(t).ExpectNil(err)
+}
+
+// resetTestBackendTables deletes all rows of given tables
+func resetTestBackendTables(t *testing.T, tables ...string) {
+	initTestBackend(t)
+	for _, table := range tables {
+		_, err := db.ExecOrchestrator("delete from " + table)
+		test.S(t).ExpectNil(err)
+	}
+}
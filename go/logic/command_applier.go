@@ -77,6 +77,14 @@ func (applier *CommandApplier) ApplyCommand(op string, value []byte) interface{}
 		return applier.enableGlobalRecoveries(value)
 	case "put-key-value":
 		return applier.putKeyValue(value)
+	case "delete-key-value":
+		return applier.deleteKeyValue(value)
+	case "delete-key-prefix":
+		return applier.deleteKeyPrefix(value)
+	case "put-kv-pairs":
+		return applier.putKVPairs(value)
+	case "enqueue-kv-outbox":
+		return applier.enqueueKVOutbox(value)
 	case "put-instance-tag":
 		return applier.putInstanceTag(value)
 	case "delete-instance-tag":
@@ -87,6 +95,20 @@ func (applier *CommandApplier) ApplyCommand(op string, value []byte) interface{}
 		return applier.healthReport(value)
 	case "set-cluster-alias-manual-override":
 		return applier.setClusterAliasManualOverride(value)
+	case "put-cluster-recovery-policy":
+		return applier.putClusterRecoveryPolicy(value)
+	case "delete-cluster-recovery-policy":
+		return applier.deleteClusterRecoveryPolicy(value)
+	case "write-scheduled-takeover":
+		return applier.writeScheduledTakeover(value)
+	case "write-rolling-operation":
+		return applier.writeRollingOperation(value)
+	case "write-rolling-operation-instance":
+		return applier.writeRollingOperationInstance(value)
+	case "write-errant-gtid-remediation":
+		return applier.writeErrantGTIDRemediation(value)
+	case "supersede-errant-gtid-remediations":
+		return applier.supersedeErrantGTIDRemediations(value)
 	}
 	return log.Errorf("Unknown command op: %s", op)
 }
@@ -260,7 +282,52 @@ func (applier *CommandApplier) putKeyValue(value []byte) interface{} {
 	if err := json.Unmarshal(value, kvPair); err != nil {
 		return log.Errore(err)
 	}
+	outboxSequence := readKVOutboxSequence()
 	err := kv.PutKVPairs([]*kv.KVPair{kvPair})
+	if err == nil {
+		recordKVDirectWrites([]*kv.KVPair{kvPair}, false, outboxSequence)
+	}
+	return err
+}
+
+func (applier *CommandApplier) putKVPairs(value []byte) interface{} {
+	kvPairs := [](*kv.KVPair){}
+	if err := json.Unmarshal(value, &kvPairs); err != nil {
+		return log.Errore(err)
+	}
+	outboxSequence := readKVOutboxSequence()
+	err := kv.PutKVPairs(kvPairs)
+	if err == nil {
+		recordKVDirectWrites(kvPairs, false, outboxSequence)
+	}
+	return err
+}
+
+func (applier *CommandApplier) enqueueKVOutbox(value []byte) interface{} {
+	submission := &KVOutboxSubmission{}
+	if err := json.Unmarshal(value, submission); err != nil {
+		return log.Errore(err)
+	}
+	err := enqueueKVOutboxSubmission(submission, true, false)
+	go DeliverKVOutbox()
+	return err
+}
+
+func (applier *CommandApplier) deleteKeyValue(value []byte) interface{} {
+	kvPair := &kv.KVPair{}
+	if err := json.Unmarshal(value, kvPair); err != nil {
+		return log.Errore(err)
+	}
+	err := deleteLocalKVKey(kvPair.Key)
+	return err
+}
+
+func (applier *CommandApplier) deleteKeyPrefix(value []byte) interface{} {
+	kvPair := &kv.KVPair{}
+	if err := json.Unmarshal(value, kvPair); err != nil {
+		return log.Errore(err)
+	}
+	err := deleteLocalKVKeyPrefix(kvPair.Key)
 	return err
 }
 
@@ -309,3 +376,62 @@ func (applier *CommandApplier) setClusterAliasManualOverride(value []byte) inter
 	err := inst.SetClusterAliasManualOverride(clusterName, alias)
 	return err
 }
+
+func (applier *CommandApplier) putClusterRecoveryPolicy(value []byte) interface{} {
+	policy := inst.ClusterRecoveryPolicy{}
+	if err := json.Unmarshal(value, &policy); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.WriteClusterRecoveryPolicy(&policy)
+	return err
+}
+
+func (applier *CommandApplier) deleteClusterRecoveryPolicy(value []byte) interface{} {
+	var policyName string
+	if err := json.Unmarshal(value, &policyName); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.DeleteClusterRecoveryPolicy(policyName)
+	return err
+}
+
+func (applier *CommandApplier) writeScheduledTakeover(value []byte) interface{} {
+	takeover := ScheduledTakeover{}
+	if err := json.Unmarshal(value, &takeover); err != nil {
+		return log.Errore(err)
+	}
+	err := writeScheduledTakeover(&takeover)
+	return err
+}
+
+func (applier *CommandApplier) writeRollingOperation(value []byte) interface{} {
+	operation := RollingOperation{}
+	if err := json.Unmarshal(value, &operation); err != nil {
+		return log.Errore(err)
+	}
+	err := writeRollingOperation(&operation)
+	return err
+}
+
+func (applier *CommandApplier) writeRollingOperationInstance(value []byte) interface{} {
+	operationInstance := RollingOperationInstance{}
+	if err := json.Unmarshal(value, &operationInstance); err != nil {
+		return log.Errore(err)
+	}
+	err := writeRollingOperationInstance(&operationInstance)
+	return err
+}
+
+func (applier *CommandApplier) writeErrantGTIDRemediation(value []byte) interface{} {
+	remediation := inst.ErrantGTIDRemediation{}
+	if err := json.Unmarshal(value, &remediation); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.WriteErrantGTIDRemediation(&remediation)
+	return err
+}
+
+func (applier *CommandApplier) supersedeErrantGTIDRemediations(value []byte) interface{} {
+	err := inst.SupersedeErrantGTIDRemediations()
+	return err
+}
@@ -0,0 +1,155 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/inst"
+	"github.com/openark/orchestrator/go/os"
+	"github.com/openark/orchestrator/go/process"
+)
+
+// Fencer makes a failed master refuse writes, so that it cannot diverge from the master promoted in its place.
+// Fence returns nil only when it can confirm the master is fenced.
+type Fencer interface {
+	Fence(topologyRecovery *TopologyRecovery, timeout time.Duration) error
+}
+
+// fencers are the built-in fencing methods, keyed by their FencingMethods name
+var fencers = map[string]Fencer{
+	config.FencingMethodMySQL:   &mysqlFencer{},
+	config.FencingMethodHTTP:    &httpFencer{},
+	config.FencingMethodCommand: &commandFencer{},
+}
+
+// FencingRequest is the JSON document POSTed to FencingHTTPURL
+type FencingRequest struct {
+	InstanceKey      inst.InstanceKey
+	ClusterName      string
+	ClusterAlias     string
+	RecoveryUID      string
+	OrchestratorHost string
+}
+
+// mysqlFencer connects to the failed master, sets read_only and super_read_only and kills client connections.
+// It only works when the master is reachable, e.g. on a partition which only affected its replicas.
+type mysqlFencer struct{}
+
+func (this *mysqlFencer) Fence(topologyRecovery *TopologyRecovery, timeout time.Duration) error {
+	instanceKey := topologyRecovery.AnalysisEntry.AnalyzedInstanceKey
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	killedConnections, superReadOnly, err := inst.FenceInstanceWrites(ctx, &instanceKey)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timed out after %v: %+v", timeout, err)
+		}
+		return err
+	}
+	if superReadOnly {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Fencing: set super_read_only on %+v and killed %d connections", instanceKey, killedConnections))
+	} else {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Fencing: set read_only on %+v, which does not support super_read_only, and killed %d connections", instanceKey, killedConnections))
+	}
+	return nil
+}
+
+// httpFencer POSTs a FencingRequest to FencingHTTPURL, expecting the receiving end to fence the master
+// by its own means (power off, revoke network access, etc.). A 2xx response confirms fencing.
+type httpFencer struct{}
+
+func (this *httpFencer) Fence(topologyRecovery *TopologyRecovery, timeout time.Duration) error {
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	body, err := json.Marshal(FencingRequest{
+		InstanceKey:      analysisEntry.AnalyzedInstanceKey,
+		ClusterName:      analysisEntry.ClusterDetails.ClusterName,
+		ClusterAlias:     analysisEntry.ClusterDetails.ClusterAlias,
+		RecoveryUID:      topologyRecovery.UID,
+		OrchestratorHost: process.ThisHostname,
+	})
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Post(config.Config.FencingHTTPURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with status %d", config.Config.FencingHTTPURL, resp.StatusCode)
+	}
+	return nil
+}
+
+// commandFencer runs FencingCommand. Exit code 0 confirms fencing.
+type commandFencer struct{}
+
+func (this *commandFencer) Fence(topologyRecovery *TopologyRecovery, timeout time.Duration) error {
+	command, _ := prepareCommand(config.Config.FencingCommand, topologyRecovery)
+	env := applyEnvironmentVariables(topologyRecovery)
+	result, err := os.CommandRunWithTimeout(command, env, timeout, int(config.Config.RecoveryProcessesAuditOutputBytes))
+	auditTopologyRecoveryStep(topologyRecovery, fmt.Sprintf("Fencing: ran %s", command), result)
+	return err
+}
+
+// fenceDeadMaster attempts the configured fencing methods in order, until one confirms the failed master is fenced.
+// It returns an error when no method succeeds and FencingPolicy requires fencing, in which case the failover
+// must not proceed.
+func fenceDeadMaster(topologyRecovery *TopologyRecovery) error {
+	if len(config.Config.FencingMethods) == 0 {
+		return nil
+	}
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	failedInstanceKey := &analysisEntry.AnalyzedInstanceKey
+	if analysisEntry.CommandHint == inst.GracefulMasterTakeoverCommandHint {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Fencing: skipping on graceful master takeover; %+v was already set read-only", *failedInstanceKey))
+		return nil
+	}
+	timeout := time.Duration(config.Config.FencingTimeoutSeconds) * time.Second
+	for _, method := range config.Config.FencingMethods {
+		fencer, ok := fencers[method]
+		if !ok {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Fencing: unknown method %s", method))
+			continue
+		}
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Fencing: fencing %+v via %s", *failedInstanceKey, method))
+		start := time.Now()
+		if err := fencer.Fence(topologyRecovery, timeout); err != nil {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Fencing: %s fencing of %+v failed in %v: %+v", method, *failedInstanceKey, time.Since(start), err))
+			continue
+		}
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Fencing: %+v fenced via %s in %v", *failedInstanceKey, method, time.Since(start)))
+		return nil
+	}
+	if config.Config.FencingPolicy == config.FencingPolicyRequired {
+		return log.Errorf("Fencing: could not confirm %+v is fenced, and FencingPolicy is %s; aborting failover", *failedInstanceKey, config.Config.FencingPolicy)
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Fencing: could not confirm %+v is fenced; proceeding as FencingPolicy is %s", *failedInstanceKey, config.Config.FencingPolicy))
+	return nil
+}
@@ -0,0 +1,175 @@
+package logic
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/inst"
+)
+
+// testFencer records its invocations and fails when err is set
+type testFencer struct {
+	err         error
+	countFenced int
+	timeout     time.Duration
+}
+
+func (this *testFencer) Fence(topologyRecovery *TopologyRecovery, timeout time.Duration) error {
+	this.countFenced++
+	this.timeout = timeout
+	return this.err
+}
+
+// withTestFencing replaces the fencing methods and configuration for the duration of a test
+func withTestFencing(testFencers map[string]Fencer, methods []string, policy string) (restore func()) {
+	originalFencers := fencers
+	originalMethods := config.Config.FencingMethods
+	originalPolicy := config.Config.FencingPolicy
+	fencers = testFencers
+	config.Config.FencingMethods = methods
+	config.Config.FencingPolicy = policy
+	return func() {
+		fencers = originalFencers
+		config.Config.FencingMethods = originalMethods
+		config.Config.FencingPolicy = originalPolicy
+	}
+}
+
+func newTestFencingRecovery(commandHint string) *TopologyRecovery {
+	analysisEntry := inst.ReplicationAnalysis{
+		AnalyzedInstanceKey: inst.InstanceKey{Hostname: "127.0.0.1", Port: 3306},
+		Analysis:            inst.DeadMaster,
+		CommandHint:         commandHint,
+	}
+	return newRecoveryPlan("recover", analysisEntry).topologyRecovery
+}
+
+func TestFenceDeadMasterPolicy(t *testing.T) {
+	failing := &testFencer{err: fmt.Errorf("unreachable")}
+	succeeding := &testFencer{}
+	testFencers := map[string]Fencer{"failing": failing, "succeeding": succeeding}
+	{
+		restore := withTestFencing(testFencers, []string{}, config.FencingPolicyRequired)
+		test.S(t).ExpectNil(fenceDeadMaster(newTestFencingRecovery("")))
+		restore()
+		test.S(t).ExpectEquals(failing.countFenced, 0)
+	}
+	{
+		// Methods are attempted in order until one succeeds
+		restore := withTestFencing(testFencers, []string{"unknown", "failing", "succeeding", "failing"}, config.FencingPolicyRequired)
+		test.S(t).ExpectNil(fenceDeadMaster(newTestFencingRecovery("")))
+		restore()
+		test.S(t).ExpectEquals(failing.countFenced, 1)
+		test.S(t).ExpectEquals(succeeding.countFenced, 1)
+	}
+	{
+		restore := withTestFencing(testFencers, []string{"failing", "unknown"}, config.FencingPolicyBestEffort)
+		test.S(t).ExpectNil(fenceDeadMaster(newTestFencingRecovery("")))
+		restore()
+		test.S(t).ExpectEquals(failing.countFenced, 2)
+	}
+	{
+		restore := withTestFencing(testFencers, []string{"failing", "unknown"}, config.FencingPolicyRequired)
+		test.S(t).ExpectNotNil(fenceDeadMaster(newTestFencingRecovery("")))
+		restore()
+		test.S(t).ExpectEquals(failing.countFenced, 3)
+	}
+	{
+		// Graceful takeover already set the master read-only
+		restore := withTestFencing(testFencers, []string{"failing"}, config.FencingPolicyRequired)
+		test.S(t).ExpectNil(fenceDeadMaster(newTestFencingRecovery(inst.GracefulMasterTakeoverCommandHint)))
+		restore()
+		test.S(t).ExpectEquals(failing.countFenced, 3)
+	}
+	{
+		defer func(timeoutSeconds uint) { config.Config.FencingTimeoutSeconds = timeoutSeconds }(config.Config.FencingTimeoutSeconds)
+		config.Config.FencingTimeoutSeconds = 7
+		restore := withTestFencing(testFencers, []string{"succeeding"}, config.FencingPolicyRequired)
+		test.S(t).ExpectNil(fenceDeadMaster(newTestFencingRecovery("")))
+		restore()
+		test.S(t).ExpectEquals(succeeding.timeout, 7*time.Second)
+	}
+}
+
+func TestMySQLFencerTimeout(t *testing.T) {
+	initTestBackend(t)
+	if config.RuntimeCLIFlags.Noop == nil {
+		config.RuntimeCLIFlags.Noop = new(bool)
+	}
+	// A server which accepts connections but never responds
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	test.S(t).ExpectNil(err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	topologyRecovery := newTestFencingRecovery("")
+	topologyRecovery.AnalysisEntry.AnalyzedInstanceKey.Port, _ = strconv.Atoi(port)
+	start := time.Now()
+	err = (&mysqlFencer{}).Fence(topologyRecovery, 200*time.Millisecond)
+	test.S(t).ExpectNotNil(err)
+	test.S(t).ExpectTrue(time.Since(start) < 5*time.Second)
+}
+
+func TestHTTPFencer(t *testing.T) {
+	defer func(url string) { config.Config.FencingHTTPURL = url }(config.Config.FencingHTTPURL)
+	{
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		config.Config.FencingHTTPURL = server.URL
+		test.S(t).ExpectNil((&httpFencer{}).Fence(newTestFencingRecovery(""), time.Second))
+		server.Close()
+	}
+	{
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		config.Config.FencingHTTPURL = server.URL
+		test.S(t).ExpectNotNil((&httpFencer{}).Fence(newTestFencingRecovery(""), time.Second))
+		server.Close()
+	}
+	{
+		done := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-done
+		}))
+		config.Config.FencingHTTPURL = server.URL
+		start := time.Now()
+		test.S(t).ExpectNotNil((&httpFencer{}).Fence(newTestFencingRecovery(""), 200*time.Millisecond))
+		test.S(t).ExpectTrue(time.Since(start) < 5*time.Second)
+		close(done)
+		server.Close()
+	}
+}
+
+func TestCommandFencer(t *testing.T) {
+	defer func(command string) { config.Config.FencingCommand = command }(config.Config.FencingCommand)
+	{
+		config.Config.FencingCommand = "test {failedPort} = 3306"
+		test.S(t).ExpectNil((&commandFencer{}).Fence(newTestFencingRecovery(""), time.Second))
+	}
+	{
+		config.Config.FencingCommand = "exit 1"
+		test.S(t).ExpectNotNil((&commandFencer{}).Fence(newTestFencingRecovery(""), time.Second))
+	}
+	{
+		config.Config.FencingCommand = "sleep 10"
+		start := time.Now()
+		test.S(t).ExpectNotNil((&commandFencer{}).Fence(newTestFencingRecovery(""), 200*time.Millisecond))
+		test.S(t).ExpectTrue(time.Since(start) < 5*time.Second)
+	}
+}
@@ -0,0 +1,341 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/kv"
+	"github.com/openark/orchestrator/go/process"
+	orcraft "github.com/openark/orchestrator/go/raft"
+)
+
+const (
+	KVOutboxStatusPending    = "pending"
+	KVOutboxStatusDelivered  = "delivered"
+	KVOutboxStatusSuperseded = "superseded"
+)
+
+// KVOutboxEntry is a single key-value write to a single KV store, as recorded in the outbox. An entry either writes
+// to the store's local cluster, or (IsDistribution) distributes to the store's other datacenters/clusters.
+type KVOutboxEntry struct {
+	Id             int64
+	RecoveryUID    string
+	StoreName      string
+	IsDistribution bool
+	Key            string
+	Value          string
+	Status         string
+	CountAttempts  uint
+	LastError      string
+	CreatedAt      time.Time
+	LastAttemptAt  time.Time
+	NextAttemptAt  time.Time
+	DeliveredAt    time.Time
+}
+
+// targetKey identifies the store, mode and key this entry writes to. Entries of same target key are delivered
+// in order of submission.
+func (this *KVOutboxEntry) targetKey() string {
+	return fmt.Sprintf("%s:%t:%s", this.StoreName, this.IsDistribution, this.Key)
+}
+
+// KVOutboxSubmission is a set of pairs a recovery writes to KV stores. With raft, it is applied by all nodes,
+// each adding entries to its own outbox.
+type KVOutboxSubmission struct {
+	RecoveryUID string
+	KVPairs     [](*kv.KVPair)
+}
+
+// kvOutboxBatch is a set of entries of same store and mode, delivered in a single write
+type kvOutboxBatch struct {
+	storeName      string
+	isDistribution bool
+	entries        [](*KVOutboxEntry)
+}
+
+var deliverKVOutboxEntrance int64
+
+// kvDirectWrites maps keys written directly, i.e. not via the outbox, to the outbox sequence at the time of
+// writing. Pending entries up to a key's direct write are superseded, such that a retry does not overwrite the
+// newer value.
+var kvDirectWrites = map[string]int64{}
+var kvDirectWritesMutex sync.Mutex
+
+// kvDirectWriteKey identifies a key written directly to stores' local clusters, or distributed
+func kvDirectWriteKey(isDistribution bool, key string) string {
+	return fmt.Sprintf("%t:%s", isDistribution, key)
+}
+
+// recordKVDirectWrites notes given pairs as written directly, after the outbox entries up to given sequence
+// (see readKVOutboxSequence) were submitted. Outbox ids are monotonic, and so this holds regardless of how
+// close in time the entries and the write are.
+func recordKVDirectWrites(kvPairs [](*kv.KVPair), isDistribution bool, outboxSequence int64) {
+	if !config.Config.KVOutboxEnabled {
+		return
+	}
+	kvDirectWritesMutex.Lock()
+	defer kvDirectWritesMutex.Unlock()
+
+	for _, kvPair := range kvPairs {
+		kvDirectWrites[kvDirectWriteKey(isDistribution, kvPair.Key)] = outboxSequence
+	}
+}
+
+// readKVDirectWrites returns a copy of the direct writes recorded so far
+func readKVDirectWrites() map[string]int64 {
+	kvDirectWritesMutex.Lock()
+	defer kvDirectWritesMutex.Unlock()
+
+	directWrites := map[string]int64{}
+	for key, outboxSequence := range kvDirectWrites {
+		directWrites[key] = outboxSequence
+	}
+	return directWrites
+}
+
+// enqueueKVOutboxSubmission adds outbox entries for given pairs: per configured store, entries writing to the
+// store's local cluster (when local is true), and distribution entries for distributing stores (when
+// distribute is true).
+func enqueueKVOutboxSubmission(submission *KVOutboxSubmission, local bool, distribute bool) (err error) {
+	now := time.Now()
+	for _, storeName := range kv.GetConfiguredKVStoreNames() {
+		modes := []bool{}
+		if local {
+			modes = append(modes, false)
+		}
+		if distribute && kv.IsKVStoreDistributing(storeName) {
+			modes = append(modes, true)
+		}
+		for _, isDistribution := range modes {
+			for _, kvPair := range submission.KVPairs {
+				entry := &KVOutboxEntry{
+					RecoveryUID:    submission.RecoveryUID,
+					StoreName:      storeName,
+					IsDistribution: isDistribution,
+					Key:            kvPair.Key,
+					Value:          kvPair.Value,
+					CreatedAt:      now,
+				}
+				if err := insertKVOutboxEntry(entry); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// submitKVPairsToOutbox adds a recovery's pairs to the outbox, and triggers delivery.
+// With raft, local writes go through the raft log such that each node writes to its own stores, while
+// distribution is only done by this node, same as with direct writes.
+func submitKVPairsToOutbox(recoveryUID string, kvPairs [](*kv.KVPair)) (err error) {
+	submission := &KVOutboxSubmission{RecoveryUID: recoveryUID, KVPairs: kvPairs}
+	if orcraft.IsRaftEnabled() {
+		if _, err := orcraft.PublishCommand("enqueue-kv-outbox", submission); err != nil {
+			return err
+		}
+		err = enqueueKVOutboxSubmission(submission, false, true)
+	} else {
+		err = enqueueKVOutboxSubmission(submission, true, true)
+	}
+	go DeliverKVOutbox()
+	return err
+}
+
+// auditKVOutboxDelivery audits the outcome of a delivery attempt as a step of the recoveries whose entries
+// were attempted. With raft, only the leader can audit recovery steps; other nodes only log.
+func auditKVOutboxDelivery(storeName string, isDistribution bool, entries [](*KVOutboxEntry), deliveryErr error) {
+	operation := "write"
+	if isDistribution {
+		operation = "distribute"
+	}
+	recoveriesEntries := map[string]int{}
+	for _, entry := range entries {
+		if entry.RecoveryUID == "" {
+			continue
+		}
+		if deliveryErr != nil && entry.CountAttempts > 1 {
+			// Only the first failure is audited; retries are logged
+			continue
+		}
+		recoveriesEntries[entry.RecoveryUID]++
+	}
+	for recoveryUID, countEntries := range recoveriesEntries {
+		message := fmt.Sprintf("KV outbox: %s %d pairs via %s on %s: delivered", operation, countEntries, storeName, process.ThisHostname)
+		if deliveryErr != nil {
+			message = fmt.Sprintf("KV outbox: %s %d pairs via %s on %s: failed; will retry until delivered: %+v", operation, countEntries, storeName, process.ThisHostname, deliveryErr)
+		}
+		log.Infof("topology_recovery: %s", message)
+		recoveryStep := NewTopologyRecoveryStep(recoveryUID, message)
+		if orcraft.IsRaftEnabled() {
+			if orcraft.IsLeader() {
+				orcraft.PublishCommand("write-recovery-step", recoveryStep)
+			}
+		} else {
+			writeTopologyRecoveryStep(recoveryStep)
+		}
+	}
+}
+
+// deliverKVOutboxEntries attempts a single delivery of given entries, all of same store and mode, and records
+// the outcome
+func deliverKVOutboxEntries(storeName string, isDistribution bool, entries [](*KVOutboxEntry)) (err error) {
+	kvPairs := [](*kv.KVPair){}
+	for _, entry := range entries {
+		kvPairs = append(kvPairs, kv.NewKVPair(entry.Key, entry.Value))
+	}
+	var deliveryErr error
+	if isDistribution {
+		deliveryErr = kv.DistributePairsToStore(storeName, kvPairs)
+	} else {
+		deliveryErr = kv.PutKVPairsToStore(storeName, kvPairs)
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		entry.CountAttempts++
+		entry.LastAttemptAt = now
+		if deliveryErr == nil {
+			entry.Status = KVOutboxStatusDelivered
+			entry.LastError = ""
+			entry.DeliveredAt = now
+		} else {
+			entry.LastError = deliveryErr.Error()
+			entry.NextAttemptAt = now.Add(kvOutboxRetryBackoff(entry.CountAttempts))
+		}
+		if updateErr := updateKVOutboxEntry(entry); updateErr != nil {
+			err = updateErr
+		}
+	}
+	if deliveryErr != nil {
+		log.Errorf("DeliverKVOutbox: %d pairs via %s (distribution: %t): %+v", len(entries), storeName, isDistribution, deliveryErr)
+	}
+	auditKVOutboxDelivery(storeName, isDistribution, entries, deliveryErr)
+	if deliveryErr != nil {
+		return deliveryErr
+	}
+	return err
+}
+
+// selectKVOutboxDeliveries picks, out of pending entries sorted by submission, those to deliver now, batched by
+// store and mode. Per store, mode and key, only the newest entry is delivered. Older entries are superseded,
+// and so are entries submitted before a direct write of their key: a stale value never overwrites a newer one.
+// Entries not yet due for retry are neither delivered nor superseded.
+func selectKVOutboxDeliveries(entries [](*KVOutboxEntry), directWrites map[string]int64, now time.Time) (superseded [](*KVOutboxEntry), batches [](*kvOutboxBatch)) {
+	newestEntries := map[string]*KVOutboxEntry{}
+	for _, entry := range entries {
+		if previousEntry, found := newestEntries[entry.targetKey()]; found {
+			superseded = append(superseded, previousEntry)
+		}
+		newestEntries[entry.targetKey()] = entry
+	}
+
+	batchesMap := map[string]*kvOutboxBatch{}
+	for _, entry := range entries {
+		if newestEntries[entry.targetKey()] != entry {
+			continue
+		}
+		if outboxSequence, found := directWrites[kvDirectWriteKey(entry.IsDistribution, entry.Key)]; found && entry.Id <= outboxSequence {
+			superseded = append(superseded, entry)
+			continue
+		}
+		if entry.NextAttemptAt.After(now) {
+			continue
+		}
+		batchKey := fmt.Sprintf("%s:%t", entry.StoreName, entry.IsDistribution)
+		batch, found := batchesMap[batchKey]
+		if !found {
+			batch = &kvOutboxBatch{storeName: entry.StoreName, isDistribution: entry.IsDistribution}
+			batchesMap[batchKey] = batch
+			batches = append(batches, batch)
+		}
+		batch.entries = append(batch.entries, entry)
+	}
+	return superseded, batches
+}
+
+// DeliverKVOutbox attempts delivery of pending outbox entries which are due, superseding stale entries; see
+// selectKVOutboxDeliveries. It is non re-entrant.
+func DeliverKVOutbox() (err error) {
+	if !atomic.CompareAndSwapInt64(&deliverKVOutboxEntrance, 0, 1) {
+		return nil
+	}
+	defer atomic.StoreInt64(&deliverKVOutboxEntrance, 0)
+
+	entries, err := readPendingKVOutboxEntries()
+	if err != nil {
+		return err
+	}
+	superseded, batches := selectKVOutboxDeliveries(entries, readKVDirectWrites(), time.Now())
+	for _, entry := range superseded {
+		if err := supersedeKVOutboxEntry(entry); err != nil {
+			return err
+		}
+	}
+	for _, batch := range batches {
+		if deliveryErr := deliverKVOutboxEntries(batch.storeName, batch.isDistribution, batch.entries); deliveryErr != nil {
+			err = deliveryErr
+		}
+	}
+	return err
+}
+
+// writeRecoveryKVPairs writes the KV pairs of a recovery: via the outbox when KVOutboxEnabled, falling back to
+// direct writes should the outbox be unavailable.
+func writeRecoveryKVPairs(topologyRecovery *TopologyRecovery, kvPairs [](*kv.KVPair)) {
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Writing KV %+v", kvPairs))
+	if config.Config.KVOutboxEnabled {
+		err := submitKVPairsToOutbox(topologyRecovery.UID, kvPairs)
+		if err == nil {
+			if orcraft.IsRaftEnabled() {
+				go orcraft.PublishCommand("async-snapshot", "")
+			}
+			return
+		}
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("KV outbox: failed submitting pairs; writing directly: %+v", err))
+	}
+	if orcraft.IsRaftEnabled() {
+		for _, kvPair := range kvPairs {
+			_, err := orcraft.PublishCommand("put-key-value", kvPair)
+			log.Errore(err)
+		}
+		// since we'll be affecting 3rd party tools here, we _prefer_ to mitigate re-applying
+		// of the put-key-value event upon startup. We _recommend_ a snapshot in the near future.
+		go orcraft.PublishCommand("async-snapshot", "")
+	} else {
+		outboxSequence := readKVOutboxSequence()
+		if err := kv.PutKVPairs(kvPairs); err == nil {
+			recordKVDirectWrites(kvPairs, false, outboxSequence)
+		} else {
+			log.Errore(err)
+		}
+	}
+	{
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Distributing KV %+v", kvPairs))
+		outboxSequence := readKVOutboxSequence()
+		if err := kv.DistributePairs(kvPairs); err == nil {
+			recordKVDirectWrites(kvPairs, true, outboxSequence)
+		} else {
+			log.Errore(err)
+		}
+	}
+}
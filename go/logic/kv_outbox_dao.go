@@ -0,0 +1,195 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/db"
+)
+
+// insertKVOutboxEntry adds a pending entry to the outbox. An entry of same recovery, store and key which
+// already exists (e.g. upon replay of the raft log) is left as is.
+func insertKVOutboxEntry(entry *KVOutboxEntry) error {
+	_, err := db.ExecOrchestrator(`
+			insert ignore
+				into kv_outbox (
+					recovery_uid, store_name, is_distribution, store_key, store_value,
+					status, count_attempts, last_error,
+					created_unixtime, last_attempt_unixtime, next_attempt_unixtime, delivered_unixtime, last_updated
+				) values (
+					?, ?, ?, ?, ?,
+					?, 0, '',
+					?, 0, ?, 0, NOW()
+				)
+			`,
+		entry.RecoveryUID, entry.StoreName, entry.IsDistribution, entry.Key, entry.Value,
+		KVOutboxStatusPending,
+		toUnixtime(entry.CreatedAt), toUnixtime(entry.CreatedAt),
+	)
+	return log.Errore(err)
+}
+
+// updateKVOutboxEntry records the outcome of a delivery attempt. A superseded entry is not brought back.
+func updateKVOutboxEntry(entry *KVOutboxEntry) error {
+	_, err := db.ExecOrchestrator(`
+			update
+				kv_outbox
+			set
+				status = ?,
+				count_attempts = ?,
+				last_error = ?,
+				last_attempt_unixtime = ?,
+				next_attempt_unixtime = ?,
+				delivered_unixtime = ?,
+				last_updated = NOW()
+			where
+				outbox_id = ?
+				and status = ?
+			`,
+		entry.Status, entry.CountAttempts, entry.LastError,
+		toUnixtime(entry.LastAttemptAt), toUnixtime(entry.NextAttemptAt), toUnixtime(entry.DeliveredAt),
+		entry.Id, KVOutboxStatusPending,
+	)
+	return log.Errore(err)
+}
+
+// supersedeKVOutboxEntry marks a pending entry, for which a newer value of same store and key exists, as superseded
+func supersedeKVOutboxEntry(entry *KVOutboxEntry) error {
+	_, err := db.ExecOrchestrator(`
+			update
+				kv_outbox
+			set
+				status = ?,
+				last_updated = NOW()
+			where
+				outbox_id = ?
+				and status = ?
+			`,
+		KVOutboxStatusSuperseded, entry.Id, KVOutboxStatusPending,
+	)
+	return log.Errore(err)
+}
+
+func readKVOutboxEntries(whereClause string, args []interface{}) (entries [](*KVOutboxEntry), err error) {
+	entries = [](*KVOutboxEntry){}
+	query := fmt.Sprintf(`
+		select
+			outbox_id,
+			recovery_uid,
+			store_name,
+			is_distribution,
+			store_key,
+			store_value,
+			status,
+			count_attempts,
+			last_error,
+			created_unixtime,
+			last_attempt_unixtime,
+			next_attempt_unixtime,
+			delivered_unixtime
+		from
+			kv_outbox
+		%s
+		order by
+			outbox_id asc
+		`, whereClause)
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		entry := &KVOutboxEntry{
+			Id:             m.GetInt64("outbox_id"),
+			RecoveryUID:    m.GetString("recovery_uid"),
+			StoreName:      m.GetString("store_name"),
+			IsDistribution: m.GetBool("is_distribution"),
+			Key:            m.GetString("store_key"),
+			Value:          m.GetString("store_value"),
+			Status:         m.GetString("status"),
+			CountAttempts:  m.GetUint("count_attempts"),
+			LastError:      m.GetString("last_error"),
+			CreatedAt:      fromUnixtime(m.GetInt64("created_unixtime")),
+			LastAttemptAt:  fromUnixtime(m.GetInt64("last_attempt_unixtime")),
+			NextAttemptAt:  fromUnixtime(m.GetInt64("next_attempt_unixtime")),
+			DeliveredAt:    fromUnixtime(m.GetInt64("delivered_unixtime")),
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, log.Errore(err)
+}
+
+// readPendingKVOutboxEntries reads all pending entries, oldest first
+func readPendingKVOutboxEntries() ([](*KVOutboxEntry), error) {
+	return readKVOutboxEntries("where status = ?", sqlutils.Args(KVOutboxStatusPending))
+}
+
+// ReadKVOutboxEntries reads the outbox entries of given recovery, or all pending entries when recoveryUID is empty
+func ReadKVOutboxEntries(recoveryUID string) ([](*KVOutboxEntry), error) {
+	if recoveryUID == "" {
+		return readPendingKVOutboxEntries()
+	}
+	return readKVOutboxEntries("where recovery_uid = ?", sqlutils.Args(recoveryUID))
+}
+
+// readKVOutboxSequence returns the id of the newest outbox entry, or 0 when there is none. Ids are assigned
+// in order of submission, and so entries up to this id were submitted before whatever follows the call.
+func readKVOutboxSequence() (outboxSequence int64) {
+	if !config.Config.KVOutboxEnabled {
+		return 0
+	}
+	query := `
+		select
+			ifnull(max(outbox_id), 0) as outbox_sequence
+		from
+			kv_outbox
+		`
+	err := db.QueryOrchestrator(query, nil, func(m sqlutils.RowMap) error {
+		outboxSequence = m.GetInt64("outbox_sequence")
+		return nil
+	})
+	log.Errore(err)
+	return outboxSequence
+}
+
+// ExpireKVOutbox removes old entries which are done with. Pending entries are kept until delivered or superseded.
+func ExpireKVOutbox() error {
+	_, err := db.ExecOrchestrator(`
+			delete
+				from kv_outbox
+			where
+				status != ?
+				and last_updated < NOW() - INTERVAL ? DAY
+			`,
+		KVOutboxStatusPending, config.Config.AuditPurgeDays,
+	)
+	return log.Errore(err)
+}
+
+// kvOutboxRetryBackoff returns the wait before the next delivery attempt, given the number of failed attempts
+func kvOutboxRetryBackoff(countAttempts uint) time.Duration {
+	backoff := time.Duration(config.Config.KVOutboxRetryBackoffSeconds) * time.Second
+	maxBackoff := time.Duration(config.Config.KVOutboxMaxRetryBackoffSeconds) * time.Second
+	for i := uint(1); i < countAttempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,153 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/kv"
+)
+
+// newTestKVOutboxEntry returns a pending entry created at given time
+func newTestKVOutboxEntry(id int64, storeName string, isDistribution bool, key string, value string, createdAt time.Time) *KVOutboxEntry {
+	return &KVOutboxEntry{
+		Id:             id,
+		StoreName:      storeName,
+		IsDistribution: isDistribution,
+		Key:            key,
+		Value:          value,
+		Status:         KVOutboxStatusPending,
+		CreatedAt:      createdAt,
+		NextAttemptAt:  createdAt,
+	}
+}
+
+func TestSelectKVOutboxDeliveries(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	earlier := now.Add(-time.Minute)
+	{
+		// A newer entry supersedes an older one of same store, mode and key
+		entries := [](*KVOutboxEntry){
+			newTestKVOutboxEntry(1, "consul", false, "mysql/master/app", "db-1:3306", earlier),
+			newTestKVOutboxEntry(2, "consul", true, "mysql/master/app", "db-1:3306", earlier),
+			newTestKVOutboxEntry(3, "zk", false, "mysql/master/app", "db-1:3306", earlier),
+			newTestKVOutboxEntry(4, "consul", false, "mysql/master/app", "db-2:3306", now),
+		}
+		superseded, batches := selectKVOutboxDeliveries(entries, map[string]int64{}, now)
+		test.S(t).ExpectEquals(len(superseded), 1)
+		test.S(t).ExpectEquals(superseded[0].Id, int64(1))
+
+		test.S(t).ExpectEquals(len(batches), 3)
+		test.S(t).ExpectEquals(batches[0].storeName, "consul")
+		test.S(t).ExpectTrue(batches[0].isDistribution)
+		test.S(t).ExpectEquals(batches[1].storeName, "zk")
+		test.S(t).ExpectEquals(batches[2].storeName, "consul")
+		test.S(t).ExpectFalse(batches[2].isDistribution)
+		test.S(t).ExpectEquals(len(batches[2].entries), 1)
+		test.S(t).ExpectEquals(batches[2].entries[0].Value, "db-2:3306")
+	}
+	{
+		// Entries not yet due are neither delivered nor superseded
+		entry := newTestKVOutboxEntry(1, "consul", false, "mysql/master/app", "db-1:3306", earlier)
+		entry.NextAttemptAt = now.Add(time.Second)
+		superseded, batches := selectKVOutboxDeliveries([](*KVOutboxEntry){entry}, map[string]int64{}, now)
+		test.S(t).ExpectEquals(len(superseded), 0)
+		test.S(t).ExpectEquals(len(batches), 0)
+	}
+	{
+		// A direct write supersedes entries of same mode and key submitted before it, regardless of time
+		entries := [](*KVOutboxEntry){
+			newTestKVOutboxEntry(1, "consul", false, "mysql/master/app", "db-1:3306", now),
+			newTestKVOutboxEntry(2, "consul", true, "mysql/master/app", "db-1:3306", now),
+			newTestKVOutboxEntry(3, "consul", false, "mysql/master/app2", "db-3:3306", now),
+			newTestKVOutboxEntry(4, "consul", false, "mysql/master/app3", "db-4:3306", now),
+		}
+		directWrites := map[string]int64{
+			kvDirectWriteKey(false, "mysql/master/app"):  3,
+			kvDirectWriteKey(false, "mysql/master/app3"): 3,
+		}
+		superseded, batches := selectKVOutboxDeliveries(entries, directWrites, now)
+		test.S(t).ExpectEquals(len(superseded), 1)
+		test.S(t).ExpectEquals(superseded[0].Id, int64(1))
+		test.S(t).ExpectEquals(len(batches), 2)
+		test.S(t).ExpectEquals(batches[0].entries[0].Id, int64(2))
+		test.S(t).ExpectEquals(len(batches[1].entries), 2)
+		test.S(t).ExpectEquals(batches[1].entries[0].Id, int64(3))
+		test.S(t).ExpectEquals(batches[1].entries[1].Id, int64(4))
+	}
+}
+
+func TestRecordKVDirectWrites(t *testing.T) {
+	originalOutboxEnabled := config.Config.KVOutboxEnabled
+	defer func() { config.Config.KVOutboxEnabled = originalOutboxEnabled }()
+	kvDirectWrites = map[string]int64{}
+
+	kvPairs := [](*kv.KVPair){kv.NewKVPair("mysql/master/app", "db-1:3306")}
+	{
+		// Not recorded when the outbox is disabled
+		config.Config.KVOutboxEnabled = false
+		recordKVDirectWrites(kvPairs, false, 7)
+		test.S(t).ExpectEquals(len(readKVDirectWrites()), 0)
+	}
+	{
+		config.Config.KVOutboxEnabled = true
+		recordKVDirectWrites(kvPairs, true, 7)
+		directWrites := readKVDirectWrites()
+		test.S(t).ExpectEquals(len(directWrites), 1)
+		test.S(t).ExpectEquals(directWrites[kvDirectWriteKey(true, "mysql/master/app")], int64(7))
+	}
+}
+
+func TestReadKVOutboxSequence(t *testing.T) {
+	originalOutboxEnabled := config.Config.KVOutboxEnabled
+	defer func() { config.Config.KVOutboxEnabled = originalOutboxEnabled }()
+	config.Config.KVOutboxEnabled = true
+	resetTestBackendTables(t, "kv_outbox")
+
+	test.S(t).ExpectEquals(readKVOutboxSequence(), int64(0))
+
+	// Entries submitted within same second are told apart
+	now := time.Now()
+	entry := newTestKVOutboxEntry(0, "consul", false, "mysql/master/app", "db-1:3306", now)
+	entry.RecoveryUID = "recovery-1"
+	test.S(t).ExpectNil(insertKVOutboxEntry(entry))
+	outboxSequence := readKVOutboxSequence()
+	test.S(t).ExpectTrue(outboxSequence > 0)
+	entry = newTestKVOutboxEntry(0, "consul", false, "mysql/master/app", "db-2:3306", now)
+	entry.RecoveryUID = "recovery-2"
+	test.S(t).ExpectNil(insertKVOutboxEntry(entry))
+
+	entries, err := readPendingKVOutboxEntries()
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(entries), 2)
+	superseded, batches := selectKVOutboxDeliveries(entries, map[string]int64{kvDirectWriteKey(false, "mysql/master/app"): outboxSequence}, now)
+	test.S(t).ExpectEquals(len(superseded), 1)
+	test.S(t).ExpectEquals(superseded[0].Value, "db-1:3306")
+	test.S(t).ExpectEquals(len(batches), 1)
+	test.S(t).ExpectEquals(batches[0].entries[0].Value, "db-2:3306")
+}
+
+func TestKVOutboxRetryBackoff(t *testing.T) {
+	originalBackoffSeconds := config.Config.KVOutboxRetryBackoffSeconds
+	originalMaxBackoffSeconds := config.Config.KVOutboxMaxRetryBackoffSeconds
+	defer func() {
+		config.Config.KVOutboxRetryBackoffSeconds = originalBackoffSeconds
+		config.Config.KVOutboxMaxRetryBackoffSeconds = originalMaxBackoffSeconds
+	}()
+	config.Config.KVOutboxRetryBackoffSeconds = 2
+	config.Config.KVOutboxMaxRetryBackoffSeconds = 20
+
+	test.S(t).ExpectEquals(kvOutboxRetryBackoff(0), 2*time.Second)
+	test.S(t).ExpectEquals(kvOutboxRetryBackoff(1), 2*time.Second)
+	test.S(t).ExpectEquals(kvOutboxRetryBackoff(2), 4*time.Second)
+	test.S(t).ExpectEquals(kvOutboxRetryBackoff(4), 16*time.Second)
+	// Capped
+	test.S(t).ExpectEquals(kvOutboxRetryBackoff(5), 20*time.Second)
+	test.S(t).ExpectEquals(kvOutboxRetryBackoff(100), 20*time.Second)
+	{
+		// Initial backoff already beyond max
+		config.Config.KVOutboxMaxRetryBackoffSeconds = 1
+		test.S(t).ExpectEquals(kvOutboxRetryBackoff(1), time.Second)
+	}
+}
@@ -0,0 +1,284 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/orchestrator/go/inst"
+	"github.com/openark/orchestrator/go/kv"
+	orcraft "github.com/openark/orchestrator/go/raft"
+	"github.com/patrickmn/go-cache"
+)
+
+// A cluster's alias must be seen unchanged on this many consecutive runs of DeleteRenamedClustersKVEntries
+// before the rename is deemed persistent, and KV entries of the previous alias are deleted
+const clusterAliasRenameMinRuns = 2
+
+// clusterAliasRename is a rename of a cluster's alias, not yet deemed persistent
+type clusterAliasRename struct {
+	alias     string
+	countSeen int
+}
+
+// clusterAliases maps cluster names to their aliases as last settled by this node, so as to detect renames
+var clusterAliases = map[string]string{}
+var clusterAliasRenames = map[string]*clusterAliasRename{}
+var clusterAliasesMutex sync.Mutex
+
+// readClusterAliasesSet returns all current cluster aliases, and the alias of each cluster
+func readClusterAliasesSet() (aliases map[string]bool, aliasesByClusterName map[string]string, err error) {
+	aliases = map[string]bool{}
+	aliasesByClusterName = map[string]string{}
+	clustersInfo, err := inst.ReadClustersInfo("")
+	if err != nil {
+		return aliases, aliasesByClusterName, err
+	}
+	for _, clusterInfo := range clustersInfo {
+		aliases[clusterInfo.ClusterAlias] = true
+		aliasesByClusterName[clusterInfo.ClusterName] = clusterInfo.ClusterAlias
+	}
+	return aliases, aliasesByClusterName, nil
+}
+
+// evictKVCaches forgets matching keys as found in, or published to, KV stores, such that they are
+// written again should they be needed
+func evictKVCaches(matches func(key string) bool) {
+	for _, kvCache := range []*cache.Cache{kvFoundCache, kvPublishedCache} {
+		for key := range kvCache.Items() {
+			if matches(key) {
+				kvCache.Delete(key)
+			}
+		}
+	}
+}
+
+// deleteLocalKVKey deletes a key from this node's KV stores
+func deleteLocalKVKey(key string) error {
+	evictKVCaches(func(k string) bool { return k == key })
+	return kv.DeleteKey(key)
+}
+
+// deleteLocalKVKeyPrefix deletes all keys beginning with given prefix from this node's KV stores
+func deleteLocalKVKeyPrefix(prefix string) error {
+	evictKVCaches(func(k string) bool { return strings.HasPrefix(k, prefix) })
+	return kv.DeleteKeyPrefix(prefix)
+}
+
+// deleteKVKeys deletes given keys from KV stores, via raft where applicable
+func deleteKVKeys(keys []string) (err error) {
+	for _, key := range keys {
+		var deleteErr error
+		if orcraft.IsRaftEnabled() {
+			_, deleteErr = orcraft.PublishCommand("delete-key-value", kv.NewKVPair(key, ""))
+		} else {
+			deleteErr = deleteLocalKVKey(key)
+		}
+		if deleteErr != nil {
+			err = log.Errore(deleteErr)
+		}
+	}
+	return err
+}
+
+// deleteClusterAliasKVEntries deletes all entries written for given cluster alias from KV stores,
+// via raft where applicable
+func deleteClusterAliasKVEntries(clusterAlias string) (err error) {
+	if clusterAlias == "" {
+		return nil
+	}
+	for _, prefix := range inst.GetOrchestratorKVPrefixes() {
+		key := fmt.Sprintf("%s%s", prefix, clusterAlias)
+		// Descendants first: hierarchical stores (ZooKeeper) do not delete a key which has children
+		var deleteErr error
+		if orcraft.IsRaftEnabled() {
+			_, deleteErr = orcraft.PublishCommand("delete-key-prefix", kv.NewKVPair(key+"/", ""))
+		} else {
+			deleteErr = deleteLocalKVKeyPrefix(key + "/")
+		}
+		if deleteErr != nil {
+			err = log.Errore(deleteErr)
+		}
+		if deleteErr := deleteKVKeys([]string{key}); deleteErr != nil {
+			err = deleteErr
+		}
+	}
+	if err == nil {
+		inst.AuditOperation("delete-kv-entries", nil, fmt.Sprintf("Deleted KV entries of cluster alias %s", clusterAlias))
+	}
+	return err
+}
+
+// ForgetCluster forgets all instances of given cluster, via raft where applicable, and deletes the
+// KV entries written for the cluster's alias
+func ForgetCluster(clusterName string) (err error) {
+	clusterAlias := ""
+	if clusterInfo, err := inst.ReadClusterInfo(clusterName); err == nil {
+		clusterAlias = clusterInfo.ClusterAlias
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("forget-cluster", clusterName)
+	} else {
+		err = inst.ForgetCluster(clusterName)
+	}
+	if err != nil {
+		return err
+	}
+	go deleteClusterAliasKVEntries(clusterAlias)
+	return nil
+}
+
+// SetClusterAliasManualOverride overrides the alias of given cluster, via raft where applicable, and
+// deletes the KV entries written under its previous alias
+func SetClusterAliasManualOverride(clusterName string, alias string) (err error) {
+	previousAlias := ""
+	if clusterInfo, err := inst.ReadClusterInfo(clusterName); err == nil {
+		previousAlias = clusterInfo.ClusterAlias
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("set-cluster-alias-manual-override", []string{clusterName, alias})
+	} else {
+		err = inst.SetClusterAliasManualOverride(clusterName, alias)
+	}
+	if err != nil {
+		return err
+	}
+	if previousAlias != alias {
+		go deleteClusterAliasKVEntries(previousAlias)
+	}
+	return nil
+}
+
+// ReadStaleKVEntries lists keys under orchestrator's KV prefixes (masters, and published replicas, intermediate
+// masters & status) which belong to no known cluster alias: these are leftovers of forgotten or renamed clusters.
+func ReadStaleKVEntries() (staleKeys []string, err error) {
+	aliases, _, err := readClusterAliasesSet()
+	if err != nil {
+		return staleKeys, err
+	}
+	if len(aliases) == 0 {
+		return staleKeys, fmt.Errorf("ReadStaleKVEntries: no clusters are known; refusing to deem all KV entries stale")
+	}
+	for _, prefix := range inst.GetOrchestratorKVPrefixes() {
+		if prefix == "/" || prefix == "" {
+			return staleKeys, fmt.Errorf("ReadStaleKVEntries: refusing to scan root KV prefix")
+		}
+		kvPairs, err := kv.ListKeyPrefix(prefix)
+		if err != nil {
+			return staleKeys, err
+		}
+		for _, kvPair := range kvPairs {
+			clusterAlias := strings.Split(strings.TrimPrefix(kvPair.Key, prefix), "/")[0]
+			if !aliases[clusterAlias] {
+				staleKeys = append(staleKeys, kvPair.Key)
+			}
+		}
+	}
+	return staleKeys, nil
+}
+
+// ReconcileKVStores (re)writes all clusters' masters, and published replicas, intermediate masters & status
+// entries to KV stores. It returns the written pairs, and the stale keys, which it does not delete.
+func ReconcileKVStores() (kvPairs [](*kv.KVPair), staleKeys []string, err error) {
+	kvPairs, _, err = SubmitMastersToKvStores("", true)
+	if err != nil {
+		return kvPairs, staleKeys, err
+	}
+	kvPublishedCache.Flush()
+	if err := PublishClusterRolesToKvStores(""); err != nil {
+		return kvPairs, staleKeys, err
+	}
+	clustersKVPairs, err := inst.GetClustersRolesKVPairs("")
+	if err != nil {
+		return kvPairs, staleKeys, err
+	}
+	for _, clusterKVPairs := range clustersKVPairs {
+		kvPairs = append(kvPairs, clusterKVPairs...)
+	}
+	staleKeys, err = ReadStaleKVEntries()
+	return kvPairs, staleKeys, err
+}
+
+// GarbageCollectKVStores deletes stale keys (see ReadStaleKVEntries) from KV stores, and returns them
+func GarbageCollectKVStores() (staleKeys []string, err error) {
+	staleKeys, err = ReadStaleKVEntries()
+	if err != nil {
+		return staleKeys, err
+	}
+	if len(staleKeys) == 0 {
+		return staleKeys, nil
+	}
+	log.Infof("GarbageCollectKVStores: deleting %d stale keys", len(staleKeys))
+	err = deleteKVKeys(staleKeys)
+	inst.AuditOperation("kv-gc", nil, fmt.Sprintf("Deleted %d stale KV entries; success=%t", len(staleKeys), err == nil))
+	return staleKeys, err
+}
+
+// DeleteRenamedClustersKVEntries deletes KV entries of aliases clusters no longer go by. Renames are
+// detected by comparing with the aliases settled on previous runs. A rename is only acted upon once seen
+// on clusterAliasRenameMinRuns consecutive runs, such that a transient alias does not get the entries of
+// the cluster's actual alias deleted.
+func DeleteRenamedClustersKVEntries() error {
+	clusterAliasesMutex.Lock()
+	defer clusterAliasesMutex.Unlock()
+
+	aliases, aliasesByClusterName, err := readClusterAliasesSet()
+	if err != nil {
+		return log.Errore(err)
+	}
+	settledAliases := map[string]string{}
+	for clusterName, currentAlias := range aliasesByClusterName {
+		settledAliases[clusterName] = currentAlias
+		previousAlias, found := clusterAliases[clusterName]
+		if !found || currentAlias == previousAlias || previousAlias == "" {
+			// forgotten clusters are taken care of by forget-cluster
+			delete(clusterAliasRenames, clusterName)
+			continue
+		}
+		rename := clusterAliasRenames[clusterName]
+		if rename == nil || rename.alias != currentAlias {
+			rename = &clusterAliasRename{alias: currentAlias}
+			clusterAliasRenames[clusterName] = rename
+		}
+		rename.countSeen++
+		if rename.countSeen < clusterAliasRenameMinRuns {
+			// Not yet deemed persistent
+			settledAliases[clusterName] = previousAlias
+			continue
+		}
+		if !aliases[previousAlias] {
+			// No other cluster now goes by this alias
+			log.Infof("DeleteRenamedClustersKVEntries: cluster %s renamed from %s to %s; deleting KV entries of %s", clusterName, previousAlias, currentAlias, previousAlias)
+			if err := deleteClusterAliasKVEntries(previousAlias); err != nil {
+				// Try again next time
+				settledAliases[clusterName] = previousAlias
+				continue
+			}
+		}
+		delete(clusterAliasRenames, clusterName)
+	}
+	for clusterName := range clusterAliasRenames {
+		if _, found := aliasesByClusterName[clusterName]; !found {
+			delete(clusterAliasRenames, clusterName)
+		}
+	}
+	clusterAliases = settledAliases
+	return nil
+}
@@ -0,0 +1,128 @@
+package logic
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/inst"
+	"github.com/openark/orchestrator/go/kv"
+	"github.com/patrickmn/go-cache"
+)
+
+// initTestKVReconcile sets up cluster db-1:3306 with given alias, and the internal KV store with no entries.
+// It returns a function restoring the KV configuration.
+func initTestKVReconcile(t *testing.T, clusterAlias string) (restore func()) {
+	resetTestBackendTables(t, "database_instance", "candidate_database_instance", "cluster_alias", "cluster_alias_override", "kv_store")
+	kv.InitKVStores()
+	originalPrefix := config.Config.KVClusterMasterPrefix
+	originalPublishReplicas := config.Config.KVPublishReplicas
+	originalPublishIntermediateMasters := config.Config.KVPublishIntermediateMasters
+	originalPublishClusterStatus := config.Config.KVPublishClusterStatus
+	config.Config.KVClusterMasterPrefix = "mysql/master/"
+	config.Config.KVPublishReplicas = false
+	config.Config.KVPublishIntermediateMasters = false
+	config.Config.KVPublishClusterStatus = false
+
+	writeTestClusterInstance(t, "db-2", "dc1", inst.NeutralPromoteRule, true)
+	test.S(t).ExpectNil(inst.SetClusterAlias("db-1:3306", clusterAlias))
+	return func() {
+		config.Config.KVClusterMasterPrefix = originalPrefix
+		config.Config.KVPublishReplicas = originalPublishReplicas
+		config.Config.KVPublishIntermediateMasters = originalPublishIntermediateMasters
+		config.Config.KVPublishClusterStatus = originalPublishClusterStatus
+	}
+}
+
+// writeTestMasterKVEntries writes master entries of given cluster aliases to KV stores
+func writeTestMasterKVEntries(t *testing.T, clusterAliases ...string) {
+	for _, clusterAlias := range clusterAliases {
+		key := inst.GetClusterMasterKVKey(clusterAlias)
+		test.S(t).ExpectNil(kv.PutKVPairs([]*kv.KVPair{kv.NewKVPair(key, "db-1:3306"), kv.NewKVPair(key+"/hostname", "db-1")}))
+	}
+}
+
+// countTestMasterKVEntries returns the number of master entries of given cluster alias found in KV stores
+func countTestMasterKVEntries(t *testing.T, clusterAlias string) (count int) {
+	key := inst.GetClusterMasterKVKey(clusterAlias)
+	for _, key := range []string{key, key + "/hostname"} {
+		_, found, err := kv.GetValue(key)
+		test.S(t).ExpectNil(err)
+		if found {
+			count++
+		}
+	}
+	return count
+}
+
+func TestReadStaleKVEntries(t *testing.T) {
+	restore := initTestKVReconcile(t, "app")
+	defer restore()
+	writeTestMasterKVEntries(t, "app", "app2", "old")
+
+	staleKeys, err := ReadStaleKVEntries()
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(staleKeys), 4)
+	for _, staleKey := range staleKeys {
+		test.S(t).ExpectTrue(staleKey != "mysql/master/app" && staleKey != "mysql/master/app/hostname")
+	}
+	{
+		// The root prefix is never scanned
+		config.Config.KVClusterMasterPrefix = "/"
+		_, err := ReadStaleKVEntries()
+		test.S(t).ExpectNotNil(err)
+		config.Config.KVClusterMasterPrefix = "mysql/master/"
+	}
+	{
+		// With no known clusters, all entries would seem stale
+		resetTestBackendTables(t, "database_instance")
+		_, err := ReadStaleKVEntries()
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestDeleteRenamedClustersKVEntries(t *testing.T) {
+	restore := initTestKVReconcile(t, "app")
+	defer restore()
+	clusterAliases = map[string]string{}
+	clusterAliasRenames = map[string]*clusterAliasRename{}
+	writeTestMasterKVEntries(t, "app", "app-new")
+
+	test.S(t).ExpectNil(DeleteRenamedClustersKVEntries())
+	test.S(t).ExpectEquals(clusterAliases["db-1:3306"], "app")
+	{
+		// A transient rename is not acted upon
+		test.S(t).ExpectNil(inst.SetClusterAlias("db-1:3306", "app-transient"))
+		test.S(t).ExpectNil(DeleteRenamedClustersKVEntries())
+		test.S(t).ExpectNil(inst.SetClusterAlias("db-1:3306", "app"))
+		test.S(t).ExpectNil(DeleteRenamedClustersKVEntries())
+		test.S(t).ExpectEquals(countTestMasterKVEntries(t, "app"), 2)
+		test.S(t).ExpectEquals(clusterAliases["db-1:3306"], "app")
+		test.S(t).ExpectEquals(len(clusterAliasRenames), 0)
+	}
+	{
+		// A rename is acted upon once seen on consecutive runs
+		kvFoundCache.Set("mysql/master/app", true, cache.DefaultExpiration)
+		kvPublishedCache.Set("mysql/master/app/hostname", "db-1", cache.DefaultExpiration)
+		kvFoundCache.Set("mysql/master/app-new", true, cache.DefaultExpiration)
+
+		test.S(t).ExpectNil(inst.SetClusterAlias("db-1:3306", "app-new"))
+		test.S(t).ExpectNil(DeleteRenamedClustersKVEntries())
+		test.S(t).ExpectEquals(countTestMasterKVEntries(t, "app"), 2)
+		test.S(t).ExpectEquals(clusterAliases["db-1:3306"], "app")
+
+		test.S(t).ExpectNil(DeleteRenamedClustersKVEntries())
+		test.S(t).ExpectEquals(countTestMasterKVEntries(t, "app"), 0)
+		test.S(t).ExpectEquals(countTestMasterKVEntries(t, "app-new"), 2)
+		test.S(t).ExpectEquals(clusterAliases["db-1:3306"], "app-new")
+		test.S(t).ExpectEquals(len(clusterAliasRenames), 0)
+
+		// Deleted entries are no longer cached as found nor as published
+		_, found := kvFoundCache.Get("mysql/master/app")
+		test.S(t).ExpectFalse(found)
+		_, found = kvPublishedCache.Get("mysql/master/app/hostname")
+		test.S(t).ExpectFalse(found)
+		_, found = kvFoundCache.Get("mysql/master/app-new")
+		test.S(t).ExpectTrue(found)
+	}
+}
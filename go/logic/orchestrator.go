@@ -70,10 +70,15 @@ var discoveryMetrics = collection.CreateOrReturnCollection(discoveryMetricsName)
 var deadInstancesDiscoveryQueueLengthGauge = metrics.NewGauge()
 
 var isElectedNode int64 = 0
+var injectSeedsEntrance int64
+var publishClusterRolesEntrance int64
+var publishClusterRolesPending int64
 
 var recentDiscoveryOperationKeys *cache.Cache
+var urgentDiscoveryKeys = cache.New(time.Minute, time.Second)
 var pseudoGTIDPublishCache = cache.New(time.Minute, time.Second)
 var kvFoundCache = cache.New(10*time.Minute, time.Minute)
+var kvPublishedCache = cache.New(10*time.Minute, time.Minute)
 
 func init() {
 	snapshotDiscoveryKeys = make(chan inst.InstanceKey, 10)
@@ -134,6 +139,12 @@ func instancePollSecondsDuration() time.Duration {
 	return time.Duration(config.Config.InstancePollSeconds) * time.Second
 }
 
+// minimalInstancePollSecondsDuration is the shortest interval at which any instance is polled, which
+// is shorter than InstancePollSeconds with AdaptiveInstancePoll
+func minimalInstancePollSecondsDuration() time.Duration {
+	return time.Duration(inst.MinimalInstancePollSeconds()) * time.Second
+}
+
 // acceptSignals registers for OS signals
 func acceptSignals() {
 	c := make(chan os.Signal, 1)
@@ -217,6 +228,48 @@ func handleDiscoveryRequests() {
 	}
 }
 
+// PrioritizeDiscovery marks an instance for urgent discovery for the next InstancePollSeconds: its
+// polls, as well as those of its master and replicas when it is discovered, go ahead of routine
+// polls in the discovery queue.
+func PrioritizeDiscovery(instanceKey *inst.InstanceKey) {
+	if !instanceKey.IsValid() {
+		return
+	}
+	urgentDiscoveryKeys.Set(instanceKey.StringCode(), true, instancePollSecondsDuration())
+}
+
+// prioritizeAnalyzedInstances marks instances involved in a detected problem for urgent discovery:
+// the analyzed instance, its master and its replicas.
+func prioritizeAnalyzedInstances(replicationAnalysis []inst.ReplicationAnalysis) {
+	for _, analysisEntry := range replicationAnalysis {
+		if analysisEntry.Analysis == inst.NoProblem {
+			continue
+		}
+		PrioritizeDiscovery(&analysisEntry.AnalyzedInstanceKey)
+		PrioritizeDiscovery(&analysisEntry.AnalyzedInstanceMasterKey)
+		for _, replicaKey := range analysisEntry.Replicas.GetInstanceKeys() {
+			PrioritizeDiscovery(&replicaKey)
+		}
+	}
+}
+
+// isUrgentDiscovery returns true when given instance is marked for urgent discovery
+func isUrgentDiscovery(instanceKey *inst.InstanceKey) bool {
+	_, found := urgentDiscoveryKeys.Get(instanceKey.StringCode())
+	return found
+}
+
+// discoveryQueueLane returns the discovery queue lane in which to push given instance
+func discoveryQueueLane(instanceKey *inst.InstanceKey, isMaster bool) discovery.QueueLane {
+	if isUrgentDiscovery(instanceKey) {
+		return discovery.UrgentLane
+	}
+	if isMaster {
+		return discovery.MasterLane
+	}
+	return discovery.RoutineLane
+}
+
 // DiscoverInstance will attempt to discover (poll) an instance (unless
 // it is already up to date) and will also ensure that its master and
 // replicas (if any) are also checked.
@@ -255,7 +308,7 @@ func DiscoverInstance(instanceKey inst.InstanceKey) {
 	// Calculate the expiry period each time as InstancePollSeconds
 	// _may_ change during the run of the process (via SIGHUP) and
 	// it is not possible to change the cache's default expiry..
-	if existsInCacheError := recentDiscoveryOperationKeys.Add(instanceKey.DisplayString(), true, instancePollSecondsDuration()); existsInCacheError != nil {
+	if existsInCacheError := recentDiscoveryOperationKeys.Add(instanceKey.DisplayString(), true, minimalInstancePollSecondsDuration()); existsInCacheError != nil {
 		// Just recently attempted
 		return
 	}
@@ -322,6 +375,15 @@ func DiscoverInstance(instanceKey inst.InstanceKey) {
 		return
 	}
 
+	// Master and replicas of an instance marked for urgent discovery are themselves urgent
+	urgent := isUrgentDiscovery(&instanceKey)
+	neighbourLane := func(key *inst.InstanceKey, isMaster bool) discovery.QueueLane {
+		if urgent {
+			return discovery.UrgentLane
+		}
+		return discoveryQueueLane(key, isMaster)
+	}
+
 	// Investigate replicas and members of the same replication group:
 	for _, replicaKey := range append(instance.ReplicationGroupMembers.GetInstanceKeys(), instance.Replicas.GetInstanceKeys()...) {
 		replicaKey := replicaKey // not needed? no concurrency here?
@@ -338,26 +400,35 @@ func DiscoverInstance(instanceKey inst.InstanceKey) {
 		dead, recheck := inst.DeadInstancesFilter.InstanceRecheckNeeded(&replicaKey)
 		if dead {
 			if recheck {
-				deadInstancesDiscoveryQueue.Push(replicaKey)
+				deadInstancesDiscoveryQueue.PushToLane(replicaKey, neighbourLane(&replicaKey, false))
 			}
 			// dead, but not recheck time
 			continue
 		} else {
-			discoveryQueue.Push(replicaKey)
+			discoveryQueue.PushToLane(replicaKey, neighbourLane(&replicaKey, false))
 		}
 	}
-	// Investigate master:
-	if instance.MasterKey.IsValid() {
-		if !inst.FiltersMatchInstanceKey(&instance.MasterKey, config.Config.DiscoveryIgnoreMasterHostnameFilters) {
-			dead, recheck := inst.DeadInstancesFilter.InstanceRecheckNeeded(&instance.MasterKey)
+	// Investigate master, as well as masters of all channels on a multi-source replica:
+	masterKeys := []inst.InstanceKey{instance.MasterKey}
+	for _, channel := range instance.ReplicationChannels {
+		masterKeys = append(masterKeys, channel.MasterKey)
+	}
+	for _, masterKey := range masterKeys {
+		masterKey := masterKey
+		if !masterKey.IsValid() {
+			continue
+		}
+		if inst.FiltersMatchInstanceKey(&masterKey, config.Config.DiscoveryIgnoreMasterHostnameFilters) {
+			continue
+		}
+		dead, recheck := inst.DeadInstancesFilter.InstanceRecheckNeeded(&masterKey)
 
-			if dead {
-				if recheck {
-					deadInstancesDiscoveryQueue.Push(instance.MasterKey)
-				}
-			} else {
-				discoveryQueue.Push(instance.MasterKey)
+		if dead {
+			if recheck {
+				deadInstancesDiscoveryQueue.PushToLane(masterKey, neighbourLane(&masterKey, true))
 			}
+		} else {
+			discoveryQueue.PushToLane(masterKey, neighbourLane(&masterKey, true))
 		}
 	}
 }
@@ -401,7 +472,7 @@ func onHealthTick() {
 	if !IsLeaderOrActive() {
 		return
 	}
-	instanceKeys, err := inst.ReadOutdatedInstanceKeys()
+	instanceKeys, masterKeys, err := inst.ReadOutdatedInstanceKeys()
 	if err != nil {
 		log.Errore(err)
 	}
@@ -427,18 +498,19 @@ func onHealthTick() {
 	if len(instanceKeys) > 0 {
 		for _, instanceKey := range instanceKeys {
 			if instanceKey.IsValid() {
+				lane := discoveryQueueLane(&instanceKey, masterKeys.HasKey(instanceKey))
 				dead, recheck := inst.DeadInstancesFilter.InstanceRecheckNeeded(&instanceKey)
 				if dead {
 					if recheck {
 						// this is a dead instance that needs recheck
-						deadInstancesDiscoveryQueue.Push(instanceKey)
+						deadInstancesDiscoveryQueue.PushToLane(instanceKey, lane)
 					}
 					// If the instance is dead, but it is not a time to recheck it
 					// it will be filtered out here.
 					continue
 				} else {
 					// this is a healthy instance that needs recheck
-					discoveryQueue.Push(instanceKey)
+					discoveryQueue.PushToLane(instanceKey, lane)
 				}
 			}
 		}
@@ -526,30 +598,127 @@ func SubmitMastersToKvStores(clusterName string, force bool) (kvPairs [](*kv.KVP
 			}
 		}
 	} else {
+		outboxSequence := readKVOutboxSequence()
 		err := kv.PutKVPairs(submitKvPairs)
 		if err == nil {
 			submittedCount += len(submitKvPairs)
+			recordKVDirectWrites(submitKvPairs, false, outboxSequence)
 		} else {
 			selectedError = err
 		}
 	}
-	if err := kv.DistributePairs(kvPairs); err != nil {
+	outboxSequence := readKVOutboxSequence()
+	if err := kv.DistributePairs(kvPairs); err == nil {
+		recordKVDirectWrites(kvPairs, true, outboxSequence)
+	} else {
 		log.Errore(err)
 	}
 	return kvPairs, submittedCount, log.Errore(selectedError)
 }
 
-func injectSeeds(seedOnce *sync.Once) {
-	seedOnce.Do(func() {
-		for _, seed := range config.Config.DiscoverySeeds {
-			instanceKey, err := inst.ParseRawInstanceKey(seed)
-			if err == nil {
-				inst.InjectSeed(instanceKey)
-			} else {
-				log.Errorf("Error parsing seed %s: %+v", seed, err)
+// PublishClusterRolesToKvStores writes clusters' replicas, intermediate masters and status (as configured)
+// to KV stores. Only entries which changed since last published are written, cluster by cluster.
+// It is non re-entrant: a call made while publishing is running is queued, and the running call follows
+// up by publishing all clusters once more, such that no change is left unpublished.
+func PublishClusterRolesToKvStores(clusterName string) (err error) {
+	atomic.StoreInt64(&publishClusterRolesPending, 1)
+	for atomic.LoadInt64(&publishClusterRolesPending) == 1 {
+		if !atomic.CompareAndSwapInt64(&publishClusterRolesEntrance, 0, 1) {
+			// Queued; the running call follows up
+			return nil
+		}
+		for atomic.CompareAndSwapInt64(&publishClusterRolesPending, 1, 0) {
+			err = publishClusterRolesToKvStores(clusterName)
+			clusterName = ""
+		}
+		atomic.StoreInt64(&publishClusterRolesEntrance, 0)
+	}
+	return err
+}
+
+// RefreshClusterAndPublishRoles re-reads the instances of given cluster from the topology, then publishes
+// clusters' roles to KV stores. Following a recovery the backend may not yet reflect the new topology,
+// and roles published off of it would be stale until the next poll.
+func RefreshClusterAndPublishRoles(clusterName string, successorKey *inst.InstanceKey) (err error) {
+	instances, err := inst.ReadClusterInstances(clusterName)
+	if err != nil {
+		log.Errore(err)
+	}
+	if successorKey != nil {
+		if successor, found, _ := inst.ReadInstance(successorKey); found && successor.ClusterName != clusterName {
+			instances = append(instances, successor)
+		}
+	}
+	inst.RefreshTopologyInstances(instances)
+	// The cluster may have been renamed by the recovery; publish all clusters
+	return PublishClusterRolesToKvStores("")
+}
+
+func publishClusterRolesToKvStores(clusterName string) (err error) {
+	clustersKVPairs, err := inst.GetClustersRolesKVPairs(clusterName)
+	if err != nil {
+		return log.Errore(err)
+	}
+	var allKVPairs [](*kv.KVPair)
+	for _, kvPairs := range clustersKVPairs {
+		allKVPairs = append(allKVPairs, kvPairs...)
+
+		var submitKvPairs [](*kv.KVPair)
+		for _, kvPair := range kvPairs {
+			if value, found := kvPublishedCache.Get(kvPair.Key); found && value == kvPair.Value {
+				continue
 			}
+			submitKvPairs = append(submitKvPairs, kvPair)
 		}
-	})
+		if len(submitKvPairs) == 0 {
+			continue
+		}
+		var submitErr error
+		if orcraft.IsRaftEnabled() {
+			_, submitErr = orcraft.PublishCommand("put-kv-pairs", submitKvPairs)
+		} else {
+			submitErr = kv.PutKVPairs(submitKvPairs)
+		}
+		if submitErr != nil {
+			err = log.Errore(submitErr)
+			continue
+		}
+		for _, kvPair := range submitKvPairs {
+			kvPublishedCache.Set(kvPair.Key, kvPair.Value, cache.DefaultExpiration)
+		}
+		log.Debugf("PublishClusterRolesToKvStores: published %d pairs", len(submitKvPairs))
+	}
+	if err := kv.DistributePairs(allKVPairs); err != nil {
+		log.Errore(err)
+	}
+	return err
+}
+
+// injectSeeds evaluates the discovery seed providers, injecting newly listed seeds and possibly forgetting
+// disappeared ones. With raft, disappeared seeds are forgotten by the leader, via raft. It is non re-entrant.
+func injectSeeds() {
+	if !atomic.CompareAndSwapInt64(&injectSeedsEntrance, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt64(&injectSeedsEntrance, 0)
+
+	forgetSeed := inst.ForgetInstance
+	if orcraft.IsRaftEnabled() {
+		forgetSeed = nil
+		if orcraft.IsLeader() {
+			forgetSeed = func(instanceKey *inst.InstanceKey) error {
+				_, err := orcraft.PublishCommand("forget", instanceKey)
+				return err
+			}
+		}
+	}
+	injected, forgotten, err := discovery.RefreshSeeds(forgetSeed)
+	if len(injected) > 0 || len(forgotten) > 0 {
+		log.Infof("injectSeeds: injected %d seeds, forgot %d seeds", len(injected), len(forgotten))
+	}
+	if err != nil {
+		log.Errore(err)
+	}
 }
 
 // ContinuousDiscovery starts an asynchronuous infinite discovery process where instances are
@@ -559,7 +728,7 @@ func ContinuousDiscovery() {
 	log.Infof("continuous discovery: setting up")
 	continuousDiscoveryStartTime := time.Now()
 	checkAndRecoverWaitPeriod := 3 * instancePollSecondsDuration()
-	recentDiscoveryOperationKeys = cache.New(instancePollSecondsDuration(), time.Second)
+	recentDiscoveryOperationKeys = cache.New(minimalInstancePollSecondsDuration(), time.Second)
 
 	inst.LoadHostnameResolveCache()
 	go handleDiscoveryRequests()
@@ -568,6 +737,7 @@ func ContinuousDiscovery() {
 	instancePollTick := time.Tick(instancePollSecondsDuration())
 	caretakingTick := time.Tick(time.Minute)
 	raftCaretakingTick := time.Tick(10 * time.Minute)
+	kvOutboxTick := time.Tick(time.Second)
 	recoveryTick := time.Tick(time.Duration(config.RecoveryPollSeconds) * time.Second)
 	autoPseudoGTIDTick := time.Tick(time.Duration(config.PseudoGTIDIntervalSeconds) * time.Second)
 	var recoveryEntrance int64
@@ -581,11 +751,14 @@ func ContinuousDiscovery() {
 	}
 
 	var seedOnce sync.Once
+	seedsTick := time.Tick(time.Duration(config.Config.DiscoverySeedsPollSeconds) * time.Second)
 
 	go ometrics.InitMetrics()
 	go ometrics.InitGraphiteMetrics()
 	go acceptSignals()
+	kv.SetLeaderCheck(IsLeader)
 	go kv.InitKVStores()
+	go kv.ServeKVFileSinkSocket()
 	if config.Config.RaftEnabled {
 		if err := orcraft.Setup(NewCommandApplier(), NewSnapshotDataCreatorApplier(), process.ThisHostname); err != nil {
 			log.Fatale(err)
@@ -604,6 +777,12 @@ func ContinuousDiscovery() {
 			go func() {
 				onHealthTick()
 			}()
+		case <-kvOutboxTick:
+			go func() {
+				if config.Config.KVOutboxEnabled && IsLeaderOrActive() {
+					DeliverKVOutbox()
+				}
+			}()
 		case <-instancePollTick:
 			go func() {
 				// This tick does NOT do instance poll (these are handled by the oversampling discoveryTick)
@@ -612,7 +791,18 @@ func ContinuousDiscovery() {
 				if IsLeaderOrActive() {
 					go inst.UpdateClusterAliases()
 					go inst.ExpireDowntime()
-					go injectSeeds(&seedOnce)
+					go seedOnce.Do(injectSeeds)
+				}
+				if IsLeader() {
+					go RunDueScheduledTakeovers()
+					go RunRollingOperations()
+					go PublishClusterRolesToKvStores("")
+				}
+			}()
+		case <-seedsTick:
+			go func() {
+				if IsLeaderOrActive() {
+					go injectSeeds()
 				}
 			}()
 		case <-autoPseudoGTIDTick:
@@ -651,9 +841,16 @@ func ContinuousDiscovery() {
 					go ExpireFailureDetectionHistory()
 					go ExpireTopologyRecoveryHistory()
 					go ExpireTopologyRecoveryStepsHistory()
+					go ExpireScheduledTakeoverHistory()
+					go ExpireRollingOperationHistory()
+					go ExpireKVOutbox()
+
+					go inst.ExpireErrantGTIDRemediations()
 
 					if runCheckAndRecoverOperationsTimeRipe() && IsLeader() {
 						go SubmitMastersToKvStores("", false)
+						go DeleteRenamedClustersKVEntries()
+						go inst.RemediateErrantGTIDs()
 					}
 				} else {
 					// Take this opportunity to refresh yourself
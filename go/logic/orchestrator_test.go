@@ -0,0 +1,21 @@
+package logic
+
+import (
+	"sync/atomic"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestPublishClusterRolesToKvStoresQueued(t *testing.T) {
+	// A call made while publishing is running is queued
+	atomic.StoreInt64(&publishClusterRolesEntrance, 1)
+	test.S(t).ExpectNil(PublishClusterRolesToKvStores(""))
+	test.S(t).ExpectEquals(atomic.LoadInt64(&publishClusterRolesPending), int64(1))
+
+	// The queued call is served by the next run
+	atomic.StoreInt64(&publishClusterRolesEntrance, 0)
+	test.S(t).ExpectNil(PublishClusterRolesToKvStores(""))
+	test.S(t).ExpectEquals(atomic.LoadInt64(&publishClusterRolesPending), int64(0))
+	test.S(t).ExpectEquals(atomic.LoadInt64(&publishClusterRolesEntrance), int64(0))
+}
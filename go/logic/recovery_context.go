@@ -0,0 +1,143 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	goos "os"
+	"time"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/orchestrator/go/inst"
+	"github.com/openark/orchestrator/go/process"
+)
+
+// RecoveryContextReplica describes a replica affected by a recovery, as last known to orchestrator
+type RecoveryContextReplica struct {
+	Key                   inst.InstanceKey
+	Found                 bool
+	MasterKey             inst.InstanceKey
+	SelfBinlogCoordinates inst.BinlogCoordinates
+	ReadBinlogCoordinates inst.BinlogCoordinates
+	ExecBinlogCoordinates inst.BinlogCoordinates
+	ExecutedGtidSet       string
+	GtidErrant            string
+	ReplicationLagSeconds *int64
+}
+
+// RecoveryContext is the JSON document made available to recovery hooks via ORC_CONTEXT_FILE
+type RecoveryContext struct {
+	Hook              string
+	RecoveryUID       string
+	OrchestratorHost  string
+	Timestamp         time.Time
+	IsSuccessful      bool
+	RecoveryType      MasterRecoveryType
+	AnalysisEntry     inst.ReplicationAnalysis
+	ClusterInfo       inst.ClusterInfo
+	SuccessorKey      *inst.InstanceKey
+	SuccessorAlias    string
+	SuccessorInstance *inst.Instance
+	LostReplicas      []RecoveryContextReplica
+	RelocatedReplicas []RecoveryContextReplica
+	Steps             []TopologyRecoveryStep
+	AllErrors         []string
+}
+
+func newRecoveryContextReplica(instanceKey inst.InstanceKey) RecoveryContextReplica {
+	replica := RecoveryContextReplica{Key: instanceKey}
+	instance, found, err := inst.ReadInstance(&instanceKey)
+	if err != nil || !found {
+		return replica
+	}
+	replica.Found = true
+	replica.MasterKey = instance.MasterKey
+	replica.SelfBinlogCoordinates = instance.SelfBinlogCoordinates
+	replica.ReadBinlogCoordinates = instance.ReadBinlogCoordinates
+	replica.ExecBinlogCoordinates = instance.ExecBinlogCoordinates
+	replica.ExecutedGtidSet = instance.ExecutedGtidSet
+	replica.GtidErrant = instance.GtidErrant
+	if instance.ReplicationLagSeconds.Valid {
+		lag := instance.ReplicationLagSeconds.Int64
+		replica.ReplicationLagSeconds = &lag
+	}
+	return replica
+}
+
+// newRecoveryContext collects what orchestrator knows about a recovery at given hook stage. Replicas of the
+// failed instance which were neither lost nor promoted are listed as relocated.
+func newRecoveryContext(topologyRecovery *TopologyRecovery, hook string) *RecoveryContext {
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	recoveryContext := &RecoveryContext{
+		Hook:              hook,
+		RecoveryUID:       topologyRecovery.UID,
+		OrchestratorHost:  process.ThisHostname,
+		Timestamp:         time.Now(),
+		IsSuccessful:      topologyRecovery.SuccessorKey != nil,
+		RecoveryType:      topologyRecovery.RecoveryType,
+		AnalysisEntry:     *analysisEntry,
+		ClusterInfo:       analysisEntry.ClusterDetails,
+		SuccessorKey:      topologyRecovery.SuccessorKey,
+		SuccessorAlias:    topologyRecovery.SuccessorAlias,
+		LostReplicas:      []RecoveryContextReplica{},
+		RelocatedReplicas: []RecoveryContextReplica{},
+		AllErrors:         topologyRecovery.AllErrors,
+	}
+	if topologyRecovery.SuccessorKey != nil {
+		if instance, found, err := inst.ReadInstance(topologyRecovery.SuccessorKey); err == nil && found {
+			recoveryContext.SuccessorInstance = instance
+		}
+	}
+	for _, key := range topologyRecovery.LostReplicas.GetInstanceKeys() {
+		recoveryContext.LostReplicas = append(recoveryContext.LostReplicas, newRecoveryContextReplica(key))
+	}
+	for _, key := range analysisEntry.Replicas.GetInstanceKeys() {
+		if topologyRecovery.LostReplicas.HasKey(key) {
+			continue
+		}
+		if topologyRecovery.SuccessorKey != nil && key.Equals(topologyRecovery.SuccessorKey) {
+			continue
+		}
+		recoveryContext.RelocatedReplicas = append(recoveryContext.RelocatedReplicas, newRecoveryContextReplica(key))
+	}
+	if steps, err := ReadTopologyRecoverySteps(topologyRecovery.UID); err == nil {
+		recoveryContext.Steps = steps
+	} else {
+		recoveryContext.Steps = []TopologyRecoveryStep{}
+	}
+	return recoveryContext
+}
+
+// writeRecoveryContextFile writes the recovery context for given hook stage into a new temporary file,
+// and returns the file name. The caller is responsible for removing the file.
+func writeRecoveryContextFile(topologyRecovery *TopologyRecovery, hook string) (fileName string, err error) {
+	b, err := json.Marshal(newRecoveryContext(topologyRecovery, hook))
+	if err != nil {
+		return "", log.Errore(err)
+	}
+	contextFile, err := ioutil.TempFile("", "orchestrator-recovery-context-")
+	if err != nil {
+		return "", log.Errore(err)
+	}
+	defer contextFile.Close()
+	if _, err := contextFile.Write(b); err != nil {
+		goos.Remove(contextFile.Name())
+		return "", log.Errore(err)
+	}
+	return contextFile.Name(), nil
+}
@@ -0,0 +1,124 @@
+package logic
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	goos "os"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/inst"
+)
+
+func newTestRecoveryContextRecovery(t *testing.T) *TopologyRecovery {
+	resetTestBackendTables(t, "database_instance", "topology_recovery_steps")
+
+	masterKey := inst.InstanceKey{Hostname: "db-1", Port: 3306}
+	for i, hostname := range []string{"db-2", "db-3"} {
+		instance := inst.NewInstance()
+		instance.Key = inst.InstanceKey{Hostname: hostname, Port: 3306}
+		instance.MasterKey = masterKey
+		instance.ClusterName = "db-1:3306"
+		instance.ExecutedGtidSet = "00000000-0000-0000-0000-000000000001:1-100"
+		instance.ExecBinlogCoordinates = inst.BinlogCoordinates{LogFile: "mysql-bin.000003", LogPos: int64(1000 + i)}
+		instance.ReplicationLagSeconds.Valid = true
+		instance.ReplicationLagSeconds.Int64 = int64(i)
+		test.S(t).ExpectNil(inst.WriteInstance(instance, true, nil))
+	}
+
+	analysisEntry := inst.ReplicationAnalysis{
+		AnalyzedInstanceKey: masterKey,
+		Analysis:            inst.DeadMaster,
+		ClusterDetails:      inst.ClusterInfo{ClusterName: "db-1:3306", ClusterAlias: "my-cluster"},
+		Replicas:            *inst.NewInstanceKeyMap(),
+	}
+	analysisEntry.Replicas.AddKey(inst.InstanceKey{Hostname: "db-2", Port: 3306})
+	analysisEntry.Replicas.AddKey(inst.InstanceKey{Hostname: "db-3", Port: 3306})
+	analysisEntry.Replicas.AddKey(inst.InstanceKey{Hostname: "db-4", Port: 3306})
+
+	topologyRecovery := NewTopologyRecovery(analysisEntry)
+	topologyRecovery.RecoveryType = MasterRecoveryGTID
+	topologyRecovery.SuccessorKey = &inst.InstanceKey{Hostname: "db-2", Port: 3306}
+	topologyRecovery.SuccessorAlias = "db-2-alias"
+	topologyRecovery.LostReplicas.AddKey(inst.InstanceKey{Hostname: "db-4", Port: 3306})
+	topologyRecovery.AddError(goos.ErrNotExist)
+	test.S(t).ExpectNil(writeTopologyRecoveryStep(NewTopologyRecoveryStep(topologyRecovery.UID, "step one")))
+	test.S(t).ExpectNil(writeTopologyRecoveryStep(NewTopologyRecoveryStep(topologyRecovery.UID, "step two")))
+	return topologyRecovery
+}
+
+func TestNewRecoveryContext(t *testing.T) {
+	topologyRecovery := newTestRecoveryContextRecovery(t)
+
+	recoveryContext := newRecoveryContext(topologyRecovery, "PostMasterFailoverProcesses")
+	test.S(t).ExpectEquals(recoveryContext.Hook, "PostMasterFailoverProcesses")
+	test.S(t).ExpectEquals(recoveryContext.RecoveryUID, topologyRecovery.UID)
+	test.S(t).ExpectTrue(recoveryContext.IsSuccessful)
+	test.S(t).ExpectEquals(recoveryContext.RecoveryType, MasterRecoveryType(MasterRecoveryGTID))
+	test.S(t).ExpectEquals(recoveryContext.ClusterInfo.ClusterAlias, "my-cluster")
+	test.S(t).ExpectEquals(recoveryContext.SuccessorAlias, "db-2-alias")
+	test.S(t).ExpectNotNil(recoveryContext.SuccessorInstance)
+	test.S(t).ExpectTrue(recoveryContext.SuccessorInstance.Key.Equals(topologyRecovery.SuccessorKey))
+	test.S(t).ExpectEquals(len(recoveryContext.AllErrors), 1)
+
+	// The lost replica is unknown to orchestrator
+	test.S(t).ExpectEquals(len(recoveryContext.LostReplicas), 1)
+	test.S(t).ExpectEquals(recoveryContext.LostReplicas[0].Key.Hostname, "db-4")
+	test.S(t).ExpectFalse(recoveryContext.LostReplicas[0].Found)
+	test.S(t).ExpectTrue(recoveryContext.LostReplicas[0].ReplicationLagSeconds == nil)
+
+	// Neither the promoted nor the lost replica are relocated
+	test.S(t).ExpectEquals(len(recoveryContext.RelocatedReplicas), 1)
+	relocated := recoveryContext.RelocatedReplicas[0]
+	test.S(t).ExpectEquals(relocated.Key.Hostname, "db-3")
+	test.S(t).ExpectTrue(relocated.Found)
+	test.S(t).ExpectEquals(relocated.MasterKey.Hostname, "db-1")
+	test.S(t).ExpectEquals(relocated.ExecutedGtidSet, "00000000-0000-0000-0000-000000000001:1-100")
+	test.S(t).ExpectEquals(relocated.ExecBinlogCoordinates.LogPos, int64(1001))
+	test.S(t).ExpectNotNil(relocated.ReplicationLagSeconds)
+	test.S(t).ExpectEquals(*relocated.ReplicationLagSeconds, int64(1))
+
+	test.S(t).ExpectEquals(len(recoveryContext.Steps), 2)
+	test.S(t).ExpectEquals(recoveryContext.Steps[0].Message, "step one")
+	test.S(t).ExpectEquals(recoveryContext.Steps[1].Message, "step two")
+}
+
+func TestNewRecoveryContextUnsuccessful(t *testing.T) {
+	topologyRecovery := newTestRecoveryContextRecovery(t)
+	topologyRecovery.SuccessorKey = nil
+
+	recoveryContext := newRecoveryContext(topologyRecovery, "PostUnsuccessfulFailoverProcesses")
+	test.S(t).ExpectFalse(recoveryContext.IsSuccessful)
+	test.S(t).ExpectTrue(recoveryContext.SuccessorInstance == nil)
+	// With no successor, all replicas which are not lost are relocated
+	test.S(t).ExpectEquals(len(recoveryContext.RelocatedReplicas), 2)
+}
+
+func TestWriteRecoveryContextFile(t *testing.T) {
+	topologyRecovery := newTestRecoveryContextRecovery(t)
+
+	fileName, err := writeRecoveryContextFile(topologyRecovery, "PostMasterFailoverProcesses")
+	test.S(t).ExpectNil(err)
+	defer goos.Remove(fileName)
+
+	content, err := ioutil.ReadFile(fileName)
+	test.S(t).ExpectNil(err)
+	recoveryContext := &RecoveryContext{}
+	test.S(t).ExpectNil(json.Unmarshal(content, recoveryContext))
+	test.S(t).ExpectEquals(recoveryContext.Hook, "PostMasterFailoverProcesses")
+	test.S(t).ExpectEquals(recoveryContext.RecoveryUID, topologyRecovery.UID)
+	test.S(t).ExpectTrue(recoveryContext.IsSuccessful)
+	test.S(t).ExpectTrue(recoveryContext.SuccessorKey.Equals(topologyRecovery.SuccessorKey))
+	test.S(t).ExpectTrue(recoveryContext.AnalysisEntry.AnalyzedInstanceKey.Equals(&topologyRecovery.AnalysisEntry.AnalyzedInstanceKey))
+	test.S(t).ExpectEquals(len(recoveryContext.LostReplicas), 1)
+	test.S(t).ExpectEquals(len(recoveryContext.RelocatedReplicas), 1)
+	test.S(t).ExpectEquals(*recoveryContext.RelocatedReplicas[0].ReplicationLagSeconds, int64(1))
+	test.S(t).ExpectEquals(len(recoveryContext.Steps), 2)
+
+	// Unknown lag is serialized as null rather than as 0
+	generic := map[string]interface{}{}
+	test.S(t).ExpectNil(json.Unmarshal(content, &generic))
+	lostReplica := generic["LostReplicas"].([]interface{})[0].(map[string]interface{})
+	test.S(t).ExpectTrue(lostReplica["ReplicationLagSeconds"] == nil)
+	test.S(t).ExpectEquals(lostReplica["Found"], false)
+}
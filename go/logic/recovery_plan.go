@@ -0,0 +1,507 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/inst"
+	"github.com/openark/orchestrator/go/kv"
+	orcraft "github.com/openark/orchestrator/go/raft"
+)
+
+// RecoveryPlanHook is an external process a recovery would execute, with placeholders substituted,
+// or a webhook it would deliver
+type RecoveryPlanHook struct {
+	Description string
+	Command     string
+	URL         string
+	Async       bool
+	FailOnError bool
+}
+
+// RecoveryPlan describes what a recovery (or a graceful takeover) would do if it ran right now.
+// Planning is a dry run: no recovery is registered, no hook is executed and no server is changed.
+type RecoveryPlan struct {
+	Operation          string
+	AnalysisEntry      inst.ReplicationAnalysis
+	FailedInstanceKey  inst.InstanceKey
+	RecoveryType       RecoveryType
+	MasterRecoveryType MasterRecoveryType
+	CandidateKey       *inst.InstanceKey
+	CandidateReason    string
+	CandidateScores    [](*inst.CandidateScore)
+	ReplicasToRelocate inst.InstanceKeyMap
+	LostReplicas       inst.InstanceKeyMap
+	Hooks              []RecoveryPlanHook
+	KVWrites           [](*kv.KVPair)
+	Steps              []string
+	Blockers           []string
+
+	topologyRecovery *TopologyRecovery
+}
+
+func newRecoveryPlan(operation string, analysisEntry inst.ReplicationAnalysis) *RecoveryPlan {
+	plan := &RecoveryPlan{
+		Operation:          operation,
+		AnalysisEntry:      analysisEntry,
+		FailedInstanceKey:  analysisEntry.AnalyzedInstanceKey,
+		MasterRecoveryType: NotMasterRecovery,
+		ReplicasToRelocate: *inst.NewInstanceKeyMap(),
+		LostReplicas:       *inst.NewInstanceKeyMap(),
+		Hooks:              []RecoveryPlanHook{},
+		KVWrites:           [](*kv.KVPair){},
+		Steps:              []string{},
+		Blockers:           []string{},
+	}
+	plan.topologyRecovery = NewTopologyRecovery(analysisEntry)
+	plan.topologyRecovery.plan = plan
+	return plan
+}
+
+// IsBlocked returns true when the planned operation would not run, or would fail to promote a server
+func (this *RecoveryPlan) IsBlocked() bool {
+	return len(this.Blockers) > 0
+}
+
+// ToJSONString marshals this plan as JSON
+func (this *RecoveryPlan) ToJSONString() string {
+	b, _ := json.Marshal(this)
+	return string(b)
+}
+
+func (this *RecoveryPlan) addStep(message string) {
+	this.Steps = append(this.Steps, message)
+}
+
+func (this *RecoveryPlan) addBlocker(message string) {
+	this.Blockers = append(this.Blockers, message)
+	this.addStep(fmt.Sprintf("blocked: %s", message))
+}
+
+// addHooks renders given processes the way executeProcesses would run them at this point of the plan
+func (this *RecoveryPlan) addHooks(processes []string, description string, failOnError bool) {
+	for _, command := range processes {
+		command, async := prepareCommand(command, this.topologyRecovery)
+		this.Hooks = append(this.Hooks, RecoveryPlanHook{
+			Description: description,
+			Command:     command,
+			Async:       async,
+			FailOnError: failOnError,
+		})
+	}
+	webhooks := getRecoveryWebhooks(description)
+	for _, webhook := range webhooks {
+		this.Hooks = append(this.Hooks, RecoveryPlanHook{
+			Description: description,
+			URL:         webhook.URL,
+			Async:       !webhook.FailOnError,
+			FailOnError: failOnError && webhook.FailOnError,
+		})
+	}
+	if len(processes) > 0 {
+		this.addStep(fmt.Sprintf("would run %d %s", len(processes), description))
+	}
+	if len(webhooks) > 0 {
+		this.addStep(fmt.Sprintf("would deliver %d %s webhooks", len(webhooks), description))
+	}
+}
+
+// setSuccessor marks given instance as the one the planned recovery would promote, which affects
+// how post-failover hooks are rendered
+func (this *RecoveryPlan) setSuccessor(successor *inst.Instance) {
+	this.topologyRecovery.SuccessorKey = &successor.Key
+	this.topologyRecovery.SuccessorAlias = successor.InstanceAlias
+	this.topologyRecovery.SuccessorBinlogCoordinates = &successor.SelfBinlogCoordinates
+}
+
+// planRecoveryRegistration applies the checks of AttemptRecoveryRegistration. Besides the blockages it enforces,
+// registration is refused when the analyzed instance already has a recovery in active period; a forced recovery
+// implicitly acknowledges such recovery first, provided it is completed.
+func (this *RecoveryPlan) planRecoveryRegistration(forceInstanceRecovery bool) {
+	analysisEntry := &this.topologyRecovery.AnalysisEntry
+
+	instanceRecoveries, err := ReadInActivePeriodInstanceRecovery(&analysisEntry.AnalyzedInstanceKey, analysisEntry.AnalyzedChannelName)
+	if err != nil {
+		this.addBlocker(fmt.Sprintf("cannot read recoveries of %+v: %+v", analysisEntry.AnalyzedInstanceKey, err))
+		return
+	}
+	for _, recovery := range instanceRecoveries {
+		switch {
+		case recovery.RecoveryEndTimestamp == "":
+			this.addBlocker(fmt.Sprintf("recovery %d of %+v, started at %s, is still running", recovery.Id, analysisEntry.AnalyzedInstanceKey, recovery.RecoveryStartTimestamp))
+		case forceInstanceRecovery:
+			this.addStep(fmt.Sprintf("would implicitly acknowledge completed recovery %d of %+v", recovery.Id, analysisEntry.AnalyzedInstanceKey))
+		default:
+			this.addBlocker(fmt.Sprintf("recovery %d of %+v, completed at %s, is in active period; acknowledge it to remove this blockage", recovery.Id, analysisEntry.AnalyzedInstanceKey, recovery.RecoveryEndTimestamp))
+		}
+	}
+	blockages, err := readRecoveryRegistrationBlockages(analysisEntry, !forceInstanceRecovery, !forceInstanceRecovery)
+	if err != nil {
+		this.addBlocker(fmt.Sprintf("cannot read recoveries in active period: %+v", err))
+		return
+	}
+	for _, blockage := range blockages {
+		if blockage.enforced {
+			this.addBlocker(blockage.message)
+		} else {
+			this.addStep(fmt.Sprintf("%s; this recovery is explicitly requested and would proceed regardless", blockage.message))
+		}
+	}
+}
+
+// planRecovery follows the path of executeCheckAndRecoverFunction on a forced recovery
+func (this *RecoveryPlan) planRecovery(candidateInstanceKey *inst.InstanceKey, skipProcesses bool) {
+	analysisEntry := &this.topologyRecovery.AnalysisEntry
+
+	checkAndRecoverFunction, isActionableRecovery := getCheckAndRecoverFunction(analysisEntry.Analysis, &analysisEntry.AnalyzedInstanceKey)
+	if checkAndRecoverFunction == nil || !isActionableRecovery {
+		this.addBlocker(fmt.Sprintf("analysis %+v on %+v has no actionable recovery", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey))
+		return
+	}
+	if orcraft.IsRaftEnabled() && !orcraft.IsLeader() {
+		this.addBlocker("this node is not the raft leader; only the leader runs recoveries")
+	}
+	// Planned operations are all explicitly requested, hence forced
+	this.planRecoveryRegistration(true)
+	if this.IsBlocked() {
+		return
+	}
+	if recoveryDisabledGlobally, _ := IsRecoveryDisabled(); recoveryDisabledGlobally {
+		this.addStep("recoveries are disabled globally; this recovery is explicitly requested and would proceed regardless")
+	}
+	if appliedPolicies := this.topologyRecovery.RecoveryPolicy().AppliedPolicies; len(appliedPolicies) > 0 {
+		this.addStep(fmt.Sprintf("cluster recovery policies apply: %s", strings.Join(appliedPolicies, ", ")))
+	}
+	if !skipProcesses {
+		this.addHooks(this.topologyRecovery.RecoveryPolicy().OnFailureDetectionProcesses, "OnFailureDetectionProcesses", true)
+	}
+
+	switch analysisEntry.Analysis {
+	case inst.DeadMaster, inst.DeadMasterAndSomeReplicas:
+		this.planDeadMaster(candidateInstanceKey, skipProcesses)
+	case inst.DeadIntermediateMaster,
+		inst.DeadIntermediateMasterAndSomeReplicas,
+		inst.DeadIntermediateMasterWithSingleReplicaFailingToConnect,
+		inst.AllIntermediateMasterReplicasFailingToConnectOrDead:
+		this.planDeadIntermediateMaster(skipProcesses)
+	default:
+		this.addStep(fmt.Sprintf("would run the %+v recovery on %+v; no detailed plan is available for this analysis", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey))
+		return
+	}
+
+	if !skipProcesses {
+		if this.topologyRecovery.SuccessorKey == nil {
+			this.addHooks(this.topologyRecovery.RecoveryPolicy().PostUnsuccessfulFailoverProcesses, "PostUnsuccessfulFailoverProcesses", false)
+		} else {
+			this.addHooks(this.topologyRecovery.RecoveryPolicy().PostFailoverProcesses, "PostFailoverProcesses", false)
+		}
+	}
+}
+
+// planDeadMaster follows the path of checkAndRecoverDeadMaster and recoverDeadMaster
+func (this *RecoveryPlan) planDeadMaster(candidateInstanceKey *inst.InstanceKey, skipProcesses bool) {
+	topologyRecovery := this.topologyRecovery
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	failedInstanceKey := &analysisEntry.AnalyzedInstanceKey
+
+	this.RecoveryType = MasterRecovery
+	topologyRecovery.Type = MasterRecovery
+	candidateInstanceKey, mustPromote, err := resolveMustPromoteCandidate(topologyRecovery, candidateInstanceKey)
+	if err != nil {
+		this.addBlocker(err.Error())
+		return
+	}
+	if mustPromote {
+		if err := checkMustPromoteCandidateTakeOver(topologyRecovery, candidateInstanceKey, GetMasterRecoveryType(analysisEntry)); err != nil {
+			this.addBlocker(err.Error())
+			return
+		}
+	}
+	if !skipProcesses {
+		this.addHooks(this.topologyRecovery.RecoveryPolicy().PreFailoverProcesses, "PreFailoverProcesses", true)
+	}
+	if len(config.Config.FencingMethods) > 0 && analysisEntry.CommandHint != inst.GracefulMasterTakeoverCommandHint {
+		this.addStep(fmt.Sprintf("would fence %+v via first successful of %s; FencingPolicy is %s", *failedInstanceKey, strings.Join(config.Config.FencingMethods, ", "), config.Config.FencingPolicy))
+	}
+	this.MasterRecoveryType = GetMasterRecoveryType(analysisEntry)
+	topologyRecovery.RecoveryType = this.MasterRecoveryType
+	auditCandidateScores(topologyRecovery)
+
+	var promotedReplica *inst.Instance
+	switch this.MasterRecoveryType {
+	case MasterRecoveryBinlogServer:
+		this.addStep(fmt.Sprintf("would recover %+v via binlog servers", *failedInstanceKey))
+		promotedReplica, err = inst.GetCandidateReplicaOfBinlogServerTopology(failedInstanceKey)
+		for _, replicaKey := range analysisEntry.Replicas.GetInstanceKeys() {
+			this.ReplicasToRelocate.AddKey(replicaKey)
+		}
+	default:
+		this.addStep(fmt.Sprintf("would regroup replicas of %+v via %+v", *failedInstanceKey, this.MasterRecoveryType))
+		var aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas [](*inst.Instance)
+		promotedReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err = inst.GetCandidateReplica(failedInstanceKey, false)
+		this.ReplicasToRelocate.AddInstances(equalReplicas)
+		this.ReplicasToRelocate.AddInstances(laterReplicas)
+		this.LostReplicas.AddInstances(aheadReplicas)
+		this.LostReplicas.AddInstances(cannotReplicateReplicas)
+	}
+	if err != nil {
+		this.addBlocker(err.Error())
+	}
+	if promotedReplica == nil {
+		this.addBlocker(fmt.Sprintf("no replica of %+v can be promoted", *failedInstanceKey))
+		return
+	}
+	this.CandidateReason = fmt.Sprintf("most up to date replica able to take over its siblings; executed coordinates: %+v, promotion rule: %+v, data center: %s, environment: %s",
+		promotedReplica.ExecBinlogCoordinates, promotedReplica.PromotionRule, promotedReplica.DataCenter, promotedReplica.PhysicalEnvironment)
+
+	replacement, actionRequired, err := SuggestReplacementForPromotedReplica(topologyRecovery, failedInstanceKey, promotedReplica, candidateInstanceKey)
+	if err != nil {
+		this.addBlocker(err.Error())
+	} else if actionRequired && replacement != nil {
+		this.addStep(fmt.Sprintf("would promote %+v over %+v, then relocate the replicas of %+v below %+v", replacement.Key, promotedReplica.Key, promotedReplica.Key, replacement.Key))
+		this.ReplicasToRelocate.AddKey(promotedReplica.Key)
+		if candidateInstanceKey != nil {
+			this.CandidateReason = fmt.Sprintf("explicitly requested candidate; regroup would first promote %+v", promotedReplica.Key)
+		} else {
+			this.CandidateReason = fmt.Sprintf("better candidate than %+v, which regroup would first promote; promotion rule: %+v, data center: %s, environment: %s",
+				promotedReplica.Key, replacement.PromotionRule, replacement.DataCenter, replacement.PhysicalEnvironment)
+		}
+		promotedReplica = replacement
+	}
+	this.CandidateKey = &promotedReplica.Key
+	delete(this.ReplicasToRelocate, promotedReplica.Key)
+	delete(this.LostReplicas, promotedReplica.Key)
+	topologyRecovery.LostReplicas = this.LostReplicas
+
+	for _, replicaKey := range this.LostReplicas.GetInstanceKeys() {
+		this.addStep(fmt.Sprintf("- lost replica: %+v", replicaKey))
+	}
+	if len(this.LostReplicas) > 0 && config.Config.DetachLostReplicasAfterMasterFailover {
+		this.addStep(fmt.Sprintf("would detach %d lost replicas", len(this.LostReplicas)))
+	}
+	this.addStep(fmt.Sprintf("would downtime %+v and lost replicas for %d seconds", *failedInstanceKey, config.LostInRecoveryDowntimeSeconds))
+
+	if mustPromote && !promotedReplica.Key.Equals(candidateInstanceKey) {
+		this.addBlocker(fmt.Sprintf("could not promote %+v, which has must promotion rule; would refuse to promote %+v", *candidateInstanceKey, promotedReplica.Key))
+		return
+	}
+	// The checks of overrideMasterPromotion
+	if waitForSQLThread, err := checkMasterPromotionOverride(topologyRecovery, promotedReplica); err != nil {
+		this.addBlocker(err.Error())
+	} else if waitForSQLThread {
+		this.addStep(fmt.Sprintf("would wait for SQL thread on %+v to apply relay logs", promotedReplica.Key))
+	}
+	if this.IsBlocked() {
+		return
+	}
+	this.setSuccessor(promotedReplica)
+	this.addStep(fmt.Sprintf("would promote %+v", promotedReplica.Key))
+
+	if config.Config.ApplyMySQLPromotionAfterMasterFailover || analysisEntry.CommandHint == inst.GracefulMasterTakeoverCommandHint {
+		this.addStep(fmt.Sprintf("would apply RESET SLAVE ALL and read-only=0 on %+v, and read-only=1 on %+v", promotedReplica.Key, *failedInstanceKey))
+	}
+	this.KVWrites = inst.GetClusterMasterKVPairs(analysisEntry.ClusterDetails.ClusterAlias, &promotedReplica.Key)
+	if len(this.KVWrites) > 0 {
+		this.addStep(fmt.Sprintf("would write and distribute %d KV pairs", len(this.KVWrites)))
+	}
+	if config.Config.MasterFailoverDetachReplicaMasterHost {
+		this.addStep(fmt.Sprintf("would detach master host on %+v", promotedReplica.Key))
+	}
+	this.addStep(fmt.Sprintf("would update cluster_alias: %v -> %v", failedInstanceKey.StringCode(), promotedReplica.Key.StringCode()))
+	if !skipProcesses {
+		this.addHooks(this.topologyRecovery.RecoveryPolicy().PostMasterFailoverProcesses, "PostMasterFailoverProcesses", false)
+	}
+}
+
+// planDeadIntermediateMaster follows the path of checkAndRecoverDeadIntermediateMaster and RecoverDeadIntermediateMaster
+func (this *RecoveryPlan) planDeadIntermediateMaster(skipProcesses bool) {
+	topologyRecovery := this.topologyRecovery
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	failedInstanceKey := &analysisEntry.AnalyzedInstanceKey
+
+	this.RecoveryType = IntermediateMasterRecovery
+	topologyRecovery.Type = IntermediateMasterRecovery
+	if !skipProcesses {
+		this.addHooks(this.topologyRecovery.RecoveryPolicy().PreFailoverProcesses, "PreFailoverProcesses", true)
+	}
+	auditCandidateScores(topologyRecovery)
+	intermediateMasterInstance, _, err := inst.ReadInstance(failedInstanceKey)
+	if err != nil || intermediateMasterInstance == nil {
+		this.addBlocker(fmt.Sprintf("cannot read intermediate master %+v", *failedInstanceKey))
+		return
+	}
+	for _, replicaKey := range analysisEntry.Replicas.GetInstanceKeys() {
+		this.ReplicasToRelocate.AddKey(replicaKey)
+	}
+
+	var successor *inst.Instance
+	candidateSibling, _ := GetCandidateSiblingOfIntermediateMaster(topologyRecovery, intermediateMasterInstance)
+	if candidateSibling != nil {
+		successor = candidateSibling
+		this.CandidateReason = fmt.Sprintf("candidate sibling of dead intermediate master; is_candidate: %t, data center: %s, environment: %s",
+			candidateSibling.IsCandidate, candidateSibling.DataCenter, candidateSibling.PhysicalEnvironment)
+		if candidateSibling.DataCenter == intermediateMasterInstance.DataCenter {
+			this.addStep(fmt.Sprintf("would relocate replicas of %+v below candidate sibling %+v", *failedInstanceKey, candidateSibling.Key))
+		} else {
+			this.addStep(fmt.Sprintf("would regroup replicas of %+v, then relocate them below candidate sibling %+v in another data center", *failedInstanceKey, candidateSibling.Key))
+		}
+	} else {
+		successor, _, err = inst.ReadInstance(&analysisEntry.AnalyzedInstanceMasterKey)
+		if err != nil || successor == nil {
+			this.addBlocker(fmt.Sprintf("no candidate sibling of %+v, and cannot read its master %+v", *failedInstanceKey, analysisEntry.AnalyzedInstanceMasterKey))
+			return
+		}
+		this.CandidateReason = "no valid candidate sibling; replicas would be regrouped and relocated up to the master of the dead intermediate master"
+		this.addStep(fmt.Sprintf("would regroup replicas of %+v, then relocate them up to %+v", *failedInstanceKey, successor.Key))
+	}
+	this.CandidateKey = &successor.Key
+	this.setSuccessor(successor)
+	if !skipProcesses {
+		this.addHooks(this.topologyRecovery.RecoveryPolicy().PostIntermediateMasterFailoverProcesses, "PostIntermediateMasterFailoverProcesses", false)
+	}
+}
+
+// PlanCheckAndRecover returns the plan of a recovery of given instance, as CheckAndRecover would run it.
+// Nothing is executed.
+func PlanCheckAndRecover(specificInstance *inst.InstanceKey, candidateInstanceKey *inst.InstanceKey, skipProcesses bool) (plan *RecoveryPlan, err error) {
+	replicationAnalysis, err := inst.GetReplicationAnalysis("", &inst.ReplicationAnalysisHints{IncludeDowntimed: true})
+	if err != nil {
+		return nil, log.Errore(err)
+	}
+	analysisEntries := []inst.ReplicationAnalysis{}
+	for _, analysisEntry := range replicationAnalysis {
+		if specificInstance.Equals(&analysisEntry.AnalyzedInstanceKey) {
+			analysisEntries = append(analysisEntries, analysisEntry)
+		}
+	}
+	if len(analysisEntries) == 0 {
+		return nil, fmt.Errorf("No analysis found for %+v; nothing to recover", *specificInstance)
+	}
+	analysisEntry := analysisEntries[0]
+	for _, entry := range analysisEntries {
+		if checkAndRecoverFunction, isActionableRecovery := getCheckAndRecoverFunction(entry.Analysis, &entry.AnalyzedInstanceKey); checkAndRecoverFunction != nil && isActionableRecovery {
+			analysisEntry = entry
+			break
+		}
+	}
+	plan = newRecoveryPlan("recover", analysisEntry)
+	plan.planRecovery(candidateInstanceKey, skipProcesses)
+	return plan, nil
+}
+
+// PlanForceMasterFailover returns the plan of a forced failover of the master of given cluster, as
+// ForceMasterFailover would run it. Nothing is executed.
+func PlanForceMasterFailover(clusterName string) (plan *RecoveryPlan, err error) {
+	clusterMasters, err := inst.ReadClusterMaster(clusterName)
+	if err != nil || len(clusterMasters) != 1 {
+		return nil, fmt.Errorf("Cannot deduce cluster master for %+v", clusterName)
+	}
+	analysisEntry, err := forceAnalysisEntry(clusterName, inst.DeadMaster, inst.ForceMasterFailoverCommandHint, &clusterMasters[0].Key)
+	if err != nil {
+		return nil, err
+	}
+	plan = newRecoveryPlan("force-master-failover", analysisEntry)
+	plan.planRecovery(nil, false)
+	return plan, nil
+}
+
+// PlanGracefulMasterTakeover returns the plan of a graceful master takeover on given cluster, as
+// GracefulMasterTakeover would run it. Nothing is executed.
+func PlanGracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey, auto bool) (plan *RecoveryPlan, err error) {
+	clusterMasters, err := inst.ReadClusterMaster(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot deduce cluster master for %+v; error: %+v", clusterName, err)
+	}
+	if len(clusterMasters) != 1 {
+		return nil, fmt.Errorf("Cannot deduce cluster master for %+v. Found %+v potential masters", clusterName, len(clusterMasters))
+	}
+	clusterMaster := clusterMasters[0]
+
+	clusterMasterDirectReplicas, err := inst.ReadReplicaInstances(&clusterMaster.Key)
+	if err != nil {
+		return nil, log.Errore(err)
+	}
+	if len(clusterMasterDirectReplicas) == 0 {
+		return nil, fmt.Errorf("Master %+v doesn't seem to have replicas", clusterMaster.Key)
+	}
+	if designatedKey != nil && !designatedKey.IsValid() {
+		designatedKey = nil
+	}
+
+	analysisEntry, err := forceAnalysisEntry(clusterName, inst.DeadMaster, inst.GracefulMasterTakeoverCommandHint, &clusterMaster.Key)
+	if err != nil {
+		return nil, err
+	}
+	operation := "graceful-master-takeover"
+	if auto {
+		operation = "graceful-master-takeover-auto"
+	}
+	plan = newRecoveryPlan(operation, analysisEntry)
+	plan.RecoveryType = MasterRecovery
+
+	designatedInstance, err := getGracefulMasterTakeoverDesignatedInstance(&clusterMaster.Key, designatedKey, clusterMasterDirectReplicas, auto, true)
+	if err != nil {
+		plan.addBlocker(err.Error())
+		return plan, nil
+	}
+	plan.CandidateKey = &designatedInstance.Key
+	switch {
+	case designatedKey != nil:
+		plan.CandidateReason = "explicitly designated"
+	case len(clusterMasterDirectReplicas) == 1:
+		plan.CandidateReason = "single direct replica of the master"
+	default:
+		plan.CandidateReason = "auto-deduced as the best candidate replica of the master"
+		plan.addStep(fmt.Sprintf("would start replication on designated replica %+v", designatedInstance.Key))
+	}
+	if err := checkGracefulMasterTakeoverDesignatedInstance(&clusterMaster.Key, designatedInstance); err != nil {
+		plan.addBlocker(err.Error())
+	}
+	for _, directReplica := range clusterMasterDirectReplicas {
+		if !directReplica.Key.Equals(&designatedInstance.Key) {
+			plan.ReplicasToRelocate.AddKey(directReplica.Key)
+		}
+	}
+	if len(plan.ReplicasToRelocate) > 0 {
+		plan.addStep(fmt.Sprintf("would relocate %d siblings of %+v below it", len(plan.ReplicasToRelocate), designatedInstance.Key))
+	}
+	if plan.IsBlocked() {
+		return plan, nil
+	}
+
+	plan.setSuccessor(designatedInstance)
+	plan.addHooks(plan.topologyRecovery.RecoveryPolicy().PreGracefulTakeoverProcesses, "PreGracefulTakeoverProcesses", true)
+	plan.topologyRecovery.SuccessorKey = nil
+	plan.addStep(fmt.Sprintf("would set %+v as read_only and wait up to %d seconds for %+v to reach its coordinates", clusterMaster.Key, config.Config.ReasonableMaintenanceReplicationLagSeconds, designatedInstance.Key))
+
+	plan.planRecovery(&designatedInstance.Key, false)
+	if plan.IsBlocked() {
+		plan.addStep(fmt.Sprintf("would undo read_only on %+v", clusterMaster.Key))
+		return plan, nil
+	}
+	plan.addStep(fmt.Sprintf("would point demoted master %+v to replicate from %+v", clusterMaster.Key, designatedInstance.Key))
+	if auto {
+		plan.addStep(fmt.Sprintf("would start replication on demoted master %+v", clusterMaster.Key))
+	}
+	plan.addHooks(plan.topologyRecovery.RecoveryPolicy().PostGracefulTakeoverProcesses, "PostGracefulTakeoverProcesses", false)
+	return plan, nil
+}
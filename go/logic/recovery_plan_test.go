@@ -0,0 +1,29 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/openark/golib/log"
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/inst"
+)
+
+func init() {
+	config.Config.HostnameResolveMethod = "none"
+	config.MarkConfigurationLoaded()
+	log.SetLevel(log.ERROR)
+}
+
+func TestRecoveryPlanBlockers(t *testing.T) {
+	plan := newRecoveryPlan("recover", inst.ReplicationAnalysis{AnalyzedInstanceKey: inst.InstanceKey{Hostname: "db-1", Port: 3306}})
+	test.S(t).ExpectFalse(plan.IsBlocked())
+	test.S(t).ExpectTrue(plan.FailedInstanceKey.Equals(&inst.InstanceKey{Hostname: "db-1", Port: 3306}))
+
+	plan.addStep("would promote db-2:3306")
+	test.S(t).ExpectFalse(plan.IsBlocked())
+	plan.addBlocker("recovery 1 of db-1:3306 is still running")
+	test.S(t).ExpectTrue(plan.IsBlocked())
+	test.S(t).ExpectEquals(len(plan.Steps), 2)
+	test.S(t).ExpectEquals(plan.Steps[1], "blocked: recovery 1 of db-1:3306 is still running")
+}
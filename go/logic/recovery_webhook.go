@@ -0,0 +1,158 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/process"
+)
+
+const (
+	recoveryWebhookSignatureHeader = "X-Orchestrator-Signature"
+	recoveryWebhookHookHeader      = "X-Orchestrator-Hook"
+	recoveryWebhookUIDHeader       = "X-Orchestrator-Recovery-UID"
+	recoveryWebhookAttemptHeader   = "X-Orchestrator-Delivery-Attempt"
+)
+
+// RecoveryWebhookPayload is the JSON document POSTed to recovery webhooks
+type RecoveryWebhookPayload struct {
+	Hook             string
+	OrchestratorHost string
+	Timestamp        time.Time
+	IsSuccessful     bool
+	TopologyRecovery *TopologyRecovery
+}
+
+// getRecoveryWebhooks returns the webhooks configured for given hook stage
+func getRecoveryWebhooks(hook string) (webhooks []config.RecoveryWebhook) {
+	for _, webhook := range config.Config.RecoveryWebhooks {
+		if webhook.AppliesToHook(hook) {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks
+}
+
+// signRecoveryWebhookBody returns the value of the signature header for given body
+func signRecoveryWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return fmt.Sprintf("sha256=%s", hex.EncodeToString(mac.Sum(nil)))
+}
+
+// postRecoveryWebhook makes a single delivery attempt. Any non-2xx response is an error.
+func postRecoveryWebhook(webhook *config.RecoveryWebhook, hook string, recoveryUID string, body []byte, attempt uint) error {
+	timeoutSeconds := webhook.TimeoutSeconds
+	if timeoutSeconds == 0 {
+		timeoutSeconds = config.Config.RecoveryWebhookTimeoutSeconds
+	}
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(recoveryWebhookHookHeader, hook)
+	req.Header.Set(recoveryWebhookUIDHeader, recoveryUID)
+	req.Header.Set(recoveryWebhookAttemptHeader, fmt.Sprintf("%d", attempt))
+	if webhook.Secret != "" {
+		req.Header.Set(recoveryWebhookSignatureHeader, signRecoveryWebhookBody(webhook.Secret, body))
+	}
+	client := &http.Client{Timeout: time.Duration(timeoutSeconds) * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with status %d", webhook.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+// deliverRecoveryWebhook POSTs given body to a webhook, retrying with exponential backoff. Each attempt is audited.
+func deliverRecoveryWebhook(webhook *config.RecoveryWebhook, hook string, body []byte, topologyRecovery *TopologyRecovery, fullDescription string) (err error) {
+	maxAttempts := webhook.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = config.Config.RecoveryWebhookMaxAttempts
+	}
+	backoff := time.Duration(config.Config.RecoveryWebhookRetryBackoffMilliseconds) * time.Millisecond
+	for attempt := uint(1); attempt <= maxAttempts; attempt++ {
+		start := time.Now()
+		if err = postRecoveryWebhook(webhook, hook, topologyRecovery.UID, body, attempt); err == nil {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Delivered %s to %s on attempt %d of %d in %v", fullDescription, webhook.URL, attempt, maxAttempts, time.Since(start)))
+			return nil
+		}
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Delivery of %s to %s failed on attempt %d of %d in %v with error: %v", fullDescription, webhook.URL, attempt, maxAttempts, time.Since(start), err))
+		if attempt < maxAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	return log.Errorf("Giving up delivery of %s to %s after %d attempts: %v", fullDescription, webhook.URL, maxAttempts, err)
+}
+
+// executeRecoveryWebhooks delivers the webhooks of given hook stage. Webhooks with FailOnError are delivered
+// synchronously and their failure is returned (the first such failure aborts further webhooks when failOnError is set);
+// all others are delivered asynchronously.
+func executeRecoveryWebhooks(webhooks []config.RecoveryWebhook, hook string, topologyRecovery *TopologyRecovery, failOnError bool) (err error) {
+	if len(webhooks) == 0 {
+		return nil
+	}
+	payload := RecoveryWebhookPayload{
+		Hook:             hook,
+		OrchestratorHost: process.ThisHostname,
+		Timestamp:        time.Now(),
+		IsSuccessful:     topologyRecovery.SuccessorKey != nil,
+		TopologyRecovery: topologyRecovery,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return log.Errore(err)
+	}
+	for i, webhook := range webhooks {
+		webhook := webhook
+		fullDescription := fmt.Sprintf("%s webhook %d of %d", hook, i+1, len(webhooks))
+		if !webhook.FailOnError {
+			go deliverRecoveryWebhook(&webhook, hook, body, topologyRecovery, fmt.Sprintf("%s (async)", fullDescription))
+			continue
+		}
+		if deliveryErr := deliverRecoveryWebhook(&webhook, hook, body, topologyRecovery, fullDescription); deliveryErr != nil {
+			if failOnError {
+				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Not delivering further %s webhooks", hook))
+				return deliveryErr
+			}
+			if err == nil {
+				// Keep first error encountered
+				err = deliveryErr
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,166 @@
+package logic
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/inst"
+)
+
+// newTestWebhookRecovery returns a recovery whose audit goes to a plan, rather than to the backend database
+func newTestWebhookRecovery() *TopologyRecovery {
+	plan := newRecoveryPlan("recover", inst.ReplicationAnalysis{AnalyzedInstanceKey: inst.InstanceKey{Hostname: "db-1", Port: 3306}})
+	return plan.topologyRecovery
+}
+
+// testWebhookServer responds with given status codes on consecutive requests, repeating the last one, and
+// records the requests it gets
+type testWebhookServer struct {
+	*httptest.Server
+	mutex       sync.Mutex
+	statusCodes []int
+	requests    []*http.Request
+	bodies      [][]byte
+}
+
+func newTestWebhookServer(statusCodes ...int) *testWebhookServer {
+	server := &testWebhookServer{statusCodes: statusCodes}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		server.mutex.Lock()
+		defer server.mutex.Unlock()
+		i := len(server.requests)
+		if i >= len(server.statusCodes) {
+			i = len(server.statusCodes) - 1
+		}
+		server.requests = append(server.requests, r)
+		server.bodies = append(server.bodies, body)
+		w.WriteHeader(server.statusCodes[i])
+	}))
+	return server
+}
+
+func (this *testWebhookServer) countRequests() int {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return len(this.requests)
+}
+
+func TestSignRecoveryWebhookBody(t *testing.T) {
+	test.S(t).ExpectEquals(
+		signRecoveryWebhookBody("key", []byte("The quick brown fox jumps over the lazy dog")),
+		"sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+	)
+	test.S(t).ExpectNotEquals(signRecoveryWebhookBody("key", []byte("a")), signRecoveryWebhookBody("other-key", []byte("a")))
+}
+
+func TestDeliverRecoveryWebhookSigned(t *testing.T) {
+	server := newTestWebhookServer(http.StatusOK)
+	defer server.Close()
+
+	topologyRecovery := newTestWebhookRecovery()
+	webhook := &config.RecoveryWebhook{URL: server.URL, Secret: "s3cr3t", MaxAttempts: 1, TimeoutSeconds: 1, FailOnError: true}
+	err := executeRecoveryWebhooks([]config.RecoveryWebhook{*webhook}, "PreFailoverProcesses", topologyRecovery, true)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(server.countRequests(), 1)
+
+	request := server.requests[0]
+	body := server.bodies[0]
+	test.S(t).ExpectEquals(request.Method, http.MethodPost)
+	test.S(t).ExpectEquals(request.Header.Get("Content-Type"), "application/json")
+	test.S(t).ExpectEquals(request.Header.Get(recoveryWebhookHookHeader), "PreFailoverProcesses")
+	test.S(t).ExpectEquals(request.Header.Get(recoveryWebhookUIDHeader), topologyRecovery.UID)
+	test.S(t).ExpectEquals(request.Header.Get(recoveryWebhookAttemptHeader), "1")
+	test.S(t).ExpectEquals(request.Header.Get(recoveryWebhookSignatureHeader), signRecoveryWebhookBody("s3cr3t", body))
+
+	payload := RecoveryWebhookPayload{}
+	test.S(t).ExpectNil(json.Unmarshal(body, &payload))
+	test.S(t).ExpectEquals(payload.Hook, "PreFailoverProcesses")
+	test.S(t).ExpectFalse(payload.IsSuccessful)
+	test.S(t).ExpectEquals(payload.TopologyRecovery.UID, topologyRecovery.UID)
+}
+
+func TestDeliverRecoveryWebhookUnsigned(t *testing.T) {
+	server := newTestWebhookServer(http.StatusNoContent)
+	defer server.Close()
+
+	webhook := &config.RecoveryWebhook{URL: server.URL, MaxAttempts: 1, TimeoutSeconds: 1}
+	test.S(t).ExpectNil(deliverRecoveryWebhook(webhook, "PostFailoverProcesses", []byte("{}"), newTestWebhookRecovery(), "test"))
+	test.S(t).ExpectEquals(server.countRequests(), 1)
+	test.S(t).ExpectEquals(server.requests[0].Header.Get(recoveryWebhookSignatureHeader), "")
+}
+
+func TestDeliverRecoveryWebhookRetry(t *testing.T) {
+	defer func(backoff uint) { config.Config.RecoveryWebhookRetryBackoffMilliseconds = backoff }(config.Config.RecoveryWebhookRetryBackoffMilliseconds)
+	config.Config.RecoveryWebhookRetryBackoffMilliseconds = 1
+
+	server := newTestWebhookServer(http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK)
+	defer server.Close()
+
+	topologyRecovery := newTestWebhookRecovery()
+	webhook := &config.RecoveryWebhook{URL: server.URL, MaxAttempts: 3, TimeoutSeconds: 1}
+	test.S(t).ExpectNil(deliverRecoveryWebhook(webhook, "PostFailoverProcesses", []byte("{}"), topologyRecovery, "test"))
+	test.S(t).ExpectEquals(server.countRequests(), 3)
+	for i, request := range server.requests {
+		test.S(t).ExpectEquals(request.Header.Get(recoveryWebhookAttemptHeader), []string{"1", "2", "3"}[i])
+	}
+	// Each attempt is audited
+	test.S(t).ExpectEquals(len(topologyRecovery.plan.Steps), 3)
+}
+
+func TestDeliverRecoveryWebhookNon2xx(t *testing.T) {
+	defer func(backoff uint) { config.Config.RecoveryWebhookRetryBackoffMilliseconds = backoff }(config.Config.RecoveryWebhookRetryBackoffMilliseconds)
+	config.Config.RecoveryWebhookRetryBackoffMilliseconds = 1
+
+	for _, statusCode := range []int{http.StatusMovedPermanently, http.StatusNotFound, http.StatusServiceUnavailable} {
+		server := newTestWebhookServer(statusCode)
+		webhook := &config.RecoveryWebhook{URL: server.URL, MaxAttempts: 2, TimeoutSeconds: 1}
+		err := deliverRecoveryWebhook(webhook, "PostFailoverProcesses", []byte("{}"), newTestWebhookRecovery(), "test")
+		server.Close()
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectEquals(server.countRequests(), 2)
+	}
+	{
+		// Attempts default to RecoveryWebhookMaxAttempts
+		server := newTestWebhookServer(http.StatusNotFound)
+		defer server.Close()
+		webhook := &config.RecoveryWebhook{URL: server.URL, TimeoutSeconds: 1}
+		test.S(t).ExpectNotNil(deliverRecoveryWebhook(webhook, "PostFailoverProcesses", []byte("{}"), newTestWebhookRecovery(), "test"))
+		test.S(t).ExpectEquals(uint(server.countRequests()), config.Config.RecoveryWebhookMaxAttempts)
+	}
+}
+
+func TestExecuteRecoveryWebhooksFailOnError(t *testing.T) {
+	failingServer := newTestWebhookServer(http.StatusInternalServerError)
+	defer failingServer.Close()
+	server := newTestWebhookServer(http.StatusOK)
+	defer server.Close()
+
+	webhooks := []config.RecoveryWebhook{
+		{URL: failingServer.URL, MaxAttempts: 1, TimeoutSeconds: 1, FailOnError: true},
+		{URL: server.URL, MaxAttempts: 1, TimeoutSeconds: 1, FailOnError: true},
+	}
+	{
+		// The first failure aborts further webhooks
+		err := executeRecoveryWebhooks(webhooks, "PreFailoverProcesses", newTestWebhookRecovery(), true)
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectEquals(failingServer.countRequests(), 1)
+		test.S(t).ExpectEquals(server.countRequests(), 0)
+	}
+	{
+		// Not aborting: further webhooks are delivered, and the first failure is returned
+		err := executeRecoveryWebhooks(webhooks, "PreFailoverProcesses", newTestWebhookRecovery(), false)
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectEquals(failingServer.countRequests(), 2)
+		test.S(t).ExpectEquals(server.countRequests(), 1)
+	}
+	{
+		test.S(t).ExpectNil(executeRecoveryWebhooks(nil, "PreFailoverProcesses", newTestWebhookRecovery(), true))
+	}
+}
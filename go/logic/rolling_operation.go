@@ -0,0 +1,599 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	goos "os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/openark/orchestrator/go/agent"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/inst"
+	"github.com/openark/orchestrator/go/os"
+	"github.com/openark/orchestrator/go/raft"
+	"github.com/openark/orchestrator/go/util"
+)
+
+const (
+	RollingOperationRunning   = "running"
+	RollingOperationPaused    = "paused"
+	RollingOperationCompleted = "completed"
+	RollingOperationFailed    = "failed"
+	RollingOperationAborted   = "aborted"
+)
+
+const (
+	RollingOperationInstancePending   = "pending"
+	RollingOperationInstanceRunning   = "running"
+	RollingOperationInstanceCompleted = "completed"
+	RollingOperationInstanceFailed    = "failed"
+)
+
+// Instance roles in a rolling operation, which determine the order in which instances are operated on
+const (
+	RollingOperationRoleLeaf               = "leaf"
+	RollingOperationRoleIntermediateMaster = "intermediate-master"
+	RollingOperationRoleMaster             = "master"
+)
+
+const rollingOperationAgentMySQLRestart = config.RollingOperationAgentPrefix + "mysql-restart"
+
+var rollingOperationRunners = make(map[string]bool)
+var rollingOperationRunnersMutex sync.Mutex
+
+// RollingOperation runs a configured command on all instances of a cluster, one phase at a time: leaves first,
+// then intermediate masters, deepest first, and at last the master, which is first demoted via graceful takeover.
+type RollingOperation struct {
+	UID           string
+	ClusterName   string
+	CommandName   string // key in RollingOperationCommands
+	Concurrency   uint   // max instances operated on at once within a phase
+	Status        string
+	StatusMessage string
+	Owner         string
+	Reason        string
+	CreatedAt     time.Time
+	EndedAt       time.Time
+	Instances     [](*RollingOperationInstance) `json:",omitempty"`
+}
+
+// RollingOperationInstance is the progress of a rolling operation on a single instance
+type RollingOperationInstance struct {
+	OperationUID  string
+	Key           inst.InstanceKey
+	Phase         int
+	Role          string
+	Status        string
+	StatusMessage string
+	StartedAt     time.Time
+	EndedAt       time.Time
+}
+
+// planRollingOperationInstances assigns the instances of a cluster to phases: all leaves in the first phase,
+// then a phase per depth of intermediate masters, deepest first, and the master in the last phase.
+func planRollingOperationInstances(uid string, master *inst.Instance, instances [](*inst.Instance)) (operationInstances [](*RollingOperationInstance)) {
+	maxIntermediateMasterDepth := uint(0)
+	for _, instance := range instances {
+		if !instance.Key.Equals(&master.Key) && len(instance.Replicas) > 0 && instance.ReplicationDepth > maxIntermediateMasterDepth {
+			maxIntermediateMasterDepth = instance.ReplicationDepth
+		}
+	}
+	for _, instance := range instances {
+		operationInstance := &RollingOperationInstance{
+			OperationUID: uid,
+			Key:          instance.Key,
+			Status:       RollingOperationInstancePending,
+		}
+		switch {
+		case instance.Key.Equals(&master.Key):
+			operationInstance.Role = RollingOperationRoleMaster
+			operationInstance.Phase = int(maxIntermediateMasterDepth) + 1
+		case len(instance.Replicas) > 0:
+			operationInstance.Role = RollingOperationRoleIntermediateMaster
+			operationInstance.Phase = int(maxIntermediateMasterDepth-instance.ReplicationDepth) + 1
+		default:
+			operationInstance.Role = RollingOperationRoleLeaf
+			operationInstance.Phase = 0
+		}
+		operationInstances = append(operationInstances, operationInstance)
+	}
+	sort.SliceStable(operationInstances, func(i, j int) bool {
+		return operationInstances[i].Phase < operationInstances[j].Phase
+	})
+	return operationInstances
+}
+
+// persistRollingOperation writes the operation, via raft when enabled
+func persistRollingOperation(operation *RollingOperation) error {
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("write-rolling-operation", operation)
+		return err
+	}
+	return writeRollingOperation(operation)
+}
+
+// persistRollingOperationInstance writes the progress of an instance, via raft when enabled
+func persistRollingOperationInstance(operationInstance *RollingOperationInstance) error {
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("write-rolling-operation-instance", operationInstance)
+		return err
+	}
+	return writeRollingOperationInstance(operationInstance)
+}
+
+func auditRollingOperation(operation *RollingOperation, instanceKey *inst.InstanceKey, message string) {
+	inst.AuditOperation("rolling-operation", instanceKey, fmt.Sprintf("%s: %s", operation.UID, message))
+}
+
+// StartRollingOperation plans and starts a rolling operation on given cluster. The leader picks it up and runs it.
+func StartRollingOperation(clusterName string, commandName string, concurrency uint, owner string, reason string) (*RollingOperation, error) {
+	if _, ok := config.Config.RollingOperationCommands[commandName]; !ok {
+		return nil, fmt.Errorf("unknown rolling operation command: %s. It must be configured in RollingOperationCommands", commandName)
+	}
+	if concurrency == 0 {
+		concurrency = 1
+	}
+	unfinished, err := readUnfinishedRollingOperations(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if len(unfinished) > 0 {
+		return nil, fmt.Errorf("cluster %s already has a %s rolling operation: %s. Abort it first", clusterName, unfinished[0].Status, unfinished[0].UID)
+	}
+	masters, err := inst.ReadClusterMaster(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot deduce cluster master for %+v; error: %+v", clusterName, err)
+	}
+	if len(masters) != 1 {
+		return nil, fmt.Errorf("Cannot deduce cluster master for %+v. Found %+v potential masters", clusterName, len(masters))
+	}
+	master := masters[0]
+	if master.IsCoMaster {
+		return nil, fmt.Errorf("rolling operations are not supported on co-master topologies; master %+v is a co-master", master.Key)
+	}
+	instances, err := inst.ReadClusterInstances(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	for _, instance := range instances {
+		if !instance.IsLastCheckValid {
+			return nil, fmt.Errorf("%+v is not healthy: last check invalid. Fix or forget it before starting a rolling operation", instance.Key)
+		}
+	}
+
+	operation := &RollingOperation{
+		UID:         util.PrettyUniqueToken(),
+		ClusterName: clusterName,
+		CommandName: commandName,
+		Concurrency: concurrency,
+		Status:      RollingOperationRunning,
+		Owner:       owner,
+		Reason:      reason,
+		CreatedAt:   time.Now(),
+	}
+	operation.Instances = planRollingOperationInstances(operation.UID, master, instances)
+	for _, operationInstance := range operation.Instances {
+		if err := persistRollingOperationInstance(operationInstance); err != nil {
+			return nil, err
+		}
+	}
+	if err := persistRollingOperation(operation); err != nil {
+		return nil, err
+	}
+	auditRollingOperation(operation, &master.Key, fmt.Sprintf("started by %s on %s: %d instances, command: %s, concurrency: %d; reason: %s", owner, clusterName, len(operation.Instances), commandName, concurrency, reason))
+	if IsLeader() {
+		go RunRollingOperations()
+	}
+	return operation, nil
+}
+
+// changeRollingOperationStatus moves an operation from one of given statuses to a new status
+func changeRollingOperationStatus(uid string, owner string, fromStatuses []string, toStatus string) (*RollingOperation, error) {
+	operation, err := ReadRollingOperation(uid)
+	if err != nil {
+		return nil, err
+	}
+	statusExpected := false
+	for _, status := range fromStatuses {
+		if operation.Status == status {
+			statusExpected = true
+		}
+	}
+	if !statusExpected {
+		return operation, fmt.Errorf("rolling operation %s is %s; expected one of: %s", uid, operation.Status, strings.Join(fromStatuses, ", "))
+	}
+	operation.Status = toStatus
+	operation.StatusMessage = fmt.Sprintf("%s by %s", toStatus, owner)
+	if toStatus == RollingOperationAborted {
+		operation.EndedAt = time.Now()
+	}
+	if err := persistRollingOperation(operation); err != nil {
+		return operation, err
+	}
+	auditRollingOperation(operation, nil, operation.StatusMessage)
+	return operation, nil
+}
+
+// PauseRollingOperation stops a running operation from starting further instances. Instances already in progress complete.
+func PauseRollingOperation(uid string, owner string) (*RollingOperation, error) {
+	return changeRollingOperationStatus(uid, owner, []string{RollingOperationRunning}, RollingOperationPaused)
+}
+
+// ResumeRollingOperation resumes a paused operation, or retries a failed one from its failed instances
+func ResumeRollingOperation(uid string, owner string) (*RollingOperation, error) {
+	operation, err := ReadRollingOperation(uid)
+	if err != nil {
+		return nil, err
+	}
+	if operation.Status == RollingOperationFailed {
+		for _, operationInstance := range operation.Instances {
+			if operationInstance.Status == RollingOperationInstanceFailed {
+				operationInstance.Status = RollingOperationInstancePending
+				operationInstance.StatusMessage = fmt.Sprintf("retry requested by %s; previously: %s", owner, operationInstance.StatusMessage)
+				if err := persistRollingOperationInstance(operationInstance); err != nil {
+					return operation, err
+				}
+			}
+		}
+	}
+	if operation, err = changeRollingOperationStatus(uid, owner, []string{RollingOperationPaused, RollingOperationFailed}, RollingOperationRunning); err != nil {
+		return operation, err
+	}
+	if IsLeader() {
+		go RunRollingOperations()
+	}
+	return operation, nil
+}
+
+// AbortRollingOperation stops a running or paused operation for good. Instances already in progress complete.
+func AbortRollingOperation(uid string, owner string) (*RollingOperation, error) {
+	return changeRollingOperationStatus(uid, owner, []string{RollingOperationRunning, RollingOperationPaused}, RollingOperationAborted)
+}
+
+// rollingOperationCommandEnv returns the environment of a rolling operation command
+func rollingOperationCommandEnv(operation *RollingOperation, instanceKey *inst.InstanceKey, clusterAlias string) []string {
+	env := goos.Environ()
+	env = append(env, fmt.Sprintf("ORC_ROLLING_OPERATION_UID=%s", operation.UID))
+	env = append(env, fmt.Sprintf("ORC_INSTANCE_HOST=%s", instanceKey.Hostname))
+	env = append(env, fmt.Sprintf("ORC_INSTANCE_PORT=%d", instanceKey.Port))
+	env = append(env, fmt.Sprintf("ORC_CLUSTER=%s", operation.ClusterName))
+	env = append(env, fmt.Sprintf("ORC_CLUSTER_ALIAS=%s", clusterAlias))
+	return env
+}
+
+// executeRollingOperationCommand runs the operation's configured command on given instance: a shell command,
+// or an orchestrator-agent call
+func executeRollingOperationCommand(operation *RollingOperation, instanceKey *inst.InstanceKey) error {
+	command := config.Config.RollingOperationCommands[operation.CommandName]
+	if command == "" {
+		return fmt.Errorf("command %s is no longer configured in RollingOperationCommands", operation.CommandName)
+	}
+	switch {
+	case command == rollingOperationAgentMySQLRestart:
+		if _, err := agent.MySQLStop(instanceKey.Hostname); err != nil {
+			return err
+		}
+		_, err := agent.MySQLStart(instanceKey.Hostname)
+		return err
+	case strings.HasPrefix(command, config.RollingOperationAgentPrefix):
+		_, err := agent.CustomCommand(instanceKey.Hostname, strings.TrimPrefix(command, config.RollingOperationAgentPrefix))
+		return err
+	}
+	clusterAlias, _ := inst.ReadAliasByClusterName(operation.ClusterName)
+	command = strings.Replace(command, "{host}", instanceKey.Hostname, -1)
+	command = strings.Replace(command, "{port}", fmt.Sprintf("%d", instanceKey.Port), -1)
+	command = strings.Replace(command, "{clusterName}", operation.ClusterName, -1)
+	command = strings.Replace(command, "{clusterAlias}", clusterAlias, -1)
+	command = strings.Replace(command, "{operationUID}", operation.UID, -1)
+
+	timeout := time.Duration(config.Config.RollingOperationCommandTimeoutSeconds) * time.Second
+	result, err := os.CommandRunWithTimeout(command, rollingOperationCommandEnv(operation, instanceKey, clusterAlias), timeout, int(config.Config.RecoveryProcessesAuditOutputBytes))
+	if err != nil && result.TimedOut {
+		return fmt.Errorf("command timed out after %v; killed its process group", timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("command failed with exit code %d: %s", result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+	return nil
+}
+
+// waitForRollingOperationInstance waits for an instance to be reachable and, if a replica, to replicate and
+// catch up. It starts replication should it find it stopped.
+func waitForRollingOperationInstance(instanceKey *inst.InstanceKey) error {
+	deadline := time.Now().Add(time.Duration(config.Config.RollingOperationCatchUpTimeoutSeconds) * time.Second)
+	lastState := "unreachable"
+	for time.Now().Before(deadline) {
+		instance, err := inst.ReadTopologyInstance(instanceKey)
+		switch {
+		case err != nil || instance == nil:
+			lastState = fmt.Sprintf("unreachable: %+v", err)
+		case !instance.ReplicationThreadsExist():
+			return nil
+		case !instance.ReplicationIOThreadRuning || !instance.ReplicationSQLThreadRuning:
+			lastState = "replication stopped"
+			if _, err := inst.StartReplication(instanceKey); err != nil {
+				lastState = fmt.Sprintf("replication stopped; failed starting replication: %+v", err)
+			}
+		case !instance.ReplicationLagSeconds.Valid:
+			lastState = "replication lag unknown"
+		case instance.ReplicationLagSeconds.Int64 > int64(config.Config.ReasonableReplicationLagSeconds):
+			lastState = fmt.Sprintf("lagging %d seconds", instance.ReplicationLagSeconds.Int64)
+		default:
+			return nil
+		}
+		time.Sleep(instancePollSecondsDuration())
+	}
+	return fmt.Errorf("%+v did not catch up within %d seconds; last seen: %s", *instanceKey, config.Config.RollingOperationCatchUpTimeoutSeconds, lastState)
+}
+
+// restoreFailedRollingOperationInstance attempts to start replication on an instance whose operation failed
+// after replication was stopped, and describes the state the instance is left in
+func restoreFailedRollingOperationInstance(instanceKey *inst.InstanceKey, downtime *inst.Downtime, replicationStopped bool) (state string) {
+	states := []string{}
+	if replicationStopped {
+		if _, err := inst.StartReplication(instanceKey); err != nil {
+			states = append(states, fmt.Sprintf("replication is stopped; failed starting it: %+v", err))
+		} else {
+			states = append(states, "replication restarted")
+		}
+	}
+	if downtime != nil {
+		states = append(states, fmt.Sprintf("remains downtimed until %s", downtime.EndsAt.UTC().Format(time.RFC3339)))
+	}
+	return strings.Join(states, "; ")
+}
+
+// runRollingOperationOnInstance operates on a single instance: demotes it via graceful takeover if it is the
+// master, downtimes it, stops replication nicely, runs the command, and waits for it to rejoin and catch up.
+// Downtime is ended on success. On failure replication is restarted if it was stopped, and the instance remains
+// downtimed until the downtime expires.
+func runRollingOperationOnInstance(operation *RollingOperation, operationInstance *RollingOperationInstance) (err error) {
+	instanceKey := &operationInstance.Key
+	var downtime *inst.Downtime
+	replicationStopped := false
+	operationInstance.Status = RollingOperationInstanceRunning
+	operationInstance.StatusMessage = ""
+	operationInstance.StartedAt = time.Now()
+	operationInstance.EndedAt = time.Time{}
+	persistRollingOperationInstance(operationInstance)
+	auditRollingOperation(operation, instanceKey, fmt.Sprintf("operating on %s", operationInstance.Role))
+
+	defer func() {
+		operationInstance.EndedAt = time.Now()
+		if err == nil {
+			operationInstance.Status = RollingOperationInstanceCompleted
+			operationInstance.StatusMessage = fmt.Sprintf("completed in %v", operationInstance.EndedAt.Sub(operationInstance.StartedAt).Round(time.Second))
+		} else {
+			operationInstance.Status = RollingOperationInstanceFailed
+			operationInstance.StatusMessage = err.Error()
+			if state := restoreFailedRollingOperationInstance(instanceKey, downtime, replicationStopped); state != "" {
+				operationInstance.StatusMessage = fmt.Sprintf("%s. Instance state: %s", operationInstance.StatusMessage, state)
+			}
+		}
+		persistRollingOperationInstance(operationInstance)
+		auditRollingOperation(operation, instanceKey, operationInstance.StatusMessage)
+	}()
+
+	if operationInstance.Role == RollingOperationRoleMaster {
+		if err := demoteRollingOperationMaster(operation, instanceKey); err != nil {
+			return err
+		}
+	} else {
+		instance, found, err := inst.ReadInstance(instanceKey)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("%+v not found", *instanceKey)
+		}
+		if !instance.IsReplica() {
+			return fmt.Errorf("%+v is no longer a replica; refusing to operate on it", *instanceKey)
+		}
+	}
+
+	downtimeDuration := time.Duration(config.Config.RollingOperationCommandTimeoutSeconds+config.Config.RollingOperationCatchUpTimeoutSeconds) * time.Second
+	newDowntime := inst.NewDowntime(instanceKey, operation.Owner, fmt.Sprintf("rolling operation %s: %s", operation.UID, operation.Reason), downtimeDuration)
+	if err := inst.BeginDowntime(newDowntime); err != nil {
+		return fmt.Errorf("failed to downtime: %+v", err)
+	}
+	downtime = newDowntime
+	replicationStopped = true
+	if _, err := inst.StopReplicationNicely(instanceKey, time.Duration(config.Config.RollingOperationCatchUpTimeoutSeconds)*time.Second); err != nil {
+		return fmt.Errorf("failed to stop replication: %+v", err)
+	}
+	if err := executeRollingOperationCommand(operation, instanceKey); err != nil {
+		return err
+	}
+	if err := waitForRollingOperationInstance(instanceKey); err != nil {
+		return err
+	}
+	if _, err := inst.EndDowntime(instanceKey); err != nil {
+		log.Errore(err)
+	}
+	return nil
+}
+
+// demoteRollingOperationMaster runs a graceful takeover, so that the master is operated on as a replica
+func demoteRollingOperationMaster(operation *RollingOperation, masterKey *inst.InstanceKey) error {
+	masters, err := inst.ReadClusterMaster(operation.ClusterName)
+	if err != nil {
+		return err
+	}
+	if len(masters) != 1 || !masters[0].Key.Equals(masterKey) {
+		return fmt.Errorf("%+v is no longer the master of %s; refusing to operate on it", *masterKey, operation.ClusterName)
+	}
+	auditRollingOperation(operation, masterKey, "demoting master via graceful master takeover")
+	topologyRecovery, _, err := GracefulMasterTakeover(operation.ClusterName, nil, true)
+	if err != nil {
+		return fmt.Errorf("graceful master takeover failed: %+v", err)
+	}
+	if topologyRecovery == nil || topologyRecovery.SuccessorKey == nil {
+		return fmt.Errorf("graceful master takeover failed: no successor promoted")
+	}
+	auditRollingOperation(operation, masterKey, fmt.Sprintf("promoted %+v via graceful master takeover %s", *topologyRecovery.SuccessorKey, topologyRecovery.UID))
+	return nil
+}
+
+// rollingOperationProceeds checks the operation is still running, and that this node may still run it
+func rollingOperationProceeds(uid string) bool {
+	if !IsLeader() {
+		return false
+	}
+	operations, err := readRollingOperations("where uid = ?", sqlutils.Args(uid))
+	if err != nil || len(operations) == 0 {
+		return false
+	}
+	return operations[0].Status == RollingOperationRunning
+}
+
+// runRollingOperationPhase operates on given instances, up to the operation's concurrency at a time, for as long
+// as the operation is running. It returns the first error encountered.
+func runRollingOperationPhase(operation *RollingOperation, operationInstances [](*RollingOperationInstance)) error {
+	concurrency := operation.Concurrency
+	if concurrency == 0 {
+		concurrency = 1
+	}
+	semaphore := make(chan bool, concurrency)
+	var wg sync.WaitGroup
+	var phaseErr error
+	var phaseErrMutex sync.Mutex
+	failed := func() bool {
+		phaseErrMutex.Lock()
+		defer phaseErrMutex.Unlock()
+		return phaseErr != nil
+	}
+	for _, operationInstance := range operationInstances {
+		semaphore <- true
+		if failed() || !rollingOperationProceeds(operation.UID) {
+			<-semaphore
+			break
+		}
+		wg.Add(1)
+		go func(operationInstance *RollingOperationInstance) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			if err := runRollingOperationOnInstance(operation, operationInstance); err != nil {
+				phaseErrMutex.Lock()
+				defer phaseErrMutex.Unlock()
+				if phaseErr == nil {
+					phaseErr = fmt.Errorf("%+v: %+v", operationInstance.Key, err)
+				}
+			}
+		}(operationInstance)
+	}
+	wg.Wait()
+	return phaseErr
+}
+
+// finishRollingOperation sets the final status of an operation, unless it was aborted meanwhile
+func finishRollingOperation(operation *RollingOperation, status string, message string) {
+	if current, err := ReadRollingOperation(operation.UID); err == nil && current.Status == RollingOperationAborted {
+		return
+	}
+	operation.Status = status
+	operation.StatusMessage = message
+	operation.EndedAt = time.Now()
+	persistRollingOperation(operation)
+	auditRollingOperation(operation, nil, fmt.Sprintf("%s: %s", status, message))
+}
+
+// failInterruptedRollingOperationInstances marks instances found running, as left by a previous leader, as failed:
+// whether their command ran, and what state they are in, is unknown. It returns the number of such instances.
+func failInterruptedRollingOperationInstances(operation *RollingOperation, operationInstances [](*RollingOperationInstance)) (countInterrupted int) {
+	for _, operationInstance := range operationInstances {
+		if operationInstance.Status != RollingOperationInstanceRunning {
+			continue
+		}
+		operationInstance.Status = RollingOperationInstanceFailed
+		operationInstance.StatusMessage = fmt.Sprintf("interrupted: found running since %s, as left by a previous leader. State unknown: it may be downtimed, with replication stopped, and its command may or may not have run", operationInstance.StartedAt.UTC().Format(time.RFC3339))
+		operationInstance.EndedAt = time.Now()
+		persistRollingOperationInstance(operationInstance)
+		auditRollingOperation(operation, &operationInstance.Key, operationInstance.StatusMessage)
+		countInterrupted++
+	}
+	return countInterrupted
+}
+
+// runRollingOperation runs an operation phase by phase, until it completes, fails, is paused or aborted, or
+// this node loses leadership. Instances found running, as left by a previous leader, fail the operation, which
+// may then be resumed once they are verified.
+func runRollingOperation(operation *RollingOperation) {
+	defer func() {
+		rollingOperationRunnersMutex.Lock()
+		defer rollingOperationRunnersMutex.Unlock()
+		delete(rollingOperationRunners, operation.UID)
+	}()
+	if !rollingOperationProceeds(operation.UID) {
+		return
+	}
+	operationInstances, err := readRollingOperationInstances(operation.UID)
+	if err != nil {
+		return
+	}
+	if countInterrupted := failInterruptedRollingOperationInstances(operation, operationInstances); countInterrupted > 0 {
+		finishRollingOperation(operation, RollingOperationFailed, fmt.Sprintf("%d instances interrupted by a change of leadership; verify them, then resume the operation", countInterrupted))
+		return
+	}
+	for rollingOperationProceeds(operation.UID) {
+		operationInstances, err := readRollingOperationInstances(operation.UID)
+		if err != nil {
+			return
+		}
+		phaseInstances := [](*RollingOperationInstance){}
+		for _, operationInstance := range operationInstances {
+			if operationInstance.Status != RollingOperationInstancePending {
+				continue
+			}
+			if len(phaseInstances) > 0 && operationInstance.Phase != phaseInstances[0].Phase {
+				break
+			}
+			phaseInstances = append(phaseInstances, operationInstance)
+		}
+		if len(phaseInstances) == 0 {
+			finishRollingOperation(operation, RollingOperationCompleted, fmt.Sprintf("operated on %d instances", len(operationInstances)))
+			return
+		}
+		if err := runRollingOperationPhase(operation, phaseInstances); err != nil {
+			finishRollingOperation(operation, RollingOperationFailed, err.Error())
+			return
+		}
+	}
+}
+
+// RunRollingOperations starts running operations which are not yet run by this node. It is expected to run on
+// the leader only.
+func RunRollingOperations() {
+	operations, err := readRollingOperations("where status = ?", sqlutils.Args(RollingOperationRunning))
+	if err != nil {
+		return
+	}
+	rollingOperationRunnersMutex.Lock()
+	defer rollingOperationRunnersMutex.Unlock()
+	for _, operation := range operations {
+		if rollingOperationRunners[operation.UID] {
+			continue
+		}
+		rollingOperationRunners[operation.UID] = true
+		go runRollingOperation(operation)
+	}
+}
@@ -0,0 +1,214 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/db"
+	"github.com/openark/orchestrator/go/inst"
+)
+
+// writeRollingOperation creates or updates a rolling operation
+func writeRollingOperation(operation *RollingOperation) error {
+	_, err := db.ExecOrchestrator(`
+			insert
+				into rolling_operation (
+					uid, cluster_name, command_name, concurrency,
+					status, status_message, owner, reason,
+					created_unixtime, ended_unixtime, last_updated
+				) values (
+					?, ?, ?, ?,
+					?, ?, ?, ?,
+					?, ?, NOW()
+				) on duplicate key update
+					status=values(status),
+					status_message=values(status_message),
+					ended_unixtime=values(ended_unixtime),
+					last_updated=values(last_updated)
+			`,
+		operation.UID, operation.ClusterName, operation.CommandName, operation.Concurrency,
+		operation.Status, operation.StatusMessage, operation.Owner, operation.Reason,
+		toUnixtime(operation.CreatedAt), toUnixtime(operation.EndedAt),
+	)
+	return log.Errore(err)
+}
+
+// writeRollingOperationInstance creates or updates the progress of a single instance of a rolling operation
+func writeRollingOperationInstance(operationInstance *RollingOperationInstance) error {
+	_, err := db.ExecOrchestrator(`
+			insert
+				into rolling_operation_instance (
+					operation_uid, hostname, port, phase, role,
+					status, status_message, start_unixtime, end_unixtime, last_updated
+				) values (
+					?, ?, ?, ?, ?,
+					?, ?, ?, ?, NOW()
+				) on duplicate key update
+					status=values(status),
+					status_message=values(status_message),
+					start_unixtime=values(start_unixtime),
+					end_unixtime=values(end_unixtime),
+					last_updated=values(last_updated)
+			`,
+		operationInstance.OperationUID, operationInstance.Key.Hostname, operationInstance.Key.Port, operationInstance.Phase, operationInstance.Role,
+		operationInstance.Status, operationInstance.StatusMessage, toUnixtime(operationInstance.StartedAt), toUnixtime(operationInstance.EndedAt),
+	)
+	return log.Errore(err)
+}
+
+func readRollingOperations(whereClause string, args []interface{}) (operations [](*RollingOperation), err error) {
+	operations = [](*RollingOperation){}
+	query := fmt.Sprintf(`
+		select
+			uid,
+			cluster_name,
+			command_name,
+			concurrency,
+			status,
+			status_message,
+			owner,
+			reason,
+			created_unixtime,
+			ended_unixtime
+		from
+			rolling_operation
+		%s
+		order by
+			created_unixtime desc
+		`, whereClause)
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		operation := &RollingOperation{
+			UID:           m.GetString("uid"),
+			ClusterName:   m.GetString("cluster_name"),
+			CommandName:   m.GetString("command_name"),
+			Concurrency:   m.GetUint("concurrency"),
+			Status:        m.GetString("status"),
+			StatusMessage: m.GetString("status_message"),
+			Owner:         m.GetString("owner"),
+			Reason:        m.GetString("reason"),
+			CreatedAt:     fromUnixtime(m.GetInt64("created_unixtime")),
+			EndedAt:       fromUnixtime(m.GetInt64("ended_unixtime")),
+		}
+		operations = append(operations, operation)
+		return nil
+	})
+	return operations, log.Errore(err)
+}
+
+// ReadRollingOperations reads the rolling operations of given cluster, or of all clusters when clusterName is empty
+func ReadRollingOperations(clusterName string) (operations [](*RollingOperation), err error) {
+	if clusterName == "" {
+		return readRollingOperations("", sqlutils.Args())
+	}
+	return readRollingOperations("where cluster_name = ?", sqlutils.Args(clusterName))
+}
+
+// ReadRollingOperation reads a single rolling operation by its UID, along with the progress of its instances
+func ReadRollingOperation(uid string) (*RollingOperation, error) {
+	operations, err := readRollingOperations("where uid = ?", sqlutils.Args(uid))
+	if err != nil {
+		return nil, err
+	}
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("rolling operation not found: %s", uid)
+	}
+	operation := operations[0]
+	if operation.Instances, err = readRollingOperationInstances(uid); err != nil {
+		return nil, err
+	}
+	return operation, nil
+}
+
+// readUnfinishedRollingOperations reads running and paused rolling operations, of given cluster or of all clusters
+// when clusterName is empty
+func readUnfinishedRollingOperations(clusterName string) (operations [](*RollingOperation), err error) {
+	if clusterName == "" {
+		return readRollingOperations("where status in (?, ?)", sqlutils.Args(RollingOperationRunning, RollingOperationPaused))
+	}
+	return readRollingOperations("where status in (?, ?) and cluster_name = ?", sqlutils.Args(RollingOperationRunning, RollingOperationPaused, clusterName))
+}
+
+func readRollingOperationInstances(uid string) (operationInstances [](*RollingOperationInstance), err error) {
+	operationInstances = [](*RollingOperationInstance){}
+	query := `
+		select
+			operation_uid,
+			hostname,
+			port,
+			phase,
+			role,
+			status,
+			status_message,
+			start_unixtime,
+			end_unixtime
+		from
+			rolling_operation_instance
+		where
+			operation_uid = ?
+		order by
+			phase, hostname, port
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(uid), func(m sqlutils.RowMap) error {
+		operationInstance := &RollingOperationInstance{
+			OperationUID:  m.GetString("operation_uid"),
+			Key:           inst.InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")},
+			Phase:         m.GetInt("phase"),
+			Role:          m.GetString("role"),
+			Status:        m.GetString("status"),
+			StatusMessage: m.GetString("status_message"),
+			StartedAt:     fromUnixtime(m.GetInt64("start_unixtime")),
+			EndedAt:       fromUnixtime(m.GetInt64("end_unixtime")),
+		}
+		operationInstances = append(operationInstances, operationInstance)
+		return nil
+	})
+	return operationInstances, log.Errore(err)
+}
+
+// ExpireRollingOperationHistory removes old rolling operations which are done with, one way or another
+func ExpireRollingOperationHistory() error {
+	_, err := db.ExecOrchestrator(`
+			delete
+				from rolling_operation_instance
+			where
+				operation_uid in (
+					select uid from rolling_operation
+					where
+						status not in (?, ?)
+						and last_updated < NOW() - INTERVAL ? DAY
+				)
+			`,
+		RollingOperationRunning, RollingOperationPaused, config.Config.AuditPurgeDays,
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	_, err = db.ExecOrchestrator(`
+			delete
+				from rolling_operation
+			where
+				status not in (?, ?)
+				and last_updated < NOW() - INTERVAL ? DAY
+			`,
+		RollingOperationRunning, RollingOperationPaused, config.Config.AuditPurgeDays,
+	)
+	return log.Errore(err)
+}
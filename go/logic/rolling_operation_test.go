@@ -0,0 +1,133 @@
+package logic
+
+import (
+	"strings"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/inst"
+)
+
+// newTestRollingOperationInstance returns an instance at given depth, with given replicas
+func newTestRollingOperationInstance(hostname string, depth uint, replicas ...string) *inst.Instance {
+	instance := inst.NewInstance()
+	instance.Key = inst.InstanceKey{Hostname: hostname, Port: 3306}
+	instance.ReplicationDepth = depth
+	for _, replica := range replicas {
+		instance.Replicas.AddKey(inst.InstanceKey{Hostname: replica, Port: 3306})
+	}
+	return instance
+}
+
+func TestPlanRollingOperationInstances(t *testing.T) {
+	type expectedInstance struct {
+		hostname string
+		phase    int
+		role     string
+	}
+	testCases := []struct {
+		name      string
+		instances [](*inst.Instance)
+		expected  []expectedInstance
+	}{
+		{
+			name: "master alone",
+			instances: [](*inst.Instance){
+				newTestRollingOperationInstance("master", 0),
+			},
+			expected: []expectedInstance{
+				{"master", 1, RollingOperationRoleMaster},
+			},
+		},
+		{
+			name: "flat",
+			instances: [](*inst.Instance){
+				newTestRollingOperationInstance("master", 0, "leaf-1", "leaf-2"),
+				newTestRollingOperationInstance("leaf-1", 1),
+				newTestRollingOperationInstance("leaf-2", 1),
+			},
+			expected: []expectedInstance{
+				{"leaf-1", 0, RollingOperationRoleLeaf},
+				{"leaf-2", 0, RollingOperationRoleLeaf},
+				{"master", 1, RollingOperationRoleMaster},
+			},
+		},
+		{
+			name: "intermediate master",
+			instances: [](*inst.Instance){
+				newTestRollingOperationInstance("master", 0, "im", "leaf-1"),
+				newTestRollingOperationInstance("im", 1, "leaf-2"),
+				newTestRollingOperationInstance("leaf-1", 1),
+				newTestRollingOperationInstance("leaf-2", 2),
+			},
+			expected: []expectedInstance{
+				{"leaf-1", 0, RollingOperationRoleLeaf},
+				{"leaf-2", 0, RollingOperationRoleLeaf},
+				{"im", 1, RollingOperationRoleIntermediateMaster},
+				{"master", 2, RollingOperationRoleMaster},
+			},
+		},
+		{
+			name: "intermediate masters at different depths, deepest first",
+			instances: [](*inst.Instance){
+				newTestRollingOperationInstance("master", 0, "im-1a", "im-1b"),
+				newTestRollingOperationInstance("im-1a", 1, "im-2"),
+				newTestRollingOperationInstance("im-2", 2, "leaf-3"),
+				newTestRollingOperationInstance("leaf-3", 3),
+				newTestRollingOperationInstance("im-1b", 1, "leaf-2"),
+				newTestRollingOperationInstance("leaf-2", 2),
+			},
+			expected: []expectedInstance{
+				{"leaf-3", 0, RollingOperationRoleLeaf},
+				{"leaf-2", 0, RollingOperationRoleLeaf},
+				{"im-2", 1, RollingOperationRoleIntermediateMaster},
+				{"im-1a", 2, RollingOperationRoleIntermediateMaster},
+				{"im-1b", 2, RollingOperationRoleIntermediateMaster},
+				{"master", 3, RollingOperationRoleMaster},
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		master := testCase.instances[0]
+		operationInstances := planRollingOperationInstances("uid", master, testCase.instances)
+		test.S(t).ExpectEquals(len(operationInstances), len(testCase.expected))
+		for i, expected := range testCase.expected {
+			operationInstance := operationInstances[i]
+			if operationInstance.Key.Hostname != expected.hostname || operationInstance.Phase != expected.phase || operationInstance.Role != expected.role {
+				t.Errorf("%s: instance %d: expected %s in phase %d as %s; got %s in phase %d as %s", testCase.name, i,
+					expected.hostname, expected.phase, expected.role,
+					operationInstance.Key.Hostname, operationInstance.Phase, operationInstance.Role)
+			}
+			test.S(t).ExpectEquals(operationInstance.OperationUID, "uid")
+			test.S(t).ExpectEquals(operationInstance.Status, RollingOperationInstancePending)
+		}
+	}
+}
+
+func TestFailInterruptedRollingOperationInstances(t *testing.T) {
+	resetTestBackendTables(t, "rolling_operation_instance")
+	operation := &RollingOperation{UID: "interrupted", ClusterName: "db-1:3306"}
+	instances := [](*inst.Instance){
+		newTestRollingOperationInstance("master", 0, "leaf-1", "leaf-2"),
+		newTestRollingOperationInstance("leaf-1", 1),
+		newTestRollingOperationInstance("leaf-2", 1),
+	}
+	operationInstances := planRollingOperationInstances(operation.UID, instances[0], instances)
+	operationInstances[0].Status = RollingOperationInstanceCompleted
+	operationInstances[1].Status = RollingOperationInstanceRunning
+	for _, operationInstance := range operationInstances {
+		test.S(t).ExpectNil(writeRollingOperationInstance(operationInstance))
+	}
+
+	test.S(t).ExpectEquals(failInterruptedRollingOperationInstances(operation, operationInstances), 1)
+
+	persisted, err := readRollingOperationInstances(operation.UID)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(persisted), 3)
+	test.S(t).ExpectEquals(persisted[0].Status, RollingOperationInstanceCompleted)
+	test.S(t).ExpectEquals(persisted[1].Status, RollingOperationInstanceFailed)
+	test.S(t).ExpectTrue(strings.HasPrefix(persisted[1].StatusMessage, "interrupted"))
+	test.S(t).ExpectEquals(persisted[2].Status, RollingOperationInstancePending)
+
+	test.S(t).ExpectEquals(failInterruptedRollingOperationInstances(operation, persisted), 0)
+}
@@ -0,0 +1,348 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/inst"
+	"github.com/openark/orchestrator/go/raft"
+	"github.com/openark/orchestrator/go/util"
+)
+
+const (
+	ScheduledTakeoverPending   = "pending"
+	ScheduledTakeoverRunning   = "running"
+	ScheduledTakeoverCompleted = "completed"
+	ScheduledTakeoverFailed    = "failed"
+	ScheduledTakeoverExpired   = "expired"
+	ScheduledTakeoverCancelled = "cancelled"
+)
+
+// scheduledTakeoversEntrance makes sure due takeovers are only run by one goroutine at a time
+var scheduledTakeoversEntrance int64
+
+// ScheduledTakeover is a graceful master takeover to run at, or after, a given time, optionally only
+// within a recurring maintenance window
+type ScheduledTakeover struct {
+	UID           string
+	ClusterName   string
+	DesignatedKey inst.InstanceKey // empty: as per graceful-master-takeover(-auto), orchestrator picks the successor
+	Auto          bool
+	NotBefore     time.Time
+	Expires       time.Time // zero: pending until run or cancelled
+	Window        string    // maintenance window spec; empty: any time after NotBefore
+	Status        string
+	StatusMessage string
+	Owner         string
+	Reason        string
+	CreatedAt     time.Time
+	LastAttemptAt time.Time
+	SuccessorKey  inst.InstanceKey
+	RecoveryUID   string
+}
+
+// NewScheduledTakeover returns a pending takeover. A takeover without a window and without an explicit expiry
+// expires ScheduledTakeoverMaxDelayMinutes past notBefore.
+func NewScheduledTakeover(clusterName string, designatedKey *inst.InstanceKey, auto bool, notBefore time.Time, window string, expires time.Time, owner string, reason string) (*ScheduledTakeover, error) {
+	takeover := &ScheduledTakeover{
+		UID:         util.PrettyUniqueToken(),
+		ClusterName: clusterName,
+		Auto:        auto,
+		NotBefore:   notBefore,
+		Expires:     expires,
+		Window:      window,
+		Status:      ScheduledTakeoverPending,
+		Owner:       owner,
+		Reason:      reason,
+		CreatedAt:   time.Now(),
+	}
+	if designatedKey != nil && designatedKey.IsValid() {
+		takeover.DesignatedKey = *designatedKey
+	}
+	if takeover.NotBefore.IsZero() {
+		takeover.NotBefore = takeover.CreatedAt
+	}
+	if window != "" {
+		maintenanceWindow, err := inst.ParseMaintenanceWindow(window)
+		if err != nil {
+			return nil, err
+		}
+		if !takeover.Expires.IsZero() {
+			if opening := maintenanceWindow.NextOpening(takeover.NotBefore); !opening.Before(takeover.Expires) {
+				return nil, fmt.Errorf("scheduled takeover expires (%s) before its window %s next opens (%s)", takeover.Expires.UTC().Format(time.RFC3339), window, opening.Format(time.RFC3339))
+			}
+		}
+	} else if takeover.Expires.IsZero() {
+		maxDelay := time.Duration(config.Config.ScheduledTakeoverMaxDelayMinutes) * time.Minute
+		if maxDelay < time.Minute {
+			maxDelay = time.Minute
+		}
+		takeover.Expires = takeover.NotBefore.Add(maxDelay)
+	}
+	if !takeover.Expires.IsZero() && !takeover.Expires.After(takeover.NotBefore) {
+		return nil, fmt.Errorf("scheduled takeover expires (%s) before it is due (%s)", takeover.Expires.UTC().Format(time.RFC3339), takeover.NotBefore.UTC().Format(time.RFC3339))
+	}
+	return takeover, nil
+}
+
+// isDue checks whether this takeover may run at given time
+func (this *ScheduledTakeover) isDue(now time.Time) bool {
+	if now.Before(this.NotBefore) {
+		return false
+	}
+	if this.Window == "" {
+		return true
+	}
+	window, err := inst.ParseMaintenanceWindow(this.Window)
+	if err != nil {
+		return false
+	}
+	return window.Contains(now)
+}
+
+func (this *ScheduledTakeover) isExpired(now time.Time) bool {
+	return !this.Expires.IsZero() && now.After(this.Expires)
+}
+
+// isRunningTimedOut checks whether this takeover is running for longer than ScheduledTakeoverRunningTimeoutMinutes,
+// implying whoever ran it is gone
+func (this *ScheduledTakeover) isRunningTimedOut(now time.Time) bool {
+	if this.Status != ScheduledTakeoverRunning {
+		return false
+	}
+	timeout := time.Duration(config.Config.ScheduledTakeoverRunningTimeoutMinutes) * time.Minute
+	if timeout < time.Minute {
+		timeout = time.Minute
+	}
+	return now.After(this.LastAttemptAt.Add(timeout))
+}
+
+// String returns a one line description of the takeover
+func (this *ScheduledTakeover) String() string {
+	description := fmt.Sprintf("%s: graceful master takeover of %s", this.UID, this.ClusterName)
+	if this.DesignatedKey.IsValid() {
+		description = fmt.Sprintf("%s onto %+v", description, this.DesignatedKey)
+	}
+	description = fmt.Sprintf("%s, not before %s", description, this.NotBefore.UTC().Format(time.RFC3339))
+	if this.Window != "" {
+		description = fmt.Sprintf("%s, within window %s UTC", description, this.Window)
+	}
+	if !this.Expires.IsZero() {
+		description = fmt.Sprintf("%s, expires %s", description, this.Expires.UTC().Format(time.RFC3339))
+	}
+	return description
+}
+
+// persistScheduledTakeover writes the takeover, via raft when enabled
+func persistScheduledTakeover(takeover *ScheduledTakeover) error {
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("write-scheduled-takeover", takeover)
+		return err
+	}
+	return writeScheduledTakeover(takeover)
+}
+
+// auditScheduledTakeover audits given message, associated with the cluster's master when known
+func auditScheduledTakeover(takeover *ScheduledTakeover, message string) {
+	var masterKey *inst.InstanceKey
+	if masters, err := inst.ReadClusterMaster(takeover.ClusterName); err == nil && len(masters) == 1 {
+		masterKey = &masters[0].Key
+	}
+	inst.AuditOperation("scheduled-takeover", masterKey, fmt.Sprintf("%s: %s", takeover.UID, message))
+}
+
+// ScheduleGracefulMasterTakeover schedules a graceful master takeover of given cluster. Pre-checks are only
+// evaluated when the takeover is due.
+func ScheduleGracefulMasterTakeover(takeover *ScheduledTakeover) error {
+	masters, err := inst.ReadClusterMaster(takeover.ClusterName)
+	if err != nil {
+		return fmt.Errorf("Cannot deduce cluster master for %+v; error: %+v", takeover.ClusterName, err)
+	}
+	if len(masters) != 1 {
+		return fmt.Errorf("Cannot deduce cluster master for %+v. Found %+v potential masters", takeover.ClusterName, len(masters))
+	}
+	pending, err := readPendingScheduledTakeovers(takeover.ClusterName)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("cluster %s already has a pending scheduled takeover: %s. Cancel it first", takeover.ClusterName, pending[0].UID)
+	}
+	if err := persistScheduledTakeover(takeover); err != nil {
+		return err
+	}
+	auditScheduledTakeover(takeover, fmt.Sprintf("scheduled by %s: %s; reason: %s", takeover.Owner, takeover.String(), takeover.Reason))
+	return nil
+}
+
+// CancelScheduledTakeover cancels a pending scheduled takeover
+func CancelScheduledTakeover(uid string, owner string) (*ScheduledTakeover, error) {
+	takeover, err := ReadScheduledTakeover(uid)
+	if err != nil {
+		return nil, err
+	}
+	if takeover.Status != ScheduledTakeoverPending {
+		return takeover, fmt.Errorf("scheduled takeover %s is %s; only pending takeovers can be cancelled", uid, takeover.Status)
+	}
+	takeover.Status = ScheduledTakeoverCancelled
+	takeover.StatusMessage = fmt.Sprintf("cancelled by %s", owner)
+	if err := persistScheduledTakeover(takeover); err != nil {
+		return takeover, err
+	}
+	auditScheduledTakeover(takeover, takeover.StatusMessage)
+	return takeover, nil
+}
+
+// checkScheduledTakeover re-evaluates the pre-checks of a due takeover: no recovery is running on the cluster,
+// the master and the successor are healthy, and a graceful takeover would not be blocked, e.g. by lag.
+// It returns the checked successor, which the takeover is then to promote.
+func checkScheduledTakeover(takeover *ScheduledTakeover) (candidateKey *inst.InstanceKey, err error) {
+	activeRecoveries, err := ReadActiveClusterRecovery(takeover.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+	if len(activeRecoveries) > 0 {
+		return nil, fmt.Errorf("recovery %s is in progress on the cluster", activeRecoveries[0].UID)
+	}
+	plan, err := PlanGracefulMasterTakeover(takeover.ClusterName, &takeover.DesignatedKey, takeover.Auto)
+	if err != nil {
+		return nil, err
+	}
+	if plan.IsBlocked() {
+		return nil, fmt.Errorf("%s", strings.Join(plan.Blockers, "; "))
+	}
+	for _, instanceKey := range []inst.InstanceKey{plan.FailedInstanceKey, *plan.CandidateKey} {
+		instance, found, err := inst.ReadInstance(&instanceKey)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("%+v not found", instanceKey)
+		}
+		if !instance.IsLastCheckValid || !instance.IsRecentlyChecked {
+			return nil, fmt.Errorf("%+v is not healthy: last check invalid or not recent", instanceKey)
+		}
+	}
+	return plan.CandidateKey, nil
+}
+
+// failTimedOutScheduledTakeover marks a takeover which is running for too long as failed. Its graceful master
+// takeover may or may not have taken place.
+func failTimedOutScheduledTakeover(takeover *ScheduledTakeover, now time.Time) {
+	if !takeover.isRunningTimedOut(now) {
+		return
+	}
+	takeover.Status = ScheduledTakeoverFailed
+	takeover.StatusMessage = fmt.Sprintf("timed out: running since %s with no outcome recorded; the graceful master takeover may or may not have taken place", takeover.LastAttemptAt.UTC().Format(time.RFC3339))
+	persistScheduledTakeover(takeover)
+	auditScheduledTakeover(takeover, takeover.StatusMessage)
+}
+
+// runScheduledTakeoverIfDue expires the takeover, or runs it when due and its pre-checks pass. A takeover whose
+// pre-checks fail remains pending, and is re-evaluated on the next run.
+func runScheduledTakeoverIfDue(takeover *ScheduledTakeover, now time.Time) {
+	if takeover.isExpired(now) {
+		takeover.Status = ScheduledTakeoverExpired
+		if takeover.StatusMessage != "" {
+			takeover.StatusMessage = fmt.Sprintf("expired; last %s", takeover.StatusMessage)
+		} else {
+			takeover.StatusMessage = "expired"
+		}
+		persistScheduledTakeover(takeover)
+		auditScheduledTakeover(takeover, takeover.StatusMessage)
+		return
+	}
+	if !takeover.isDue(now) {
+		return
+	}
+	candidateKey, err := checkScheduledTakeover(takeover)
+	if err != nil {
+		message := fmt.Sprintf("pre-check failed: %+v", err)
+		if message != takeover.StatusMessage {
+			// Only audit changes, as pre-checks are re-evaluated every few seconds
+			takeover.StatusMessage = message
+			takeover.LastAttemptAt = now
+			persistScheduledTakeover(takeover)
+			auditScheduledTakeover(takeover, message)
+		}
+		return
+	}
+
+	takeover.Status = ScheduledTakeoverRunning
+	takeover.StatusMessage = "running graceful master takeover"
+	takeover.LastAttemptAt = now
+	if err := persistScheduledTakeover(takeover); err != nil {
+		log.Errore(err)
+		return
+	}
+	auditScheduledTakeover(takeover, fmt.Sprintf("pre-checks passed; running graceful master takeover onto %+v", *candidateKey))
+
+	if !takeover.DesignatedKey.IsValid() && takeover.Auto {
+		// Designating the checked candidate, rather than having it deduced again, which is where auto mode
+		// would start replication on it
+		if _, err := inst.StartReplication(candidateKey); err != nil {
+			log.Errore(err)
+		}
+	}
+	topologyRecovery, _, err := GracefulMasterTakeover(takeover.ClusterName, candidateKey, takeover.Auto)
+	if topologyRecovery != nil {
+		takeover.RecoveryUID = topologyRecovery.UID
+	}
+	switch {
+	case err != nil:
+		takeover.Status = ScheduledTakeoverFailed
+		takeover.StatusMessage = fmt.Sprintf("graceful master takeover failed: %+v", err)
+	case topologyRecovery == nil || topologyRecovery.SuccessorKey == nil:
+		takeover.Status = ScheduledTakeoverFailed
+		takeover.StatusMessage = "graceful master takeover failed: no successor promoted"
+	default:
+		takeover.Status = ScheduledTakeoverCompleted
+		takeover.SuccessorKey = *topologyRecovery.SuccessorKey
+		takeover.StatusMessage = fmt.Sprintf("promoted %+v", takeover.SuccessorKey)
+	}
+	persistScheduledTakeover(takeover)
+	auditScheduledTakeover(takeover, takeover.StatusMessage)
+}
+
+// RunDueScheduledTakeovers expires, or runs, pending scheduled takeovers, and fails takeovers left running
+// for too long. It is expected to run on the leader only.
+func RunDueScheduledTakeovers() {
+	// This function is non re-entrant (it can only be running once at any point in time)
+	if atomic.CompareAndSwapInt64(&scheduledTakeoversEntrance, 0, 1) {
+		defer atomic.StoreInt64(&scheduledTakeoversEntrance, 0)
+	} else {
+		return
+	}
+	if takeovers, err := readRunningScheduledTakeovers(); err == nil {
+		for _, takeover := range takeovers {
+			failTimedOutScheduledTakeover(takeover, time.Now())
+		}
+	}
+	takeovers, err := readPendingScheduledTakeovers("")
+	if err != nil {
+		return
+	}
+	for _, takeover := range takeovers {
+		runScheduledTakeoverIfDue(takeover, time.Now())
+	}
+}
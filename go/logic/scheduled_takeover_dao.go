@@ -0,0 +1,175 @@
+/*
+   Copyright 2026 The orchestrator Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/db"
+	"github.com/openark/orchestrator/go/inst"
+)
+
+func toUnixtime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func fromUnixtime(unixtime int64) time.Time {
+	if unixtime == 0 {
+		return time.Time{}
+	}
+	return time.Unix(unixtime, 0)
+}
+
+// writeScheduledTakeover creates or updates a scheduled takeover
+func writeScheduledTakeover(takeover *ScheduledTakeover) error {
+	_, err := db.ExecOrchestrator(`
+			insert
+				into scheduled_takeover (
+					uid, cluster_name, designated_hostname, designated_port, is_auto,
+					not_before_unixtime, expires_unixtime, maintenance_window,
+					status, status_message, owner, reason,
+					created_unixtime, last_attempt_unixtime,
+					successor_hostname, successor_port, recovery_uid, last_updated
+				) values (
+					?, ?, ?, ?, ?,
+					?, ?, ?,
+					?, ?, ?, ?,
+					?, ?,
+					?, ?, ?, NOW()
+				) on duplicate key update
+					status=values(status),
+					status_message=values(status_message),
+					last_attempt_unixtime=values(last_attempt_unixtime),
+					successor_hostname=values(successor_hostname),
+					successor_port=values(successor_port),
+					recovery_uid=values(recovery_uid),
+					last_updated=values(last_updated)
+			`,
+		takeover.UID, takeover.ClusterName, takeover.DesignatedKey.Hostname, takeover.DesignatedKey.Port, takeover.Auto,
+		toUnixtime(takeover.NotBefore), toUnixtime(takeover.Expires), takeover.Window,
+		takeover.Status, takeover.StatusMessage, takeover.Owner, takeover.Reason,
+		toUnixtime(takeover.CreatedAt), toUnixtime(takeover.LastAttemptAt),
+		takeover.SuccessorKey.Hostname, takeover.SuccessorKey.Port, takeover.RecoveryUID,
+	)
+	return log.Errore(err)
+}
+
+func readScheduledTakeovers(whereClause string, args []interface{}) (takeovers [](*ScheduledTakeover), err error) {
+	takeovers = [](*ScheduledTakeover){}
+	query := fmt.Sprintf(`
+		select
+			uid,
+			cluster_name,
+			designated_hostname,
+			designated_port,
+			is_auto,
+			not_before_unixtime,
+			expires_unixtime,
+			maintenance_window,
+			status,
+			status_message,
+			owner,
+			reason,
+			created_unixtime,
+			last_attempt_unixtime,
+			successor_hostname,
+			successor_port,
+			recovery_uid
+		from
+			scheduled_takeover
+		%s
+		order by
+			not_before_unixtime, created_unixtime
+		`, whereClause)
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		takeover := &ScheduledTakeover{
+			UID:           m.GetString("uid"),
+			ClusterName:   m.GetString("cluster_name"),
+			DesignatedKey: inst.InstanceKey{Hostname: m.GetString("designated_hostname"), Port: m.GetInt("designated_port")},
+			Auto:          m.GetBool("is_auto"),
+			NotBefore:     fromUnixtime(m.GetInt64("not_before_unixtime")),
+			Expires:       fromUnixtime(m.GetInt64("expires_unixtime")),
+			Window:        m.GetString("maintenance_window"),
+			Status:        m.GetString("status"),
+			StatusMessage: m.GetString("status_message"),
+			Owner:         m.GetString("owner"),
+			Reason:        m.GetString("reason"),
+			CreatedAt:     fromUnixtime(m.GetInt64("created_unixtime")),
+			LastAttemptAt: fromUnixtime(m.GetInt64("last_attempt_unixtime")),
+			SuccessorKey:  inst.InstanceKey{Hostname: m.GetString("successor_hostname"), Port: m.GetInt("successor_port")},
+			RecoveryUID:   m.GetString("recovery_uid"),
+		}
+		takeovers = append(takeovers, takeover)
+		return nil
+	})
+	return takeovers, log.Errore(err)
+}
+
+// ReadScheduledTakeovers reads the scheduled takeovers of given cluster, or of all clusters when clusterName is empty
+func ReadScheduledTakeovers(clusterName string) (takeovers [](*ScheduledTakeover), err error) {
+	if clusterName == "" {
+		return readScheduledTakeovers("", sqlutils.Args())
+	}
+	return readScheduledTakeovers("where cluster_name = ?", sqlutils.Args(clusterName))
+}
+
+// ReadScheduledTakeover reads a single scheduled takeover by its UID
+func ReadScheduledTakeover(uid string) (*ScheduledTakeover, error) {
+	takeovers, err := readScheduledTakeovers("where uid = ?", sqlutils.Args(uid))
+	if err != nil {
+		return nil, err
+	}
+	if len(takeovers) == 0 {
+		return nil, fmt.Errorf("scheduled takeover not found: %s", uid)
+	}
+	return takeovers[0], nil
+}
+
+// readPendingScheduledTakeovers reads scheduled takeovers yet to run, of given cluster or of all clusters when
+// clusterName is empty
+func readPendingScheduledTakeovers(clusterName string) (takeovers [](*ScheduledTakeover), err error) {
+	if clusterName == "" {
+		return readScheduledTakeovers("where status = ?", sqlutils.Args(ScheduledTakeoverPending))
+	}
+	return readScheduledTakeovers("where status = ? and cluster_name = ?", sqlutils.Args(ScheduledTakeoverPending, clusterName))
+}
+
+// readRunningScheduledTakeovers reads scheduled takeovers which are running, or were left running
+func readRunningScheduledTakeovers() (takeovers [](*ScheduledTakeover), err error) {
+	return readScheduledTakeovers("where status = ?", sqlutils.Args(ScheduledTakeoverRunning))
+}
+
+// ExpireScheduledTakeoverHistory removes old scheduled takeovers which are done with, one way or another
+func ExpireScheduledTakeoverHistory() error {
+	_, err := db.ExecOrchestrator(`
+			delete
+				from scheduled_takeover
+			where
+				status not in (?, ?)
+				and last_updated < NOW() - INTERVAL ? DAY
+			`,
+		ScheduledTakeoverPending, ScheduledTakeoverRunning, config.Config.AuditPurgeDays,
+	)
+	return log.Errore(err)
+}
@@ -0,0 +1,197 @@
+package logic
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/inst"
+)
+
+// saturday is a Saturday, 10:00 UTC
+var saturday = time.Date(2026, time.June, 6, 10, 0, 0, 0, time.UTC)
+
+func TestNewScheduledTakeover(t *testing.T) {
+	{
+		takeover, err := NewScheduledTakeover("db-1:3306", nil, true, saturday, "", time.Time{}, "owner", "reason")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(takeover.Status, ScheduledTakeoverPending)
+		test.S(t).ExpectFalse(takeover.DesignatedKey.IsValid())
+		test.S(t).ExpectTrue(takeover.NotBefore.Equal(saturday))
+		// Expires ScheduledTakeoverMaxDelayMinutes past its time
+		test.S(t).ExpectTrue(takeover.Expires.Equal(saturday.Add(time.Duration(config.Config.ScheduledTakeoverMaxDelayMinutes) * time.Minute)))
+	}
+	{
+		// Due now
+		takeover, err := NewScheduledTakeover("db-1:3306", &inst.InstanceKey{}, false, time.Time{}, "", time.Time{}, "owner", "reason")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectFalse(takeover.DesignatedKey.IsValid())
+		test.S(t).ExpectTrue(takeover.NotBefore.Equal(takeover.CreatedAt))
+	}
+	{
+		designatedKey := &inst.InstanceKey{Hostname: "db-2", Port: 3306}
+		takeover, err := NewScheduledTakeover("db-1:3306", designatedKey, false, saturday, "", saturday.Add(time.Hour), "owner", "reason")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(takeover.DesignatedKey.Equals(designatedKey))
+		test.S(t).ExpectTrue(takeover.Expires.Equal(saturday.Add(time.Hour)))
+	}
+	{
+		// A window without explicit expiry does not expire
+		takeover, err := NewScheduledTakeover("db-1:3306", nil, true, saturday, "Sat,Sun 03:00-05:00", time.Time{}, "owner", "reason")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(takeover.Expires.IsZero())
+	}
+	{
+		_, err := NewScheduledTakeover("db-1:3306", nil, true, saturday, "Sat 25:00-26:00", time.Time{}, "owner", "reason")
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := NewScheduledTakeover("db-1:3306", nil, true, saturday, "", saturday.Add(-time.Hour), "owner", "reason")
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		// The window next opens on Sunday 03:00, past expiry
+		_, err := NewScheduledTakeover("db-1:3306", nil, true, saturday, "Sat,Sun 03:00-05:00", saturday.Add(12*time.Hour), "owner", "reason")
+		test.S(t).ExpectNotNil(err)
+		takeover, err := NewScheduledTakeover("db-1:3306", nil, true, saturday, "Sat,Sun 03:00-05:00", saturday.Add(24*time.Hour), "owner", "reason")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(takeover.Expires.Equal(saturday.Add(24 * time.Hour)))
+	}
+}
+
+func TestScheduledTakeoverIsDue(t *testing.T) {
+	{
+		takeover := &ScheduledTakeover{NotBefore: saturday}
+		test.S(t).ExpectFalse(takeover.isDue(saturday.Add(-time.Second)))
+		test.S(t).ExpectTrue(takeover.isDue(saturday))
+		test.S(t).ExpectTrue(takeover.isDue(saturday.Add(48 * time.Hour)))
+	}
+	{
+		takeover := &ScheduledTakeover{NotBefore: saturday, Window: "Sat,Sun 23:00-01:30"}
+		test.S(t).ExpectFalse(takeover.isDue(saturday))
+		test.S(t).ExpectTrue(takeover.isDue(saturday.Add(13 * time.Hour)))
+		test.S(t).ExpectTrue(takeover.isDue(saturday.Add(15 * time.Hour)))
+		test.S(t).ExpectFalse(takeover.isDue(saturday.Add(16 * time.Hour)))
+		// Before NotBefore, though within the window
+		test.S(t).ExpectFalse(takeover.isDue(saturday.Add(-9 * time.Hour)))
+	}
+	{
+		takeover := &ScheduledTakeover{NotBefore: saturday, Window: "invalid"}
+		test.S(t).ExpectFalse(takeover.isDue(saturday))
+	}
+}
+
+func TestScheduledTakeoverIsExpired(t *testing.T) {
+	{
+		takeover := &ScheduledTakeover{NotBefore: saturday, Expires: saturday.Add(time.Hour)}
+		test.S(t).ExpectFalse(takeover.isExpired(saturday))
+		test.S(t).ExpectFalse(takeover.isExpired(saturday.Add(time.Hour)))
+		test.S(t).ExpectTrue(takeover.isExpired(saturday.Add(time.Hour + time.Second)))
+	}
+	{
+		takeover := &ScheduledTakeover{NotBefore: saturday}
+		test.S(t).ExpectFalse(takeover.isExpired(saturday.Add(365 * 24 * time.Hour)))
+	}
+}
+
+func TestScheduledTakeoverIsRunningTimedOut(t *testing.T) {
+	defer func(timeoutMinutes uint) { config.Config.ScheduledTakeoverRunningTimeoutMinutes = timeoutMinutes }(config.Config.ScheduledTakeoverRunningTimeoutMinutes)
+	config.Config.ScheduledTakeoverRunningTimeoutMinutes = 30
+
+	takeover := &ScheduledTakeover{Status: ScheduledTakeoverRunning, LastAttemptAt: saturday}
+	test.S(t).ExpectFalse(takeover.isRunningTimedOut(saturday.Add(30 * time.Minute)))
+	test.S(t).ExpectTrue(takeover.isRunningTimedOut(saturday.Add(31 * time.Minute)))
+
+	takeover.Status = ScheduledTakeoverPending
+	test.S(t).ExpectFalse(takeover.isRunningTimedOut(saturday.Add(31 * time.Minute)))
+}
+
+// newTestScheduledTakeover writes and returns a pending takeover of a cluster unknown to the backend
+func newTestScheduledTakeover(t *testing.T, window string, expires time.Time) *ScheduledTakeover {
+	resetTestBackendTables(t, "scheduled_takeover", "topology_recovery")
+	takeover, err := NewScheduledTakeover("db-1:3306", nil, true, saturday, window, expires, "owner", "reason")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectNil(writeScheduledTakeover(takeover))
+	return takeover
+}
+
+func TestRunScheduledTakeoverIfDue(t *testing.T) {
+	{
+		takeover := newTestScheduledTakeover(t, "", time.Time{})
+		runScheduledTakeoverIfDue(takeover, saturday.Add(2*time.Hour))
+		persisted, err := ReadScheduledTakeover(takeover.UID)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(persisted.Status, ScheduledTakeoverExpired)
+		test.S(t).ExpectEquals(persisted.StatusMessage, "expired")
+	}
+	{
+		// Not due: untouched
+		takeover := newTestScheduledTakeover(t, "Sat,Sun 23:00-01:30", time.Time{})
+		runScheduledTakeoverIfDue(takeover, saturday.Add(time.Hour))
+		persisted, err := ReadScheduledTakeover(takeover.UID)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(persisted.Status, ScheduledTakeoverPending)
+		test.S(t).ExpectEquals(persisted.StatusMessage, "")
+		test.S(t).ExpectTrue(persisted.LastAttemptAt.IsZero())
+	}
+	{
+		// Pre-checks fail as the cluster has no known master. The takeover remains pending
+		takeover := newTestScheduledTakeover(t, "", time.Time{})
+		runScheduledTakeoverIfDue(takeover, saturday.Add(time.Minute))
+		persisted, err := ReadScheduledTakeover(takeover.UID)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(persisted.Status, ScheduledTakeoverPending)
+		test.S(t).ExpectTrue(strings.HasPrefix(persisted.StatusMessage, "pre-check failed: Cannot deduce cluster master"))
+		test.S(t).ExpectTrue(persisted.LastAttemptAt.Equal(saturday.Add(time.Minute)))
+
+		// The same failure is not persisted again
+		runScheduledTakeoverIfDue(persisted, saturday.Add(2*time.Minute))
+		persisted, err = ReadScheduledTakeover(takeover.UID)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(persisted.LastAttemptAt.Equal(saturday.Add(time.Minute)))
+
+		// Once expired, the last failure is kept
+		runScheduledTakeoverIfDue(persisted, saturday.Add(2*time.Hour))
+		persisted, err = ReadScheduledTakeover(takeover.UID)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(persisted.Status, ScheduledTakeoverExpired)
+		test.S(t).ExpectTrue(strings.HasPrefix(persisted.StatusMessage, "expired; last pre-check failed"))
+	}
+	{
+		// A recovery is in progress on the cluster
+		takeover := newTestScheduledTakeover(t, "", time.Time{})
+		writeTestActiveRecovery(t, "db-1", "db-1:3306", 10)
+		runScheduledTakeoverIfDue(takeover, saturday.Add(time.Minute))
+		persisted, err := ReadScheduledTakeover(takeover.UID)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(persisted.Status, ScheduledTakeoverPending)
+		test.S(t).ExpectTrue(strings.Contains(persisted.StatusMessage, "is in progress on the cluster"))
+	}
+}
+
+func TestFailTimedOutScheduledTakeover(t *testing.T) {
+	takeover := newTestScheduledTakeover(t, "", time.Time{})
+	takeover.Status = ScheduledTakeoverRunning
+	takeover.LastAttemptAt = saturday
+	test.S(t).ExpectNil(writeScheduledTakeover(takeover))
+
+	running, err := readRunningScheduledTakeovers()
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(running), 1)
+
+	failTimedOutScheduledTakeover(running[0], saturday.Add(time.Minute))
+	running, err = readRunningScheduledTakeovers()
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(running), 1)
+
+	failTimedOutScheduledTakeover(running[0], saturday.Add(24*time.Hour))
+	running, err = readRunningScheduledTakeovers()
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(running), 0)
+	persisted, err := ReadScheduledTakeover(takeover.UID)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(persisted.Status, ScheduledTakeoverFailed)
+	test.S(t).ExpectTrue(strings.HasPrefix(persisted.StatusMessage, "timed out"))
+}
@@ -50,7 +50,15 @@ type SnapshotData struct {
 	Detections,
 	KVStore,
 	Recovery,
-	RecoverySteps sqlutils.NamedResultData
+	RecoverySteps,
+	ClusterRecoveryPolicies,
+	ScheduledTakeovers,
+	RollingOperations,
+	RollingOperationInstances,
+	ErrantGTIDRemediations sqlutils.NamedResultData
+	// kv_outbox is deliberately excluded: each node adds entries to its own outbox as it applies the raft log,
+	// and records its own deliveries to its own stores. Another node's entries would have this node skip
+	// deliveries it never made, or distribute pairs on behalf of the leader.
 
 	LeaderURI string
 }
@@ -102,6 +110,11 @@ func CreateSnapshotData() *SnapshotData {
 	readTableData("topology_recovery", &snapshotData.Recovery)
 	readTableData("topology_recovery_steps", &snapshotData.RecoverySteps)
 	readTableData("cluster_injected_pseudo_gtid", &snapshotData.InjectedPseudoGTIDClusters)
+	readTableData("cluster_recovery_policy", &snapshotData.ClusterRecoveryPolicies)
+	readTableData("scheduled_takeover", &snapshotData.ScheduledTakeovers)
+	readTableData("rolling_operation", &snapshotData.RollingOperations)
+	readTableData("rolling_operation_instance", &snapshotData.RollingOperationInstances)
+	readTableData("errant_gtid_remediation", &snapshotData.ErrantGTIDRemediations)
 
 	log.Debugf("raft snapshot data created")
 	return snapshotData
@@ -208,6 +221,11 @@ func (this *SnapshotDataCreatorApplier) Restore(rc io.ReadCloser) error {
 	writeTableData("topology_failure_detection", &snapshotData.Detections)
 	writeTableData("topology_recovery_steps", &snapshotData.RecoverySteps)
 	writeTableData("cluster_injected_pseudo_gtid", &snapshotData.InjectedPseudoGTIDClusters)
+	writeTableData("cluster_recovery_policy", &snapshotData.ClusterRecoveryPolicies)
+	writeTableData("scheduled_takeover", &snapshotData.ScheduledTakeovers)
+	writeTableData("rolling_operation", &snapshotData.RollingOperations)
+	writeTableData("rolling_operation_instance", &snapshotData.RollingOperationInstances)
+	writeTableData("errant_gtid_remediation", &snapshotData.ErrantGTIDRemediations)
 
 	// recovery disable
 	{
@@ -24,6 +24,7 @@ import (
 	goos "os"
 	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -31,7 +32,6 @@ import (
 	"github.com/openark/orchestrator/go/attributes"
 	"github.com/openark/orchestrator/go/config"
 	"github.com/openark/orchestrator/go/inst"
-	"github.com/openark/orchestrator/go/kv"
 	ometrics "github.com/openark/orchestrator/go/metrics"
 	"github.com/openark/orchestrator/go/os"
 	"github.com/openark/orchestrator/go/process"
@@ -50,6 +50,8 @@ const (
 	CoMasterRecovery                            = "CoMasterRecovery"
 	IntermediateMasterRecovery                  = "IntermediateMasterRecovery"
 	ReplicationGroupMemberRecovery              = "ReplicationGroupMemberRecovery"
+	ReplicationGroupQuorumRecovery              = "ReplicationGroupQuorumRecovery"
+	GaleraNodeRecovery                          = "GaleraNodeRecovery"
 )
 
 type RecoveryAcknowledgement struct {
@@ -116,6 +118,8 @@ type TopologyRecovery struct {
 	RelatedRecoveryId          int64
 	Type                       RecoveryType
 	RecoveryType               MasterRecoveryType
+
+	plan *RecoveryPlan // non-nil on a dry run, in which case audited steps go to the plan
 }
 
 func NewTopologyRecovery(replicationAnalysis inst.ReplicationAnalysis) *TopologyRecovery {
@@ -144,11 +148,17 @@ func (this *TopologyRecovery) AddErrors(errs []error) {
 	}
 }
 
+// RecoveryPolicy returns the effective recovery policy of the recovered cluster
+func (this *TopologyRecovery) RecoveryPolicy() *inst.RecoveryPolicy {
+	return this.AnalysisEntry.ClusterDetails.GetRecoveryPolicy()
+}
+
 type TopologyRecoveryStep struct {
-	Id          int64
-	RecoveryUID string
-	AuditAt     string
-	Message     string
+	Id            int64
+	RecoveryUID   string
+	AuditAt       string
+	Message       string
+	ProcessResult *os.CommandResult // outcome of a hook process, when this step audits one
 }
 
 func NewTopologyRecoveryStep(uid string, message string) *TopologyRecoveryStep {
@@ -196,6 +206,12 @@ var recoverDeadCoMasterFailureCounter = metrics.NewCounter()
 var recoverDeadReplicationGroupMemberCounter = metrics.NewCounter()
 var recoverDeadReplicationGroupMemberSuccessCounter = metrics.NewCounter()
 var recoverDeadReplicationGroupMemberFailureCounter = metrics.NewCounter()
+var recoverReplicationGroupLostQuorumCounter = metrics.NewCounter()
+var recoverReplicationGroupLostQuorumSuccessCounter = metrics.NewCounter()
+var recoverReplicationGroupLostQuorumFailureCounter = metrics.NewCounter()
+var recoverDeadGaleraNodeCounter = metrics.NewCounter()
+var recoverDeadGaleraNodeSuccessCounter = metrics.NewCounter()
+var recoverDeadGaleraNodeFailureCounter = metrics.NewCounter()
 var countPendingRecoveriesGauge = metrics.NewGauge()
 
 func init() {
@@ -211,6 +227,12 @@ func init() {
 	metrics.Register("recover.dead_replication_group_member.start", recoverDeadReplicationGroupMemberCounter)
 	metrics.Register("recover.dead_replication_group_member.success", recoverDeadReplicationGroupMemberSuccessCounter)
 	metrics.Register("recover.dead_replication_group_member.fail", recoverDeadReplicationGroupMemberFailureCounter)
+	metrics.Register("recover.replication_group_lost_quorum.start", recoverReplicationGroupLostQuorumCounter)
+	metrics.Register("recover.replication_group_lost_quorum.success", recoverReplicationGroupLostQuorumSuccessCounter)
+	metrics.Register("recover.replication_group_lost_quorum.fail", recoverReplicationGroupLostQuorumFailureCounter)
+	metrics.Register("recover.dead_galera_node.start", recoverDeadGaleraNodeCounter)
+	metrics.Register("recover.dead_galera_node.success", recoverDeadGaleraNodeSuccessCounter)
+	metrics.Register("recover.dead_galera_node.fail", recoverDeadGaleraNodeFailureCounter)
 	metrics.Register("recover.pending", countPendingRecoveriesGauge)
 
 	go initializeTopologyRecoveryPostConfiguration()
@@ -234,12 +256,22 @@ func initializeTopologyRecoveryPostConfiguration() {
 
 // AuditTopologyRecovery audits a single step in a topology recovery process.
 func AuditTopologyRecovery(topologyRecovery *TopologyRecovery, message string) error {
+	return auditTopologyRecoveryStep(topologyRecovery, message, nil)
+}
+
+// auditTopologyRecoveryStep audits a single step, possibly with the outcome of a hook process.
+func auditTopologyRecoveryStep(topologyRecovery *TopologyRecovery, message string, processResult *os.CommandResult) error {
 	log.Infof("topology_recovery: %s", message)
 	if topologyRecovery == nil {
 		return nil
 	}
+	if topologyRecovery.plan != nil {
+		topologyRecovery.plan.addStep(message)
+		return nil
+	}
 
 	recoveryStep := NewTopologyRecoveryStep(topologyRecovery.UID, message)
+	recoveryStep.ProcessResult = processResult
 	if orcraft.IsRaftEnabled() {
 		_, err := orcraft.PublishCommand("write-recovery-step", recoveryStep)
 		return err
@@ -283,6 +315,7 @@ func prepareCommand(command string, topologyRecovery *TopologyRecovery) (result
 	command = strings.Replace(command, "{failureCluster}", analysisEntry.ClusterDetails.ClusterName, -1)
 	command = strings.Replace(command, "{failureClusterAlias}", analysisEntry.ClusterDetails.ClusterAlias, -1)
 	command = strings.Replace(command, "{failureClusterDomain}", analysisEntry.ClusterDetails.ClusterDomain, -1)
+	command = strings.Replace(command, "{failureChannel}", analysisEntry.AnalyzedChannelName, -1)
 	command = strings.Replace(command, "{countSlaves}", fmt.Sprintf("%d", analysisEntry.CountReplicas), -1)
 	command = strings.Replace(command, "{countReplicas}", fmt.Sprintf("%d", analysisEntry.CountReplicas), -1)
 	command = strings.Replace(command, "{isDowntimed}", fmt.Sprint(analysisEntry.IsDowntimed), -1)
@@ -329,6 +362,7 @@ func applyEnvironmentVariables(topologyRecovery *TopologyRecovery) []string {
 	env = append(env, fmt.Sprintf("ORC_FAILURE_CLUSTER=%s", analysisEntry.ClusterDetails.ClusterName))
 	env = append(env, fmt.Sprintf("ORC_FAILURE_CLUSTER_ALIAS=%s", analysisEntry.ClusterDetails.ClusterAlias))
 	env = append(env, fmt.Sprintf("ORC_FAILURE_CLUSTER_DOMAIN=%s", analysisEntry.ClusterDetails.ClusterDomain))
+	env = append(env, fmt.Sprintf("ORC_FAILURE_CHANNEL=%s", analysisEntry.AnalyzedChannelName))
 	env = append(env, fmt.Sprintf("ORC_COUNT_REPLICAS=%d", analysisEntry.CountReplicas))
 	env = append(env, fmt.Sprintf("ORC_IS_DOWNTIMED=%v", analysisEntry.IsDowntimed))
 	env = append(env, fmt.Sprintf("ORC_AUTO_MASTER_RECOVERY=%v", analysisEntry.ClusterDetails.HasAutomatedMasterRecovery))
@@ -355,42 +389,75 @@ func applyEnvironmentVariables(topologyRecovery *TopologyRecovery) []string {
 	return env
 }
 
-func executeProcess(command string, env []string, topologyRecovery *TopologyRecovery, fullDescription string) (err error) {
+func executeProcess(command string, env []string, topologyRecovery *TopologyRecovery, fullDescription string, timeout time.Duration) (err error) {
 	// Log the command to be run and record how long it takes as this may be useful
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Running %s: %s", fullDescription, command))
 	start := time.Now()
 	var info string
-	if err = os.CommandRun(command, env); err == nil {
+	result, err := os.CommandRunWithTimeout(command, env, timeout, int(config.Config.RecoveryProcessesAuditOutputBytes))
+	if err == nil {
 		info = fmt.Sprintf("Completed %s in %v", fullDescription, time.Since(start))
+	} else if result.TimedOut {
+		info = fmt.Sprintf("Execution of %s timed out after %v; killed its process group", fullDescription, timeout)
+		log.Errorf(info)
 	} else {
-		info = fmt.Sprintf("Execution of %s failed in %v with error: %v", fullDescription, time.Since(start), err)
+		info = fmt.Sprintf("Execution of %s failed in %v with exit code %d, error: %v", fullDescription, time.Since(start), result.ExitCode, err)
 		log.Errorf(info)
 	}
-	AuditTopologyRecovery(topologyRecovery, info)
+	auditTopologyRecoveryStep(topologyRecovery, info, result)
 	return err
 }
 
-// executeProcesses executes a list of processes
+// executeProcesses executes a list of processes, followed by the webhooks configured for the same hook stage
 func executeProcesses(processes []string, description string, topologyRecovery *TopologyRecovery, failOnError bool) (err error) {
-	if len(processes) == 0 {
+	webhooks := getRecoveryWebhooks(description)
+	if len(processes) == 0 && len(webhooks) == 0 {
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("No %s hooks to run", description))
 		return nil
 	}
 
-	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Running %d %s hooks", len(processes), description))
+	if len(webhooks) > 0 {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Running %d %s hooks and %d webhooks", len(processes), description, len(webhooks)))
+	} else {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Running %d %s hooks", len(processes), description))
+	}
+	timeout := config.Config.GetRecoveryHookTimeout(description)
+	contextFile := ""
+	var asyncProcesses sync.WaitGroup
+	if len(processes) > 0 {
+		if contextFile, err = writeRecoveryContextFile(topologyRecovery, description); err != nil {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Unable to write recovery context file for %s hooks: %+v", description, err))
+			err = nil
+		} else {
+			// The context file outlives any async hooks still reading it
+			defer func() {
+				go func() {
+					asyncProcesses.Wait()
+					goos.Remove(contextFile)
+				}()
+			}()
+		}
+	}
 	for i, command := range processes {
 		command, async := prepareCommand(command, topologyRecovery)
 		env := applyEnvironmentVariables(topologyRecovery)
+		if contextFile != "" {
+			env = append(env, fmt.Sprintf("ORC_CONTEXT_FILE=%s", contextFile))
+		}
 
 		fullDescription := fmt.Sprintf("%s hook %d of %d", description, i+1, len(processes))
 		if async {
 			fullDescription = fmt.Sprintf("%s (async)", fullDescription)
 		}
 		if async {
-			// Ignore errors
-			go executeProcess(command, env, topologyRecovery, fullDescription)
+			// Ignore errors; the outcome is audited once the process completes
+			asyncProcesses.Add(1)
+			go func() {
+				defer asyncProcesses.Done()
+				executeProcess(command, env, topologyRecovery, fullDescription, timeout)
+			}()
 		} else {
-			if cmdErr := executeProcess(command, env, topologyRecovery, fullDescription); cmdErr != nil {
+			if cmdErr := executeProcess(command, env, topologyRecovery, fullDescription, timeout); cmdErr != nil {
 				if failOnError {
 					AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Not running further %s hooks", description))
 					return cmdErr
@@ -402,6 +469,14 @@ func executeProcesses(processes []string, description string, topologyRecovery *
 			}
 		}
 	}
+	if webhookErr := executeRecoveryWebhooks(webhooks, description, topologyRecovery, failOnError); webhookErr != nil {
+		if failOnError {
+			return webhookErr
+		}
+		if err == nil {
+			err = webhookErr
+		}
+	}
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("done running %s hooks", description))
 	return err
 }
@@ -520,13 +595,26 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 	postponedAll := false
 
 	inst.AuditOperation("recover-dead-master", failedInstanceKey, "problem found; will recover")
+	candidateInstanceKey, mustPromote, err := resolveMustPromoteCandidate(topologyRecovery, candidateInstanceKey)
+	if err != nil {
+		return false, nil, lostReplicas, topologyRecovery.AddError(err)
+	}
+	if mustPromote {
+		if err := checkMustPromoteCandidateTakeOver(topologyRecovery, candidateInstanceKey, GetMasterRecoveryType(analysisEntry)); err != nil {
+			return false, nil, lostReplicas, topologyRecovery.AddError(err)
+		}
+	}
 	if !skipProcesses {
-		if err := executeProcesses(config.Config.PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
+		if err := executeProcesses(topologyRecovery.RecoveryPolicy().PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
 			return false, nil, lostReplicas, topologyRecovery.AddError(err)
 		}
 	}
+	if err := fenceDeadMaster(topologyRecovery); err != nil {
+		return false, nil, lostReplicas, topologyRecovery.AddError(err)
+	}
 
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: will recover %+v", *failedInstanceKey))
+	auditCandidateScores(topologyRecovery)
 
 	topologyRecovery.RecoveryType = GetMasterRecoveryType(analysisEntry)
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: masterRecoveryType=%+v", topologyRecovery.RecoveryType))
@@ -601,6 +689,11 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 		promotedReplica, err = replacePromotedReplicaWithCandidate(topologyRecovery, &analysisEntry.AnalyzedInstanceKey, promotedReplica, candidateInstanceKey)
 		topologyRecovery.AddError(err)
 	}
+	if promotedReplica != nil && mustPromote && !promotedReplica.Key.Equals(candidateInstanceKey) {
+		err = topologyRecovery.AddError(fmt.Errorf("RecoverDeadMaster: could not promote %+v, which has must promotion rule; refusing to promote %+v", *candidateInstanceKey, promotedReplica.Key))
+		AuditTopologyRecovery(topologyRecovery, err.Error())
+		promotedReplica = nil
+	}
 
 	if promotedReplica == nil {
 		message := "Failure: no replica promoted."
@@ -615,7 +708,7 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 }
 
 func MasterFailoverGeographicConstraintSatisfied(analysisEntry *inst.ReplicationAnalysis, suggestedInstance *inst.Instance) (satisfied bool, dissatisfiedReason string) {
-	if config.Config.PreventCrossDataCenterMasterFailover {
+	if analysisEntry.ClusterDetails.GetRecoveryPolicy().PreventCrossDataCenterMasterFailover {
 		if suggestedInstance.DataCenter != analysisEntry.AnalyzedInstanceDataCenter {
 			return false, fmt.Sprintf("PreventCrossDataCenterMasterFailover: will not promote server in %s when failed server in %s", suggestedInstance.DataCenter, analysisEntry.AnalyzedInstanceDataCenter)
 		}
@@ -628,6 +721,131 @@ func MasterFailoverGeographicConstraintSatisfied(analysisEntry *inst.Replication
 	return true, ""
 }
 
+// auditCandidateScores audits the ranking of the replicas of the failed instance as candidates for its promotion,
+// explaining why a replica is or is not a good candidate. On a dry run the ranking is attached to the plan.
+func auditCandidateScores(topologyRecovery *TopologyRecovery) {
+	scores, err := inst.ScoreReplicasAsCandidates(&topologyRecovery.AnalysisEntry.AnalyzedInstanceKey, topologyRecovery.RecoveryPolicy())
+	if err != nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("candidate scoring: %+v", err))
+		return
+	}
+	if topologyRecovery.plan != nil {
+		topologyRecovery.plan.CandidateScores = scores
+		return
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("candidate scoring: %d replicas ranked", len(scores)))
+	for _, score := range scores {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("candidate scoring: %s", score.Summary()))
+	}
+}
+
+// getHealthyMustPromoteInstances returns the instances of given cluster, other than the failed instance, which have
+// the "must" promotion rule and are fit for promotion
+func getHealthyMustPromoteInstances(clusterName string, failedInstanceKey *inst.InstanceKey) (mustPromoteInstances [](*inst.Instance), err error) {
+	candidates, err := inst.ReadClusterCandidateInstances(clusterName)
+	if err != nil {
+		return mustPromoteInstances, err
+	}
+	for _, candidate := range candidates {
+		if candidate.PromotionRule != inst.MustPromoteRule || candidate.Key.Equals(failedInstanceKey) {
+			continue
+		}
+		if !candidate.IsLastCheckValid || candidate.IsDowntimed || !candidate.LogBinEnabled || candidate.IsBinlogServer() {
+			continue
+		}
+		if inst.IsBannedFromBeingCandidateReplica(candidate) {
+			continue
+		}
+		mustPromoteInstances = append(mustPromoteInstances, candidate)
+	}
+	return mustPromoteInstances, nil
+}
+
+// MustPromoteRuleSatisfied returns false when the cluster has healthy instances with "must" promotion rule,
+// and the suggested instance is not one of them
+func MustPromoteRuleSatisfied(analysisEntry *inst.ReplicationAnalysis, suggestedInstanceKey *inst.InstanceKey) (satisfied bool, dissatisfiedReason string) {
+	mustPromoteInstances, err := getHealthyMustPromoteInstances(analysisEntry.ClusterDetails.ClusterName, &analysisEntry.AnalyzedInstanceKey)
+	if err != nil || len(mustPromoteInstances) == 0 {
+		return true, ""
+	}
+	mustPromoteKeys := inst.NewInstanceKeyMap()
+	mustPromoteKeys.AddInstances(mustPromoteInstances)
+	if mustPromoteKeys.HasKey(*suggestedInstanceKey) {
+		return true, ""
+	}
+	return false, fmt.Sprintf("will not promote %+v while healthy instances with must promotion rule exist: %s", *suggestedInstanceKey, mustPromoteKeys.ToCommaDelimitedList())
+}
+
+// resolveMustPromoteCandidate applies the "must" promotion rule to a master recovery: while healthy instances with
+// "must" promotion rule exist, nothing else may be promoted. When no candidate is requested, it returns one such
+// instance as candidate, preferring one in the failed master's DC & env. A requested candidate which is not such
+// instance is an error. The rule is applied once, before regrouping: the returned candidate is binding for the
+// rest of the recovery. mustPromote is true when the returned candidate is such instance.
+func resolveMustPromoteCandidate(topologyRecovery *TopologyRecovery, candidateInstanceKey *inst.InstanceKey) (resolvedCandidateKey *inst.InstanceKey, mustPromote bool, err error) {
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	mustPromoteInstances, err := getHealthyMustPromoteInstances(analysisEntry.ClusterDetails.ClusterName, &analysisEntry.AnalyzedInstanceKey)
+	if err != nil {
+		return candidateInstanceKey, false, log.Errore(err)
+	}
+	if len(mustPromoteInstances) == 0 {
+		return candidateInstanceKey, false, nil
+	}
+	if len(mustPromoteInstances) > 1 {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: WARNING: found %d healthy instances with must promotion rule; only one is expected per cluster", len(mustPromoteInstances)))
+	}
+	if candidateInstanceKey != nil {
+		if satisfied, reason := MustPromoteRuleSatisfied(analysisEntry, candidateInstanceKey); !satisfied {
+			return candidateInstanceKey, false, fmt.Errorf("RecoverDeadMaster: refusing requested candidate; %s", reason)
+		}
+		return candidateInstanceKey, true, nil
+	}
+	mustPromoteInstance := mustPromoteInstances[0]
+	for _, instance := range mustPromoteInstances {
+		if instance.DataCenter == analysisEntry.AnalyzedInstanceDataCenter &&
+			instance.PhysicalEnvironment == analysisEntry.AnalyzedInstancePhysicalEnvironment {
+			mustPromoteInstance = instance
+			break
+		}
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: %+v has must promotion rule; will not promote any other instance", mustPromoteInstance.Key))
+	return &mustPromoteInstance.Key, true, nil
+}
+
+// checkMustPromoteCandidateTakeOver verifies, before replicas are regrouped, that given instance with "must" promotion
+// rule is able to take over the replicas of the failed master. Regrouping promotes the best candidate replica, which
+// the must instance can then take over only if it is that replica, or if regrouping moves it below that replica.
+// Nothing is executed.
+func checkMustPromoteCandidateTakeOver(topologyRecovery *TopologyRecovery, mustPromoteKey *inst.InstanceKey, masterRecoveryType MasterRecoveryType) error {
+	failedInstanceKey := &topologyRecovery.AnalysisEntry.AnalyzedInstanceKey
+	if masterRecoveryType == MasterRecoveryBinlogServer {
+		candidateReplica, err := inst.GetCandidateReplicaOfBinlogServerTopology(failedInstanceKey)
+		if err != nil {
+			return err
+		}
+		if candidateReplica == nil || !candidateReplica.Key.Equals(mustPromoteKey) {
+			return fmt.Errorf("RecoverDeadMaster: %+v, which has must promotion rule, is not the candidate replica of binlog server topology of %+v; refusing to recover", *mustPromoteKey, *failedInstanceKey)
+		}
+		return nil
+	}
+	candidateReplica, aheadReplicas, _, _, cannotReplicateReplicas, err := inst.GetCandidateReplica(failedInstanceKey, false)
+	if err != nil {
+		return err
+	}
+	if candidateReplica.Key.Equals(mustPromoteKey) {
+		return nil
+	}
+	lostReplicas := inst.NewInstanceKeyMap()
+	lostReplicas.AddInstances(aheadReplicas)
+	lostReplicas.AddInstances(cannotReplicateReplicas)
+	if lostReplicas.HasKey(*mustPromoteKey) {
+		return fmt.Errorf("RecoverDeadMaster: %+v, which has must promotion rule, cannot take over its siblings: it would be lost when regrouping them below %+v; refusing to recover", *mustPromoteKey, candidateReplica.Key)
+	}
+	if !topologyRecovery.AnalysisEntry.Replicas.HasKey(*mustPromoteKey) {
+		return fmt.Errorf("RecoverDeadMaster: %+v, which has must promotion rule, is not a direct replica of %+v and cannot take over; refusing to recover", *mustPromoteKey, *failedInstanceKey)
+	}
+	return nil
+}
+
 // SuggestReplacementForPromotedReplica returns a server to take over the already
 // promoted replica, if such server is found and makes an improvement over the promoted replica.
 func SuggestReplacementForPromotedReplica(topologyRecovery *TopologyRecovery, deadInstanceKey *inst.InstanceKey, promotedReplica *inst.Instance, candidateInstanceKey *inst.InstanceKey) (replacement *inst.Instance, actionRequired bool, err error) {
@@ -837,6 +1055,33 @@ func replacePromotedReplicaWithCandidate(topologyRecovery *TopologyRecovery, dea
 	return promotedReplica, nil
 }
 
+// checkMasterPromotionOverride tells whether the promotion of given replica, as the new master in given recovery,
+// is to be cancelled, in which case the returned error explains why. Otherwise it tells whether the promotion is to
+// wait for the replica's SQL thread to apply its relay logs. Nothing is executed.
+func checkMasterPromotionOverride(topologyRecovery *TopologyRecovery, promotedReplica *inst.Instance) (waitForSQLThread bool, err error) {
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	if satisfied, reason := MasterFailoverGeographicConstraintSatisfied(analysisEntry, promotedReplica); !satisfied {
+		return false, fmt.Errorf("RecoverDeadMaster: failed %+v promotion; %s", promotedReplica.Key, reason)
+	}
+	if satisfied, reason := MustPromoteRuleSatisfied(analysisEntry, &promotedReplica.Key); !satisfied {
+		return false, fmt.Errorf("RecoverDeadMaster: failed promotion; %s", reason)
+	}
+	promotedReplicaLagSeconds := promotedReplica.EffectiveReplicationLagSeconds().Int64
+	recoveryPolicy := topologyRecovery.RecoveryPolicy()
+	if recoveryPolicy.FailMasterPromotionOnLagMinutes > 0 &&
+		time.Duration(promotedReplicaLagSeconds)*time.Second >= time.Duration(recoveryPolicy.FailMasterPromotionOnLagMinutes)*time.Minute {
+		// candidate replica lags too much
+		return false, fmt.Errorf("RecoverDeadMaster: failed promotion. FailMasterPromotionOnLagMinutes is set to %d (minutes) and promoted replica %+v 's lag is %d (seconds)", recoveryPolicy.FailMasterPromotionOnLagMinutes, promotedReplica.Key, promotedReplicaLagSeconds)
+	}
+	if promotedReplica.SQLThreadUpToDate() {
+		return false, nil
+	}
+	if config.Config.FailMasterPromotionIfSQLThreadNotUpToDate {
+		return false, fmt.Errorf("RecoverDeadMaster: failed promotion. FailMasterPromotionIfSQLThreadNotUpToDate is set and promoted replica %+v 's sql thread is not up to date (relay logs still unapplied). Aborting promotion", promotedReplica.Key)
+	}
+	return recoveryPolicy.DelayMasterPromotionIfSQLThreadNotUpToDate, nil
+}
+
 // checkAndRecoverDeadMaster checks a given analysis, decides whether to take action, and possibly takes action
 // Returns true when action was taken.
 func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidateInstanceKey *inst.InstanceKey, forceInstanceRecovery bool, skipProcesses bool) (recoveryAttempted bool, topologyRecovery *TopologyRecovery, err error) {
@@ -867,20 +1112,17 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 			return promotedReplica, err
 		}
 		// Scenarios where we might cancel the promotion.
-		if satisfied, reason := MasterFailoverGeographicConstraintSatisfied(&analysisEntry, promotedReplica); !satisfied {
-			return nil, fmt.Errorf("RecoverDeadMaster: failed %+v promotion; %s", promotedReplica.Key, reason)
-		}
-		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: promoted replica lag seconds: %+v", promotedReplica.ReplicationLagSeconds.Int64))
-		if config.Config.FailMasterPromotionOnLagMinutes > 0 &&
-			time.Duration(promotedReplica.ReplicationLagSeconds.Int64)*time.Second >= time.Duration(config.Config.FailMasterPromotionOnLagMinutes)*time.Minute {
-			// candidate replica lags too much
-			return nil, fmt.Errorf("RecoverDeadMaster: failed promotion. FailMasterPromotionOnLagMinutes is set to %d (minutes) and promoted replica %+v 's lag is %d (seconds)", config.Config.FailMasterPromotionOnLagMinutes, promotedReplica.Key, promotedReplica.ReplicationLagSeconds.Int64)
+		promotedReplicaLagSeconds := promotedReplica.EffectiveReplicationLagSeconds().Int64
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: promoted replica lag seconds: %+v", promotedReplicaLagSeconds))
+		if promotedReplica.ReplicationQueueLagSeconds.Valid {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: promoted replica queue lag seconds: %+v, apply rate: %.2f trx/s", promotedReplica.ReplicationQueueLagSeconds.Int64, promotedReplica.ReplicationApplyRate))
 		}
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: promoted replica sql thread up-to-date: %+v", promotedReplica.SQLThreadUpToDate()))
-		if config.Config.FailMasterPromotionIfSQLThreadNotUpToDate && !promotedReplica.SQLThreadUpToDate() {
-			return nil, fmt.Errorf("RecoverDeadMaster: failed promotion. FailMasterPromotionIfSQLThreadNotUpToDate is set and promoted replica %+v 's sql thread is not up to date (relay logs still unapplied). Aborting promotion", promotedReplica.Key)
+		waitForSQLThread, overrideErr := checkMasterPromotionOverride(topologyRecovery, promotedReplica)
+		if overrideErr != nil {
+			return nil, overrideErr
 		}
-		if config.Config.DelayMasterPromotionIfSQLThreadNotUpToDate && !promotedReplica.SQLThreadUpToDate() {
+		if waitForSQLThread {
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("DelayMasterPromotionIfSQLThreadNotUpToDate: waiting for SQL thread on %+v", promotedReplica.Key))
 			if _, err := inst.WaitForSQLThreadUpToDate(&promotedReplica.Key, 0, 0); err != nil {
 				return nil, fmt.Errorf("DelayMasterPromotionIfSQLThreadNotUpToDate error: %+v", err)
@@ -929,24 +1171,7 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 		}
 
 		kvPairs := inst.GetClusterMasterKVPairs(analysisEntry.ClusterDetails.ClusterAlias, &promotedReplica.Key)
-		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Writing KV %+v", kvPairs))
-		if orcraft.IsRaftEnabled() {
-			for _, kvPair := range kvPairs {
-				_, err := orcraft.PublishCommand("put-key-value", kvPair)
-				log.Errore(err)
-			}
-			// since we'll be affecting 3rd party tools here, we _prefer_ to mitigate re-applying
-			// of the put-key-value event upon startup. We _recommend_ a snapshot in the near future.
-			go orcraft.PublishCommand("async-snapshot", "")
-		} else {
-			err := kv.PutKVPairs(kvPairs)
-			log.Errore(err)
-		}
-		{
-			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Distributing KV %+v", kvPairs))
-			err := kv.DistributePairs(kvPairs)
-			log.Errore(err)
-		}
+		writeRecoveryKVPairs(topologyRecovery, kvPairs)
 		if config.Config.MasterFailoverDetachReplicaMasterHost {
 			postponedFunction := func() error {
 				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: detaching master host on promoted master"))
@@ -972,7 +1197,7 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 
 		if !skipProcesses {
 			// Execute post master-failover processes
-			executeProcesses(config.Config.PostMasterFailoverProcesses, "PostMasterFailoverProcesses", topologyRecovery, false)
+			executeProcesses(topologyRecovery.RecoveryPolicy().PostMasterFailoverProcesses, "PostMasterFailoverProcesses", topologyRecovery, false)
 		}
 	} else {
 		recoverDeadMasterFailureCounter.Inc(1)
@@ -1126,10 +1351,11 @@ func RecoverDeadIntermediateMaster(topologyRecovery *TopologyRecovery, skipProce
 
 	inst.AuditOperation("recover-dead-intermediate-master", failedInstanceKey, "problem found; will recover")
 	if !skipProcesses {
-		if err := executeProcesses(config.Config.PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
+		if err := executeProcesses(topologyRecovery.RecoveryPolicy().PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
 			return nil, topologyRecovery.AddError(err)
 		}
 	}
+	auditCandidateScores(topologyRecovery)
 
 	intermediateMasterInstance, _, err := inst.ReadInstance(failedInstanceKey)
 	if err != nil {
@@ -1229,7 +1455,7 @@ func RecoverDeadReplicationGroupMemberWithReplicas(topologyRecovery *TopologyRec
 	failedGroupMemberInstanceKey := &analysisEntry.AnalyzedInstanceKey
 	inst.AuditOperation("recover-dead-replication-group-member-with-replicas", failedGroupMemberInstanceKey, "problem found; will recover")
 	if !skipProcesses {
-		if err := executeProcesses(config.Config.PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
+		if err := executeProcesses(topologyRecovery.RecoveryPolicy().PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
 			return nil, topologyRecovery.AddError(err)
 		}
 	}
@@ -1257,6 +1483,46 @@ func RecoverDeadReplicationGroupMemberWithReplicas(topologyRecovery *TopologyRec
 	return successorInstance, err
 }
 
+// RecoverDeadGaleraNodeWithReplicas relocates the async replicas of a failed Galera node to another node of the
+// same Galera cluster. The cluster itself needs no failover: its other nodes keep serving writes.
+func RecoverDeadGaleraNodeWithReplicas(topologyRecovery *TopologyRecovery, skipProcesses bool) (successorInstance *inst.Instance, err error) {
+	topologyRecovery.Type = GaleraNodeRecovery
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	failedNodeKey := &analysisEntry.AnalyzedInstanceKey
+	inst.AuditOperation("recover-dead-galera-node-with-replicas", failedNodeKey, "problem found; will recover")
+	if !skipProcesses {
+		if err := executeProcesses(topologyRecovery.RecoveryPolicy().PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
+			return nil, topologyRecovery.AddError(err)
+		}
+	}
+	failedNode, _, err := inst.ReadInstance(failedNodeKey)
+	if err != nil {
+		return nil, topologyRecovery.AddError(err)
+	}
+	if failedNode == nil || !failedNode.IsGaleraNode() {
+		return nil, topologyRecovery.AddError(fmt.Errorf("RecoverDeadGaleraNodeWithReplicas: %+v is not a known Galera node", *failedNodeKey))
+	}
+	AuditTopologyRecovery(topologyRecovery, "Finding a synced Galera node to relocate replicas to")
+	galeraNodes, err := inst.ReadGaleraClusterNodes(failedNode.WsrepClusterStateUUID)
+	if err != nil {
+		return nil, topologyRecovery.AddError(err)
+	}
+	candidateNode := inst.GetGaleraReplicaRelocationTarget(galeraNodes, failedNode)
+	if candidateNode == nil {
+		return nil, topologyRecovery.AddError(errors.New("RecoverDeadGaleraNodeWithReplicas: unable to find a synced Galera node to relocate replicas to"))
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Found Galera node %+v", candidateNode.Key))
+	relocatedReplicas, successorInstance, err, errs := inst.RelocateReplicas(failedNodeKey, &candidateNode.Key, "")
+	topologyRecovery.AddErrors(errs)
+	if len(relocatedReplicas) != len(failedNode.Replicas.GetInstanceKeys()) {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadGaleraNodeWithReplicas: failed to move all replicas to Galera node %+v", candidateNode.Key))
+		return nil, topologyRecovery.AddError(fmt.Errorf("RecoverDeadGaleraNodeWithReplicas: Unable to relocate replicas to %+v", candidateNode.Key))
+	}
+	AuditTopologyRecovery(topologyRecovery, "All replicas successfully relocated")
+	resolveRecovery(topologyRecovery, successorInstance)
+	return successorInstance, err
+}
+
 // checkAndRecoverDeadIntermediateMaster checks a given analysis, decides whether to take action, and possibly takes action
 // Returns true when action was taken.
 func checkAndRecoverDeadIntermediateMaster(analysisEntry inst.ReplicationAnalysis, candidateInstanceKey *inst.InstanceKey, forceInstanceRecovery bool, skipProcesses bool) (bool, *TopologyRecovery, error) {
@@ -1280,7 +1546,7 @@ func checkAndRecoverDeadIntermediateMaster(analysisEntry inst.ReplicationAnalysi
 			// Execute post intermediate-master-failover processes
 			topologyRecovery.SuccessorKey = &promotedReplica.Key
 			topologyRecovery.SuccessorAlias = promotedReplica.InstanceAlias
-			executeProcesses(config.Config.PostIntermediateMasterFailoverProcesses, "PostIntermediateMasterFailoverProcesses", topologyRecovery, false)
+			executeProcesses(topologyRecovery.RecoveryPolicy().PostIntermediateMasterFailoverProcesses, "PostIntermediateMasterFailoverProcesses", topologyRecovery, false)
 		}
 	} else {
 		recoverDeadIntermediateMasterFailureCounter.Inc(1)
@@ -1300,12 +1566,13 @@ func RecoverDeadCoMaster(topologyRecovery *TopologyRecovery, skipProcesses bool)
 	}
 	inst.AuditOperation("recover-dead-co-master", failedInstanceKey, "problem found; will recover")
 	if !skipProcesses {
-		if err := executeProcesses(config.Config.PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
+		if err := executeProcesses(topologyRecovery.RecoveryPolicy().PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
 			return nil, lostReplicas, topologyRecovery.AddError(err)
 		}
 	}
 
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadCoMaster: will recover %+v", *failedInstanceKey))
+	auditCandidateScores(topologyRecovery)
 
 	var coMasterRecoveryType MasterRecoveryType = MasterRecoveryPseudoGTID
 	if analysisEntry.OracleGTIDImmediateTopology || analysisEntry.MariaDBGTIDImmediateTopology {
@@ -1353,7 +1620,7 @@ func RecoverDeadCoMaster(topologyRecovery *TopologyRecovery, skipProcesses bool)
 		}
 	}
 	if promotedReplica != nil {
-		if config.Config.DelayMasterPromotionIfSQLThreadNotUpToDate {
+		if topologyRecovery.RecoveryPolicy().DelayMasterPromotionIfSQLThreadNotUpToDate {
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Waiting to ensure the SQL thread catches up on %+v", promotedReplica.Key))
 			if _, err := inst.WaitForSQLThreadUpToDate(&promotedReplica.Key, 0, 0); err != nil {
 				return promotedReplica, lostReplicas, err
@@ -1445,7 +1712,7 @@ func checkAndRecoverDeadCoMaster(analysisEntry inst.ReplicationAnalysis, candida
 			// Execute post intermediate-master-failover processes
 			topologyRecovery.SuccessorKey = &promotedReplica.Key
 			topologyRecovery.SuccessorAlias = promotedReplica.InstanceAlias
-			executeProcesses(config.Config.PostMasterFailoverProcesses, "PostMasterFailoverProcesses", topologyRecovery, false)
+			executeProcesses(topologyRecovery.RecoveryPolicy().PostMasterFailoverProcesses, "PostMasterFailoverProcesses", topologyRecovery, false)
 		}
 	} else {
 		recoverDeadCoMasterFailureCounter.Inc(1)
@@ -1468,7 +1735,7 @@ func checkAndRecoverNonWriteableMaster(analysisEntry inst.ReplicationAnalysis, c
 
 	inst.AuditOperation("recover-non-writeable-master", &analysisEntry.AnalyzedInstanceKey, "problem found; will recover")
 	if !skipProcesses {
-		if err := executeProcesses(config.Config.PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
+		if err := executeProcesses(topologyRecovery.RecoveryPolicy().PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
 			return false, topologyRecovery, topologyRecovery.AddError(err)
 		}
 	}
@@ -1555,6 +1822,113 @@ func checkAndRecoverGenericProblem(analysisEntry inst.ReplicationAnalysis, candi
 	return false, nil, nil
 }
 
+// RecoverReplicationGroupLostQuorum unblocks a replication group which has lost quorum, by forcing the group
+// membership to be that of the largest partition orchestrator can reach. The group members are all probed anew
+// beforehand, and the recovery is aborted if the group has regained quorum in the meantime, if there is no
+// single largest partition, or if any member of the last known membership is neither reachable nor confirmed
+// dead (see inst.IsReplicationGroupMemberConfirmedDead): orchestrator may then be partitioned away from a
+// majority which still has quorum.
+func RecoverReplicationGroupLostQuorum(topologyRecovery *TopologyRecovery, skipProcesses bool) (successorInstance *inst.Instance, err error) {
+	topologyRecovery.Type = ReplicationGroupQuorumRecovery
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	inst.AuditOperation("recover-replication-group-lost-quorum", &analysisEntry.AnalyzedInstanceKey, "problem found; will recover")
+	if !skipProcesses {
+		if err := executeProcesses(topologyRecovery.RecoveryPolicy().PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
+			return nil, topologyRecovery.AddError(err)
+		}
+	}
+	knownMembers, err := inst.ReadReplicationGroupInstances(analysisEntry.ReplicationGroupName)
+	if err != nil {
+		return nil, topologyRecovery.AddError(err)
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Probing %d known members of replication group %s", len(knownMembers), analysisEntry.ReplicationGroupName))
+	members := [](*inst.Instance){}
+	for _, knownMember := range knownMembers {
+		member, err := inst.ReadTopologyInstance(&knownMember.Key)
+		if err != nil {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverReplicationGroupLostQuorum: cannot reach %+v", knownMember.Key))
+			continue
+		}
+		members = append(members, member)
+	}
+	knownMemberKeys := inst.GetReplicationGroupKnownMemberKeys(knownMembers, members)
+	probedMemberKeys := inst.NewInstanceKeyMap()
+	probedMemberKeys.AddInstances(members)
+	probedReplicasByMember := map[inst.InstanceKey]([](*inst.Instance)){}
+	for _, memberKey := range knownMemberKeys.GetInstanceKeys() {
+		if probedMemberKeys.HasKey(memberKey) {
+			continue
+		}
+		// An unreachable member: its replicas serve as second opinion on whether it is dead
+		replicas, err := inst.ReadReplicaInstances(&memberKey)
+		if err != nil {
+			return nil, topologyRecovery.AddError(err)
+		}
+		for _, replica := range replicas {
+			if replica, err := inst.ReadTopologyInstance(&replica.Key); err == nil {
+				probedReplicasByMember[memberKey] = append(probedReplicasByMember[memberKey], replica)
+			}
+		}
+	}
+	if err := inst.ValidateReplicationGroupForceMembers(knownMemberKeys, members, probedReplicasByMember); err != nil {
+		return nil, topologyRecovery.AddError(err)
+	}
+	partitions := inst.GetReplicationGroupPartitions(members)
+	for _, partition := range partitions {
+		if partition.HasQuorum {
+			return nil, topologyRecovery.AddError(fmt.Errorf("RecoverReplicationGroupLostQuorum: group %s has quorum on %+v; will not force members", analysisEntry.ReplicationGroupName, partition.GetInstanceKeys()))
+		}
+	}
+	partition, err := inst.GetLargestReplicationGroupPartition(partitions)
+	if err != nil {
+		return nil, topologyRecovery.AddError(err)
+	}
+	localAddresses, err := partition.LocalAddresses()
+	if err != nil {
+		return nil, topologyRecovery.AddError(err)
+	}
+	forcingMember := partition.Members[0]
+	for _, member := range partition.Members {
+		if member.ReplicationGroupMemberRole == inst.GroupReplicationMemberRolePrimary {
+			forcingMember = member
+		}
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Forcing group members %+v on %+v", localAddresses, forcingMember.Key))
+	successorInstance, err = inst.ForceReplicationGroupMembers(&forcingMember.Key, localAddresses)
+	if err != nil {
+		return nil, topologyRecovery.AddError(err)
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Replication group %s is now made of %d member(s)", analysisEntry.ReplicationGroupName, len(localAddresses)))
+	resolveRecovery(topologyRecovery, successorInstance)
+	return successorInstance, nil
+}
+
+// checkAndRecoverReplicationGroupLostQuorum checks whether action needs to be taken for a replication group
+// which has lost quorum, and takes the action if applicable.
+func checkAndRecoverReplicationGroupLostQuorum(analysisEntry inst.ReplicationAnalysis, candidateInstanceKey *inst.InstanceKey, forceInstanceRecovery bool, skipProcesses bool) (bool, *TopologyRecovery, error) {
+	if !(forceInstanceRecovery || analysisEntry.ClusterDetails.HasAutomatedReplicationGroupRecovery) {
+		return false, nil, nil
+	}
+	topologyRecovery, err := AttemptRecoveryRegistration(&analysisEntry, !forceInstanceRecovery, !forceInstanceRecovery)
+	if topologyRecovery == nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverReplicationGroupLostQuorum: found an active or recent recovery on %+v. Will not issue another RecoverReplicationGroupLostQuorum.", analysisEntry.AnalyzedInstanceKey))
+		return false, nil, err
+	}
+	recoverReplicationGroupLostQuorumCounter.Inc(1)
+	successorInstance, err := RecoverReplicationGroupLostQuorum(topologyRecovery, skipProcesses)
+	if successorInstance != nil {
+		recoverReplicationGroupLostQuorumSuccessCounter.Inc(1)
+		if !skipProcesses {
+			topologyRecovery.SuccessorKey = &successorInstance.Key
+			topologyRecovery.SuccessorAlias = successorInstance.InstanceAlias
+			executeProcesses(topologyRecovery.RecoveryPolicy().PostMasterFailoverProcesses, "PostMasterFailoverProcesses", topologyRecovery, false)
+		}
+	} else {
+		recoverReplicationGroupLostQuorumFailureCounter.Inc(1)
+	}
+	return true, topologyRecovery, err
+}
+
 // checkAndRecoverDeadGroupMemberWithReplicas checks whether action needs to be taken for an analysis involving a dead
 // replication group member, and takes the action if applicable. Notice that under our view of the world, a primary
 // replication group member is akin to a master in traditional async/semisync replication; whereas secondary group
@@ -1587,7 +1961,7 @@ func checkAndRecoverDeadGroupMemberWithReplicas(analysisEntry inst.ReplicationAn
 			topologyRecovery.SuccessorKey = &recoveredToGroupMember.Key
 			topologyRecovery.SuccessorAlias = recoveredToGroupMember.InstanceAlias
 			// For the same reasons that were mentioned above, we re-use the post intermediate master fail-over hooks
-			executeProcesses(config.Config.PostIntermediateMasterFailoverProcesses, "PostIntermediateMasterFailoverProcesses", topologyRecovery, false)
+			executeProcesses(topologyRecovery.RecoveryPolicy().PostIntermediateMasterFailoverProcesses, "PostIntermediateMasterFailoverProcesses", topologyRecovery, false)
 		}
 	} else {
 		recoverDeadReplicationGroupMemberFailureCounter.Inc(1)
@@ -1595,6 +1969,36 @@ func checkAndRecoverDeadGroupMemberWithReplicas(analysisEntry inst.ReplicationAn
 	return true, topologyRecovery, err
 }
 
+// checkAndRecoverDeadGaleraNodeWithReplicas checks whether action needs to be taken for a dead Galera node which has
+// async replicas, and takes the action if applicable. Such replicas are akin to those of a dead intermediate master:
+// they need a new source, while the Galera cluster keeps serving. Hence intermediate master configuration applies.
+func checkAndRecoverDeadGaleraNodeWithReplicas(analysisEntry inst.ReplicationAnalysis, candidateInstanceKey *inst.InstanceKey, forceInstanceRecovery bool, skipProcesses bool) (bool, *TopologyRecovery, error) {
+	if !(forceInstanceRecovery || analysisEntry.ClusterDetails.HasAutomatedIntermediateMasterRecovery) {
+		return false, nil, nil
+	}
+	topologyRecovery, err := AttemptRecoveryRegistration(&analysisEntry, !forceInstanceRecovery, !forceInstanceRecovery)
+	if topologyRecovery == nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("found an active or recent recovery on %+v. Will not issue another RecoverDeadGaleraNodeWithReplicas.", analysisEntry.AnalyzedInstanceKey))
+		return false, nil, err
+	}
+	recoverDeadGaleraNodeCounter.Inc(1)
+
+	recoveredToNode, err := RecoverDeadGaleraNodeWithReplicas(topologyRecovery, skipProcesses)
+
+	if recoveredToNode != nil {
+		recoverDeadGaleraNodeSuccessCounter.Inc(1)
+
+		if !skipProcesses {
+			topologyRecovery.SuccessorKey = &recoveredToNode.Key
+			topologyRecovery.SuccessorAlias = recoveredToNode.InstanceAlias
+			executeProcesses(topologyRecovery.RecoveryPolicy().PostIntermediateMasterFailoverProcesses, "PostIntermediateMasterFailoverProcesses", topologyRecovery, false)
+		}
+	} else {
+		recoverDeadGaleraNodeFailureCounter.Inc(1)
+	}
+	return true, topologyRecovery, err
+}
+
 // Force a re-read of a topology instance; this is done because we need to substantiate a suspicion
 // that we may have a failover scenario. we want to speed up reading the complete picture.
 func emergentlyReadTopologyInstance(instanceKey *inst.InstanceKey, analysisCode inst.AnalysisCode) (instance *inst.Instance, err error) {
@@ -1698,7 +2102,8 @@ func checkAndExecuteFailureDetectionProcesses(analysisEntry inst.ReplicationAnal
 	if skipProcesses {
 		return true, false, nil
 	}
-	err = executeProcesses(config.Config.OnFailureDetectionProcesses, "OnFailureDetectionProcesses", NewTopologyRecovery(analysisEntry), true)
+	detectionTopologyRecovery := NewTopologyRecovery(analysisEntry)
+	err = executeProcesses(detectionTopologyRecovery.RecoveryPolicy().OnFailureDetectionProcesses, "OnFailureDetectionProcesses", detectionTopologyRecovery, true)
 	return true, true, err
 }
 
@@ -1754,6 +2159,18 @@ func getCheckAndRecoverFunction(analysisCode inst.AnalysisCode, analyzedInstance
 	// replication group members
 	case inst.DeadReplicationGroupMemberWithReplicas:
 		return checkAndRecoverDeadGroupMemberWithReplicas, true
+	case inst.ReplicationGroupLostQuorum:
+		return checkAndRecoverReplicationGroupLostQuorum, true
+	case inst.ReplicationGroupPartitioned, inst.ReplicationGroupWithoutPrimary, inst.ReplicationGroupMemberStuckRecovering:
+		return checkAndRecoverGenericProblem, false
+	// Galera clusters
+	case inst.DeadGaleraNodeWithReplicas:
+		return checkAndRecoverDeadGaleraNodeWithReplicas, true
+	case inst.GaleraNodeNonPrimary, inst.GaleraNodeDesynced, inst.GaleraClusterSizeShrunk:
+		return checkAndRecoverGenericProblem, false
+	// multi-source replication channels
+	case inst.DeadChannelMaster:
+		return checkAndRecoverGenericProblem, false
 	// recoverable structure analysis
 	case inst.NoWriteableMasterStructureWarning:
 		return checkAndRecoverNonWriteableMaster, true
@@ -1788,6 +2205,8 @@ func runEmergentOperations(analysisEntry *inst.ReplicationAnalysis, allowInstanc
 		go emergentlyReadTopologyInstance(&analysisEntry.AnalyzedInstanceKey, analysisEntry.Analysis)
 	case inst.FirstTierReplicaFailingToConnectToMaster:
 		go emergentlyReadTopologyInstance(&analysisEntry.AnalyzedInstanceMasterKey, analysisEntry.Analysis)
+	case inst.UnreachableChannelMaster, inst.ChannelFailingToConnectToMaster:
+		go emergentlyReadTopologyInstance(&analysisEntry.AnalyzedInstanceMasterKey, analysisEntry.Analysis)
 	}
 }
 
@@ -1885,11 +2304,11 @@ func executeCheckAndRecoverFunction(analysisEntry inst.ReplicationAnalysis, cand
 	if !skipProcesses {
 		if topologyRecovery.SuccessorKey == nil {
 			// Execute general unsuccessful post failover processes
-			executeProcesses(config.Config.PostUnsuccessfulFailoverProcesses, "PostUnsuccessfulFailoverProcesses", topologyRecovery, false)
+			executeProcesses(topologyRecovery.RecoveryPolicy().PostUnsuccessfulFailoverProcesses, "PostUnsuccessfulFailoverProcesses", topologyRecovery, false)
 		} else {
 			// Execute general post failover processes
 			inst.EndDowntime(topologyRecovery.SuccessorKey)
-			executeProcesses(config.Config.PostFailoverProcesses, "PostFailoverProcesses", topologyRecovery, false)
+			executeProcesses(topologyRecovery.RecoveryPolicy().PostFailoverProcesses, "PostFailoverProcesses", topologyRecovery, false)
 		}
 	}
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Waiting for %d postponed functions", topologyRecovery.PostponedFunctionsContainer.Len()))
@@ -1898,6 +2317,10 @@ func executeCheckAndRecoverFunction(analysisEntry inst.ReplicationAnalysis, cand
 	if topologyRecovery.PostponedFunctionsContainer.Len() > 0 {
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Executed postponed functions: %+v", strings.Join(topologyRecovery.PostponedFunctionsContainer.Descriptions(), ", ")))
 	}
+	if topologyRecovery.SuccessorKey != nil {
+		// Topology changed; publish replicas & roles right away rather than wait for next poll
+		go RefreshClusterAndPublishRoles(topologyRecovery.AnalysisEntry.ClusterDetails.ClusterName, topologyRecovery.SuccessorKey)
+	}
 	return recoveryAttempted, topologyRecovery, err
 }
 
@@ -1908,6 +2331,7 @@ func CheckAndRecover(specificInstance *inst.InstanceKey, candidateInstanceKey *i
 	if err != nil {
 		return false, nil, log.Errore(err)
 	}
+	prioritizeAnalyzedInstances(replicationAnalysis)
 	if *config.RuntimeCLIFlags.Noop {
 		log.Infof("--noop provided; will not execute processes")
 		skipProcesses = true
@@ -2043,7 +2467,10 @@ func ForceMasterTakeover(clusterName string, destination *inst.Instance) (topolo
 	return topologyRecovery, nil
 }
 
-func getGracefulMasterTakeoverDesignatedInstance(clusterMasterKey *inst.InstanceKey, designatedKey *inst.InstanceKey, clusterMasterDirectReplicas [](*inst.Instance), auto bool) (designatedInstance *inst.Instance, err error) {
+// getGracefulMasterTakeoverDesignatedInstance returns the replica to promote in a graceful master takeover: the designated
+// one, or the single direct replica of the master, or, with auto, the best candidate replica, on which replication is
+// then started. On a dry run replication is not started.
+func getGracefulMasterTakeoverDesignatedInstance(clusterMasterKey *inst.InstanceKey, designatedKey *inst.InstanceKey, clusterMasterDirectReplicas [](*inst.Instance), auto bool, dryRun bool) (designatedInstance *inst.Instance, err error) {
 	if designatedKey == nil {
 		// User did not specify a replica to promote
 		if len(clusterMasterDirectReplicas) == 1 {
@@ -2060,6 +2487,9 @@ func getGracefulMasterTakeoverDesignatedInstance(clusterMasterKey *inst.Instance
 			return nil, fmt.Errorf("GracefulMasterTakeover: no target instance indicated, failed to auto-detect candidate replica for master %+v. Aborting", *clusterMasterKey)
 		}
 		log.Debugf("GracefulMasterTakeover: candidateReplica=%+v", designatedInstance.Key)
+		if dryRun {
+			return designatedInstance, nil
+		}
 		if _, err := inst.StartReplication(&designatedInstance.Key); err != nil {
 			return nil, fmt.Errorf("GracefulMasterTakeover:cannot start replication on designated replica %+v. Aborting", designatedKey)
 		}
@@ -2080,6 +2510,25 @@ func getGracefulMasterTakeoverDesignatedInstance(clusterMasterKey *inst.Instance
 	return designatedInstance, nil
 }
 
+// checkGracefulMasterTakeoverDesignatedInstance returns an error when given designated instance may not take over
+// given master in a graceful master takeover
+func checkGracefulMasterTakeoverDesignatedInstance(clusterMasterKey *inst.InstanceKey, designatedInstance *inst.Instance) error {
+	if inst.IsBannedFromBeingCandidateReplica(designatedInstance) {
+		return fmt.Errorf("GracefulMasterTakeover: designated instance %+v cannot be promoted due to promotion rule or it is explicitly ignored in PromotionIgnoreHostnameFilters configuration", designatedInstance.Key)
+	}
+	masterOfDesignatedInstance, err := inst.GetInstanceMaster(designatedInstance)
+	if err != nil {
+		return err
+	}
+	if !masterOfDesignatedInstance.Key.Equals(clusterMasterKey) {
+		return fmt.Errorf("Sanity check failure. It seems like the designated instance %+v does not replicate from the master %+v (designated instance's master key is %+v). This error is strange. Panicking", designatedInstance.Key, *clusterMasterKey, designatedInstance.MasterKey)
+	}
+	if !designatedInstance.HasReasonableMaintenanceReplicationLag() {
+		return fmt.Errorf("Desginated instance %+v seems to be lagging too much for this operation. Aborting.", designatedInstance.Key)
+	}
+	return nil
+}
+
 // GracefulMasterTakeover will demote master of existing topology and promote its
 // direct replica instead.
 // It expects that replica to have no siblings.
@@ -2109,25 +2558,13 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey,
 		// An empty or invalid key is as good as no key
 		designatedKey = nil
 	}
-	designatedInstance, err := getGracefulMasterTakeoverDesignatedInstance(&clusterMaster.Key, designatedKey, clusterMasterDirectReplicas, auto)
+	designatedInstance, err := getGracefulMasterTakeoverDesignatedInstance(&clusterMaster.Key, designatedKey, clusterMasterDirectReplicas, auto, false)
 	if err != nil {
 		return nil, nil, log.Errore(err)
 	}
-
-	if inst.IsBannedFromBeingCandidateReplica(designatedInstance) {
-		return nil, nil, fmt.Errorf("GracefulMasterTakeover: designated instance %+v cannot be promoted due to promotion rule or it is explicitly ignored in PromotionIgnoreHostnameFilters configuration", designatedInstance.Key)
-	}
-
-	masterOfDesignatedInstance, err := inst.GetInstanceMaster(designatedInstance)
-	if err != nil {
+	if err := checkGracefulMasterTakeoverDesignatedInstance(&clusterMaster.Key, designatedInstance); err != nil {
 		return nil, nil, err
 	}
-	if !masterOfDesignatedInstance.Key.Equals(&clusterMaster.Key) {
-		return nil, nil, fmt.Errorf("Sanity check failure. It seems like the designated instance %+v does not replicate from the master %+v (designated instance's master key is %+v). This error is strange. Panicking", designatedInstance.Key, clusterMaster.Key, designatedInstance.MasterKey)
-	}
-	if !designatedInstance.HasReasonableMaintenanceReplicationLag() {
-		return nil, nil, fmt.Errorf("Desginated instance %+v seems to be lagging too much for this operation. Aborting.", designatedInstance.Key)
-	}
 
 	if len(clusterMasterDirectReplicas) > 1 {
 		log.Infof("GracefulMasterTakeover: Will let %+v take over its siblings", designatedInstance.Key)
@@ -2167,7 +2604,7 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey,
 		SuccessorKey:  &designatedInstance.Key,
 		AnalysisEntry: analysisEntry,
 	}
-	if err := executeProcesses(config.Config.PreGracefulTakeoverProcesses, "PreGracefulTakeoverProcesses", preGracefulTakeoverTopologyRecovery, true); err != nil {
+	if err := executeProcesses(preGracefulTakeoverTopologyRecovery.RecoveryPolicy().PreGracefulTakeoverProcesses, "PreGracefulTakeoverProcesses", preGracefulTakeoverTopologyRecovery, true); err != nil {
 		return nil, nil, fmt.Errorf("Failed running PreGracefulTakeoverProcesses: %+v", err)
 	}
 
@@ -2230,7 +2667,7 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey,
 			err = startReplicationErr
 		}
 	}
-	executeProcesses(config.Config.PostGracefulTakeoverProcesses, "PostGracefulTakeoverProcesses", topologyRecovery, false)
+	executeProcesses(topologyRecovery.RecoveryPolicy().PostGracefulTakeoverProcesses, "PostGracefulTakeoverProcesses", topologyRecovery, false)
 
 	return topologyRecovery, promotedMasterCoordinates, err
 }
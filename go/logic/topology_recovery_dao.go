@@ -17,6 +17,7 @@
 package logic
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -25,6 +26,7 @@ import (
 	"github.com/openark/orchestrator/go/config"
 	"github.com/openark/orchestrator/go/db"
 	"github.com/openark/orchestrator/go/inst"
+	"github.com/openark/orchestrator/go/os"
 	"github.com/openark/orchestrator/go/process"
 	"github.com/openark/orchestrator/go/raft"
 	"github.com/openark/orchestrator/go/util"
@@ -35,6 +37,7 @@ func AttemptFailureDetectionRegistration(analysisEntry *inst.ReplicationAnalysis
 	args := sqlutils.Args(
 		analysisEntry.AnalyzedInstanceKey.Hostname,
 		analysisEntry.AnalyzedInstanceKey.Port,
+		analysisEntry.AnalyzedChannelName,
 		process.ThisHostname,
 		util.ProcessToken.Hash,
 		string(analysisEntry.Analysis),
@@ -55,6 +58,7 @@ func AttemptFailureDetectionRegistration(analysisEntry *inst.ReplicationAnalysis
 				into topology_failure_detection (
 					hostname,
 					port,
+					channel_name,
 					in_active_period,
 					end_active_period_unixtime,
 					processing_node_hostname,
@@ -67,6 +71,7 @@ func AttemptFailureDetectionRegistration(analysisEntry *inst.ReplicationAnalysis
 					is_actionable,
 					start_active_period
 				) values (
+					?,
 					?,
 					?,
 					1,
@@ -146,6 +151,7 @@ func writeTopologyRecovery(topologyRecovery *TopologyRecovery) (*TopologyRecover
 					uid,
 					hostname,
 					port,
+					channel_name,
 					in_active_period,
 					start_active_period,
 					end_active_period_unixtime,
@@ -162,6 +168,7 @@ func writeTopologyRecovery(topologyRecovery *TopologyRecovery) (*TopologyRecover
 					?,
 					?,
 					?,
+					?,
 					1,
 					NOW(),
 					0,
@@ -172,18 +179,18 @@ func writeTopologyRecovery(topologyRecovery *TopologyRecovery) (*TopologyRecover
 					?,
 					?,
 					?,
-					(select ifnull(max(detection_id), 0) from topology_failure_detection where hostname=? and port=?)
+					(select ifnull(max(detection_id), 0) from topology_failure_detection where hostname=? and port=? and channel_name=?)
 				)
 			`,
 		sqlutils.NilIfZero(topologyRecovery.Id),
 		topologyRecovery.UID,
-		analysisEntry.AnalyzedInstanceKey.Hostname, analysisEntry.AnalyzedInstanceKey.Port,
+		analysisEntry.AnalyzedInstanceKey.Hostname, analysisEntry.AnalyzedInstanceKey.Port, analysisEntry.AnalyzedChannelName,
 		process.ThisHostname, util.ProcessToken.Hash,
 		string(analysisEntry.Analysis),
 		analysisEntry.ClusterDetails.ClusterName,
 		analysisEntry.ClusterDetails.ClusterAlias,
 		analysisEntry.CountReplicas, analysisEntry.Replicas.ToCommaDelimitedList(),
-		analysisEntry.AnalyzedInstanceKey.Hostname, analysisEntry.AnalyzedInstanceKey.Port,
+		analysisEntry.AnalyzedInstanceKey.Hostname, analysisEntry.AnalyzedInstanceKey.Port, analysisEntry.AnalyzedChannelName,
 	)
 	if err != nil {
 		return nil, err
@@ -203,30 +210,58 @@ func writeTopologyRecovery(topologyRecovery *TopologyRecovery) (*TopologyRecover
 	return topologyRecovery, nil
 }
 
+// recoveryRegistrationBlockage is a reason for AttemptRecoveryRegistration to refuse registering a recovery:
+// recoveries in active period which block the registration, unless it is explicitly requested
+type recoveryRegistrationBlockage struct {
+	blockingRecoveries []*TopologyRecovery
+	message            string
+	enforced           bool
+}
+
+// readRecoveryRegistrationBlockages reads the recoveries in active period which stand in the way of registering a
+// recovery of given analysis: recoveries which promoted the analyzed instance, and recoveries of its cluster.
+// A blockage is enforced per given flags; a blockage which is not enforced does not prevent the registration.
+func readRecoveryRegistrationBlockages(analysisEntry *inst.ReplicationAnalysis, failIfFailedInstanceInActiveRecovery bool, failIfClusterInActiveRecovery bool) (blockages []recoveryRegistrationBlockage, err error) {
+	// Let's check if this instance has just been promoted recently and is still in active period.
+	recoveries, err := ReadInActivePeriodSuccessorInstanceRecovery(&analysisEntry.AnalyzedInstanceKey)
+	if err != nil {
+		return blockages, err
+	}
+	if len(recoveries) > 0 {
+		blockages = append(blockages, recoveryRegistrationBlockage{
+			blockingRecoveries: recoveries,
+			message:            fmt.Sprintf("instance %+v has recently been promoted (by failover of %+v) and is in active period. It will not be failed over. You may acknowledge the failure on %+v (-c ack-instance-recoveries) to remove this blockage", analysisEntry.AnalyzedInstanceKey, recoveries[0].AnalysisEntry.AnalyzedInstanceKey, recoveries[0].AnalysisEntry.AnalyzedInstanceKey),
+			enforced:           failIfFailedInstanceInActiveRecovery,
+		})
+	}
+	// Let's check if this cluster has just experienced a failover and is still in active period.
+	recoveries, err = ReadInActivePeriodClusterRecovery(analysisEntry.ClusterDetails.ClusterName)
+	if err != nil {
+		return blockages, err
+	}
+	if len(recoveries) > 0 {
+		blockages = append(blockages, recoveryRegistrationBlockage{
+			blockingRecoveries: recoveries,
+			message:            fmt.Sprintf("cluster %+v has recently experienced a failover (of %+v) and is in active period. It will not be failed over again. You may acknowledge the failure on this cluster (-c ack-cluster-recoveries) or on %+v (-c ack-instance-recoveries) to remove this blockage", analysisEntry.ClusterDetails.ClusterName, recoveries[0].AnalysisEntry.AnalyzedInstanceKey, recoveries[0].AnalysisEntry.AnalyzedInstanceKey),
+			enforced:           failIfClusterInActiveRecovery,
+		})
+	}
+	return blockages, nil
+}
+
 // AttemptRecoveryRegistration tries to add a recovery entry; if this fails that means recovery is already in place.
 func AttemptRecoveryRegistration(analysisEntry *inst.ReplicationAnalysis, failIfFailedInstanceInActiveRecovery bool, failIfClusterInActiveRecovery bool) (*TopologyRecovery, error) {
-	if failIfFailedInstanceInActiveRecovery {
-		// Let's check if this instance has just been promoted recently and is still in active period.
-		// If so, we reject recovery registration to avoid flapping.
-		recoveries, err := ReadInActivePeriodSuccessorInstanceRecovery(&analysisEntry.AnalyzedInstanceKey)
-		if err != nil {
-			return nil, log.Errore(err)
-		}
-		if len(recoveries) > 0 {
-			RegisterBlockedRecoveries(analysisEntry, recoveries)
-			return nil, log.Errorf("AttemptRecoveryRegistration: instance %+v has recently been promoted (by failover of %+v) and is in active period. It will not be failed over. You may acknowledge the failure on %+v (-c ack-instance-recoveries) to remove this blockage", analysisEntry.AnalyzedInstanceKey, recoveries[0].AnalysisEntry.AnalyzedInstanceKey, recoveries[0].AnalysisEntry.AnalyzedInstanceKey)
-		}
-	}
-	if failIfClusterInActiveRecovery {
-		// Let's check if this cluster has just experienced a failover and is still in active period.
-		// If so, we reject recovery registration to avoid flapping.
-		recoveries, err := ReadInActivePeriodClusterRecovery(analysisEntry.ClusterDetails.ClusterName)
+	if failIfFailedInstanceInActiveRecovery || failIfClusterInActiveRecovery {
+		// We reject recovery registration to avoid flapping.
+		blockages, err := readRecoveryRegistrationBlockages(analysisEntry, failIfFailedInstanceInActiveRecovery, failIfClusterInActiveRecovery)
 		if err != nil {
 			return nil, log.Errore(err)
 		}
-		if len(recoveries) > 0 {
-			RegisterBlockedRecoveries(analysisEntry, recoveries)
-			return nil, log.Errorf("AttemptRecoveryRegistration: cluster %+v has recently experienced a failover (of %+v) and is in active period. It will not be failed over again. You may acknowledge the failure on this cluster (-c ack-cluster-recoveries) or on %+v (-c ack-instance-recoveries) to remove this blockage", analysisEntry.ClusterDetails.ClusterName, recoveries[0].AnalysisEntry.AnalyzedInstanceKey, recoveries[0].AnalysisEntry.AnalyzedInstanceKey)
+		for _, blockage := range blockages {
+			if blockage.enforced {
+				RegisterBlockedRecoveries(analysisEntry, blockage.blockingRecoveries)
+				return nil, log.Errorf("AttemptRecoveryRegistration: %s", blockage.message)
+			}
 		}
 	}
 	if !failIfFailedInstanceInActiveRecovery {
@@ -251,17 +286,62 @@ func AttemptRecoveryRegistration(analysisEntry *inst.ReplicationAnalysis, failIf
 }
 
 // ClearActiveRecoveries clears the "in_active_period" flag for old-enough recoveries, thereby allowing for
-// further recoveries on cleared instances.
+// further recoveries on cleared instances. A recovery is old enough per the RecoveryPeriodBlockSeconds
+// of its cluster's recovery policy.
 func ClearActiveRecoveries() error {
-	_, err := db.ExecOrchestrator(`
+	clustersInfo := []inst.ClusterInfo{}
+	query := `
+		select
+			distinct cluster_name, cluster_alias
+		from
+			topology_recovery
+		where
+			in_active_period = 1
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(), func(m sqlutils.RowMap) error {
+		clustersInfo = append(clustersInfo, inst.ClusterInfo{ClusterName: m.GetString("cluster_name"), ClusterAlias: m.GetString("cluster_alias")})
+		return nil
+	})
+	if err != nil {
+		return log.Errore(err)
+	}
+	if len(clustersInfo) == 0 {
+		return nil
+	}
+	// Clusters are grouped by their block period, such that all are cleared in a single statement
+	clusterNamesByBlockSeconds := make(map[int][]string)
+	blockSecondsOrder := []int{}
+	for _, clusterInfo := range clustersInfo {
+		blockSeconds := clusterInfo.GetRecoveryPolicy().RecoveryPeriodBlockSeconds
+		if _, found := clusterNamesByBlockSeconds[blockSeconds]; !found {
+			blockSecondsOrder = append(blockSecondsOrder, blockSeconds)
+		}
+		clusterNamesByBlockSeconds[blockSeconds] = append(clusterNamesByBlockSeconds[blockSeconds], clusterInfo.ClusterName)
+	}
+	conditions := []string{}
+	args := sqlutils.Args()
+	for _, blockSeconds := range blockSecondsOrder {
+		clusterNames := clusterNamesByBlockSeconds[blockSeconds]
+		conditions = append(conditions, fmt.Sprintf(
+			"(cluster_name in (%s) AND start_active_period < NOW() - INTERVAL ? SECOND)",
+			strings.TrimSuffix(strings.Repeat("?, ", len(clusterNames)), ", "),
+		))
+		for _, clusterName := range clusterNames {
+			args = append(args, clusterName)
+		}
+		args = append(args, blockSeconds)
+	}
+	_, err = db.ExecOrchestrator(fmt.Sprintf(`
 			update topology_recovery set
 				in_active_period = 0,
 				end_active_period_unixtime = UNIX_TIMESTAMP()
 			where
 				in_active_period = 1
-				AND start_active_period < NOW() - INTERVAL ? SECOND
-			`,
-		config.Config.RecoveryPeriodBlockSeconds,
+				AND (
+					%s
+				)
+			`, strings.Join(conditions, " OR ")),
+		args...,
 	)
 	return log.Errore(err)
 }
@@ -510,6 +590,7 @@ func readRecoveries(whereCondition string, limit string, args []interface{}) ([]
 			uid,
       hostname,
       port,
+      channel_name,
       (IFNULL(end_active_period_unixtime, 0) = 0) as is_active,
       start_active_period,
       IFNULL(end_active_period_unixtime, 0) as end_active_period_unixtime,
@@ -554,6 +635,7 @@ func readRecoveries(whereCondition string, limit string, args []interface{}) ([]
 
 		topologyRecovery.AnalysisEntry.AnalyzedInstanceKey.Hostname = m.GetString("hostname")
 		topologyRecovery.AnalysisEntry.AnalyzedInstanceKey.Port = m.GetInt("port")
+		topologyRecovery.AnalysisEntry.AnalyzedChannelName = m.GetString("channel_name")
 		topologyRecovery.AnalysisEntry.Analysis = inst.AnalysisCode(m.GetString("analysis"))
 		topologyRecovery.AnalysisEntry.ClusterDetails.ClusterName = m.GetString("cluster_name")
 		topologyRecovery.AnalysisEntry.ClusterDetails.ClusterAlias = m.GetString("cluster_alias")
@@ -605,6 +687,17 @@ func ReadInActivePeriodClusterRecovery(clusterName string) ([]*TopologyRecovery,
 	return readRecoveries(whereClause, ``, sqlutils.Args(clusterName))
 }
 
+// ReadInActivePeriodInstanceRecovery reads recoveries (possibly complete!) of given instance and channel that are
+// in active period. Such recoveries prevent registration of another recovery of same instance and channel.
+func ReadInActivePeriodInstanceRecovery(instanceKey *inst.InstanceKey, channelName string) ([]*TopologyRecovery, error) {
+	whereClause := `
+		where
+			in_active_period=1
+			and hostname=? and port=?
+			and channel_name=?`
+	return readRecoveries(whereClause, ``, sqlutils.Args(instanceKey.Hostname, instanceKey.Port, channelName))
+}
+
 // ReadRecentlyActiveClusterRecovery reads recently completed entries for a given cluster
 func ReadRecentlyActiveClusterRecovery(clusterName string) ([]*TopologyRecovery, error) {
 	whereClause := `
@@ -697,6 +790,7 @@ func readFailureDetections(whereCondition string, limit string, args []interface
       detection_id,
       hostname,
       port,
+      channel_name,
       in_active_period as is_active,
       start_active_period,
       end_active_period_unixtime,
@@ -726,6 +820,7 @@ func readFailureDetections(whereCondition string, limit string, args []interface
 
 		failureDetection.AnalysisEntry.AnalyzedInstanceKey.Hostname = m.GetString("hostname")
 		failureDetection.AnalysisEntry.AnalyzedInstanceKey.Port = m.GetInt("port")
+		failureDetection.AnalysisEntry.AnalyzedChannelName = m.GetString("channel_name")
 		failureDetection.AnalysisEntry.Analysis = inst.AnalysisCode(m.GetString("analysis"))
 		failureDetection.AnalysisEntry.ClusterDetails.ClusterName = m.GetString("cluster_name")
 		failureDetection.AnalysisEntry.ClusterDetails.ClusterAlias = m.GetString("cluster_alias")
@@ -806,12 +901,20 @@ func ReadBlockedRecoveries(clusterName string) ([]BlockedTopologyRecovery, error
 
 // writeTopologyRecoveryStep writes down a single step in a recovery process
 func writeTopologyRecoveryStep(topologyRecoveryStep *TopologyRecoveryStep) error {
+	processResult := ""
+	if topologyRecoveryStep.ProcessResult != nil {
+		b, err := json.Marshal(topologyRecoveryStep.ProcessResult)
+		if err != nil {
+			return log.Errore(err)
+		}
+		processResult = string(b)
+	}
 	sqlResult, err := db.ExecOrchestrator(`
 			insert ignore
 				into topology_recovery_steps (
-					recovery_step_id, recovery_uid, audit_at, message
-				) values (?, ?, now(), ?)
-			`, sqlutils.NilIfZero(topologyRecoveryStep.Id), topologyRecoveryStep.RecoveryUID, topologyRecoveryStep.Message,
+					recovery_step_id, recovery_uid, audit_at, message, process_result
+				) values (?, ?, now(), ?, ?)
+			`, sqlutils.NilIfZero(topologyRecoveryStep.Id), topologyRecoveryStep.RecoveryUID, topologyRecoveryStep.Message, processResult,
 	)
 	if err != nil {
 		return log.Errore(err)
@@ -825,7 +928,7 @@ func ReadTopologyRecoverySteps(recoveryUID string) ([]TopologyRecoveryStep, erro
 	res := []TopologyRecoveryStep{}
 	query := `
 		select
-			recovery_step_id, recovery_uid, audit_at, message
+			recovery_step_id, recovery_uid, audit_at, message, process_result
 		from
 			topology_recovery_steps
 		where
@@ -839,6 +942,13 @@ func ReadTopologyRecoverySteps(recoveryUID string) ([]TopologyRecoveryStep, erro
 		recoveryStep.Id = m.GetInt64("recovery_step_id")
 		recoveryStep.AuditAt = m.GetString("audit_at")
 		recoveryStep.Message = m.GetString("message")
+		if processResult := m.GetString("process_result"); processResult != "" {
+			recoveryStep.ProcessResult = &os.CommandResult{}
+			if err := json.Unmarshal([]byte(processResult), recoveryStep.ProcessResult); err != nil {
+				log.Errore(err)
+				recoveryStep.ProcessResult = nil
+			}
+		}
 
 		res = append(res, recoveryStep)
 		return nil
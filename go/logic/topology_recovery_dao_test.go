@@ -0,0 +1,97 @@
+package logic
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/db"
+	"github.com/openark/orchestrator/go/inst"
+)
+
+// writeTestActiveRecovery writes a recovery in active period, which started given number of seconds ago
+func writeTestActiveRecovery(t *testing.T, hostname string, clusterName string, secondsAgo int) {
+	_, err := db.ExecOrchestrator(`
+			insert into topology_recovery (
+				hostname, port, in_active_period, start_active_period, processing_node_hostname, processcing_node_token, cluster_name, cluster_alias, uid
+			) values (
+				?, 3306, 1, NOW() - INTERVAL ? SECOND, '', '', ?, '', ?
+			)
+		`, hostname, secondsAgo, clusterName, hostname,
+	)
+	test.S(t).ExpectNil(err)
+}
+
+func TestClearActiveRecoveries(t *testing.T) {
+	resetTestBackendTables(t, "topology_recovery", "cluster_recovery_policy")
+	defer func(blockSeconds int) { config.Config.RecoveryPeriodBlockSeconds = blockSeconds }(config.Config.RecoveryPeriodBlockSeconds)
+	config.Config.RecoveryPeriodBlockSeconds = 600
+
+	blockSeconds := 60
+	policy := &inst.ClusterRecoveryPolicy{PolicyName: "fast", ClusterPattern: "^fast", Overrides: inst.RecoveryPolicyOverrides{RecoveryPeriodBlockSeconds: &blockSeconds}}
+	test.S(t).ExpectNil(inst.WriteClusterRecoveryPolicy(policy))
+	defer inst.DeleteClusterRecoveryPolicy(policy.PolicyName)
+
+	writeTestActiveRecovery(t, "fast-1", "fast:3306", 120)
+	writeTestActiveRecovery(t, "fast-2", "fast:3306", 10)
+	writeTestActiveRecovery(t, "slow-1", "slow:3306", 1200)
+	writeTestActiveRecovery(t, "slow-2", "slow:3306", 120)
+
+	test.S(t).ExpectNil(ClearActiveRecoveries())
+
+	// Each cluster is cleared per its own block period
+	for clusterName, hostnames := range map[string][]string{"fast:3306": {"fast-2"}, "slow:3306": {"slow-2"}} {
+		recoveries, err := ReadInActivePeriodClusterRecovery(clusterName)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(recoveries), len(hostnames))
+		for i, recovery := range recoveries {
+			test.S(t).ExpectEquals(recovery.AnalysisEntry.AnalyzedInstanceKey.Hostname, hostnames[i])
+		}
+	}
+	// Nothing to clear
+	resetTestBackendTables(t, "topology_recovery")
+	test.S(t).ExpectNil(ClearActiveRecoveries())
+}
+
+func TestReadRecoveryRegistrationBlockages(t *testing.T) {
+	resetTestBackendTables(t, "topology_recovery", "blocked_topology_recovery")
+
+	analysisEntry := &inst.ReplicationAnalysis{
+		AnalyzedInstanceKey: inst.InstanceKey{Hostname: "db-1", Port: 3306},
+		ClusterDetails:      inst.ClusterInfo{ClusterName: "db:3306"},
+	}
+	{
+		blockages, err := readRecoveryRegistrationBlockages(analysisEntry, true, true)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(blockages), 0)
+	}
+	writeTestActiveRecovery(t, "db-0", "db:3306", 10)
+	{
+		// Cluster in active period
+		blockages, err := readRecoveryRegistrationBlockages(analysisEntry, true, false)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(blockages), 1)
+		test.S(t).ExpectFalse(blockages[0].enforced)
+
+		topologyRecovery, err := AttemptRecoveryRegistration(analysisEntry, true, true)
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectTrue(topologyRecovery == nil)
+	}
+	_, err := db.ExecOrchestrator(`update topology_recovery set successor_hostname='db-1', successor_port=3306`)
+	test.S(t).ExpectNil(err)
+	{
+		// Recent promotion of the instance, checked first
+		blockages, err := readRecoveryRegistrationBlockages(analysisEntry, true, false)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(blockages), 2)
+		test.S(t).ExpectTrue(blockages[0].enforced)
+		test.S(t).ExpectEquals(blockages[0].blockingRecoveries[0].AnalysisEntry.AnalyzedInstanceKey.Hostname, "db-0")
+		test.S(t).ExpectFalse(blockages[1].enforced)
+	}
+	{
+		// An explicitly requested recovery is not blocked
+		topologyRecovery, err := AttemptRecoveryRegistration(analysisEntry, false, false)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectNotNil(topologyRecovery)
+	}
+}
@@ -0,0 +1,203 @@
+package logic
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+	"github.com/openark/orchestrator/go/config"
+	"github.com/openark/orchestrator/go/inst"
+)
+
+// writeTestClusterInstance writes a replica of db-1:3306 to the backend, with given promotion rule
+func writeTestClusterInstance(t *testing.T, hostname string, dataCenter string, promotionRule inst.CandidatePromotionRule, logBinEnabled bool) {
+	instance := inst.NewInstance()
+	instance.Key = inst.InstanceKey{Hostname: hostname, Port: 3306}
+	instance.MasterKey = inst.InstanceKey{Hostname: "db-1", Port: 3306}
+	instance.ClusterName = "db-1:3306"
+	instance.DataCenter = dataCenter
+	instance.LogBinEnabled = logBinEnabled
+	test.S(t).ExpectNil(inst.WriteInstance(instance, true, nil))
+	test.S(t).ExpectNil(inst.RegisterCandidateInstance(inst.NewCandidateDatabaseInstance(&instance.Key, promotionRule)))
+}
+
+func newTestMustPromoteRecovery(t *testing.T) *TopologyRecovery {
+	resetTestBackendTables(t, "database_instance", "candidate_database_instance", "database_instance_downtime")
+	analysisEntry := inst.ReplicationAnalysis{
+		AnalyzedInstanceKey:        inst.InstanceKey{Hostname: "db-1", Port: 3306},
+		AnalyzedInstanceDataCenter: "dc2",
+		Analysis:                   inst.DeadMaster,
+		ClusterDetails:             inst.ClusterInfo{ClusterName: "db-1:3306"},
+		Replicas:                   *inst.NewInstanceKeyMap(),
+	}
+	return newRecoveryPlan("recover", analysisEntry).topologyRecovery
+}
+
+func TestResolveMustPromoteCandidate(t *testing.T) {
+	db3Key := &inst.InstanceKey{Hostname: "db-3", Port: 3306}
+	db4Key := &inst.InstanceKey{Hostname: "db-4", Port: 3306}
+	{
+		// No must instances: the requested candidate, if any, stands
+		topologyRecovery := newTestMustPromoteRecovery(t)
+		writeTestClusterInstance(t, "db-2", "dc1", inst.PreferPromoteRule, true)
+
+		candidateKey, mustPromote, err := resolveMustPromoteCandidate(topologyRecovery, nil)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectFalse(mustPromote)
+		test.S(t).ExpectTrue(candidateKey == nil)
+
+		candidateKey, mustPromote, err = resolveMustPromoteCandidate(topologyRecovery, db4Key)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectFalse(mustPromote)
+		test.S(t).ExpectTrue(candidateKey.Equals(db4Key))
+	}
+	{
+		// A must instance in the failed master's DC is preferred
+		topologyRecovery := newTestMustPromoteRecovery(t)
+		writeTestClusterInstance(t, "db-2", "dc1", inst.MustPromoteRule, true)
+		writeTestClusterInstance(t, "db-3", "dc2", inst.MustPromoteRule, true)
+		writeTestClusterInstance(t, "db-4", "dc2", inst.PreferPromoteRule, true)
+
+		candidateKey, mustPromote, err := resolveMustPromoteCandidate(topologyRecovery, nil)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(mustPromote)
+		test.S(t).ExpectTrue(candidateKey.Equals(db3Key))
+
+		// A requested candidate must be a must instance
+		candidateKey, mustPromote, err = resolveMustPromoteCandidate(topologyRecovery, db4Key)
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectFalse(mustPromote)
+
+		candidateKey, mustPromote, err = resolveMustPromoteCandidate(topologyRecovery, &inst.InstanceKey{Hostname: "db-2", Port: 3306})
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(mustPromote)
+		test.S(t).ExpectEquals(candidateKey.Hostname, "db-2")
+	}
+	{
+		// Unhealthy must instances do not count
+		topologyRecovery := newTestMustPromoteRecovery(t)
+		writeTestClusterInstance(t, "db-2", "dc2", inst.MustPromoteRule, false)
+		writeTestClusterInstance(t, "db-3", "dc2", inst.MustPromoteRule, true)
+		test.S(t).ExpectNil(inst.BeginDowntime(inst.NewDowntime(db3Key, "test", "test", time.Hour)))
+
+		candidateKey, mustPromote, err := resolveMustPromoteCandidate(topologyRecovery, db4Key)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectFalse(mustPromote)
+		test.S(t).ExpectTrue(candidateKey.Equals(db4Key))
+	}
+	{
+		// The failed master itself does not count
+		topologyRecovery := newTestMustPromoteRecovery(t)
+		writeTestClusterInstance(t, "db-1", "dc2", inst.MustPromoteRule, true)
+
+		candidateKey, mustPromote, err := resolveMustPromoteCandidate(topologyRecovery, nil)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectFalse(mustPromote)
+		test.S(t).ExpectTrue(candidateKey == nil)
+	}
+}
+
+func TestCheckMasterPromotionOverride(t *testing.T) {
+	defer func(lagMinutes uint, failIfNotUpToDate bool, delayIfNotUpToDate bool) {
+		config.Config.FailMasterPromotionOnLagMinutes = lagMinutes
+		config.Config.FailMasterPromotionIfSQLThreadNotUpToDate = failIfNotUpToDate
+		config.Config.DelayMasterPromotionIfSQLThreadNotUpToDate = delayIfNotUpToDate
+	}(config.Config.FailMasterPromotionOnLagMinutes, config.Config.FailMasterPromotionIfSQLThreadNotUpToDate, config.Config.DelayMasterPromotionIfSQLThreadNotUpToDate)
+	resetTestBackendTables(t, "cluster_recovery_policy")
+	topologyRecovery := newTestMustPromoteRecovery(t)
+
+	promotedReplica := inst.NewInstance()
+	promotedReplica.Key = inst.InstanceKey{Hostname: "db-2", Port: 3306}
+	promotedReplica.ReplicationLagSeconds = sql.NullInt64{Int64: 120, Valid: true}
+	promotedReplica.ReadBinlogCoordinates = inst.BinlogCoordinates{LogFile: "mysql-bin.000002", LogPos: 400}
+	promotedReplica.ExecBinlogCoordinates = inst.BinlogCoordinates{LogFile: "mysql-bin.000002", LogPos: 300}
+	{
+		waitForSQLThread, err := checkMasterPromotionOverride(topologyRecovery, promotedReplica)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectFalse(waitForSQLThread)
+	}
+	{
+		config.Config.DelayMasterPromotionIfSQLThreadNotUpToDate = true
+		topologyRecovery.AnalysisEntry.ClusterDetails.RecoveryPolicy = nil
+		waitForSQLThread, err := checkMasterPromotionOverride(topologyRecovery, promotedReplica)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(waitForSQLThread)
+	}
+	{
+		config.Config.DelayMasterPromotionIfSQLThreadNotUpToDate = false
+		config.Config.FailMasterPromotionIfSQLThreadNotUpToDate = true
+		_, err := checkMasterPromotionOverride(topologyRecovery, promotedReplica)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		// Lagging too much
+		config.Config.FailMasterPromotionIfSQLThreadNotUpToDate = false
+		config.Config.FailMasterPromotionOnLagMinutes = 2
+		topologyRecovery.AnalysisEntry.ClusterDetails.RecoveryPolicy = nil
+		_, err := checkMasterPromotionOverride(topologyRecovery, promotedReplica)
+		test.S(t).ExpectNotNil(err)
+
+		promotedReplica.ReplicationLagSeconds.Int64 = 60
+		_, err = checkMasterPromotionOverride(topologyRecovery, promotedReplica)
+		test.S(t).ExpectNil(err)
+	}
+	{
+		// A healthy must instance exists, and is not the promoted replica
+		writeTestClusterInstance(t, "db-3", "dc2", inst.MustPromoteRule, true)
+		_, err := checkMasterPromotionOverride(topologyRecovery, promotedReplica)
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+// writeTestReplica writes a replica of given master to the backend, with given promotion rule and executed position
+func writeTestReplica(t *testing.T, hostname string, masterHostname string, serverID uint, promotionRule inst.CandidatePromotionRule, execPos int64, logReplicationUpdatesEnabled bool) *inst.InstanceKey {
+	instance := inst.NewInstance()
+	instance.Key = inst.InstanceKey{Hostname: hostname, Port: 3306}
+	instance.MasterKey = inst.InstanceKey{Hostname: masterHostname, Port: 3306}
+	instance.ClusterName = "db-1:3306"
+	instance.DataCenter = "dc2"
+	instance.ServerID = serverID
+	instance.Version = "8.0.30"
+	instance.Binlog_format = "ROW"
+	instance.LogBinEnabled = true
+	instance.LogReplicationUpdatesEnabled = logReplicationUpdatesEnabled
+	instance.ExecBinlogCoordinates = inst.BinlogCoordinates{LogFile: "mysql-bin.000002", LogPos: execPos}
+	instance.ReadBinlogCoordinates = instance.ExecBinlogCoordinates
+	test.S(t).ExpectNil(inst.WriteInstance(instance, true, nil))
+	test.S(t).ExpectNil(inst.RegisterCandidateInstance(inst.NewCandidateDatabaseInstance(&instance.Key, promotionRule)))
+	return &instance.Key
+}
+
+func TestCheckMustPromoteCandidateTakeOver(t *testing.T) {
+	{
+		// The must instance is the best candidate replica
+		topologyRecovery := newTestMustPromoteRecovery(t)
+		writeTestReplica(t, "db-2", "db-1", 2, inst.NeutralPromoteRule, 500, true)
+		mustPromoteKey := writeTestReplica(t, "db-3", "db-1", 3, inst.MustPromoteRule, 600, true)
+		test.S(t).ExpectNil(checkMustPromoteCandidateTakeOver(topologyRecovery, mustPromoteKey, MasterRecoveryGTID))
+	}
+	{
+		// The must instance would be regrouped below the best candidate replica, then take it over
+		topologyRecovery := newTestMustPromoteRecovery(t)
+		writeTestReplica(t, "db-2", "db-1", 2, inst.NeutralPromoteRule, 600, true)
+		mustPromoteKey := writeTestReplica(t, "db-3", "db-1", 3, inst.MustPromoteRule, 500, true)
+		topologyRecovery.AnalysisEntry.Replicas.AddKey(*mustPromoteKey)
+		test.S(t).ExpectNil(checkMustPromoteCandidateTakeOver(topologyRecovery, mustPromoteKey, MasterRecoveryGTID))
+	}
+	{
+		// The must instance is ahead of the best candidate replica, and would be lost
+		topologyRecovery := newTestMustPromoteRecovery(t)
+		writeTestReplica(t, "db-2", "db-1", 2, inst.NeutralPromoteRule, 500, true)
+		mustPromoteKey := writeTestReplica(t, "db-3", "db-1", 3, inst.MustPromoteRule, 600, false)
+		topologyRecovery.AnalysisEntry.Replicas.AddKey(*mustPromoteKey)
+		test.S(t).ExpectNotNil(checkMustPromoteCandidateTakeOver(topologyRecovery, mustPromoteKey, MasterRecoveryGTID))
+	}
+	{
+		// The must instance is not a direct replica of the failed master
+		topologyRecovery := newTestMustPromoteRecovery(t)
+		writeTestReplica(t, "db-2", "db-1", 2, inst.NeutralPromoteRule, 600, true)
+		mustPromoteKey := writeTestReplica(t, "db-3", "db-2", 3, inst.MustPromoteRule, 500, true)
+		test.S(t).ExpectNotNil(checkMustPromoteCandidateTakeOver(topologyRecovery, mustPromoteKey, MasterRecoveryGTID))
+	}
+}
@@ -17,12 +17,15 @@
 package os
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/openark/golib/log"
 	"github.com/openark/orchestrator/go/config"
@@ -30,6 +33,46 @@ import (
 
 var EmptyEnv = []string{}
 
+// commandWaitDelay is how long to wait for output of a command which exited (or was killed), in case
+// some orphaned descendant still holds its stdout or stderr
+const commandWaitDelay = time.Second
+
+// CommandResult is the outcome of a command run by CommandRunWithTimeout
+type CommandResult struct {
+	ExitCode       int
+	DurationMillis int64
+	TimedOut       bool
+	Stdout         string
+	Stderr         string
+}
+
+// cappedBuffer keeps the first bytes written to it, up to a limit, and discards the rest
+type cappedBuffer struct {
+	buffer    bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (this *cappedBuffer) Write(p []byte) (int, error) {
+	remaining := this.limit - this.buffer.Len()
+	if len(p) > remaining {
+		this.truncated = true
+		if remaining > 0 {
+			this.buffer.Write(p[:remaining])
+		}
+	} else {
+		this.buffer.Write(p)
+	}
+	return len(p), nil
+}
+
+func (this *cappedBuffer) String() string {
+	if this.truncated {
+		return this.buffer.String() + "...(truncated)"
+	}
+	return this.buffer.String()
+}
+
 // CommandRun executes some text as a command. This is assumed to be
 // text that will be run by a shell so we need to write out the
 // command to a temporary file and then ask the shell to execute
@@ -38,7 +81,7 @@ func CommandRun(commandText string, env []string, arguments ...string) error {
 	// show the actual command we have been asked to run
 	log.Infof("CommandRun(%v,%+v)", commandText, arguments)
 
-	cmd, shellScript, err := generateShellScript(commandText, env, arguments...)
+	cmd, shellScript, err := generateShellScript(context.Background(), commandText, env, arguments...)
 	defer os.Remove(shellScript)
 	if err != nil {
 		return log.Errore(err)
@@ -66,11 +109,60 @@ func CommandRun(commandText string, env []string, arguments ...string) error {
 	return nil
 }
 
+// CommandRunWithTimeout executes some text as a command, like CommandRun. The command runs in its own
+// process group, and when it does not complete within given timeout (zero meaning no timeout), the entire
+// process group is killed. The result has the exit code, duration, and stdout and stderr each truncated to
+// maxOutputBytes. An error is returned when the command times out or exits with nonzero code.
+func CommandRunWithTimeout(commandText string, env []string, timeout time.Duration, maxOutputBytes int, arguments ...string) (result *CommandResult, err error) {
+	log.Infof("CommandRunWithTimeout(%v,%+v,%v)", commandText, arguments, timeout)
+	result = &CommandResult{ExitCode: -1}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd, shellScript, err := generateShellScript(ctx, commandText, env, arguments...)
+	defer os.Remove(shellScript)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	stdout := &cappedBuffer{limit: maxOutputBytes}
+	stderr := &cappedBuffer{limit: maxOutputBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// negative pid: the whole process group
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = commandWaitDelay
+
+	start := time.Now()
+	err = cmd.Run()
+	result.DurationMillis = time.Since(start).Nanoseconds() / int64(time.Millisecond)
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		result.TimedOut = true
+		return result, log.Errorf("CommandRunWithTimeout: timed out after %v; killed process group", timeout)
+	}
+	if err != nil {
+		return result, log.Errore(fmt.Errorf("(%s) %s", err.Error(), result.Stderr))
+	}
+	log.Infof("CommandRunWithTimeout successful in %dms", result.DurationMillis)
+	return result, nil
+}
+
 // generateShellScript generates a temporary shell script based on
 // the given command to be executed, writes the command to a temporary
 // file and returns the exec.Command which can be executed together
 // with the script name that was created.
-func generateShellScript(commandText string, env []string, arguments ...string) (*exec.Cmd, string, error) {
+func generateShellScript(ctx context.Context, commandText string, env []string, arguments ...string) (*exec.Cmd, string, error) {
 	shell := config.Config.ProcessesShellCommand
 
 	commandBytes := []byte(commandText)
@@ -83,7 +175,7 @@ func generateShellScript(commandText string, env []string, arguments ...string)
 	shellArguments := append([]string{}, tmpFile.Name())
 	shellArguments = append(shellArguments, arguments...)
 
-	cmd := exec.Command(shell, shellArguments...)
+	cmd := exec.CommandContext(ctx, shell, shellArguments...)
 	cmd.Env = env
 
 	return cmd, tmpFile.Name(), nil
@@ -2,7 +2,11 @@ package os
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
 )
 
 func TestCommandRun(t *testing.T) {
@@ -16,3 +20,29 @@ func TestCommandRun(t *testing.T) {
 		t.Errorf(fmt.Sprintf("Expected CommandRun to return an Error '%s' but got '%s'", expectedMsg, cmdErr.Error()))
 	}
 }
+
+func TestCommandRunWithTimeout(t *testing.T) {
+	{
+		result, err := CommandRunWithTimeout("echo \"VAR1=$VAR1\" && echo oops >&2 && exit 3", []string{"VAR1=a"}, 0, 1024)
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectEquals(result.ExitCode, 3)
+		test.S(t).ExpectFalse(result.TimedOut)
+		test.S(t).ExpectEquals(result.Stdout, "VAR1=a\n")
+		test.S(t).ExpectEquals(result.Stderr, "oops\n")
+	}
+	{
+		result, err := CommandRunWithTimeout("echo 0123456789", []string{}, time.Minute, 4)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(result.ExitCode, 0)
+		test.S(t).ExpectEquals(result.Stdout, "0123...(truncated)")
+	}
+	{
+		// The sleeping background child is in the same process group, and is killed along with the shell
+		start := time.Now()
+		result, err := CommandRunWithTimeout("sleep 30 & sleep 30", []string{}, 200*time.Millisecond, 1024)
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectTrue(result.TimedOut)
+		test.S(t).ExpectTrue(strings.Contains(err.Error(), "timed out"))
+		test.S(t).ExpectTrue(time.Since(start) < 10*time.Second)
+	}
+}